@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var changeCmd = &cobra.Command{
+	Use:   "change",
+	Short: "review and merge changes to a protected project",
+}
+
+var changeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the project's changes, newest first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		changes, err := c.ListChanges(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := table()
+		fmt.Fprintln(w, "ID\tSTATE\tENVIRONMENT\tAUTHOR\tBRANCH\tURL")
+		for _, ch := range changes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", ch.ID, ch.State, ch.Environment, ch.Author, ch.Branch, ch.URL)
+		}
+		return w.Flush()
+	},
+}
+
+var changeShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "show a change with its pull request's review status",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		ch, err := c.GetChange(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		printf("%s %s into %s (%s) by %s\n", ch.State, ch.Branch, ch.Base, ch.Environment, ch.Author)
+		if ch.URL != "" {
+			printf("pull request #%d %s\n", ch.Number, ch.URL)
+		}
+		if pr := ch.PullRequest; pr != nil {
+			printf("approvals: %d, changes requested: %d\n", pr.Approvals, pr.ChangesRequested)
+			if pr.BlockedBy != "" {
+				printf("not mergeable yet: %s\n", pr.BlockedBy)
+			} else {
+				printf("ready to merge\n")
+			}
+		}
+		return nil
+	},
+}
+
+var changeOpenFlags struct {
+	title string
+	body  string
+}
+
+var changeOpenCmd = &cobra.Command{
+	Use:   "open",
+	Short: "open the pull request for your changes in the current environment",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		ch, err := c.OpenChange(ctx, changeOpenFlags.title, changeOpenFlags.body)
+		if err != nil {
+			return err
+		}
+		printf("opened pull request #%d for %s: %s\n", ch.Number, ch.Branch, ch.URL)
+		return nil
+	},
+}
+
+var changeMergeCmd = &cobra.Command{
+	Use:   "merge <id>",
+	Short: "merge an approved change into its environment",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		ch, err := c.MergeChange(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		printf("merged %s into %s\n", ch.Branch, ch.Base)
+		return nil
+	},
+}
+
+func init() {
+	changeOpenCmd.Flags().StringVarP(&changeOpenFlags.title, "title", "t", "", "pull request title, the portal picks one when unset")
+	changeOpenCmd.Flags().StringVarP(&changeOpenFlags.body, "body", "b", "", "pull request description")
+
+	addProjectFlag(changeCmd)
+	changeCmd.AddCommand(changeListCmd, changeShowCmd, changeOpenCmd, changeMergeCmd)
+	rootCmd.AddCommand(changeCmd)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/client"
+	"github.com/spf13/cobra"
+)
+
+// projectFlag and envFlag override the context's project and environment for a single invocation
+var projectFlag, envFlag string
+
+// addProjectFlag registers --project and --env on a client command group
+func addProjectFlag(c *cobra.Command) {
+	c.PersistentFlags().StringVarP(&projectFlag, "project", "p", "", "project name or id, defaults to the one picked with 'lws project use'")
+	c.PersistentFlags().StringVarP(&envFlag, "env", "e", "", "project environment, defaults to the one picked with 'lws env use' or the project's default")
+}
+
+// loadContext reads the stored context without env overrides, safe to save back
+func loadContext() (string, client.Context, error) {
+	path, err := client.ContextPath()
+	if err != nil {
+		return "", client.Context{}, err
+	}
+	ctx, err := client.LoadContext(path)
+	return path, ctx, err
+}
+
+// newClient builds a client from the context file, needProject fails early
+// instead of letting the portal answer with a 400
+func newClient(needProject bool) (*client.Client, error) {
+	_, stored, err := loadContext()
+	if err != nil {
+		return nil, err
+	}
+	ctx := stored.WithEnv()
+	if projectFlag != "" {
+		ctx.Project = projectFlag
+	}
+	if envFlag != "" {
+		ctx.Environment = envFlag
+	}
+	if needProject && ctx.Project == "" {
+		return nil, errors.New("no project selected, run `lws project use <name>` or pass --project")
+	}
+	return client.New(ctx)
+}
+
+// cmdContext is cancelled on ctrl-c so in flight requests stop
+func cmdContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
+
+func table() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+}
+
+func printf(format string, args ...any) {
+	fmt.Fprintf(os.Stdout, format, args...)
+}
+
+func init() {
+	// client errors are user facing, cobra's usage dump just buries them
+	rootCmd.SilenceUsage = true
+}
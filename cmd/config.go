@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/client"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "read and write the current project's config",
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "list config entries or print one value, secrets stay masked",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		entries, err := c.GetConfig(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 1 {
+			for _, e := range entries {
+				if e.Key == args[0] {
+					printf("%s\n", e.Value)
+					return nil
+				}
+			}
+			return fmt.Errorf("config key %q not set", args[0])
+		}
+
+		w := table()
+		fmt.Fprintln(w, "KEY\tTYPE\tVALUE\tVERSION\tENVIRONMENT")
+		for _, e := range entries {
+			// values the environment doesn't override are the project wide ones
+			env := e.Environment
+			if env == "" {
+				env = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", e.Key, e.Type, e.Value, e.Version, env)
+		}
+		return w.Flush()
+	},
+}
+
+var configSetFlags struct {
+	valueType string
+	secret    bool
+	commit    bool
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key=value>...",
+	Short: "set one or more config keys, with --env only for that environment",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries := make([]client.ConfigEntry, 0, len(args))
+		for _, arg := range args {
+			key, value, ok := strings.Cut(arg, "=")
+			if !ok || key == "" {
+				return fmt.Errorf("expected key=value, got %q", arg)
+			}
+			entries = append(entries, client.ConfigEntry{
+				Key:    key,
+				Value:  value,
+				Type:   configSetFlags.valueType,
+				Secret: configSetFlags.secret,
+			})
+		}
+
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		if err := c.SetConfig(ctx, entries, configSetFlags.commit); err != nil {
+			return err
+		}
+		printf("set %d key(s)\n", len(entries))
+		return nil
+	},
+}
+
+func init() {
+	configSetCmd.Flags().StringVar(&configSetFlags.valueType, "type", "string", "string, number, bool or json")
+	configSetCmd.Flags().BoolVar(&configSetFlags.secret, "secret", false, "store masked, only revealed to the runtime")
+	configSetCmd.Flags().BoolVar(&configSetFlags.commit, "commit", false, "also write the config file to the project repo")
+
+	addProjectFlag(configCmd)
+	configCmd.AddCommand(configGetCmd, configSetCmd)
+	rootCmd.AddCommand(configCmd)
+}
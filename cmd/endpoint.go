@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/client"
+	"github.com/spf13/cobra"
+)
+
+var endpointCmd = &cobra.Command{
+	Use:     "endpoint",
+	Aliases: []string{"ep"},
+	Short:   "manage the current project's endpoints",
+}
+
+// endpointFlags back both create and update, update only sends what was set
+var endpointFlags struct {
+	name, method, scope, function, apiKeyScope string
+	rateLimit, rateWindow, rateBurst           int32
+	rateKey                                    string
+	jwt                                        client.JWT
+}
+
+var endpointListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list endpoints",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		eps, err := c.ListEndpoints(ctx)
+		if err != nil {
+			return err
+		}
+		fns, err := c.ListFunctions(ctx)
+		if err != nil {
+			return err
+		}
+		fnNames := make(map[string]string, len(fns))
+		for _, f := range fns {
+			fnNames[f.ID] = f.Name
+		}
+
+		w := table()
+		fmt.Fprintln(w, "METHOD\tPATH\tFUNCTION\tSCOPE\tRATE LIMIT\tID")
+		for _, e := range eps {
+			rl := "-"
+			if e.RateLimit != nil {
+				rl = fmt.Sprintf("%d/%ds", e.RateLimit.Limit, e.RateLimit.WindowSeconds)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Method, e.Name, fnNames[e.FunctionID], e.Scope, rl, e.ID)
+		}
+		return w.Flush()
+	},
+}
+
+var endpointCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "bind a function to a path and method",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if endpointFlags.name == "" || endpointFlags.function == "" {
+			return errors.New("--name and --function are required")
+		}
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+
+		req, err := endpointRequest(ctx, cmd, c)
+		if err != nil {
+			return err
+		}
+		if req.Method == "" {
+			req.Method = "GET"
+		}
+		e, err := c.CreateEndpoint(ctx, req)
+		if err != nil {
+			return err
+		}
+		printf("created %s %s (%s)\n", e.Method, e.Name, e.ID)
+		return nil
+	},
+}
+
+var endpointUpdateCmd = &cobra.Command{
+	Use:   "update <path|id>",
+	Short: "change an endpoint, unset flags keep their current values",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+
+		e, err := c.FindEndpoint(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		req, err := endpointRequest(ctx, cmd, c)
+		if err != nil {
+			return err
+		}
+		// rate limit and jwt settings are replaced as a whole, fill in what wasn't passed
+		if req.RateLimit != nil && e.RateLimit != nil {
+			if !cmd.Flags().Changed("rate-limit") {
+				req.RateLimit.Limit = e.RateLimit.Limit
+			}
+			if !cmd.Flags().Changed("rate-window") {
+				req.RateLimit.WindowSeconds = e.RateLimit.WindowSeconds
+			}
+			if !cmd.Flags().Changed("rate-burst") {
+				req.RateLimit.Burst = e.RateLimit.Burst
+			}
+			if !cmd.Flags().Changed("rate-key") {
+				req.RateLimit.Key = e.RateLimit.Key
+			}
+		}
+		if req.JWT != nil && e.JWT != nil {
+			f := cmd.Flags()
+			if !f.Changed("jwt-secret") && !f.Changed("jwt-jwks") {
+				req.JWT.Secret, req.JWT.JWKS = e.JWT.Secret, e.JWT.JWKS
+			}
+			if !f.Changed("jwt-issuer") {
+				req.JWT.Issuer = e.JWT.Issuer
+			}
+			if !f.Changed("jwt-audience") {
+				req.JWT.Audience = e.JWT.Audience
+			}
+		}
+		updated, err := c.UpdateEndpoint(ctx, e.ID, req)
+		if err != nil {
+			return err
+		}
+		printf("updated %s %s\n", updated.Method, updated.Name)
+		return nil
+	},
+}
+
+var endpointDeleteCmd = &cobra.Command{
+	Use:   "delete <path|id>",
+	Short: "delete an endpoint",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		e, err := c.FindEndpoint(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		if err := c.DeleteEndpoint(ctx, e.ID); err != nil {
+			return err
+		}
+		printf("deleted %s %s\n", e.Method, e.Name)
+		return nil
+	},
+}
+
+// endpointRequest turns the flags that were set into a request body, resolving
+// --function by name
+func endpointRequest(ctx context.Context, cmd *cobra.Command, c *client.Client) (client.EndpointRequest, error) {
+	f := cmd.Flags()
+	req := client.EndpointRequest{
+		Name:   endpointFlags.name,
+		Method: endpointFlags.method,
+		Scope:  endpointFlags.scope,
+	}
+	if endpointFlags.function != "" {
+		fn, err := c.FindFunction(ctx, endpointFlags.function)
+		if err != nil {
+			return req, err
+		}
+		req.FunctionID = fn.ID
+	}
+	if f.Changed("api-key-scope") {
+		req.APIKeyScope = &endpointFlags.apiKeyScope
+	}
+	if f.Changed("jwt-secret") || f.Changed("jwt-jwks") || f.Changed("jwt-issuer") || f.Changed("jwt-audience") {
+		jwt := endpointFlags.jwt
+		req.JWT = &jwt
+	}
+	if f.Changed("rate-limit") || f.Changed("rate-window") || f.Changed("rate-burst") || f.Changed("rate-key") {
+		req.RateLimit = &client.RateLimit{
+			Limit:         endpointFlags.rateLimit,
+			WindowSeconds: endpointFlags.rateWindow,
+			Burst:         endpointFlags.rateBurst,
+			Key:           endpointFlags.rateKey,
+		}
+	}
+	return req, nil
+}
+
+func init() {
+	for _, c := range []*cobra.Command{endpointCreateCmd, endpointUpdateCmd} {
+		f := c.Flags()
+		f.StringVar(&endpointFlags.name, "name", "", "path under /x/<project>, e.g. /orders/:id")
+		f.StringVar(&endpointFlags.method, "method", "", "http method, GET when creating")
+		f.StringVar(&endpointFlags.scope, "scope", "", "public, authn, api_key or jwt")
+		f.StringVar(&endpointFlags.function, "function", "", "function name or id to invoke")
+		f.StringVar(&endpointFlags.apiKeyScope, "api-key-scope", "", "scope api keys need when --scope is api_key")
+		f.Int32Var(&endpointFlags.rateLimit, "rate-limit", 0, "requests allowed per window, 0 removes the limit")
+		f.Int32Var(&endpointFlags.rateWindow, "rate-window", 60, "rate limit window in seconds")
+		f.Int32Var(&endpointFlags.rateBurst, "rate-burst", 0, "bucket size, defaults to the limit")
+		f.StringVar(&endpointFlags.rateKey, "rate-key", "", "ip, api_key or user")
+		f.StringVar(&endpointFlags.jwt.Secret, "jwt-secret", "", "HS256 secret (32+ chars) when --scope is jwt")
+		f.StringVar(&endpointFlags.jwt.JWKS, "jwt-jwks", "", "https jwks url or repo path, instead of --jwt-secret")
+		f.StringVar(&endpointFlags.jwt.Issuer, "jwt-issuer", "", "required iss claim")
+		f.StringVar(&endpointFlags.jwt.Audience, "jwt-audience", "", "required aud claim")
+	}
+
+	addProjectFlag(endpointCmd)
+	endpointCmd.AddCommand(endpointListCmd, endpointCreateCmd, endpointUpdateCmd, endpointDeleteCmd)
+	rootCmd.AddCommand(endpointCmd)
+}
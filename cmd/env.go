@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/client"
+	"github.com/spf13/cobra"
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "list, create and promote the current project's environments",
+}
+
+var envListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list environments, * marks the one commands act on",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		envs, err := c.ListEnvironments(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := table()
+		fmt.Fprintln(w, "\tNAME\tBRANCH\tGATEWAY")
+		for _, e := range envs {
+			mark := ""
+			if e.Selected {
+				mark = "*"
+			}
+			name := e.Name
+			if e.Default {
+				name += " (default)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", mark, name, e.Branch, e.Gateway)
+		}
+		return w.Flush()
+	},
+}
+
+var envCreateFlags struct {
+	branch string
+	from   string
+}
+
+var envCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "create an environment, starting its branch from another environment's",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		e, err := c.CreateEnvironment(ctx, args[0], envCreateFlags.branch, envCreateFlags.from)
+		if err != nil {
+			return err
+		}
+		printf("created environment %s on branch %s, served at %s\n", e.Name, e.Branch, e.Gateway)
+		return nil
+	},
+}
+
+var envDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "delete an environment and its config overrides, the branch stays in the repo",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		if err := c.DeleteEnvironment(ctx, args[0]); err != nil {
+			return err
+		}
+		printf("deleted environment %s\n", args[0])
+		return nil
+	},
+}
+
+var envPromoteMessage string
+
+var envPromoteCmd = &cobra.Command{
+	Use:   "promote <from> [to]",
+	Short: "merge one environment into another, the default environment when to is left out",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		to := ""
+		if len(args) == 2 {
+			to = args[1]
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		res, err := c.WithMessage(envPromoteMessage).Promote(ctx, args[0], to)
+		if err != nil {
+			return err
+		}
+		switch {
+		case res.Status == "review":
+			printf("opened pull request #%d to promote %s into %s: %s\n", res.Number, res.From, res.To, res.URL)
+		case res.Status == "up-to-date":
+			printf("%s already has everything in %s\n", res.To, res.From)
+		case res.FastForward:
+			printf("fast-forwarded %s to %s (%s)\n", res.To, res.From, res.Commit)
+		default:
+			printf("merged %s into %s (%s)\n", res.From, res.To, res.Commit)
+		}
+		return nil
+	},
+}
+
+var envUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "select the environment later commands act on",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		envs, err := c.ListEnvironments(ctx)
+		if err != nil {
+			return err
+		}
+		for _, e := range envs {
+			if e.Name == args[0] {
+				return useEnvironment(e)
+			}
+		}
+		return fmt.Errorf("environment %q not found", args[0])
+	},
+}
+
+// useEnvironment stores the environment in the context, picking the default one clears it
+// so it keeps following the project's default
+func useEnvironment(e client.Environment) error {
+	path, ctx, err := loadContext()
+	if err != nil {
+		return err
+	}
+	ctx.Environment = e.Name
+	if e.Default {
+		ctx.Environment = ""
+	}
+	if err := client.SaveContext(path, ctx); err != nil {
+		return err
+	}
+	printf("using environment %s\n", e.Name)
+	return nil
+}
+
+func init() {
+	envCreateCmd.Flags().StringVar(&envCreateFlags.branch, "branch", "", "branch of the project repo, defaults to the environment name")
+	envCreateCmd.Flags().StringVar(&envCreateFlags.from, "from", "", "environment the branch starts from, defaults to the project's default")
+	envPromoteCmd.Flags().StringVarP(&envPromoteMessage, "message", "m", "", "merge commit message, the portal picks one when unset")
+
+	addProjectFlag(envCmd)
+	envCmd.AddCommand(envListCmd, envCreateCmd, envDeleteCmd, envPromoteCmd, envUseCmd)
+	rootCmd.AddCommand(envCmd)
+}
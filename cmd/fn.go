@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/client"
+	"github.com/spf13/cobra"
+)
+
+var fnCmd = &cobra.Command{
+	Use:     "fn",
+	Aliases: []string{"function"},
+	Short:   "manage the current project's functions",
+}
+
+var fnListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list functions",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		fns, err := c.ListFunctions(ctx)
+		if err != nil {
+			return err
+		}
+		w := table()
+		fmt.Fprintln(w, "NAME\tLANGUAGE\tPATH\tID")
+		for _, f := range fns {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Name, f.Language, f.Path, f.ID)
+		}
+		return w.Flush()
+	},
+}
+
+var fnCreateLanguage, fnCreateFile string
+
+// fnMessage is the commit message for commands that change a function's source
+var fnMessage string
+
+var fnCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "create a function, optionally seeded from a local file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		lang := fnCreateLanguage
+		if lang == "" && fnCreateFile != "" {
+			lang = client.LanguageOf(fnCreateFile)
+		}
+		if lang == "" {
+			return errors.New("--language is required")
+		}
+		source := ""
+		if fnCreateFile != "" {
+			data, err := os.ReadFile(fnCreateFile)
+			if err != nil {
+				return err
+			}
+			source = string(data)
+		}
+
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		fn, err := c.WithMessage(fnMessage).CreateFunction(ctx, args[0], lang, source)
+		if err != nil {
+			return err
+		}
+		printf("created %s at %s (%s)\n", fn.Name, fn.Path, fn.ID)
+		if fn.Operation != "" {
+			printf("the push failed and is retried as operation %s\n", fn.Operation)
+		}
+		return nil
+	},
+}
+
+var fnGetOut string
+
+var fnGetCmd = &cobra.Command{
+	Use:   "get <name|id>",
+	Short: "print a function's source",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		fn, err := c.GetFunction(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		if fnGetOut != "" {
+			return os.WriteFile(fnGetOut, []byte(fn.Content), 0644)
+		}
+		printf("%s", fn.Content)
+		return nil
+	},
+}
+
+var fnEditCmd = &cobra.Command{
+	Use:   "edit <name|id>",
+	Short: "open a function in $EDITOR and push it when saved",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		fn, err := c.GetFunction(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		// keep the extension so the editor picks the right syntax
+		tmp, err := os.CreateTemp("", "lws-*-"+filepath.Base(fn.Path))
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		if _, err := tmp.WriteString(fn.Content); err != nil {
+			tmp.Close()
+			return err
+		}
+		tmp.Close()
+
+		// a save someone else made meanwhile comes back as a merge to review in the editor
+		base, etag := fn.Content, fn.ETag
+		for {
+			if err := runEditor(tmp.Name()); err != nil {
+				return err
+			}
+			edited, err := os.ReadFile(tmp.Name())
+			if err != nil {
+				return err
+			}
+			if string(edited) == base {
+				printf("no changes\n")
+				return nil
+			}
+			if hasConflictMarkers(string(edited)) {
+				printf("conflict markers left in the source, reopening\n")
+				continue
+			}
+			_, err = c.WithMessage(fnMessage).PushSource(ctx, fn.ID, etag, string(edited))
+			var conflict *client.ConflictError
+			if !errors.As(err, &conflict) {
+				if err != nil {
+					return err
+				}
+				printf("pushed %s\n", fn.Path)
+				return nil
+			}
+			printf("%s, reopening with your edits merged onto the current source\n", conflict.Error())
+			if err := os.WriteFile(tmp.Name(), []byte(conflict.Merged), 0600); err != nil {
+				return err
+			}
+			base, etag = conflict.Current, conflict.ETag
+		}
+	},
+}
+
+var fnPullCmd = &cobra.Command{
+	Use:   "pull [dir]",
+	Short: "write every function's source into dir/functions/<language>/, defaults to the current dir",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		written, err := c.Pull(ctx, dirArg(args))
+		for _, p := range written {
+			printf("pulled %s\n", p)
+		}
+		return err
+	},
+}
+
+var fnPushCmd = &cobra.Command{
+	Use:   "push [dir]",
+	Short: "push changed sources under dir/functions/, creating functions for new files",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		results, err := c.WithMessage(fnMessage).Push(ctx, dirArg(args))
+		for _, r := range results {
+			printf("%-9s %s\n", r.Action, r.Path)
+		}
+		return err
+	},
+}
+
+var fnDeleteCmd = &cobra.Command{
+	Use:   "delete <name|id>",
+	Short: "delete a function and remove it from the repo",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		fn, err := c.FindFunction(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		op, err := c.WithMessage(fnMessage).DeleteFunction(ctx, fn.ID)
+		if err != nil {
+			return err
+		}
+		if op != "" {
+			printf("deleting %s, the push failed and is retried as operation %s\n", fn.Path, op)
+			return nil
+		}
+		printf("deleted %s\n", fn.Path)
+		return nil
+	},
+}
+
+// hasConflictMarkers spots the <<<<<<< lines a conflicting merge leaves behind
+func hasConflictMarkers(source string) bool {
+	return strings.HasPrefix(source, "<<<<<<< ") || strings.Contains(source, "\n<<<<<<< ")
+}
+
+func dirArg(args []string) string {
+	if len(args) == 1 {
+		return args[0]
+	}
+	return "."
+}
+
+// runEditor runs $VISUAL or $EDITOR (falling back to vi) attached to the terminal
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// allow values like "code --wait"
+	parts := strings.Fields(editor)
+	e := exec.Command(parts[0], append(parts[1:], path)...)
+	e.Stdin, e.Stdout, e.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := e.Run(); err != nil {
+		return fmt.Errorf("running %s: %w", editor, err)
+	}
+	return nil
+}
+
+func init() {
+	fnCreateCmd.Flags().StringVarP(&fnCreateLanguage, "language", "l", "", "python, go, rust, javascript or lua, inferred from --file when unset")
+	fnCreateCmd.Flags().StringVarP(&fnCreateFile, "file", "f", "", "initial source")
+	fnGetCmd.Flags().StringVarP(&fnGetOut, "out", "o", "", "write the source to a file instead of stdout")
+	for _, c := range []*cobra.Command{fnCreateCmd, fnEditCmd, fnPushCmd, fnDeleteCmd} {
+		c.Flags().StringVarP(&fnMessage, "message", "m", "", "commit message, the portal picks one when unset")
+	}
+
+	addProjectFlag(fnCmd)
+	fnCmd.AddCommand(fnListCmd, fnCreateCmd, fnGetCmd, fnEditCmd, fnPullCmd, fnPushCmd, fnDeleteCmd)
+	rootCmd.AddCommand(fnCmd)
+}
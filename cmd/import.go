@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"github.com/ashupednekar/litewebservices-portal/internal/client"
+	"github.com/spf13/cobra"
+)
+
+var projectImport client.ProjectImport
+
+var projectImportCmd = &cobra.Command{
+	Use:   "import <repo-url>",
+	Short: "create a project on a repo that already exists",
+	Long: `
+	clones the branch and registers the functions under --root, laid out as
+	<language>/<name><ext> files or bundles. without a token the portal's own
+	credentials are used, which only works for repos on the portal's forge
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := vcsFlags.connection()
+		if err != nil {
+			return err
+		}
+		req := projectImport
+		req.URL = args[0]
+		req.Vendor = conn.Vendor
+		req.AuthMode = conn.AuthMode
+		req.Token = conn.Token
+		req.SSHKey = conn.SSHKey
+		req.SSHKeyPassword = conn.SSHKeyPassword
+
+		c, err := newClient(false)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		res, err := c.ImportProject(ctx, req)
+		if err != nil {
+			return err
+		}
+		printf("imported %s@%s as project %s (%s)\n", args[0], res.Branch, res.Name, res.ID)
+		printSyncPlan(res.Report)
+		for _, warning := range res.Warnings {
+			printf("warning: %s\n", warning)
+		}
+		if projectUseAfterCreate {
+			return useProject(client.Project{ID: res.ID, Name: res.Name})
+		}
+		return nil
+	},
+}
+
+func init() {
+	f := projectImportCmd.Flags()
+	f.StringVar(&projectImport.Name, "name", "", "project name, the repo's when empty")
+	f.StringVar(&projectImport.Branch, "branch", "main", "branch the default environment tracks")
+	f.StringVar(&projectImport.Root, "root", "functions", "directory the functions live under, empty for the repo's top")
+	f.StringVar(&vcsFlags.vendor, "vendor", "", "github or gitea, needed for forges other than github.com and the portal's")
+	f.BoolVar(&projectUseAfterCreate, "use", false, "select the project once imported")
+	addVcsCredentialFlags(projectImportCmd, "")
+	projectCmd.AddCommand(projectImportCmd)
+}
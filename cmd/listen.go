@@ -3,6 +3,7 @@ package cmd
 import (
 	"log"
 
+	"github.com/ashupednekar/litewebservices-portal/pkg"
 	"github.com/ashupednekar/litewebservices-portal/pkg/server"
 	"github.com/spf13/cobra"
 )
@@ -14,6 +15,7 @@ var listenCmd = &cobra.Command{
 	starts lws portal, a full stack stateless(local state) server
 	`,
 	Run: func(cmd *cobra.Command, args []string) {
+		pkg.LoadCfg()
 		s, err := server.NewServer()
 		if err != nil {
 			log.Fatal(err)
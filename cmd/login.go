@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/client"
+	"github.com/spf13/cobra"
+)
+
+var loginURL, loginToken string
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "store the portal url and a personal access token",
+	Long: `
+	saves the portal url and a personal access token (create one on the dashboard)
+	to the context file. the token is read from --token, LWS_TOKEN or stdin
+	`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, stored, err := loadContext()
+		if err != nil {
+			return err
+		}
+
+		ctx := client.Context{URL: loginURL, Token: loginToken, Project: stored.Project}
+		if ctx.URL == "" {
+			ctx.URL = stored.URL
+		}
+		if ctx.URL == "" {
+			return errors.New("--url is required")
+		}
+		if ctx.Token == "" {
+			ctx.Token = os.Getenv("LWS_TOKEN")
+		}
+		if ctx.Token == "" {
+			fmt.Fprint(os.Stderr, "personal access token: ")
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && line == "" {
+				return fmt.Errorf("reading token: %w", err)
+			}
+			ctx.Token = strings.TrimSpace(line)
+		}
+
+		c, err := client.New(ctx)
+		if err != nil {
+			return err
+		}
+		reqCtx, cancel := cmdContext()
+		defer cancel()
+		projects, err := c.ListProjects(reqCtx)
+		if err != nil {
+			return fmt.Errorf("checking token against %s: %w", ctx.URL, err)
+		}
+
+		// a project the new token can't see would fail every later command
+		if ctx.Project != "" {
+			visible := false
+			for _, p := range projects {
+				visible = visible || p.Name == ctx.Project || p.ID == ctx.Project
+			}
+			if !visible {
+				ctx.Project = ""
+			}
+		}
+
+		if err := client.SaveContext(path, ctx); err != nil {
+			return err
+		}
+		printf("logged in to %s, %d project(s) visible\n", ctx.URL, len(projects))
+		if ctx.Project == "" && len(projects) > 0 {
+			printf("pick one with `lws project use <name>`\n")
+		}
+		return nil
+	},
+}
+
+func init() {
+	loginCmd.Flags().StringVar(&loginURL, "url", "", "portal url, e.g. https://lws.example.com")
+	loginCmd.Flags().StringVar(&loginToken, "token", "", "personal access token (lwsp_...)")
+	rootCmd.AddCommand(loginCmd)
+}
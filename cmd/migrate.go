@@ -43,6 +43,7 @@ var migrateDownCmd = &cobra.Command{
 }
 
 func runMigrations(direction string) error {
+	pkg.LoadCfg()
 	connections.ConnectDB()
 	if connections.DBPool == nil {
 		return fmt.Errorf("failed to connect to database")
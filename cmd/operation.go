@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/client"
+	"github.com/spf13/cobra"
+)
+
+var opCmd = &cobra.Command{
+	Use:     "op",
+	Aliases: []string{"operation"},
+	Short:   "look into function creates and deletes whose push to the repo hasn't landed",
+}
+
+var opListState string
+
+var opListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the project's pending operations, newest first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		ops, err := c.ListOperations(ctx, opListState)
+		if err != nil {
+			return err
+		}
+
+		w := table()
+		fmt.Fprintln(w, "ID\tKIND\tPATH\tBRANCH\tSTATE\tATTEMPTS\tNEXT\tERROR")
+		for _, op := range ops {
+			next := "-"
+			if op.State == "pending" {
+				next = time.Until(op.NextAttemptAt).Round(time.Second).String()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n", op.ID, op.Kind, op.Path, op.Branch, op.State, op.Attempts, next, op.LastError)
+		}
+		return w.Flush()
+	},
+}
+
+var opRetryCmd = &cobra.Command{
+	Use:   "retry <id>",
+	Short: "run a pending operation now",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		op, err := c.RetryOperation(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		printOperation(op)
+		return nil
+	},
+}
+
+var opAbandonCmd = &cobra.Command{
+	Use:   "abandon <id>",
+	Short: "stop retrying a pending operation, an unpushed create loses its function",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		op, err := c.AbandonOperation(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		printOperation(op)
+		return nil
+	},
+}
+
+func printOperation(op client.Operation) {
+	printf("%s %s: %s after %d failed attempt(s)\n", op.Kind, op.Path, op.State, op.Attempts)
+	if op.LastError != "" {
+		printf("last error: %s\n", op.LastError)
+	}
+}
+
+func init() {
+	opListCmd.Flags().StringVar(&opListState, "state", "", "pending, done or compensated, pending when unset")
+
+	addProjectFlag(opCmd)
+	opCmd.AddCommand(opListCmd, opRetryCmd, opAbandonCmd)
+	rootCmd.AddCommand(opCmd)
+}
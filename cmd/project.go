@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/client"
+	"github.com/spf13/cobra"
+)
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "create, list and select projects",
+}
+
+var projectUseAfterCreate bool
+
+var projectCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "create a project and its repo",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(false)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		var vcs *client.VcsConnection
+		if vcsFlags.vendor != "" {
+			conn, err := vcsFlags.connection()
+			if err != nil {
+				return err
+			}
+			vcs = &conn
+		}
+		p, err := c.CreateProject(ctx, args[0], vcs)
+		if err != nil {
+			return err
+		}
+		printf("created project %s (%s)\n", p.Name, p.ID)
+		if projectUseAfterCreate {
+			return useProject(p)
+		}
+		return nil
+	},
+}
+
+var projectListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list projects you're a member of, * marks the current one",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, stored, err := loadContext()
+		if err != nil {
+			return err
+		}
+		current := stored.WithEnv().Project
+		if projectFlag != "" {
+			current = projectFlag
+		}
+		c, err := newClient(false)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		projects, err := c.ListProjects(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := table()
+		fmt.Fprintln(w, "\tNAME\tROLE\tID")
+		for _, p := range projects {
+			mark := ""
+			if p.Name == current || p.ID == current {
+				mark = "*"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", mark, p.Name, p.Role, p.ID)
+		}
+		return w.Flush()
+	},
+}
+
+var projectUseCmd = &cobra.Command{
+	Use:   "use <name|id>",
+	Short: "select the project later commands act on",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(false)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		p, err := c.FindProject(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return useProject(p)
+	},
+}
+
+var projectSyncDryRun bool
+
+var projectSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "pull the project repo now and bring the portal's functions level with it",
+	Long: `
+	adds functions new to the repo, follows ones that moved or changed name or
+	language, and removes ones whose files are gone from every environment
+	`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(true)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		plan, err := c.SyncProject(ctx, projectSyncDryRun)
+		if err != nil {
+			return err
+		}
+		printSyncPlan(plan)
+		switch {
+		case plan.Applied:
+			printf("synced\n")
+		case projectSyncDryRun:
+			printf("dry run, nothing changed\n")
+		default:
+			printf("already in sync\n")
+		}
+		return nil
+	},
+}
+
+// printSyncPlan lists what a sync changes, one function per line
+func printSyncPlan(plan client.SyncPlan) {
+	w := table()
+	for _, fn := range plan.Add {
+		fmt.Fprintf(w, "add\t%s\t%s\t%s\n", fn.Name, fn.Language, fn.Path)
+	}
+	for _, ch := range plan.Update {
+		fmt.Fprintf(w, "update\t%s\t%s\t%s, was %s (%s)\n", ch.To.Name, ch.To.Language, ch.To.Path, ch.From.Name, ch.From.Language)
+	}
+	for _, ch := range plan.Rename {
+		fmt.Fprintf(w, "rename\t%s\t%s\t%s, was %s\n", ch.To.Name, ch.To.Language, ch.To.Path, ch.From.Path)
+	}
+	for _, fn := range plan.Remove {
+		fmt.Fprintf(w, "remove\t%s\t%s\t%s\n", fn.Name, fn.Language, fn.Path)
+	}
+	for _, cf := range plan.Conflicts {
+		fmt.Fprintf(w, "conflict\t%s\t%s\t%s, name taken by %s\n", cf.Name, cf.Language, cf.Path, cf.With)
+	}
+	for _, s := range plan.Skipped {
+		fmt.Fprintf(w, "skipped\t\t\t%s, %s\n", s.Path, s.Reason)
+	}
+	w.Flush()
+}
+
+var projectProtectCmd = &cobra.Command{
+	Use:   "protect <name|id> <on|off>",
+	Short: "send portal saves through reviewed pull requests instead of pushing them",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var protected bool
+		switch args[1] {
+		case "on":
+			protected = true
+		case "off":
+		default:
+			return fmt.Errorf("expected on or off, got %q", args[1])
+		}
+		c, err := newClient(false)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		p, err := c.FindProject(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		if err := c.SetProtected(ctx, p.ID, protected); err != nil {
+			return err
+		}
+		printf("protection of %s is %s\n", p.Name, args[1])
+		return nil
+	},
+}
+
+// useProject stores the project name in the context, names read better than ids in the file
+func useProject(p client.Project) error {
+	path, ctx, err := loadContext()
+	if err != nil {
+		return err
+	}
+	ctx.Project = p.Name
+	// environments belong to a project, the next one starts on its default
+	ctx.Environment = ""
+	if err := client.SaveContext(path, ctx); err != nil {
+		return err
+	}
+	printf("using project %s\n", p.Name)
+	return nil
+}
+
+func init() {
+	projectCreateCmd.Flags().BoolVar(&projectUseAfterCreate, "use", false, "select the project once created")
+	addVcsFlags(projectCreateCmd)
+	projectSyncCmd.Flags().BoolVar(&projectSyncDryRun, "dry-run", false, "only show what the sync would change")
+	addProjectFlag(projectSyncCmd)
+	addProjectFlag(projectListCmd)
+
+	projectCmd.AddCommand(projectCreateCmd, projectListCmd, projectUseCmd, projectSyncCmd, projectProtectCmd, projectVcsCmd)
+	rootCmd.AddCommand(projectCmd)
+}
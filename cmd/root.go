@@ -7,7 +7,7 @@ import (
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "litewebservices portal",
+	Use:   "lws",
 	Short: "entrypoint portal for lws stack",
 	Long: `
 	- user mgmt
@@ -15,6 +15,7 @@ var rootCmd = &cobra.Command{
 	- audit
 	- function mgtm
 	- endpoint gateway
+	- cli client for a running portal (login, project, fn, endpoint, config)
 	`,
 }
 
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/client"
+	"github.com/spf13/cobra"
+)
+
+// vcsFlags describe a project connection for `project create` and `project vcs set`.
+// Credentials come from files or the environment rather than flags, so they stay out
+// of shell history
+type vcsFlagSet struct {
+	vendor, baseURL, owner, repo, auth string
+	tokenFile, sshKeyFile              string
+}
+
+var vcsFlags vcsFlagSet
+
+func addVcsFlags(cmd *cobra.Command) {
+	f := cmd.Flags()
+	f.StringVar(&vcsFlags.vendor, "vendor", "", "put the repo on its own connection: github or gitea")
+	f.StringVar(&vcsFlags.baseURL, "base-url", "", "forge url, e.g. https://github.com")
+	f.StringVar(&vcsFlags.owner, "owner", "", "user or org holding the repo")
+	f.StringVar(&vcsFlags.repo, "repo", "", "repo name, the project name when empty")
+	addVcsCredentialFlags(cmd, "token")
+}
+
+func addVcsCredentialFlags(cmd *cobra.Command, auth string) {
+	f := cmd.Flags()
+	f.StringVar(&vcsFlags.auth, "auth", auth, "how git authenticates: token or ssh")
+	f.StringVar(&vcsFlags.tokenFile, "token-file", "", "file holding the api token, LWS_VCS_TOKEN otherwise")
+	f.StringVar(&vcsFlags.sshKeyFile, "ssh-key-file", "", "private key for --auth ssh, its passphrase in LWS_VCS_SSH_KEY_PASSWORD")
+}
+
+func (f *vcsFlagSet) connection() (client.VcsConnection, error) {
+	conn := client.VcsConnection{
+		Vendor:         f.vendor,
+		BaseURL:        f.baseURL,
+		Owner:          f.owner,
+		Repo:           f.repo,
+		AuthMode:       f.auth,
+		Token:          os.Getenv("LWS_VCS_TOKEN"),
+		SSHKeyPassword: os.Getenv("LWS_VCS_SSH_KEY_PASSWORD"),
+	}
+	if f.tokenFile != "" {
+		data, err := os.ReadFile(f.tokenFile)
+		if err != nil {
+			return conn, fmt.Errorf("reading token: %w", err)
+		}
+		conn.Token = strings.TrimSpace(string(data))
+	}
+	if f.sshKeyFile != "" {
+		data, err := os.ReadFile(f.sshKeyFile)
+		if err != nil {
+			return conn, fmt.Errorf("reading ssh key: %w", err)
+		}
+		conn.SSHKey = string(data)
+	}
+	return conn, nil
+}
+
+var projectVcsCmd = &cobra.Command{
+	Use:   "vcs",
+	Short: "show or change where a project's repo lives",
+}
+
+var projectVcsShowCmd = &cobra.Command{
+	Use:   "show <name|id>",
+	Short: "show a project's vcs connection",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(false)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		p, err := c.FindProject(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		conn, err := c.GetVcsConnection(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		printConnection(conn)
+		return nil
+	},
+}
+
+var projectVcsSetCmd = &cobra.Command{
+	Use:   "set <name|id>",
+	Short: "move a project's repo onto its own forge, org or credentials",
+	Long: `
+	points the project at a repo that already exists on the given connection and
+	registers the portal's webhook on it. credentials left out keep the stored ones
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if vcsFlags.vendor == "" {
+			return errors.New("--vendor is required")
+		}
+		conn, err := vcsFlags.connection()
+		if err != nil {
+			return err
+		}
+		c, err := newClient(false)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		p, err := c.FindProject(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		saved, err := c.SetVcsConnection(ctx, p.ID, conn)
+		if err != nil {
+			return err
+		}
+		printConnection(saved)
+		return nil
+	},
+}
+
+var projectVcsResetCmd = &cobra.Command{
+	Use:   "reset <name|id>",
+	Short: "put a project back on the portal's default connection",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := newClient(false)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := cmdContext()
+		defer cancel()
+		p, err := c.FindProject(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		conn, err := c.DeleteVcsConnection(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		printConnection(conn)
+		return nil
+	},
+}
+
+func printConnection(conn client.VcsConnection) {
+	w := table()
+	source := "project"
+	if conn.Default {
+		source = "portal default"
+	}
+	fmt.Fprintf(w, "connection\t%s\n", source)
+	fmt.Fprintf(w, "vendor\t%s\n", conn.Vendor)
+	fmt.Fprintf(w, "base url\t%s\n", conn.BaseURL)
+	fmt.Fprintf(w, "repo\t%s/%s\n", conn.Owner, conn.Repo)
+	fmt.Fprintf(w, "auth\t%s\n", conn.AuthMode)
+	w.Flush()
+}
+
+func init() {
+	addVcsFlags(projectVcsSetCmd)
+	projectVcsCmd.AddCommand(projectVcsShowCmd, projectVcsSetCmd, projectVcsResetCmd)
+}
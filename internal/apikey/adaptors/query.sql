@@ -0,0 +1,27 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (project_id, name, prefix, key_hash, scopes, created_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: ListAPIKeys :many
+SELECT k.*, u.name AS created_by_name
+FROM api_keys k
+LEFT JOIN users u ON u.id = k.created_by
+WHERE k.project_id = $1
+ORDER BY k.created_at DESC;
+
+-- name: DeleteAPIKey :execrows
+DELETE FROM api_keys
+WHERE id = $1 AND project_id = $2;
+
+-- name: GetAPIKeyForProject :one
+SELECT k.*
+FROM api_keys k
+JOIN projects p ON p.id = k.project_id
+WHERE k.key_hash = $1 AND p.name = $2;
+
+-- name: TouchAPIKey :exec
+-- last_used_at is only written once a minute so busy keys don't turn every request into a write
+UPDATE api_keys
+SET last_used_at = now()
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute');
@@ -0,0 +1,168 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: query.sql
+
+package adaptors
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createAPIKey = `-- name: CreateAPIKey :one
+INSERT INTO api_keys (project_id, name, prefix, key_hash, scopes, created_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, project_id, name, prefix, key_hash, scopes, created_by, created_at, expires_at, last_used_at
+`
+
+type CreateAPIKeyParams struct {
+	ProjectID pgtype.UUID
+	Name      string
+	Prefix    string
+	KeyHash   string
+	Scopes    []string
+	CreatedBy []byte
+	ExpiresAt pgtype.Timestamptz
+}
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, createAPIKey,
+		arg.ProjectID,
+		arg.Name,
+		arg.Prefix,
+		arg.KeyHash,
+		arg.Scopes,
+		arg.CreatedBy,
+		arg.ExpiresAt,
+	)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		&i.Scopes,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const deleteAPIKey = `-- name: DeleteAPIKey :execrows
+DELETE FROM api_keys
+WHERE id = $1 AND project_id = $2
+`
+
+type DeleteAPIKeyParams struct {
+	ID        pgtype.UUID
+	ProjectID pgtype.UUID
+}
+
+func (q *Queries) DeleteAPIKey(ctx context.Context, arg DeleteAPIKeyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteAPIKey, arg.ID, arg.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getAPIKeyForProject = `-- name: GetAPIKeyForProject :one
+SELECT k.id, k.project_id, k.name, k.prefix, k.key_hash, k.scopes, k.created_by, k.created_at, k.expires_at, k.last_used_at
+FROM api_keys k
+JOIN projects p ON p.id = k.project_id
+WHERE k.key_hash = $1 AND p.name = $2
+`
+
+type GetAPIKeyForProjectParams struct {
+	KeyHash string
+	Name    string
+}
+
+func (q *Queries) GetAPIKeyForProject(ctx context.Context, arg GetAPIKeyForProjectParams) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, getAPIKeyForProject, arg.KeyHash, arg.Name)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		&i.Scopes,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const listAPIKeys = `-- name: ListAPIKeys :many
+SELECT k.id, k.project_id, k.name, k.prefix, k.key_hash, k.scopes, k.created_by, k.created_at, k.expires_at, k.last_used_at, u.name AS created_by_name
+FROM api_keys k
+LEFT JOIN users u ON u.id = k.created_by
+WHERE k.project_id = $1
+ORDER BY k.created_at DESC
+`
+
+type ListAPIKeysRow struct {
+	ID            pgtype.UUID
+	ProjectID     pgtype.UUID
+	Name          string
+	Prefix        string
+	KeyHash       string
+	Scopes        []string
+	CreatedBy     []byte
+	CreatedAt     pgtype.Timestamptz
+	ExpiresAt     pgtype.Timestamptz
+	LastUsedAt    pgtype.Timestamptz
+	CreatedByName pgtype.Text
+}
+
+func (q *Queries) ListAPIKeys(ctx context.Context, projectID pgtype.UUID) ([]ListAPIKeysRow, error) {
+	rows, err := q.db.Query(ctx, listAPIKeys, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAPIKeysRow
+	for rows.Next() {
+		var i ListAPIKeysRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Name,
+			&i.Prefix,
+			&i.KeyHash,
+			&i.Scopes,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.CreatedByName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const touchAPIKey = `-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = now()
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+`
+
+// last_used_at is only written once a minute so busy keys don't turn every request into a write
+func (q *Queries) TouchAPIKey(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, touchAPIKey, id)
+	return err
+}
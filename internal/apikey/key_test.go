@@ -0,0 +1,75 @@
+package apikey
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerate(t *testing.T) {
+	raw, prefix, hash, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !strings.HasPrefix(raw, prefix+"_") || !strings.HasPrefix(prefix, Prefix) {
+		t.Errorf("key %q does not start with prefix %q", raw, prefix)
+	}
+	if hash != Hash(raw) {
+		t.Error("returned hash doesn't match Hash(raw)")
+	}
+	if other, _, _, _ := Generate(); other == raw {
+		t.Error("Generate() returned the same key twice")
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   string
+	}{
+		{name: "x-api-key", header: map[string]string{"X-API-Key": "lws_abc_def"}, want: "lws_abc_def"},
+		{name: "bearer key", header: map[string]string{"Authorization": "Bearer lws_abc_def"}, want: "lws_abc_def"},
+		{name: "bearer jwt", header: map[string]string{"Authorization": "Bearer eyJhbGciOi"}, want: ""},
+		{name: "none", header: map[string]string{}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.header {
+				h.Set(k, v)
+			}
+			if got := FromRequest(h); got != tt.want {
+				t.Errorf("FromRequest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyScopesAndExpiry(t *testing.T) {
+	k := Key{Scopes: []string{"orders:read"}}
+	if !k.HasScope("") || !k.HasScope("orders:read") || k.HasScope("orders:write") {
+		t.Errorf("HasScope() wrong for %v", k.Scopes)
+	}
+	if !(Key{Scopes: []string{"*"}}).HasScope("anything") {
+		t.Error("wildcard key should satisfy every scope")
+	}
+
+	now := time.Now()
+	if k.Expired(now) {
+		t.Error("key without expiry reported expired")
+	}
+	k.ExpiresAt = now
+	if !k.Expired(now) {
+		t.Error("key past expiry not reported expired")
+	}
+
+	scopes, ok := NormalizeScopes([]string{" Orders:Read ", "orders:read", ""})
+	if !ok || len(scopes) != 1 || scopes[0] != "orders:read" {
+		t.Errorf("NormalizeScopes() = %v, %v", scopes, ok)
+	}
+	if _, ok := NormalizeScopes([]string{"has space"}); ok {
+		t.Error("NormalizeScopes() accepted an invalid scope")
+	}
+}
@@ -0,0 +1,57 @@
+package apikey
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/apikey/adaptors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Store verifies keys against the api_keys table
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+func NewStore(pool *pgxpool.Pool) *Store {
+	return &Store{pool: pool}
+}
+
+// Authenticate resolves raw to a key of the named project and records its use
+func (s *Store) Authenticate(ctx context.Context, project, raw string) (Key, error) {
+	if !strings.HasPrefix(raw, Prefix) {
+		return Key{}, ErrInvalid
+	}
+	q := adaptors.New(s.pool)
+	row, err := q.GetAPIKeyForProject(ctx, adaptors.GetAPIKeyForProjectParams{
+		KeyHash: Hash(raw),
+		Name:    project,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Key{}, ErrInvalid
+		}
+		return Key{}, err
+	}
+	key := Key{
+		ID:     hex.EncodeToString(row.ID.Bytes[:]),
+		Name:   row.Name,
+		Prefix: row.Prefix,
+		Scopes: row.Scopes,
+	}
+	if row.ExpiresAt.Valid {
+		key.ExpiresAt = row.ExpiresAt.Time
+	}
+	if key.Expired(time.Now()) {
+		return Key{}, ErrExpired
+	}
+	if err := q.TouchAPIKey(ctx, row.ID); err != nil {
+		fmt.Printf("[WARN] failed to record use of api key %s: %v\n", row.Prefix, err)
+	}
+	return key, nil
+}
@@ -8,6 +8,35 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ApiKey struct {
+	ID         pgtype.UUID
+	ProjectID  pgtype.UUID
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	CreatedBy  []byte
+	CreatedAt  pgtype.Timestamptz
+	ExpiresAt  pgtype.Timestamptz
+	LastUsedAt pgtype.Timestamptz
+}
+
+type ChangeRequest struct {
+	ID          pgtype.UUID
+	ProjectID   pgtype.UUID
+	Environment string
+	Branch      string
+	BaseBranch  string
+	Author      []byte
+	State       string
+	Title       string
+	Number      pgtype.Int8
+	Url         pgtype.Text
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+	MergedAt    pgtype.Timestamptz
+}
+
 type Credential struct {
 	ID              []byte
 	UserID          []byte
@@ -22,31 +51,146 @@ type Credential struct {
 }
 
 type Endpoint struct {
+	ID                pgtype.UUID
+	ProjectID         pgtype.UUID
+	Name              string
+	Method            string
+	Scope             string
+	FunctionID        pgtype.UUID
+	CreatedAt         pgtype.Timestamptz
+	RateLimit         pgtype.Int4
+	RateWindowSeconds int32
+	RateBurst         pgtype.Int4
+	RateKey           string
+	ApiKeyScope       string
+	JwtSecret         string
+	JwtJwks           string
+	JwtIssuer         string
+	JwtAudience       string
+}
+
+type Function struct {
+	ID          pgtype.UUID
+	ProjectID   pgtype.UUID
+	Name        string
+	Language    string
+	Path        string
+	CreatedBy   []byte
+	CreatedAt   pgtype.Timestamptz
+	ContentHash string
+}
+
+type FunctionOperation struct {
+	ID            pgtype.UUID
+	ProjectID     pgtype.UUID
+	FunctionID    pgtype.UUID
+	Kind          string
+	Branch        string
+	FunctionPath  string
+	Edits         []byte
+	CommitMessage string
+	AuthorName    string
+	AuthorEmail   string
+	State         string
+	Attempts      int32
+	LastError     pgtype.Text
+	NextAttemptAt pgtype.Timestamptz
+	CreatedBy     []byte
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+}
+
+type PersonalAccessToken struct {
 	ID         pgtype.UUID
-	ProjectID  pgtype.UUID
+	UserID     []byte
 	Name       string
-	Method     string
-	Scope      string
-	FunctionID pgtype.UUID
+	Prefix     string
+	TokenHash  string
+	Access     string
+	ProjectIds []pgtype.UUID
 	CreatedAt  pgtype.Timestamptz
+	ExpiresAt  pgtype.Timestamptz
+	LastUsedAt pgtype.Timestamptz
 }
 
-type Function struct {
-	ID        pgtype.UUID
+type Project struct {
+	ID                  pgtype.UUID
+	Name                string
+	Description         pgtype.Text
+	CreatedBy           []byte
+	CreatedAt           pgtype.Timestamptz
+	WebhookSecret       pgtype.Text
+	Protected           bool
+	FunctionsRoot       string
+	RepoCreatedByPortal bool
+}
+
+type ProjectConfig struct {
+	ProjectID   pgtype.UUID
+	Key         string
+	Value       string
+	ValueType   string
+	Secret      bool
+	Version     int32
+	UpdatedBy   []byte
+	UpdatedAt   pgtype.Timestamptz
+	Environment string
+}
+
+type ProjectConfigHistory struct {
+	ID          int64
+	ProjectID   pgtype.UUID
+	Key         string
+	Value       string
+	ValueType   string
+	Secret      bool
+	Version     int32
+	Deleted     bool
+	ChangedBy   []byte
+	ChangedAt   pgtype.Timestamptz
+	Environment string
+}
+
+type ProjectEnvironment struct {
 	ProjectID pgtype.UUID
 	Name      string
-	Language  string
-	Path      string
-	CreatedBy []byte
+	Branch    string
+	IsDefault bool
 	CreatedAt pgtype.Timestamptz
 }
 
-type Project struct {
-	ID          pgtype.UUID
-	Name        string
-	Description pgtype.Text
-	CreatedBy   []byte
-	CreatedAt   pgtype.Timestamptz
+type ProjectInvite struct {
+	ID         pgtype.UUID
+	ProjectID  pgtype.UUID
+	TokenHash  string
+	Role       string
+	CreatedBy  []byte
+	CreatedAt  pgtype.Timestamptz
+	ExpiresAt  pgtype.Timestamptz
+	RedeemedBy []byte
+	RedeemedAt pgtype.Timestamptz
+}
+
+type ProjectVcsConnection struct {
+	ProjectID      pgtype.UUID
+	Vendor         string
+	BaseUrl        string
+	Owner          string
+	RepoName       string
+	AuthMode       string
+	Token          []byte
+	SshKey         []byte
+	SshKeyPassword []byte
+	UpdatedBy      []byte
+	CreatedAt      pgtype.Timestamptz
+	UpdatedAt      pgtype.Timestamptz
+}
+
+type RateLimitBucket struct {
+	Key       string
+	Tokens    float64
+	Allowed   bool
+	UpdatedAt pgtype.Timestamptz
 }
 
 type User struct {
@@ -9,6 +9,9 @@ SELECT * FROM users WHERE name = $1;
 -- name: GetUserByID :one
 SELECT * FROM users WHERE id = $1;
 
+-- name: GetUserCommitIdentity :one
+SELECT name, display_name FROM users WHERE id = $1;
+
 -- name: UpdateUser :exec
 UPDATE users
 SET display_name = $2,
@@ -107,3 +110,28 @@ DELETE FROM user_sessions WHERE expires_at < now();
 
 -- name: DeleteUserSessionsByUserID :exec
 DELETE FROM user_sessions WHERE user_id = $1;
+
+-- name: CreateAccessToken :one
+INSERT INTO personal_access_tokens (user_id, name, prefix, token_hash, access, project_ids, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: ListAccessTokens :many
+SELECT * FROM personal_access_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeleteAccessToken :execrows
+DELETE FROM personal_access_tokens
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetAccessTokenByHash :one
+SELECT t.*, u.name AS user_name
+FROM personal_access_tokens t
+JOIN users u ON u.id = t.user_id
+WHERE t.token_hash = $1 AND (t.expires_at IS NULL OR t.expires_at > now());
+
+-- name: TouchAccessToken :exec
+UPDATE personal_access_tokens
+SET last_used_at = now()
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute');
@@ -11,6 +11,48 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const createAccessToken = `-- name: CreateAccessToken :one
+INSERT INTO personal_access_tokens (user_id, name, prefix, token_hash, access, project_ids, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, user_id, name, prefix, token_hash, access, project_ids, created_at, expires_at, last_used_at
+`
+
+type CreateAccessTokenParams struct {
+	UserID     []byte
+	Name       string
+	Prefix     string
+	TokenHash  string
+	Access     string
+	ProjectIds []pgtype.UUID
+	ExpiresAt  pgtype.Timestamptz
+}
+
+func (q *Queries) CreateAccessToken(ctx context.Context, arg CreateAccessTokenParams) (PersonalAccessToken, error) {
+	row := q.db.QueryRow(ctx, createAccessToken,
+		arg.UserID,
+		arg.Name,
+		arg.Prefix,
+		arg.TokenHash,
+		arg.Access,
+		arg.ProjectIds,
+		arg.ExpiresAt,
+	)
+	var i PersonalAccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Prefix,
+		&i.TokenHash,
+		&i.Access,
+		&i.ProjectIds,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
 const createCredential = `-- name: CreateCredential :exec
 INSERT INTO credentials (
     id,
@@ -99,6 +141,24 @@ func (q *Queries) CreateUserSession(ctx context.Context, arg CreateUserSessionPa
 	return err
 }
 
+const deleteAccessToken = `-- name: DeleteAccessToken :execrows
+DELETE FROM personal_access_tokens
+WHERE id = $1 AND user_id = $2
+`
+
+type DeleteAccessTokenParams struct {
+	ID     pgtype.UUID
+	UserID []byte
+}
+
+func (q *Queries) DeleteAccessToken(ctx context.Context, arg DeleteAccessTokenParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteAccessToken, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const deleteExpiredSessions = `-- name: DeleteExpiredSessions :exec
 DELETE FROM webauthn_sessions
 WHERE expires_at < now()
@@ -146,6 +206,46 @@ func (q *Queries) DeleteUserSessionsByUserID(ctx context.Context, userID []byte)
 	return err
 }
 
+const getAccessTokenByHash = `-- name: GetAccessTokenByHash :one
+SELECT t.id, t.user_id, t.name, t.prefix, t.token_hash, t.access, t.project_ids, t.created_at, t.expires_at, t.last_used_at, u.name AS user_name
+FROM personal_access_tokens t
+JOIN users u ON u.id = t.user_id
+WHERE t.token_hash = $1 AND (t.expires_at IS NULL OR t.expires_at > now())
+`
+
+type GetAccessTokenByHashRow struct {
+	ID         pgtype.UUID
+	UserID     []byte
+	Name       string
+	Prefix     string
+	TokenHash  string
+	Access     string
+	ProjectIds []pgtype.UUID
+	CreatedAt  pgtype.Timestamptz
+	ExpiresAt  pgtype.Timestamptz
+	LastUsedAt pgtype.Timestamptz
+	UserName   string
+}
+
+func (q *Queries) GetAccessTokenByHash(ctx context.Context, tokenHash string) (GetAccessTokenByHashRow, error) {
+	row := q.db.QueryRow(ctx, getAccessTokenByHash, tokenHash)
+	var i GetAccessTokenByHashRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Prefix,
+		&i.TokenHash,
+		&i.Access,
+		&i.ProjectIds,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.UserName,
+	)
+	return i, err
+}
+
 const getCredentialByID = `-- name: GetCredentialByID :one
 SELECT id, user_id, public_key, attestation_type, aaguid, sign_count, transports, flags, created_at, updated_at
 FROM credentials
@@ -265,6 +365,22 @@ func (q *Queries) GetUserByName(ctx context.Context, name string) (User, error)
 	return i, err
 }
 
+const getUserCommitIdentity = `-- name: GetUserCommitIdentity :one
+SELECT name, display_name FROM users WHERE id = $1
+`
+
+type GetUserCommitIdentityRow struct {
+	Name        string
+	DisplayName string
+}
+
+func (q *Queries) GetUserCommitIdentity(ctx context.Context, id []byte) (GetUserCommitIdentityRow, error) {
+	row := q.db.QueryRow(ctx, getUserCommitIdentity, id)
+	var i GetUserCommitIdentityRow
+	err := row.Scan(&i.Name, &i.DisplayName)
+	return i, err
+}
+
 const getUserSession = `-- name: GetUserSession :one
 SELECT session_id, user_id, created_at, expires_at, user_agent, ip_address FROM user_sessions WHERE session_id = $1 AND expires_at > now()
 `
@@ -283,6 +399,43 @@ func (q *Queries) GetUserSession(ctx context.Context, sessionID string) (UserSes
 	return i, err
 }
 
+const listAccessTokens = `-- name: ListAccessTokens :many
+SELECT id, user_id, name, prefix, token_hash, access, project_ids, created_at, expires_at, last_used_at FROM personal_access_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListAccessTokens(ctx context.Context, userID []byte) ([]PersonalAccessToken, error) {
+	rows, err := q.db.Query(ctx, listAccessTokens, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PersonalAccessToken
+	for rows.Next() {
+		var i PersonalAccessToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Prefix,
+			&i.TokenHash,
+			&i.Access,
+			&i.ProjectIds,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const saveSession = `-- name: SaveSession :exec
 INSERT INTO webauthn_sessions (
     session_id,
@@ -342,6 +495,17 @@ func (q *Queries) SaveSession(ctx context.Context, arg SaveSessionParams) error
 	return err
 }
 
+const touchAccessToken = `-- name: TouchAccessToken :exec
+UPDATE personal_access_tokens
+SET last_used_at = now()
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+`
+
+func (q *Queries) TouchAccessToken(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, touchAccessToken, id)
+	return err
+}
+
 const updateCredential = `-- name: UpdateCredential :exec
 UPDATE credentials
 SET public_key = $2,
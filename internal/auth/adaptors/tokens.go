@@ -0,0 +1,37 @@
+package adaptors
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/auth"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetAccessToken looks a personal access token up by hash and records its use
+func (db *WebauthnStore) GetAccessToken(raw string) (auth.AccessToken, bool, error) {
+	if !strings.HasPrefix(raw, auth.AccessTokenPrefix) {
+		return auth.AccessToken{}, false, nil
+	}
+	ctx := context.Background()
+	row, err := db.queries.GetAccessTokenByHash(ctx, auth.HashAccessToken(raw))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return auth.AccessToken{}, false, nil
+		}
+		return auth.AccessToken{}, false, err
+	}
+	if err := db.queries.TouchAccessToken(ctx, row.ID); err != nil {
+		log.Printf("[WARN] failed to record use of token %s: %v", row.Prefix, err)
+	}
+	return auth.AccessToken{
+		ID:         row.ID,
+		UserID:     row.UserID,
+		UserName:   row.UserName,
+		Name:       row.Name,
+		Access:     row.Access,
+		ProjectIDs: row.ProjectIds,
+	}, true, nil
+}
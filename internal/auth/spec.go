@@ -29,6 +29,9 @@ type PasskeyStore interface {
 
 	// User authentication session methods
 	SessionStore
+
+	// Personal access tokens for the api
+	TokenStore
 }
 
 func NewWebauthn() (*webauthn.WebAuthn, error) {
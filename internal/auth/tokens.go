@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// AccessTokenPrefix marks personal access tokens, api keys for endpoints use lws_
+const AccessTokenPrefix = "lwsp_"
+
+const (
+	AccessRead  = "read"
+	AccessWrite = "write"
+)
+
+const MaxAccessTokenTTL = 365 * 24 * time.Hour
+
+// AccessToken is a verified personal access token, it acts as its user but only on
+// ProjectIDs (every project when empty) and only for reads unless Access is write
+type AccessToken struct {
+	ID         pgtype.UUID
+	UserID     []byte
+	UserName   string
+	Name       string
+	Access     string
+	ProjectIDs []pgtype.UUID
+}
+
+func (t AccessToken) CanWrite() bool {
+	return t.Access == AccessWrite
+}
+
+func (t AccessToken) AllowsProject(id pgtype.UUID) bool {
+	if len(t.ProjectIDs) == 0 {
+		return true
+	}
+	for _, p := range t.ProjectIDs {
+		if p == id {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenStore resolves personal access tokens, found is false for unknown or expired ones
+type TokenStore interface {
+	GetAccessToken(raw string) (token AccessToken, found bool, err error)
+}
+
+// NewAccessToken returns lwsp_<prefix>_<secret> along with the prefix to show and the
+// hash to persist
+func NewAccessToken() (raw, prefix, hash string, err error) {
+	id := make([]byte, 4)
+	secret := make([]byte, 24)
+	if _, err = rand.Read(id); err != nil {
+		return
+	}
+	if _, err = rand.Read(secret); err != nil {
+		return
+	}
+	prefix = AccessTokenPrefix + hex.EncodeToString(id)
+	raw = prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return raw, prefix, HashAccessToken(raw), nil
+}
+
+func HashAccessToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// BearerToken returns the bearer credential of a request, if any
+func BearerToken(h http.Header) (string, bool) {
+	token, ok := strings.CutPrefix(h.Get("Authorization"), "Bearer ")
+	token = strings.TrimSpace(token)
+	return token, ok && token != ""
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestNewAccessToken(t *testing.T) {
+	raw, prefix, hash, err := NewAccessToken()
+	if err != nil {
+		t.Fatalf("NewAccessToken() error = %v", err)
+	}
+	if !strings.HasPrefix(raw, prefix+"_") || !strings.HasPrefix(prefix, AccessTokenPrefix) {
+		t.Errorf("token %q does not start with prefix %q", raw, prefix)
+	}
+	if hash != HashAccessToken(raw) {
+		t.Error("returned hash doesn't match HashAccessToken(raw)")
+	}
+}
+
+func TestAccessTokenScope(t *testing.T) {
+	a := pgtype.UUID{Bytes: [16]byte{1}, Valid: true}
+	b := pgtype.UUID{Bytes: [16]byte{2}, Valid: true}
+
+	if !(AccessToken{}).AllowsProject(a) {
+		t.Error("unscoped token should allow every project")
+	}
+	scoped := AccessToken{ProjectIDs: []pgtype.UUID{a}}
+	if !scoped.AllowsProject(a) || scoped.AllowsProject(b) {
+		t.Error("scoped token allows the wrong projects")
+	}
+	if (AccessToken{Access: AccessRead}).CanWrite() || !(AccessToken{Access: AccessWrite}).CanWrite() {
+		t.Error("CanWrite() doesn't follow access")
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	h := http.Header{}
+	if _, ok := BearerToken(h); ok {
+		t.Error("BearerToken() found a token in an empty header")
+	}
+	h.Set("Authorization", "Bearer  lwsp_abc ")
+	if got, ok := BearerToken(h); !ok || got != "lwsp_abc" {
+		t.Errorf("BearerToken() = %q, %v", got, ok)
+	}
+	h.Set("Authorization", "Basic Zm9vOmJhcg==")
+	if _, ok := BearerToken(h); ok {
+		t.Error("BearerToken() accepted basic auth")
+	}
+}
@@ -0,0 +1,691 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ProjectHeader picks the project for /api routes, see middleware.ProjectHeader
+const ProjectHeader = "X-LWS-Project"
+
+// EnvironmentHeader picks the project environment, see middleware.EnvironmentHeader
+const EnvironmentHeader = "X-LWS-Environment"
+
+var ErrNotLoggedIn = errors.New("not logged in, run `lws login` first")
+
+// APIError is a non 2xx answer from the portal
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("portal returned %d: %s", e.Status, e.Message)
+}
+
+// Client talks to the portal api with a personal access token
+type Client struct {
+	base    string
+	token   string
+	project string
+	// environment is the project environment requests act on, the default one when empty
+	environment string
+	message     string
+	http        *http.Client
+}
+
+func New(ctx Context) (*Client, error) {
+	if ctx.URL == "" || ctx.Token == "" {
+		return nil, ErrNotLoggedIn
+	}
+	return &Client{
+		base:        strings.TrimRight(ctx.URL, "/"),
+		token:       ctx.Token,
+		project:     ctx.Project,
+		environment: ctx.Environment,
+		http:        &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// WithProject returns a copy of the client acting on another project
+func (c *Client) WithProject(project string) *Client {
+	cp := *c
+	cp.project = project
+	return &cp
+}
+
+// WithEnvironment returns a copy of the client acting on another environment of the project
+func (c *Client) WithEnvironment(environment string) *Client {
+	cp := *c
+	cp.environment = environment
+	return &cp
+}
+
+// WithMessage returns a copy of the client whose function changes are committed with message
+func (c *Client) WithMessage(message string) *Client {
+	cp := *c
+	cp.message = message
+	return &cp
+}
+
+// messageQuery carries the commit message on requests without a json body
+func (c *Client) messageQuery() string {
+	if c.message == "" {
+		return ""
+	}
+	return "?message=" + url.QueryEscape(c.message)
+}
+
+type Project struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Role        string `json:"role"`
+	Protected   bool   `json:"protected"`
+}
+
+// VcsConnection is where a project's repo lives. Credentials are only ever sent, the
+// portal answers with HasSSHKey instead
+type VcsConnection struct {
+	Vendor         string `json:"vendor"`
+	BaseURL        string `json:"base_url"`
+	Owner          string `json:"owner"`
+	Repo           string `json:"repo,omitempty"`
+	AuthMode       string `json:"auth_mode,omitempty"`
+	Token          string `json:"token,omitempty"`
+	SSHKey         string `json:"ssh_key,omitempty"`
+	SSHKeyPassword string `json:"ssh_key_password,omitempty"`
+	HasSSHKey      bool   `json:"has_ssh_key,omitempty"`
+	// Default is set for projects on the deployment's connection
+	Default bool `json:"default,omitempty"`
+	// DeploymentCredentials is set for project connections borrowing the deployment's
+	DeploymentCredentials bool `json:"deployment_credentials,omitempty"`
+}
+
+// ProjectImport adopts an existing repo, see ImportProject
+type ProjectImport struct {
+	URL    string `json:"url"`
+	Name   string `json:"name,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	// Root is the directory functions live under, empty for the repo's top
+	Root           string `json:"root"`
+	Vendor         string `json:"vendor,omitempty"`
+	AuthMode       string `json:"auth_mode,omitempty"`
+	Token          string `json:"token,omitempty"`
+	SSHKey         string `json:"ssh_key,omitempty"`
+	SSHKeyPassword string `json:"ssh_key_password,omitempty"`
+}
+
+type SyncedFunction struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	Path     string `json:"path"`
+}
+
+type FunctionChange struct {
+	ID   string         `json:"id"`
+	From SyncedFunction `json:"from"`
+	To   SyncedFunction `json:"to"`
+}
+
+// SyncPlan is what a sync changes in the portal's functions to match the repo, Applied
+// is false for dry runs
+type SyncPlan struct {
+	Add     []SyncedFunction `json:"add"`
+	Update  []FunctionChange `json:"update"`
+	Rename  []FunctionChange `json:"rename"`
+	Remove  []SyncedFunction `json:"remove"`
+	Skipped []struct {
+		Path   string `json:"path"`
+		Reason string `json:"reason"`
+	} `json:"skipped"`
+	// Conflicts name a function already registered under the same name in With
+	Conflicts []struct {
+		SyncedFunction
+		With string `json:"with"`
+	} `json:"conflicts"`
+	Applied bool `json:"applied"`
+}
+
+type ImportResult struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Branch   string   `json:"branch"`
+	Root     string   `json:"root"`
+	Report   SyncPlan `json:"report"`
+	Warnings []string `json:"warnings"`
+}
+
+// Change is a user's edits to a protected project's environment, reviewed as a pull request
+type Change struct {
+	ID          string `json:"id"`
+	Environment string `json:"environment"`
+	Branch      string `json:"branch"`
+	Base        string `json:"base"`
+	Author      string `json:"author"`
+	State       string `json:"state"`
+	Title       string `json:"title"`
+	Number      int64  `json:"number,omitempty"`
+	URL         string `json:"url,omitempty"`
+	// PullRequest is only filled in for open changes by GetChange
+	PullRequest *struct {
+		State            string `json:"state"`
+		Mergeable        bool   `json:"mergeable"`
+		Approvals        int    `json:"approvals"`
+		ChangesRequested int    `json:"changes_requested"`
+		BlockedBy        string `json:"blocked_by"`
+	} `json:"pull_request,omitempty"`
+}
+
+type Function struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	Path     string `json:"path"`
+	Content  string `json:"content,omitempty"`
+	// ETag is the source's blob hash, PushSource sends it back so the portal can refuse stale writes
+	ETag   string `json:"etag,omitempty"`
+	Commit string `json:"commit,omitempty"`
+	// Operation is set when the function was created but its push is still being retried
+	Operation string `json:"operation,omitempty"`
+}
+
+// Operation is a function create or delete on its way to the repo, pending until the push
+// lands and compensated when it was given up on
+type Operation struct {
+	ID            string    `json:"id"`
+	Kind          string    `json:"kind"`
+	FunctionID    string    `json:"function_id"`
+	Path          string    `json:"path"`
+	Branch        string    `json:"branch"`
+	Message       string    `json:"message"`
+	State         string    `json:"state"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ConflictError is the portal refusing a PushSource because the function changed since
+// the etag it was given. Merged holds the push applied onto the current source, with
+// diff3 markers around the Conflicts regions both sides changed
+type ConflictError struct {
+	Message   string `json:"error"`
+	ETag      string `json:"etag"`
+	BaseETag  string `json:"base_etag"`
+	Current   string `json:"current"`
+	Merged    string `json:"merged"`
+	Conflicts int    `json:"conflicts"`
+	Diff      string `json:"diff"`
+}
+
+func (e *ConflictError) Error() string {
+	if e.Conflicts == 0 {
+		return e.Message + ", the changes merge cleanly"
+	}
+	return fmt.Sprintf("%s, %d conflicting region(s)", e.Message, e.Conflicts)
+}
+
+type RateLimit struct {
+	Limit         int32  `json:"limit"`
+	WindowSeconds int32  `json:"window_seconds"`
+	Burst         int32  `json:"burst,omitempty"`
+	Key           string `json:"key,omitempty"`
+}
+
+type Endpoint struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Method      string     `json:"method"`
+	Scope       string     `json:"scope"`
+	FunctionID  string     `json:"function_id"`
+	RateLimit   *RateLimit `json:"rate_limit,omitempty"`
+	APIKeyScope string     `json:"api_key_scope,omitempty"`
+	JWT         *JWT       `json:"jwt,omitempty"`
+}
+
+// EndpointRequest mirrors the create/update body, nil fields keep what's stored on update
+type EndpointRequest struct {
+	Name        string     `json:"name,omitempty"`
+	Method      string     `json:"method,omitempty"`
+	Scope       string     `json:"scope,omitempty"`
+	FunctionID  string     `json:"function_id,omitempty"`
+	RateLimit   *RateLimit `json:"rate_limit,omitempty"`
+	APIKeyScope *string    `json:"api_key_scope,omitempty"`
+	JWT         *JWT       `json:"jwt,omitempty"`
+}
+
+// JWT is an endpoint's token verification config, exactly one of Secret or JWKS.
+// The portal answers with the secret masked, sending the mask back keeps it
+type JWT struct {
+	Secret   string `json:"secret,omitempty"`
+	JWKS     string `json:"jwks,omitempty"`
+	Issuer   string `json:"issuer,omitempty"`
+	Audience string `json:"audience,omitempty"`
+}
+
+type ConfigEntry struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Secret  bool   `json:"secret"`
+	Version int32  `json:"version,omitempty"`
+	// Environment is set on values that override the project wide one
+	Environment string `json:"environment,omitempty"`
+}
+
+// Environment is a named branch of the project repo with its own endpoints and config
+type Environment struct {
+	Name     string `json:"name"`
+	Branch   string `json:"branch"`
+	Default  bool   `json:"default"`
+	Selected bool   `json:"selected"`
+	Gateway  string `json:"gateway"`
+}
+
+// PromoteResult tells how a promote went, Status is promoted or up-to-date
+type PromoteResult struct {
+	Status      string `json:"status"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	FastForward bool   `json:"fast_forward"`
+	Commit      string `json:"commit"`
+	// Number and URL are the pull request a protected project's promote opens
+	Number int64  `json:"number,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+func (c *Client) ListProjects(ctx context.Context) ([]Project, error) {
+	var out []Project
+	return out, c.do(ctx, http.MethodGet, "/api/projects/", nil, &out)
+}
+
+// CreateProject creates a project and its repo, on vcs when it's set and on the
+// deployment's connection otherwise
+func (c *Client) CreateProject(ctx context.Context, name string, vcs *VcsConnection) (Project, error) {
+	body := struct {
+		Name string         `json:"name"`
+		VCS  *VcsConnection `json:"vcs,omitempty"`
+	}{name, vcs}
+	// create answers with the raw uuid, list it back to get the hex id everything else takes
+	if err := c.do(ctx, http.MethodPost, "/api/projects/", body, nil); err != nil {
+		return Project{}, err
+	}
+	return c.FindProject(ctx, name)
+}
+
+// ImportProject creates a project on an existing repo, registering the functions found
+// on its branch under the root
+func (c *Client) ImportProject(ctx context.Context, req ProjectImport) (ImportResult, error) {
+	var out ImportResult
+	return out, c.do(ctx, http.MethodPost, "/api/projects/import/", req, &out)
+}
+
+// FindProject resolves a project by name or hex id among the ones the token can see
+func (c *Client) FindProject(ctx context.Context, ref string) (Project, error) {
+	projects, err := c.ListProjects(ctx)
+	if err != nil {
+		return Project{}, err
+	}
+	for _, p := range projects {
+		if p.Name == ref || p.ID == ref {
+			return p, nil
+		}
+	}
+	return Project{}, fmt.Errorf("project %q not found", ref)
+}
+
+// SetProtected switches the pull request workflow of a project, id is the hex id
+func (c *Client) SetProtected(ctx context.Context, id string, protected bool) error {
+	return c.do(ctx, http.MethodPut, "/api/projects/"+id+"/protected/", map[string]bool{"protected": protected}, nil)
+}
+
+func (c *Client) GetVcsConnection(ctx context.Context, id string) (VcsConnection, error) {
+	var out VcsConnection
+	return out, c.do(ctx, http.MethodGet, "/api/projects/"+id+"/vcs/", nil, &out)
+}
+
+// SetVcsConnection moves a project's repo onto conn, empty credentials keep the stored ones
+func (c *Client) SetVcsConnection(ctx context.Context, id string, conn VcsConnection) (VcsConnection, error) {
+	var out VcsConnection
+	return out, c.do(ctx, http.MethodPut, "/api/projects/"+id+"/vcs/", conn, &out)
+}
+
+// DeleteVcsConnection puts a project back on the deployment's connection
+func (c *Client) DeleteVcsConnection(ctx context.Context, id string) (VcsConnection, error) {
+	var out VcsConnection
+	return out, c.do(ctx, http.MethodDelete, "/api/projects/"+id+"/vcs/", nil, &out)
+}
+
+func (c *Client) ListChanges(ctx context.Context) ([]Change, error) {
+	var out []Change
+	return out, c.do(ctx, http.MethodGet, "/api/changes/", nil, &out)
+}
+
+func (c *Client) GetChange(ctx context.Context, id string) (Change, error) {
+	var out Change
+	return out, c.do(ctx, http.MethodGet, "/api/changes/"+id+"/", nil, &out)
+}
+
+// OpenChange opens the pull request for the caller's change in the client's environment
+func (c *Client) OpenChange(ctx context.Context, title, body string) (Change, error) {
+	var out Change
+	return out, c.do(ctx, http.MethodPost, "/api/changes/", map[string]string{"title": title, "body": body}, &out)
+}
+
+func (c *Client) MergeChange(ctx context.Context, id string) (Change, error) {
+	var out Change
+	return out, c.do(ctx, http.MethodPost, "/api/changes/"+id+"/merge/", nil, &out)
+}
+
+// SyncProject reconciles the portal's functions with the current environment's branch,
+// a dry run only reports what would change
+func (c *Client) SyncProject(ctx context.Context, dryRun bool) (SyncPlan, error) {
+	path := "/api/projects/sync/"
+	if dryRun {
+		path += "?dry_run=true"
+	}
+	var out SyncPlan
+	return out, c.do(ctx, http.MethodPost, path, nil, &out)
+}
+
+func (c *Client) ListFunctions(ctx context.Context) ([]Function, error) {
+	var out []Function
+	return out, c.do(ctx, http.MethodGet, "/api/functions/", nil, &out)
+}
+
+// GetFunction takes a function name or hex id and returns it with its source
+func (c *Client) GetFunction(ctx context.Context, ref string) (Function, error) {
+	fn, err := c.FindFunction(ctx, ref)
+	if err != nil {
+		return fn, err
+	}
+	return c.functionSource(ctx, fn.ID)
+}
+
+func (c *Client) functionSource(ctx context.Context, id string) (Function, error) {
+	var out Function
+	return out, c.do(ctx, http.MethodGet, "/api/functions/"+id+"/", nil, &out)
+}
+
+// FindFunction resolves a function by name or hex id without fetching its source
+func (c *Client) FindFunction(ctx context.Context, ref string) (Function, error) {
+	fns, err := c.ListFunctions(ctx)
+	if err != nil {
+		return Function{}, err
+	}
+	for _, f := range fns {
+		if f.Name == ref || f.ID == ref {
+			return f, nil
+		}
+	}
+	return Function{}, fmt.Errorf("function %q not found", ref)
+}
+
+func (c *Client) CreateFunction(ctx context.Context, name, language, source string) (Function, error) {
+	var out Function
+	// the create body carries the source under "path"
+	body := map[string]string{"name": name, "language": language, "path": source, "message": c.message}
+	return out, c.do(ctx, http.MethodPost, "/api/functions/", body, &out)
+}
+
+// PushSource replaces a function's source if it's still at etag, the portal commits
+// and pushes it and the new etag is returned. An empty etag overwrites whatever is
+// there, a stale one fails with a *ConflictError
+func (c *Client) PushSource(ctx context.Context, id, etag, source string) (string, error) {
+	req, err := c.request(ctx, http.MethodPut, "/api/functions/"+id+"/"+c.messageQuery(), strings.NewReader(source))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	if etag == "" {
+		etag = "*"
+	} else {
+		etag = `"` + etag + `"`
+	}
+	req.Header.Set("If-Match", etag)
+	var out struct {
+		ETag string `json:"etag"`
+	}
+	return out.ETag, c.send(req, &out)
+}
+
+// BundleFile is a file of a bundle function, Path relative to the bundle directory
+type BundleFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content,omitempty"`
+	ETag    string `json:"etag,omitempty"`
+}
+
+type FunctionFiles struct {
+	Bundle bool         `json:"bundle"`
+	Dir    string       `json:"dir"`
+	Entry  string       `json:"entry"`
+	Files  []BundleFile `json:"files"`
+}
+
+// Files returns every file of a function with its etag
+func (c *Client) Files(ctx context.Context, id string) (FunctionFiles, error) {
+	var out FunctionFiles
+	return out, c.do(ctx, http.MethodGet, "/api/functions/"+id+"/files/", nil, &out)
+}
+
+// SaveFiles writes and deletes files of a bundle in one commit. Each file is checked
+// against its ETag, empty for a file that shouldn't exist yet; the new etags are returned
+func (c *Client) SaveFiles(ctx context.Context, id string, write, remove []BundleFile) ([]BundleFile, error) {
+	body := map[string]any{"files": write, "delete": remove, "message": c.message}
+	var out struct {
+		Files []BundleFile `json:"files"`
+	}
+	return out.Files, c.do(ctx, http.MethodPut, "/api/functions/"+id+"/files/", body, &out)
+}
+
+// DeleteFunction removes a function and its files, the operation is returned when the
+// push failed and the portal retries it in the background
+func (c *Client) DeleteFunction(ctx context.Context, id string) (string, error) {
+	var out struct {
+		Operation string `json:"operation"`
+	}
+	return out.Operation, c.do(ctx, http.MethodDelete, "/api/functions/"+id+"/"+c.messageQuery(), nil, &out)
+}
+
+// ListOperations lists the project's function operations in state, pending when empty
+func (c *Client) ListOperations(ctx context.Context, state string) ([]Operation, error) {
+	path := "/api/operations/"
+	if state != "" {
+		path += "?state=" + url.QueryEscape(state)
+	}
+	var out []Operation
+	return out, c.do(ctx, http.MethodGet, path, nil, &out)
+}
+
+// RetryOperation runs a pending operation now and returns how it went
+func (c *Client) RetryOperation(ctx context.Context, id string) (Operation, error) {
+	var out Operation
+	return out, c.do(ctx, http.MethodPost, "/api/operations/"+id+"/retry/", nil, &out)
+}
+
+// AbandonOperation gives up on a pending operation, a create loses its function
+func (c *Client) AbandonOperation(ctx context.Context, id string) (Operation, error) {
+	var out Operation
+	return out, c.do(ctx, http.MethodPost, "/api/operations/"+id+"/abandon/", nil, &out)
+}
+
+func (c *Client) ListEndpoints(ctx context.Context) ([]Endpoint, error) {
+	var out []Endpoint
+	return out, c.do(ctx, http.MethodGet, "/api/endpoints/", nil, &out)
+}
+
+// FindEndpoint resolves an endpoint by hex id or by path, which is only
+// unambiguous while a single method is bound to it
+func (c *Client) FindEndpoint(ctx context.Context, ref string) (Endpoint, error) {
+	eps, err := c.ListEndpoints(ctx)
+	if err != nil {
+		return Endpoint{}, err
+	}
+	name := ref
+	if !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+	var found []Endpoint
+	for _, e := range eps {
+		if e.ID == ref {
+			return e, nil
+		}
+		if e.Name == name {
+			found = append(found, e)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Endpoint{}, fmt.Errorf("endpoint %q not found", ref)
+	case 1:
+		return found[0], nil
+	default:
+		return Endpoint{}, fmt.Errorf("%d endpoints are bound to %s, use the endpoint id", len(found), name)
+	}
+}
+
+func (c *Client) CreateEndpoint(ctx context.Context, req EndpointRequest) (Endpoint, error) {
+	var out Endpoint
+	return out, c.do(ctx, http.MethodPost, "/api/endpoints/", req, &out)
+}
+
+func (c *Client) UpdateEndpoint(ctx context.Context, id string, req EndpointRequest) (Endpoint, error) {
+	var out Endpoint
+	return out, c.do(ctx, http.MethodPut, "/api/endpoints/"+id+"/", req, &out)
+}
+
+func (c *Client) DeleteEndpoint(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/endpoints/"+id+"/", nil, nil)
+}
+
+func (c *Client) GetConfig(ctx context.Context) ([]ConfigEntry, error) {
+	var out []ConfigEntry
+	return out, c.do(ctx, http.MethodGet, "/api/config/", nil, &out)
+}
+
+// SetConfig upserts entries, commit also writes the config file to the project repo.
+// With an environment picked the entries override the project wide values for it only
+func (c *Client) SetConfig(ctx context.Context, entries []ConfigEntry, commit bool) error {
+	body := map[string]any{"entries": entries, "commit": commit, "override": c.environment != ""}
+	return c.do(ctx, http.MethodPut, "/api/config/", body, nil)
+}
+
+func (c *Client) ListEnvironments(ctx context.Context) ([]Environment, error) {
+	var out []Environment
+	return out, c.do(ctx, http.MethodGet, "/api/environments/", nil, &out)
+}
+
+// CreateEnvironment adds an environment on branch, started from the from environment's
+// branch. Empty branch means the environment's name, empty from the default environment
+func (c *Client) CreateEnvironment(ctx context.Context, name, branch, from string) (Environment, error) {
+	var out Environment
+	body := map[string]string{"name": name, "branch": branch, "from": from}
+	return out, c.do(ctx, http.MethodPost, "/api/environments/", body, &out)
+}
+
+func (c *Client) DeleteEnvironment(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/api/environments/"+url.PathEscape(name)+"/", nil, nil)
+}
+
+// Promote merges environment from into to, the default environment when to is empty
+func (c *Client) Promote(ctx context.Context, from, to string) (PromoteResult, error) {
+	var out PromoteResult
+	body := map[string]string{"from": from, "to": to, "message": c.message}
+	return out, c.do(ctx, http.MethodPost, "/api/environments/promote/", body, &out)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := c.request(ctx, method, path, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req, out)
+}
+
+func (c *Client) request(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if c.project != "" {
+		req.Header.Set(ProjectHeader, c.project)
+	}
+	if c.environment != "" {
+		req.Header.Set(EnvironmentHeader, c.environment)
+	}
+	return req, nil
+}
+
+func (c *Client) send(req *http.Request, out any) error {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusConflict {
+		conflict := &ConflictError{}
+		// other 409s are plain {"error": ...} bodies without a base
+		if json.Unmarshal(data, conflict) == nil && conflict.BaseETag != "" {
+			return conflict
+		}
+	}
+	if resp.StatusCode >= 300 {
+		return apiError(resp.StatusCode, data)
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decoding response from %s: %w", req.URL.Path, err)
+	}
+	return nil
+}
+
+// apiError pulls the message out of the {"error": ...} or {"msg": ...} bodies handlers answer with
+func apiError(status int, body []byte) error {
+	var msg struct {
+		Error string `json:"error"`
+		Msg   string `json:"msg"`
+	}
+	_ = json.Unmarshal(body, &msg)
+	e := &APIError{Status: status, Message: msg.Error}
+	if e.Message == "" {
+		e.Message = msg.Msg
+	}
+	if e.Message == "" {
+		e.Message = strings.TrimSpace(string(body))
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(status)
+	}
+	return e
+}
@@ -0,0 +1,357 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakePortal serves the handful of function routes the client uses from memory
+type fakePortal struct {
+	mu      sync.Mutex
+	fns     map[string]*Function
+	headers http.Header
+	query   string
+	nextID  int
+}
+
+func newFakePortal(fns ...Function) *fakePortal {
+	p := &fakePortal{fns: map[string]*Function{}}
+	for i := range fns {
+		f := fns[i]
+		p.fns[f.ID] = &f
+	}
+	return p
+}
+
+func (p *fakePortal) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.headers = r.Header.Clone()
+	p.query = r.URL.RawQuery
+
+	if r.Header.Get("Authorization") != "Bearer lwsp_test" {
+		w.WriteHeader(401)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid access token"})
+		return
+	}
+
+	rest := strings.TrimPrefix(r.URL.Path, "/api/functions/")
+	id := strings.TrimSuffix(rest, "/")
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		out := []Function{}
+		for _, f := range p.fns {
+			out = append(out, Function{ID: f.ID, Name: f.Name, Language: f.Language, Path: f.Path})
+		}
+		json.NewEncoder(w).Encode(out)
+	case r.Method == http.MethodPost && id == "":
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		p.nextID++
+		f := &Function{
+			ID:       strings.Repeat("f", 31) + string(rune('0'+p.nextID)),
+			Name:     req["name"],
+			Language: req["language"],
+			Path:     "functions/" + req["language"] + "/" + req["name"] + ".lua",
+			Content:  req["path"],
+		}
+		p.fns[f.ID] = f
+		w.WriteHeader(201)
+		json.NewEncoder(w).Encode(f)
+	case r.Method == http.MethodGet:
+		f, ok := p.fns[id]
+		if !ok {
+			w.WriteHeader(404)
+			json.NewEncoder(w).Encode(map[string]string{"error": "not found"})
+			return
+		}
+		out := *f
+		out.ETag = blobHash([]byte(f.Content))
+		json.NewEncoder(w).Encode(out)
+	case r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f := p.fns[id]
+		current := blobHash([]byte(f.Content))
+		base := strings.Trim(r.Header.Get("If-Match"), `"`)
+		if base != "*" && base != current {
+			w.WriteHeader(409)
+			json.NewEncoder(w).Encode(ConflictError{
+				Message: "function changed since it was loaded", ETag: current, BaseETag: base,
+				Current: f.Content, Merged: f.Content + "\n" + string(body), Conflicts: 0,
+			})
+			return
+		}
+		f.Content = string(body)
+		json.NewEncoder(w).Encode(map[string]string{"status": "saved", "etag": blobHash(body)})
+	default:
+		w.WriteHeader(405)
+	}
+}
+
+func newTestClient(t *testing.T, h http.Handler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	c, err := New(Context{URL: srv.URL + "/", Token: "lwsp_test", Project: "demo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestContextRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "context.json")
+	t.Setenv("LWS_URL", "")
+	t.Setenv("LWS_TOKEN", "")
+	t.Setenv("LWS_PROJECT", "")
+
+	empty, err := LoadContext(path)
+	if err != nil || empty != (Context{}) {
+		t.Fatalf("missing file: got %+v, %v", empty, err)
+	}
+
+	want := Context{URL: "https://portal.example", Token: "lwsp_abc", Project: "demo"}
+	if err := SaveContext(path, want); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("context file mode = %v, want 0600", info.Mode().Perm())
+	}
+	got, err := LoadContext(path)
+	if err != nil || got != want {
+		t.Fatalf("got %+v, %v want %+v", got, err, want)
+	}
+
+	t.Setenv("LWS_PROJECT", "other")
+	got, _ = LoadContext(path)
+	if got != want {
+		t.Errorf("env leaked into the stored context: %+v", got)
+	}
+	if env := got.WithEnv(); env.Project != "other" || env.Token != want.Token {
+		t.Errorf("env override: got %+v", env)
+	}
+}
+
+func TestNewRequiresLogin(t *testing.T) {
+	if _, err := New(Context{URL: "http://x"}); err != ErrNotLoggedIn {
+		t.Errorf("got %v, want ErrNotLoggedIn", err)
+	}
+}
+
+func TestClientSendsTokenAndProject(t *testing.T) {
+	portal := newFakePortal(Function{ID: "aa", Name: "hello", Language: "lua", Path: "functions/lua/hello.lua", Content: "return 1"})
+	c := newTestClient(t, portal)
+
+	fn, err := c.GetFunction(context.Background(), "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fn.Content != "return 1" {
+		t.Errorf("content = %q", fn.Content)
+	}
+	if got := portal.headers.Get(ProjectHeader); got != "demo" {
+		t.Errorf("%s = %q, want demo", ProjectHeader, got)
+	}
+	if got := portal.headers.Get(EnvironmentHeader); got != "" {
+		t.Errorf("%s = %q without an environment picked", EnvironmentHeader, got)
+	}
+
+	if _, err := c.WithEnvironment("staging").ListFunctions(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := portal.headers.Get(EnvironmentHeader); got != "staging" {
+		t.Errorf("%s = %q, want staging", EnvironmentHeader, got)
+	}
+}
+
+func TestClientAPIError(t *testing.T) {
+	c := newTestClient(t, newFakePortal())
+	c.token = "wrong"
+
+	_, err := c.ListFunctions(context.Background())
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("got %T %v, want *APIError", err, err)
+	}
+	if apiErr.Status != 401 || apiErr.Message != "invalid access token" {
+		t.Errorf("got %+v", apiErr)
+	}
+}
+
+func TestPullPushRoundTrip(t *testing.T) {
+	portal := newFakePortal(
+		Function{ID: "aa", Name: "hello", Language: "lua", Path: "functions/lua/hello.lua", Content: "return 1"},
+		Function{ID: "bb", Name: "bye", Language: "lua", Path: "functions/lua/bye.lua", Content: "return 2"},
+	)
+	c := newTestClient(t, portal)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	written, err := c.Pull(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 2 {
+		t.Fatalf("pulled %v", written)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "functions", "lua", "hello.lua"))
+	if err != nil || string(data) != "return 1" {
+		t.Fatalf("hello.lua = %q, %v", data, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "functions", "lua", "hello.lua"), []byte("return 42"), 0644)
+	os.WriteFile(filepath.Join(dir, "functions", "lua", "fresh.lua"), []byte("return 3"), 0644)
+	os.WriteFile(filepath.Join(dir, "functions", "lua", "notes.txt"), []byte("ignored"), 0644)
+
+	results, err := c.Push(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := map[string]PushAction{}
+	for _, r := range results {
+		actions[r.Path] = r.Action
+	}
+	want := map[string]PushAction{
+		"functions/lua/hello.lua": PushUpdated,
+		"functions/lua/bye.lua":   PushUnchanged,
+		"functions/lua/fresh.lua": PushCreated,
+	}
+	if len(actions) != len(want) {
+		t.Fatalf("push results %v, want %v", actions, want)
+	}
+	for p, a := range want {
+		if actions[p] != a {
+			t.Errorf("%s: got %q want %q", p, actions[p], a)
+		}
+	}
+	if portal.fns["aa"].Content != "return 42" {
+		t.Errorf("remote hello = %q", portal.fns["aa"].Content)
+	}
+}
+
+func TestParseFunctionPath(t *testing.T) {
+	cases := []struct {
+		path, name, lang string
+		ok               bool
+	}{
+		{"functions/lua/hello.lua", "hello", "lua", true},
+		{"functions/python/job.py", "job", "python", true},
+		{"functions/lua/hello.py", "", "", false},
+		{"functions/lua/nested/hello.lua", "", "", false},
+		{"other/lua/hello.lua", "", "", false},
+		{"functions/lua/.lua", "", "", false},
+	}
+	for _, tc := range cases {
+		name, lang, ok := parseFunctionPath(tc.path)
+		if ok != tc.ok || name != tc.name || lang != tc.lang {
+			t.Errorf("%s: got (%q, %q, %v)", tc.path, name, lang, ok)
+		}
+	}
+}
+
+func TestLocalPathRejectsEscapes(t *testing.T) {
+	if _, err := localPath("/tmp/x", "../etc/passwd"); err == nil {
+		t.Error("expected error for parent traversal")
+	}
+	if _, err := localPath("/tmp/x", "functions/../../etc"); err == nil {
+		t.Error("expected error for embedded traversal")
+	}
+	got, err := localPath("/tmp/x", "functions/lua/a.lua")
+	if err != nil || got != filepath.Join("/tmp/x", "functions", "lua", "a.lua") {
+		t.Errorf("got %q, %v", got, err)
+	}
+}
+
+func TestBlobHashMatchesGit(t *testing.T) {
+	// git hash-object of an empty file and of "hello\n"
+	if got := blobHash(nil); got != "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391" {
+		t.Errorf("empty blob = %s", got)
+	}
+	if got := blobHash([]byte("hello\n")); got != "ce013625030ba8dba906f756967f9e9ca394464a" {
+		t.Errorf("hello blob = %s", got)
+	}
+}
+
+func TestPushSourceConflict(t *testing.T) {
+	portal := newFakePortal(Function{ID: "aa", Name: "hello", Language: "lua", Path: "functions/lua/hello.lua", Content: "return 1"})
+	c := newTestClient(t, portal)
+	ctx := context.Background()
+
+	fn, err := c.GetFunction(ctx, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err := c.PushSource(ctx, fn.ID, fn.ETag, "return 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if etag != blobHash([]byte("return 2")) {
+		t.Errorf("new etag = %s", etag)
+	}
+
+	// the etag from before the first push is stale now
+	_, err = c.PushSource(ctx, fn.ID, fn.ETag, "return 3")
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("want a conflict, got %v", err)
+	}
+	if conflict.ETag != etag || conflict.Current != "return 2" {
+		t.Errorf("conflict = %+v", conflict)
+	}
+	if portal.fns["aa"].Content != "return 2" {
+		t.Errorf("stale push overwrote the source: %q", portal.fns["aa"].Content)
+	}
+
+	if _, err := c.WithMessage("force it & move on").PushSource(ctx, fn.ID, "", "return 4"); err != nil {
+		t.Fatalf("unconditional push: %v", err)
+	}
+	if portal.query != "message=force+it+%26+move+on" {
+		t.Errorf("commit message sent as %q", portal.query)
+	}
+}
+
+func TestPushWritesMergeOnConflict(t *testing.T) {
+	portal := newFakePortal(Function{ID: "aa", Name: "hello", Language: "lua", Path: "functions/lua/hello.lua", Content: "return 1"})
+	c := newTestClient(t, portal)
+	ctx := context.Background()
+	dir := t.TempDir()
+	if _, err := c.Pull(ctx, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// someone else saves after the pull
+	portal.fns["aa"].Content = "return 9"
+	local := filepath.Join(dir, "functions", "lua", "hello.lua")
+	os.WriteFile(local, []byte("return 42"), 0644)
+
+	results, err := c.Push(ctx, dir)
+	if err == nil || len(results) != 1 || results[0].Action != PushConflict {
+		t.Fatalf("push = %v, %v", results, err)
+	}
+	data, _ := os.ReadFile(local)
+	if string(data) != "return 9\nreturn 42" {
+		t.Errorf("local file = %q, want the merge", data)
+	}
+
+	// the merge was made against the current version so the next push goes through
+	results, err = c.Push(ctx, dir)
+	if err != nil || results[0].Action != PushUpdated {
+		t.Fatalf("second push = %v, %v", results, err)
+	}
+	if portal.fns["aa"].Content != "return 9\nreturn 42" {
+		t.Errorf("remote = %q", portal.fns["aa"].Content)
+	}
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Context is what the cli remembers between runs: which portal to talk to, the
+// personal access token to use, the project commands act on and optionally one of its
+// environments
+type Context struct {
+	URL         string `json:"url"`
+	Token       string `json:"token"`
+	Project     string `json:"project,omitempty"`
+	Environment string `json:"environment,omitempty"`
+}
+
+// ContextPath is LWS_CONTEXT when set, else lws/context.json under the user config dir
+func ContextPath() (string, error) {
+	if p := os.Getenv("LWS_CONTEXT"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating config dir: %w", err)
+	}
+	return filepath.Join(dir, "lws", "context.json"), nil
+}
+
+// LoadContext reads the context file, a missing file is an empty context
+func LoadContext(path string) (Context, error) {
+	var ctx Context
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return ctx, fmt.Errorf("reading context: %w", err)
+	default:
+		if err := json.Unmarshal(data, &ctx); err != nil {
+			return ctx, fmt.Errorf("parsing context %s: %w", path, err)
+		}
+	}
+	return ctx, nil
+}
+
+// WithEnv applies LWS_URL, LWS_TOKEN, LWS_PROJECT and LWS_ENVIRONMENT over what's stored so CI
+// can skip login. Keep it out of anything that gets saved back
+func (ctx Context) WithEnv() Context {
+	if v := os.Getenv("LWS_URL"); v != "" {
+		ctx.URL = v
+	}
+	if v := os.Getenv("LWS_TOKEN"); v != "" {
+		ctx.Token = v
+	}
+	if v := os.Getenv("LWS_PROJECT"); v != "" {
+		ctx.Project = v
+	}
+	if v := os.Getenv("LWS_ENVIRONMENT"); v != "" {
+		ctx.Environment = v
+	}
+	return ctx
+}
+
+// SaveContext writes the context owner-only since it holds the token
+func SaveContext(path string, ctx Context) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("creating context dir: %w", err)
+	}
+	data, err := json.MarshalIndent(ctx, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("writing context: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
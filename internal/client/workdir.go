@@ -0,0 +1,253 @@
+package client
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// extLang maps source extensions to languages, functions live at functions/<language>/<name><ext>
+var extLang = map[string]string{
+	".py":  "python",
+	".go":  "go",
+	".rs":  "rust",
+	".js":  "javascript",
+	".lua": "lua",
+}
+
+const functionsDir = "functions"
+
+// etagsFile records, per repo path, the etag each source was pulled or pushed at so
+// push can tell the portal which version local edits started from
+const etagsFile = ".lws/etags.json"
+
+// blobHash is the git blob id of data, the etag the portal gives a source
+func blobHash(data []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(data))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func loadETags(dir string) (map[string]string, error) {
+	etags := map[string]string{}
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(etagsFile)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return etags, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &etags); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", etagsFile, err)
+	}
+	return etags, nil
+}
+
+func saveETags(dir string, etags map[string]string) error {
+	p := filepath.Join(dir, filepath.FromSlash(etagsFile))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(etags, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0644)
+}
+
+// LanguageOf guesses a function language from a file name, empty when unknown
+func LanguageOf(file string) string {
+	return extLang[filepath.Ext(file)]
+}
+
+// Pull writes every function's source under dir using the same layout as the
+// project repo, returning the repo paths it wrote
+func (c *Client) Pull(ctx context.Context, dir string) (written []string, err error) {
+	fns, err := c.ListFunctions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	etags, err := loadETags(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if saveErr := saveETags(dir, etags); err == nil {
+			err = saveErr
+		}
+	}()
+	written = make([]string, 0, len(fns))
+	for _, f := range fns {
+		full, err := c.functionSource(ctx, f.ID)
+		if err != nil {
+			return written, fmt.Errorf("fetching %s: %w", f.Name, err)
+		}
+		dst, err := localPath(dir, full.Path)
+		if err != nil {
+			return written, err
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(dst, []byte(full.Content), 0644); err != nil {
+			return written, err
+		}
+		etags[full.Path] = full.ETag
+		written = append(written, full.Path)
+	}
+	return written, nil
+}
+
+type PushAction string
+
+const (
+	PushCreated   PushAction = "created"
+	PushUpdated   PushAction = "updated"
+	PushUnchanged PushAction = "unchanged"
+	// PushConflict means the function changed remotely since it was pulled, the
+	// merge was written over the local file for review and the next push sends it
+	PushConflict PushAction = "conflict"
+)
+
+type PushResult struct {
+	Path   string
+	Action PushAction
+}
+
+// Push uploads the sources found under dir/functions, updating functions whose
+// path already exists and creating the rest. Files with unknown extensions or
+// outside functions/<language>/ are ignored. Updates are made against the etag
+// recorded at pull, files that moved on remotely come back as PushConflict
+func (c *Client) Push(ctx context.Context, dir string) (results []PushResult, err error) {
+	fns, err := c.ListFunctions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	etags, err := loadETags(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if saveErr := saveETags(dir, etags); err == nil {
+			err = saveErr
+		}
+		conflicts := 0
+		for _, r := range results {
+			if r.Action == PushConflict {
+				conflicts++
+			}
+		}
+		if err == nil && conflicts > 0 {
+			err = fmt.Errorf("%d function(s) changed remotely, resolve the merged files and push again", conflicts)
+		}
+	}()
+	byPath := make(map[string]Function, len(fns))
+	for _, f := range fns {
+		byPath[f.Path] = f
+	}
+
+	root := filepath.Join(dir, functionsDir)
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == root {
+				return fmt.Errorf("no %s directory in %s, run `lws fn pull` first", functionsDir, dir)
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		repoPath := filepath.ToSlash(rel)
+		name, lang, ok := parseFunctionPath(repoPath)
+		if !ok {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		existing, ok := byPath[repoPath]
+		if !ok {
+			if _, err := c.CreateFunction(ctx, name, lang, string(data)); err != nil {
+				return fmt.Errorf("creating %s: %w", repoPath, err)
+			}
+			etags[repoPath] = blobHash(data)
+			results = append(results, PushResult{Path: repoPath, Action: PushCreated})
+			return nil
+		}
+
+		remote, err := c.functionSource(ctx, existing.ID)
+		if err != nil {
+			return fmt.Errorf("fetching %s: %w", repoPath, err)
+		}
+		if remote.Content == string(data) {
+			etags[repoPath] = remote.ETag
+			results = append(results, PushResult{Path: repoPath, Action: PushUnchanged})
+			return nil
+		}
+		// dirs pulled before etags were recorded push over the current source
+		base, ok := etags[repoPath]
+		if !ok {
+			base = remote.ETag
+		}
+		etag, err := c.PushSource(ctx, existing.ID, base, string(data))
+		var conflict *ConflictError
+		if errors.As(err, &conflict) {
+			if err := os.WriteFile(p, []byte(conflict.Merged), 0644); err != nil {
+				return err
+			}
+			etags[repoPath] = conflict.ETag
+			results = append(results, PushResult{Path: repoPath, Action: PushConflict})
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("pushing %s: %w", repoPath, err)
+		}
+		etags[repoPath] = etag
+		results = append(results, PushResult{Path: repoPath, Action: PushUpdated})
+		return nil
+	})
+	return results, err
+}
+
+// parseFunctionPath splits functions/<language>/<name><ext>, rejecting
+// anything the portal wouldn't have created
+func parseFunctionPath(p string) (name, lang string, ok bool) {
+	parts := strings.Split(p, "/")
+	if len(parts) != 3 || parts[0] != functionsDir {
+		return "", "", false
+	}
+	ext := path.Ext(parts[2])
+	lang, ok = extLang[ext]
+	if !ok || lang != parts[1] {
+		return "", "", false
+	}
+	name = strings.TrimSuffix(parts[2], ext)
+	if name == "" {
+		return "", "", false
+	}
+	return name, lang, true
+}
+
+// localPath maps a repo path into dir, refusing paths that would escape it
+func localPath(dir, repoPath string) (string, error) {
+	clean := path.Clean("/" + repoPath)
+	if clean == "/" || clean != "/"+strings.TrimPrefix(repoPath, "/") {
+		return "", fmt.Errorf("refusing to write suspicious path %q", repoPath)
+	}
+	return filepath.Join(dir, filepath.FromSlash(clean[1:])), nil
+}
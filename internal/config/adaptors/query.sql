@@ -0,0 +1,70 @@
+-- PROJECT CONFIG QUERIES
+-- environment '' holds the project wide values, an environment's rows override them
+
+-- name: ListProjectConfig :many
+SELECT *
+FROM project_configs
+WHERE project_id = $1 AND environment = $2
+ORDER BY key ASC;
+
+-- name: ListResolvedProjectConfig :many
+-- an environment's values with project wide ones filling in the keys it doesn't set
+SELECT DISTINCT ON (key) *
+FROM project_configs
+WHERE project_id = $1 AND environment IN ('', sqlc.arg(environment)::TEXT)
+ORDER BY key ASC, environment DESC;
+
+-- name: GetProjectConfig :one
+SELECT *
+FROM project_configs
+WHERE project_id = $1 AND environment = $2 AND key = $3;
+
+-- name: UpsertProjectConfig :one
+INSERT INTO project_configs (project_id, environment, key, value, value_type, secret, version, updated_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (project_id, environment, key) DO UPDATE
+SET value = EXCLUDED.value,
+    value_type = EXCLUDED.value_type,
+    secret = EXCLUDED.secret,
+    version = EXCLUDED.version,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = now()
+RETURNING *;
+
+-- name: DeleteProjectConfig :exec
+DELETE FROM project_configs
+WHERE project_id = $1 AND environment = $2 AND key = $3;
+
+-- name: DeleteEnvironmentConfig :exec
+DELETE FROM project_configs
+WHERE project_id = $1 AND environment = $2;
+
+-- CONFIG HISTORY
+
+-- name: LockConfigKey :exec
+-- holds the key until the transaction ends, so concurrent edits number their versions in turn
+SELECT pg_advisory_xact_lock(hashtextextended(sqlc.arg(project_id)::uuid::text || '/' || sqlc.arg(environment)::text || '/' || sqlc.arg(key)::text, 0));
+
+-- name: GetLatestConfigVersion :one
+SELECT COALESCE(MAX(version), 0)::INTEGER
+FROM project_config_history
+WHERE project_id = $1 AND environment = $2 AND key = $3;
+
+-- name: InsertConfigHistory :exec
+INSERT INTO project_config_history (project_id, environment, key, value, value_type, secret, version, deleted, changed_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: ListConfigHistory :many
+SELECT *
+FROM project_config_history
+WHERE project_id = $1 AND environment = $2 AND key = $3
+ORDER BY version DESC;
+
+-- name: GetConfigHistoryVersion :one
+SELECT *
+FROM project_config_history
+WHERE project_id = $1 AND environment = $2 AND key = $3 AND version = $4;
+
+-- name: DeleteEnvironmentConfigHistory :exec
+DELETE FROM project_config_history
+WHERE project_id = $1 AND environment = $2;
@@ -0,0 +1,376 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: query.sql
+
+package adaptors
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteEnvironmentConfig = `-- name: DeleteEnvironmentConfig :exec
+DELETE FROM project_configs
+WHERE project_id = $1 AND environment = $2
+`
+
+type DeleteEnvironmentConfigParams struct {
+	ProjectID   pgtype.UUID
+	Environment string
+}
+
+func (q *Queries) DeleteEnvironmentConfig(ctx context.Context, arg DeleteEnvironmentConfigParams) error {
+	_, err := q.db.Exec(ctx, deleteEnvironmentConfig, arg.ProjectID, arg.Environment)
+	return err
+}
+
+const deleteEnvironmentConfigHistory = `-- name: DeleteEnvironmentConfigHistory :exec
+DELETE FROM project_config_history
+WHERE project_id = $1 AND environment = $2
+`
+
+type DeleteEnvironmentConfigHistoryParams struct {
+	ProjectID   pgtype.UUID
+	Environment string
+}
+
+func (q *Queries) DeleteEnvironmentConfigHistory(ctx context.Context, arg DeleteEnvironmentConfigHistoryParams) error {
+	_, err := q.db.Exec(ctx, deleteEnvironmentConfigHistory, arg.ProjectID, arg.Environment)
+	return err
+}
+
+const deleteProjectConfig = `-- name: DeleteProjectConfig :exec
+DELETE FROM project_configs
+WHERE project_id = $1 AND environment = $2 AND key = $3
+`
+
+type DeleteProjectConfigParams struct {
+	ProjectID   pgtype.UUID
+	Environment string
+	Key         string
+}
+
+func (q *Queries) DeleteProjectConfig(ctx context.Context, arg DeleteProjectConfigParams) error {
+	_, err := q.db.Exec(ctx, deleteProjectConfig, arg.ProjectID, arg.Environment, arg.Key)
+	return err
+}
+
+const getConfigHistoryVersion = `-- name: GetConfigHistoryVersion :one
+SELECT id, project_id, key, value, value_type, secret, version, deleted, changed_by, changed_at, environment
+FROM project_config_history
+WHERE project_id = $1 AND environment = $2 AND key = $3 AND version = $4
+`
+
+type GetConfigHistoryVersionParams struct {
+	ProjectID   pgtype.UUID
+	Environment string
+	Key         string
+	Version     int32
+}
+
+func (q *Queries) GetConfigHistoryVersion(ctx context.Context, arg GetConfigHistoryVersionParams) (ProjectConfigHistory, error) {
+	row := q.db.QueryRow(ctx, getConfigHistoryVersion,
+		arg.ProjectID,
+		arg.Environment,
+		arg.Key,
+		arg.Version,
+	)
+	var i ProjectConfigHistory
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Key,
+		&i.Value,
+		&i.ValueType,
+		&i.Secret,
+		&i.Version,
+		&i.Deleted,
+		&i.ChangedBy,
+		&i.ChangedAt,
+		&i.Environment,
+	)
+	return i, err
+}
+
+const getLatestConfigVersion = `-- name: GetLatestConfigVersion :one
+SELECT COALESCE(MAX(version), 0)::INTEGER
+FROM project_config_history
+WHERE project_id = $1 AND environment = $2 AND key = $3
+`
+
+type GetLatestConfigVersionParams struct {
+	ProjectID   pgtype.UUID
+	Environment string
+	Key         string
+}
+
+func (q *Queries) GetLatestConfigVersion(ctx context.Context, arg GetLatestConfigVersionParams) (int32, error) {
+	row := q.db.QueryRow(ctx, getLatestConfigVersion, arg.ProjectID, arg.Environment, arg.Key)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const getProjectConfig = `-- name: GetProjectConfig :one
+SELECT project_id, key, value, value_type, secret, version, updated_by, updated_at, environment
+FROM project_configs
+WHERE project_id = $1 AND environment = $2 AND key = $3
+`
+
+type GetProjectConfigParams struct {
+	ProjectID   pgtype.UUID
+	Environment string
+	Key         string
+}
+
+func (q *Queries) GetProjectConfig(ctx context.Context, arg GetProjectConfigParams) (ProjectConfig, error) {
+	row := q.db.QueryRow(ctx, getProjectConfig, arg.ProjectID, arg.Environment, arg.Key)
+	var i ProjectConfig
+	err := row.Scan(
+		&i.ProjectID,
+		&i.Key,
+		&i.Value,
+		&i.ValueType,
+		&i.Secret,
+		&i.Version,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+		&i.Environment,
+	)
+	return i, err
+}
+
+const insertConfigHistory = `-- name: InsertConfigHistory :exec
+INSERT INTO project_config_history (project_id, environment, key, value, value_type, secret, version, deleted, changed_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+`
+
+type InsertConfigHistoryParams struct {
+	ProjectID   pgtype.UUID
+	Environment string
+	Key         string
+	Value       string
+	ValueType   string
+	Secret      bool
+	Version     int32
+	Deleted     bool
+	ChangedBy   []byte
+}
+
+func (q *Queries) InsertConfigHistory(ctx context.Context, arg InsertConfigHistoryParams) error {
+	_, err := q.db.Exec(ctx, insertConfigHistory,
+		arg.ProjectID,
+		arg.Environment,
+		arg.Key,
+		arg.Value,
+		arg.ValueType,
+		arg.Secret,
+		arg.Version,
+		arg.Deleted,
+		arg.ChangedBy,
+	)
+	return err
+}
+
+const listConfigHistory = `-- name: ListConfigHistory :many
+SELECT id, project_id, key, value, value_type, secret, version, deleted, changed_by, changed_at, environment
+FROM project_config_history
+WHERE project_id = $1 AND environment = $2 AND key = $3
+ORDER BY version DESC
+`
+
+type ListConfigHistoryParams struct {
+	ProjectID   pgtype.UUID
+	Environment string
+	Key         string
+}
+
+func (q *Queries) ListConfigHistory(ctx context.Context, arg ListConfigHistoryParams) ([]ProjectConfigHistory, error) {
+	rows, err := q.db.Query(ctx, listConfigHistory, arg.ProjectID, arg.Environment, arg.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProjectConfigHistory
+	for rows.Next() {
+		var i ProjectConfigHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Key,
+			&i.Value,
+			&i.ValueType,
+			&i.Secret,
+			&i.Version,
+			&i.Deleted,
+			&i.ChangedBy,
+			&i.ChangedAt,
+			&i.Environment,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProjectConfig = `-- name: ListProjectConfig :many
+
+SELECT project_id, key, value, value_type, secret, version, updated_by, updated_at, environment
+FROM project_configs
+WHERE project_id = $1 AND environment = $2
+ORDER BY key ASC
+`
+
+type ListProjectConfigParams struct {
+	ProjectID   pgtype.UUID
+	Environment string
+}
+
+// PROJECT CONFIG QUERIES
+// environment ” holds the project wide values, an environment's rows override them
+func (q *Queries) ListProjectConfig(ctx context.Context, arg ListProjectConfigParams) ([]ProjectConfig, error) {
+	rows, err := q.db.Query(ctx, listProjectConfig, arg.ProjectID, arg.Environment)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProjectConfig
+	for rows.Next() {
+		var i ProjectConfig
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.Key,
+			&i.Value,
+			&i.ValueType,
+			&i.Secret,
+			&i.Version,
+			&i.UpdatedBy,
+			&i.UpdatedAt,
+			&i.Environment,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listResolvedProjectConfig = `-- name: ListResolvedProjectConfig :many
+SELECT DISTINCT ON (key) project_id, key, value, value_type, secret, version, updated_by, updated_at, environment
+FROM project_configs
+WHERE project_id = $1 AND environment IN ('', $2::TEXT)
+ORDER BY key ASC, environment DESC
+`
+
+type ListResolvedProjectConfigParams struct {
+	ProjectID   pgtype.UUID
+	Environment string
+}
+
+// an environment's values with project wide ones filling in the keys it doesn't set
+func (q *Queries) ListResolvedProjectConfig(ctx context.Context, arg ListResolvedProjectConfigParams) ([]ProjectConfig, error) {
+	rows, err := q.db.Query(ctx, listResolvedProjectConfig, arg.ProjectID, arg.Environment)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProjectConfig
+	for rows.Next() {
+		var i ProjectConfig
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.Key,
+			&i.Value,
+			&i.ValueType,
+			&i.Secret,
+			&i.Version,
+			&i.UpdatedBy,
+			&i.UpdatedAt,
+			&i.Environment,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockConfigKey = `-- name: LockConfigKey :exec
+
+SELECT pg_advisory_xact_lock(hashtextextended($1::uuid::text || '/' || $2::text || '/' || $3::text, 0))
+`
+
+type LockConfigKeyParams struct {
+	ProjectID   pgtype.UUID
+	Environment string
+	Key         string
+}
+
+// CONFIG HISTORY
+// holds the key until the transaction ends, so concurrent edits number their versions in turn
+func (q *Queries) LockConfigKey(ctx context.Context, arg LockConfigKeyParams) error {
+	_, err := q.db.Exec(ctx, lockConfigKey, arg.ProjectID, arg.Environment, arg.Key)
+	return err
+}
+
+const upsertProjectConfig = `-- name: UpsertProjectConfig :one
+INSERT INTO project_configs (project_id, environment, key, value, value_type, secret, version, updated_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (project_id, environment, key) DO UPDATE
+SET value = EXCLUDED.value,
+    value_type = EXCLUDED.value_type,
+    secret = EXCLUDED.secret,
+    version = EXCLUDED.version,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = now()
+RETURNING project_id, key, value, value_type, secret, version, updated_by, updated_at, environment
+`
+
+type UpsertProjectConfigParams struct {
+	ProjectID   pgtype.UUID
+	Environment string
+	Key         string
+	Value       string
+	ValueType   string
+	Secret      bool
+	Version     int32
+	UpdatedBy   []byte
+}
+
+func (q *Queries) UpsertProjectConfig(ctx context.Context, arg UpsertProjectConfigParams) (ProjectConfig, error) {
+	row := q.db.QueryRow(ctx, upsertProjectConfig,
+		arg.ProjectID,
+		arg.Environment,
+		arg.Key,
+		arg.Value,
+		arg.ValueType,
+		arg.Secret,
+		arg.Version,
+		arg.UpdatedBy,
+	)
+	var i ProjectConfig
+	err := row.Scan(
+		&i.ProjectID,
+		&i.Key,
+		&i.Value,
+		&i.ValueType,
+		&i.Secret,
+		&i.Version,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+		&i.Environment,
+	)
+	return i, err
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+const (
+	TypeString = "string"
+	TypeNumber = "number"
+	TypeBool   = "bool"
+	TypeJSON   = "json"
+)
+
+// MaskedValue is returned in place of secret values on every read path
+const MaskedValue = "********"
+
+// RepoPath is where the committed config lives inside the project repo
+const RepoPath = "config/lws.yaml"
+
+var keyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+type Entry struct {
+	Key    string
+	Value  string
+	Type   string
+	Secret bool
+}
+
+func ValidKey(key string) bool {
+	return len(key) <= 128 && keyPattern.MatchString(key)
+}
+
+// Normalize validates raw against the value type and returns its canonical string form
+func Normalize(valueType, raw string) (string, error) {
+	switch valueType {
+	case TypeString:
+		return raw, nil
+	case TypeNumber:
+		n, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return "", fmt.Errorf("value is not a number: %s", raw)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case TypeBool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return "", fmt.Errorf("value is not a bool: %s", raw)
+		}
+		return strconv.FormatBool(b), nil
+	case TypeJSON:
+		var v any
+		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+			return "", fmt.Errorf("value is not valid json: %w", err)
+		}
+		out, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	default:
+		return "", fmt.Errorf("invalid value type: %s (supported: string, number, bool, json)", valueType)
+	}
+}
+
+// Typed converts a normalized value into its native go representation
+func Typed(valueType, value string) (any, error) {
+	switch valueType {
+	case TypeNumber:
+		return strconv.ParseFloat(value, 64)
+	case TypeBool:
+		return strconv.ParseBool(value)
+	case TypeJSON:
+		var v any
+		err := json.Unmarshal([]byte(value), &v)
+		return v, err
+	default:
+		return value, nil
+	}
+}
+
+func Mask(value string, secret bool) string {
+	if secret {
+		return MaskedValue
+	}
+	return value
+}
+
+// RenderYAML produces the config/lws.yaml document committed to the project repo.
+// Secret values are never written, only their key names are listed.
+func RenderYAML(entries []Entry) ([]byte, error) {
+	sorted := make([]Entry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+
+	values := yaml.MapSlice{}
+	secrets := []string{}
+	for _, e := range sorted {
+		if e.Secret {
+			secrets = append(secrets, e.Key)
+			continue
+		}
+		v, err := Typed(e.Type, e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", e.Key, err)
+		}
+		values = append(values, yaml.MapItem{Key: e.Key, Value: v})
+	}
+
+	doc := yaml.MapSlice{
+		{Key: "config", Value: values},
+		{Key: "secrets", Value: secrets},
+	}
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte("# managed by litewebservices portal, secret values are not committed\n"), out...), nil
+}
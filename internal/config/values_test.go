@@ -0,0 +1,80 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		valueType string
+		raw       string
+		want      string
+		wantErr   bool
+	}{
+		{name: "string passthrough", valueType: TypeString, raw: " production ", want: " production "},
+		{name: "integer number", valueType: TypeNumber, raw: "10", want: "10"},
+		{name: "float number", valueType: TypeNumber, raw: " 2.50 ", want: "2.5"},
+		{name: "invalid number", valueType: TypeNumber, raw: "ten", wantErr: true},
+		{name: "bool", valueType: TypeBool, raw: "TRUE", want: "true"},
+		{name: "invalid bool", valueType: TypeBool, raw: "yes", wantErr: true},
+		{name: "json compacted", valueType: TypeJSON, raw: `{ "a": [1, 2] }`, want: `{"a":[1,2]}`},
+		{name: "invalid json", valueType: TypeJSON, raw: `{a}`, wantErr: true},
+		{name: "unknown type", valueType: "yaml", raw: "a: b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.valueType, tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Normalize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderYAML(t *testing.T) {
+	out, err := RenderYAML([]Entry{
+		{Key: "MAX_ITEMS", Value: "10", Type: TypeNumber},
+		{Key: "APP_MODE", Value: "production", Type: TypeString},
+		{Key: "DB_PASSWORD", Value: "hunter2", Type: TypeString, Secret: true},
+	})
+	if err != nil {
+		t.Fatalf("RenderYAML() error = %v", err)
+	}
+	doc := string(out)
+
+	if strings.Contains(doc, "hunter2") {
+		t.Errorf("RenderYAML() leaked a secret value:\n%s", doc)
+	}
+	if !strings.Contains(doc, "DB_PASSWORD") {
+		t.Errorf("RenderYAML() missing secret key name:\n%s", doc)
+	}
+	if strings.Index(doc, "APP_MODE") > strings.Index(doc, "MAX_ITEMS") {
+		t.Errorf("RenderYAML() keys not sorted:\n%s", doc)
+	}
+	if !strings.Contains(doc, "MAX_ITEMS: 10") {
+		t.Errorf("RenderYAML() number not rendered natively:\n%s", doc)
+	}
+}
+
+func TestValidKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"APP_MODE":    true,
+		"db.host":     true,
+		"_private":    true,
+		"1_LEADING":   false,
+		"WITH SPACE":  false,
+		"":            false,
+		"feature-flg": true,
+	} {
+		if got := ValidKey(key); got != want {
+			t.Errorf("ValidKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
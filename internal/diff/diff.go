@@ -0,0 +1,185 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lines splits text into lines keeping their terminators, so joining them gives the text back
+func Lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// match maps every line of a to the line of b it's paired with in a shortest
+// edit script, or -1 when the line was deleted. Pairs are strictly increasing.
+func match(a, b []string) []int {
+	n, m := len(a), len(b)
+	out := make([]int, n)
+	for i := range out {
+		out[i] = -1
+	}
+	if n == 0 || m == 0 {
+		return out
+	}
+
+	// myers: v[k] is the furthest x on diagonal k, trace keeps v per edit distance
+	max := n + m
+	offset := max
+	v := make([]int, 2*max+2)
+	var trace [][]int
+	found := false
+	for d := 0; d <= max && !found; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	// walk back through the trace, recording the diagonal (matching) moves
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			out[x] = y
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		out[x] = y
+	}
+	return out
+}
+
+type op struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+func script(a, b []string) []op {
+	pairs := match(a, b)
+	var ops []op
+	j := 0
+	for i, p := range pairs {
+		if p < 0 {
+			ops = append(ops, op{'-', a[i]})
+			continue
+		}
+		for ; j < p; j++ {
+			ops = append(ops, op{'+', b[j]})
+		}
+		ops = append(ops, op{' ', a[i]})
+		j++
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, op{'+', b[j]})
+	}
+	return ops
+}
+
+// Unified renders a unified diff from a to b with three lines of context, empty when they're equal
+func Unified(fromName, toName, a, b string) string {
+	ops := script(Lines(a), Lines(b))
+	const context = 3
+
+	var sb strings.Builder
+	header := false
+	for start := 0; start < len(ops); {
+		// find the next change
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		lo := max(start-context, 0)
+		// extend the hunk while changes are within two contexts of each other
+		hi := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				hi = i
+			} else if i-hi > 2*context {
+				break
+			}
+		}
+		hi = min(hi+context+1, len(ops))
+
+		aStart, bStart := 1, 1
+		for _, o := range ops[:lo] {
+			if o.kind != '+' {
+				aStart++
+			}
+			if o.kind != '-' {
+				bStart++
+			}
+		}
+		aLen, bLen := 0, 0
+		for _, o := range ops[lo:hi] {
+			if o.kind != '+' {
+				aLen++
+			}
+			if o.kind != '-' {
+				bLen++
+			}
+		}
+
+		if !header {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+			header = true
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+		for _, o := range ops[lo:hi] {
+			sb.WriteByte(o.kind)
+			sb.WriteString(o.line)
+			if !strings.HasSuffix(o.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = hi
+	}
+	return sb.String()
+}
+
+func hunkRange(start, length int) string {
+	if length == 0 {
+		start--
+	}
+	if length == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, length)
+}
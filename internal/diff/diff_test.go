@@ -0,0 +1,96 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLinesRoundTrip(t *testing.T) {
+	for _, s := range []string{"", "a", "a\n", "a\nb", "a\n\nb\n"} {
+		if got := strings.Join(Lines(s), ""); got != s {
+			t.Errorf("%q: joined back to %q", s, got)
+		}
+	}
+}
+
+func TestMatchIsMonotonicAndCorrect(t *testing.T) {
+	a := Lines("a\nb\nc\nd\ne\n")
+	b := Lines("x\nb\nc\ny\ne\nz\n")
+	m := match(a, b)
+	last := -1
+	kept := 0
+	for i, j := range m {
+		if j < 0 {
+			continue
+		}
+		if j <= last {
+			t.Fatalf("pairs not increasing: %v", m)
+		}
+		if a[i] != b[j] {
+			t.Fatalf("paired %q with %q", a[i], b[j])
+		}
+		last = j
+		kept++
+	}
+	if kept != 3 {
+		t.Errorf("kept %d lines, want b, c and e: %v", kept, m)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	if got := Unified("a", "b", "same\n", "same\n"); got != "" {
+		t.Errorf("equal inputs produced %q", got)
+	}
+	got := Unified("base", "theirs", "one\ntwo\nthree\n", "one\n2\nthree\nfour\n")
+	want := "--- base\n+++ theirs\n@@ -1,3 +1,4 @@\n one\n-two\n+2\n three\n+four\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedSplitsDistantHunks(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 20; i++ {
+		a = append(a, "line\n")
+		b = append(b, "line\n")
+	}
+	b[1] = "first\n"
+	b[18] = "second\n"
+	got := Unified("a", "b", strings.Join(a, ""), strings.Join(b, ""))
+	if n := strings.Count(got, "@@ -"); n != 2 {
+		t.Errorf("got %d hunks, want 2:\n%s", n, got)
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+	cases := []struct {
+		name, ours, theirs, want string
+		conflicts                int
+	}{
+		{"only ours", "a\nB\nc\nd\ne\n", base, "a\nB\nc\nd\ne\n", 0},
+		{"only theirs", base, "a\nb\nc\nD\ne\n", "a\nb\nc\nD\ne\n", 0},
+		{"disjoint", "A\nb\nc\nd\ne\n", "a\nb\nc\nd\nE\n", "A\nb\nc\nd\nE\n", 0},
+		{"same change", "a\nX\nc\nd\ne\n", "a\nX\nc\nd\ne\n", "a\nX\nc\nd\ne\n", 0},
+		{"append both ends", "top\n" + base, base + "bottom\n", "top\n" + base + "bottom\n", 0},
+		{
+			"conflict", "a\nours\nc\nd\ne\n", "a\ntheirs\nc\nd\ne\n",
+			"a\n<<<<<<< yours\nours\n||||||| base\nb\n=======\ntheirs\n>>>>>>> current\nc\nd\ne\n", 1,
+		},
+		{"theirs deleted a line", base, "a\nc\nd\ne\n", "a\nc\nd\ne\n", 0},
+	}
+	for _, tc := range cases {
+		got, n := Merge3(base, tc.ours, tc.theirs, "yours", "current")
+		if got != tc.want || n != tc.conflicts {
+			t.Errorf("%s: got %d conflicts\n%s\nwant %d\n%s", tc.name, n, got, tc.conflicts, tc.want)
+		}
+	}
+}
+
+func TestMerge3MissingFinalNewline(t *testing.T) {
+	got, n := Merge3("x", "ours", "theirs", "yours", "current")
+	want := "<<<<<<< yours\nours\n||||||| base\nx\n=======\ntheirs\n>>>>>>> current\n"
+	if got != want || n != 1 {
+		t.Errorf("got %d conflicts\n%q", n, got)
+	}
+}
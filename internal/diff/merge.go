@@ -0,0 +1,81 @@
+package diff
+
+import "strings"
+
+// Merge3 applies the changes base→ours and base→theirs together. Regions both
+// sides changed differently are written diff3 style between conflict markers
+// and counted in conflicts.
+func Merge3(base, ours, theirs string, oursName, theirsName string) (merged string, conflicts int) {
+	o, a, b := Lines(base), Lines(ours), Lines(theirs)
+	ma, mb := match(o, a), match(o, b)
+
+	var sb strings.Builder
+	emit := func(lines []string) {
+		for _, l := range lines {
+			sb.WriteString(l)
+		}
+	}
+	// emitSide ends a conflict section with a newline so markers stay on their own lines
+	emitSide := func(lines []string) {
+		emit(lines)
+		if n := len(lines); n > 0 && !strings.HasSuffix(lines[n-1], "\n") {
+			sb.WriteByte('\n')
+		}
+	}
+	resolve := func(oc, ac, bc []string) {
+		switch {
+		case equal(ac, oc):
+			emit(bc)
+		case equal(bc, oc), equal(ac, bc):
+			emit(ac)
+		default:
+			conflicts++
+			sb.WriteString("<<<<<<< " + oursName + "\n")
+			emitSide(ac)
+			sb.WriteString("||||||| base\n")
+			emitSide(oc)
+			sb.WriteString("=======\n")
+			emitSide(bc)
+			sb.WriteString(">>>>>>> " + theirsName + "\n")
+		}
+	}
+
+	i, ai, bi := 0, 0, 0
+	for i < len(o) || ai < len(a) || bi < len(b) {
+		// lines unchanged on both sides pass straight through
+		k := 0
+		for i+k < len(o) && ma[i+k] == ai+k && mb[i+k] == bi+k {
+			k++
+		}
+		if k > 0 {
+			emit(o[i : i+k])
+			i, ai, bi = i+k, ai+k, bi+k
+			continue
+		}
+
+		// the changed region runs up to the next base line both sides kept
+		j := i
+		for j < len(o) && (ma[j] < 0 || mb[j] < 0) {
+			j++
+		}
+		if j == len(o) {
+			resolve(o[i:], a[ai:], b[bi:])
+			break
+		}
+		resolve(o[i:j], a[ai:ma[j]], b[bi:mb[j]])
+		i, ai, bi = j, ma[j], mb[j]
+	}
+	return sb.String(), conflicts
+}
+
+func equal(x, y []string) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
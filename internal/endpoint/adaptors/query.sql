@@ -0,0 +1,60 @@
+-- ENDPOINT QUERIES
+
+-- name: CreateEndpoint :one
+INSERT INTO endpoints (project_id, name, method, scope, function_id, rate_limit, rate_window_seconds, rate_burst, rate_key,
+                       api_key_scope, jwt_secret, jwt_jwks, jwt_issuer, jwt_audience)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING *;
+
+-- name: GetEndpointByID :one
+SELECT e.*, f.name AS function_name, f.language AS function_language
+FROM endpoints e
+JOIN functions f ON f.id = e.function_id
+WHERE e.id = $1 AND e.project_id = $2;
+
+-- name: ListEndpointsForProject :many
+SELECT e.*, f.name AS function_name, f.language AS function_language
+FROM endpoints e
+JOIN functions f ON f.id = e.function_id
+WHERE e.project_id = $1
+ORDER BY e.name ASC, e.method ASC;
+
+-- name: UpdateEndpoint :one
+UPDATE endpoints
+SET name = $3,
+    method = $4,
+    scope = $5,
+    function_id = $6,
+    rate_limit = $7,
+    rate_window_seconds = $8,
+    rate_burst = $9,
+    rate_key = $10,
+    api_key_scope = $11,
+    jwt_secret = $12,
+    jwt_jwks = $13,
+    jwt_issuer = $14,
+    jwt_audience = $15
+WHERE id = $1 AND project_id = $2
+RETURNING *;
+
+-- name: DeleteEndpoint :exec
+DELETE FROM endpoints
+WHERE id = $1 AND project_id = $2;
+
+-- name: ListGatewayRoutes :many
+SELECT e.id, e.project_id, e.name, e.method, e.scope,
+       e.rate_limit, e.rate_window_seconds, e.rate_burst, e.rate_key,
+       e.api_key_scope, e.jwt_secret, e.jwt_jwks, e.jwt_issuer, e.jwt_audience,
+       f.id AS function_id, f.name AS function_name, f.language AS function_language, f.path AS function_path
+FROM endpoints e
+JOIN functions f ON f.id = e.function_id
+JOIN projects p ON p.id = e.project_id
+WHERE p.name = $1;
+
+-- name: GetGatewayEnvironment :one
+-- an empty environment resolves to the project's default one
+SELECT e.name, e.branch
+FROM project_environments e
+JOIN projects p ON p.id = e.project_id
+WHERE p.name = sqlc.arg(project)::TEXT
+  AND (e.name = sqlc.arg(environment)::TEXT OR (sqlc.arg(environment)::TEXT = '' AND e.is_default));
@@ -0,0 +1,393 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: query.sql
+
+package adaptors
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createEndpoint = `-- name: CreateEndpoint :one
+
+INSERT INTO endpoints (project_id, name, method, scope, function_id, rate_limit, rate_window_seconds, rate_burst, rate_key,
+                       api_key_scope, jwt_secret, jwt_jwks, jwt_issuer, jwt_audience)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING id, project_id, name, method, scope, function_id, created_at, rate_limit, rate_window_seconds, rate_burst, rate_key, api_key_scope, jwt_secret, jwt_jwks, jwt_issuer, jwt_audience
+`
+
+type CreateEndpointParams struct {
+	ProjectID         pgtype.UUID
+	Name              string
+	Method            string
+	Scope             string
+	FunctionID        pgtype.UUID
+	RateLimit         pgtype.Int4
+	RateWindowSeconds int32
+	RateBurst         pgtype.Int4
+	RateKey           string
+	ApiKeyScope       string
+	JwtSecret         string
+	JwtJwks           string
+	JwtIssuer         string
+	JwtAudience       string
+}
+
+// ENDPOINT QUERIES
+func (q *Queries) CreateEndpoint(ctx context.Context, arg CreateEndpointParams) (Endpoint, error) {
+	row := q.db.QueryRow(ctx, createEndpoint,
+		arg.ProjectID,
+		arg.Name,
+		arg.Method,
+		arg.Scope,
+		arg.FunctionID,
+		arg.RateLimit,
+		arg.RateWindowSeconds,
+		arg.RateBurst,
+		arg.RateKey,
+		arg.ApiKeyScope,
+		arg.JwtSecret,
+		arg.JwtJwks,
+		arg.JwtIssuer,
+		arg.JwtAudience,
+	)
+	var i Endpoint
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Method,
+		&i.Scope,
+		&i.FunctionID,
+		&i.CreatedAt,
+		&i.RateLimit,
+		&i.RateWindowSeconds,
+		&i.RateBurst,
+		&i.RateKey,
+		&i.ApiKeyScope,
+		&i.JwtSecret,
+		&i.JwtJwks,
+		&i.JwtIssuer,
+		&i.JwtAudience,
+	)
+	return i, err
+}
+
+const deleteEndpoint = `-- name: DeleteEndpoint :exec
+DELETE FROM endpoints
+WHERE id = $1 AND project_id = $2
+`
+
+type DeleteEndpointParams struct {
+	ID        pgtype.UUID
+	ProjectID pgtype.UUID
+}
+
+func (q *Queries) DeleteEndpoint(ctx context.Context, arg DeleteEndpointParams) error {
+	_, err := q.db.Exec(ctx, deleteEndpoint, arg.ID, arg.ProjectID)
+	return err
+}
+
+const getEndpointByID = `-- name: GetEndpointByID :one
+SELECT e.id, e.project_id, e.name, e.method, e.scope, e.function_id, e.created_at, e.rate_limit, e.rate_window_seconds, e.rate_burst, e.rate_key, e.api_key_scope, e.jwt_secret, e.jwt_jwks, e.jwt_issuer, e.jwt_audience, f.name AS function_name, f.language AS function_language
+FROM endpoints e
+JOIN functions f ON f.id = e.function_id
+WHERE e.id = $1 AND e.project_id = $2
+`
+
+type GetEndpointByIDParams struct {
+	ID        pgtype.UUID
+	ProjectID pgtype.UUID
+}
+
+type GetEndpointByIDRow struct {
+	ID                pgtype.UUID
+	ProjectID         pgtype.UUID
+	Name              string
+	Method            string
+	Scope             string
+	FunctionID        pgtype.UUID
+	CreatedAt         pgtype.Timestamptz
+	RateLimit         pgtype.Int4
+	RateWindowSeconds int32
+	RateBurst         pgtype.Int4
+	RateKey           string
+	ApiKeyScope       string
+	JwtSecret         string
+	JwtJwks           string
+	JwtIssuer         string
+	JwtAudience       string
+	FunctionName      string
+	FunctionLanguage  string
+}
+
+func (q *Queries) GetEndpointByID(ctx context.Context, arg GetEndpointByIDParams) (GetEndpointByIDRow, error) {
+	row := q.db.QueryRow(ctx, getEndpointByID, arg.ID, arg.ProjectID)
+	var i GetEndpointByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Method,
+		&i.Scope,
+		&i.FunctionID,
+		&i.CreatedAt,
+		&i.RateLimit,
+		&i.RateWindowSeconds,
+		&i.RateBurst,
+		&i.RateKey,
+		&i.ApiKeyScope,
+		&i.JwtSecret,
+		&i.JwtJwks,
+		&i.JwtIssuer,
+		&i.JwtAudience,
+		&i.FunctionName,
+		&i.FunctionLanguage,
+	)
+	return i, err
+}
+
+const getGatewayEnvironment = `-- name: GetGatewayEnvironment :one
+SELECT e.name, e.branch
+FROM project_environments e
+JOIN projects p ON p.id = e.project_id
+WHERE p.name = $1::TEXT
+  AND (e.name = $2::TEXT OR ($2::TEXT = '' AND e.is_default))
+`
+
+type GetGatewayEnvironmentParams struct {
+	Project     string
+	Environment string
+}
+
+type GetGatewayEnvironmentRow struct {
+	Name   string
+	Branch string
+}
+
+// an empty environment resolves to the project's default one
+func (q *Queries) GetGatewayEnvironment(ctx context.Context, arg GetGatewayEnvironmentParams) (GetGatewayEnvironmentRow, error) {
+	row := q.db.QueryRow(ctx, getGatewayEnvironment, arg.Project, arg.Environment)
+	var i GetGatewayEnvironmentRow
+	err := row.Scan(&i.Name, &i.Branch)
+	return i, err
+}
+
+const listEndpointsForProject = `-- name: ListEndpointsForProject :many
+SELECT e.id, e.project_id, e.name, e.method, e.scope, e.function_id, e.created_at, e.rate_limit, e.rate_window_seconds, e.rate_burst, e.rate_key, e.api_key_scope, e.jwt_secret, e.jwt_jwks, e.jwt_issuer, e.jwt_audience, f.name AS function_name, f.language AS function_language
+FROM endpoints e
+JOIN functions f ON f.id = e.function_id
+WHERE e.project_id = $1
+ORDER BY e.name ASC, e.method ASC
+`
+
+type ListEndpointsForProjectRow struct {
+	ID                pgtype.UUID
+	ProjectID         pgtype.UUID
+	Name              string
+	Method            string
+	Scope             string
+	FunctionID        pgtype.UUID
+	CreatedAt         pgtype.Timestamptz
+	RateLimit         pgtype.Int4
+	RateWindowSeconds int32
+	RateBurst         pgtype.Int4
+	RateKey           string
+	ApiKeyScope       string
+	JwtSecret         string
+	JwtJwks           string
+	JwtIssuer         string
+	JwtAudience       string
+	FunctionName      string
+	FunctionLanguage  string
+}
+
+func (q *Queries) ListEndpointsForProject(ctx context.Context, projectID pgtype.UUID) ([]ListEndpointsForProjectRow, error) {
+	rows, err := q.db.Query(ctx, listEndpointsForProject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListEndpointsForProjectRow
+	for rows.Next() {
+		var i ListEndpointsForProjectRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Name,
+			&i.Method,
+			&i.Scope,
+			&i.FunctionID,
+			&i.CreatedAt,
+			&i.RateLimit,
+			&i.RateWindowSeconds,
+			&i.RateBurst,
+			&i.RateKey,
+			&i.ApiKeyScope,
+			&i.JwtSecret,
+			&i.JwtJwks,
+			&i.JwtIssuer,
+			&i.JwtAudience,
+			&i.FunctionName,
+			&i.FunctionLanguage,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGatewayRoutes = `-- name: ListGatewayRoutes :many
+SELECT e.id, e.project_id, e.name, e.method, e.scope,
+       e.rate_limit, e.rate_window_seconds, e.rate_burst, e.rate_key,
+       e.api_key_scope, e.jwt_secret, e.jwt_jwks, e.jwt_issuer, e.jwt_audience,
+       f.id AS function_id, f.name AS function_name, f.language AS function_language, f.path AS function_path
+FROM endpoints e
+JOIN functions f ON f.id = e.function_id
+JOIN projects p ON p.id = e.project_id
+WHERE p.name = $1
+`
+
+type ListGatewayRoutesRow struct {
+	ID                pgtype.UUID
+	ProjectID         pgtype.UUID
+	Name              string
+	Method            string
+	Scope             string
+	RateLimit         pgtype.Int4
+	RateWindowSeconds int32
+	RateBurst         pgtype.Int4
+	RateKey           string
+	ApiKeyScope       string
+	JwtSecret         string
+	JwtJwks           string
+	JwtIssuer         string
+	JwtAudience       string
+	FunctionID        pgtype.UUID
+	FunctionName      string
+	FunctionLanguage  string
+	FunctionPath      string
+}
+
+func (q *Queries) ListGatewayRoutes(ctx context.Context, name string) ([]ListGatewayRoutesRow, error) {
+	rows, err := q.db.Query(ctx, listGatewayRoutes, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListGatewayRoutesRow
+	for rows.Next() {
+		var i ListGatewayRoutesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Name,
+			&i.Method,
+			&i.Scope,
+			&i.RateLimit,
+			&i.RateWindowSeconds,
+			&i.RateBurst,
+			&i.RateKey,
+			&i.ApiKeyScope,
+			&i.JwtSecret,
+			&i.JwtJwks,
+			&i.JwtIssuer,
+			&i.JwtAudience,
+			&i.FunctionID,
+			&i.FunctionName,
+			&i.FunctionLanguage,
+			&i.FunctionPath,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateEndpoint = `-- name: UpdateEndpoint :one
+UPDATE endpoints
+SET name = $3,
+    method = $4,
+    scope = $5,
+    function_id = $6,
+    rate_limit = $7,
+    rate_window_seconds = $8,
+    rate_burst = $9,
+    rate_key = $10,
+    api_key_scope = $11,
+    jwt_secret = $12,
+    jwt_jwks = $13,
+    jwt_issuer = $14,
+    jwt_audience = $15
+WHERE id = $1 AND project_id = $2
+RETURNING id, project_id, name, method, scope, function_id, created_at, rate_limit, rate_window_seconds, rate_burst, rate_key, api_key_scope, jwt_secret, jwt_jwks, jwt_issuer, jwt_audience
+`
+
+type UpdateEndpointParams struct {
+	ID                pgtype.UUID
+	ProjectID         pgtype.UUID
+	Name              string
+	Method            string
+	Scope             string
+	FunctionID        pgtype.UUID
+	RateLimit         pgtype.Int4
+	RateWindowSeconds int32
+	RateBurst         pgtype.Int4
+	RateKey           string
+	ApiKeyScope       string
+	JwtSecret         string
+	JwtJwks           string
+	JwtIssuer         string
+	JwtAudience       string
+}
+
+func (q *Queries) UpdateEndpoint(ctx context.Context, arg UpdateEndpointParams) (Endpoint, error) {
+	row := q.db.QueryRow(ctx, updateEndpoint,
+		arg.ID,
+		arg.ProjectID,
+		arg.Name,
+		arg.Method,
+		arg.Scope,
+		arg.FunctionID,
+		arg.RateLimit,
+		arg.RateWindowSeconds,
+		arg.RateBurst,
+		arg.RateKey,
+		arg.ApiKeyScope,
+		arg.JwtSecret,
+		arg.JwtJwks,
+		arg.JwtIssuer,
+		arg.JwtAudience,
+	)
+	var i Endpoint
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Method,
+		&i.Scope,
+		&i.FunctionID,
+		&i.CreatedAt,
+		&i.RateLimit,
+		&i.RateWindowSeconds,
+		&i.RateBurst,
+		&i.RateKey,
+		&i.ApiKeyScope,
+		&i.JwtSecret,
+		&i.JwtJwks,
+		&i.JwtIssuer,
+		&i.JwtAudience,
+	)
+	return i, err
+}
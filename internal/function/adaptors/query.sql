@@ -1,6 +1,6 @@
 -- name: CreateFunction :one
-INSERT INTO functions (project_id, name, language, path, created_by)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO functions (project_id, name, language, path, created_by, content_hash)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: GetFunctionByID :one
@@ -20,8 +20,78 @@ SET path = $2
 WHERE id = $1
 RETURNING *;
 
-
+-- name: UpdateFunctionFromRepo :exec
+UPDATE functions
+SET name = $2,
+    language = $3,
+    path = $4,
+    content_hash = $5
+WHERE id = $1;
 
 -- name: DeleteFunction :exec
 DELETE FROM functions
 WHERE id = $1;
+
+-- FUNCTION OPERATIONS
+
+-- name: CreateFunctionOperation :one
+INSERT INTO function_operations (project_id, function_id, kind, branch, function_path, edits, commit_message, author_name, author_email, created_by, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, now() + sqlc.arg(lease)::interval)
+RETURNING *;
+
+-- name: GetFunctionOperation :one
+SELECT *
+FROM function_operations
+WHERE id = $1 AND project_id = $2;
+
+-- name: ListFunctionOperations :many
+SELECT *
+FROM function_operations
+WHERE project_id = $1 AND state = $2
+ORDER BY created_at DESC
+LIMIT 100;
+
+-- name: ListPendingCreatePaths :many
+SELECT f.path
+FROM functions f
+JOIN function_operations o ON o.function_id = f.id
+WHERE f.project_id = $1 AND o.kind = 'create' AND o.state = 'pending';
+
+-- name: ClaimDueFunctionOperations :many
+-- leases due operations to one worker, others skip them until the lease runs out
+UPDATE function_operations
+SET next_attempt_at = now() + sqlc.arg(lease)::interval,
+    updated_at = now()
+WHERE id IN (
+    SELECT o.id
+    FROM function_operations o
+    WHERE o.state = 'pending' AND o.next_attempt_at <= now()
+    ORDER BY o.next_attempt_at
+    LIMIT sqlc.arg(batch)::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: FailFunctionOperation :one
+UPDATE function_operations
+SET attempts = attempts + 1,
+    last_error = $2,
+    next_attempt_at = now() + sqlc.arg(backoff)::interval,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: FinishFunctionOperation :execrows
+UPDATE function_operations
+SET state = $2,
+    last_error = COALESCE($3, last_error),
+    updated_at = now()
+WHERE id = $1 AND state = 'pending';
+
+-- name: RetryFunctionOperation :one
+-- leases a pending operation to the request retrying it
+UPDATE function_operations
+SET next_attempt_at = now() + sqlc.arg(lease)::interval,
+    updated_at = now()
+WHERE id = sqlc.arg(id) AND project_id = sqlc.arg(project_id) AND state = 'pending'
+RETURNING *;
@@ -11,18 +11,78 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const claimDueFunctionOperations = `-- name: ClaimDueFunctionOperations :many
+UPDATE function_operations
+SET next_attempt_at = now() + $1::interval,
+    updated_at = now()
+WHERE id IN (
+    SELECT o.id
+    FROM function_operations o
+    WHERE o.state = 'pending' AND o.next_attempt_at <= now()
+    ORDER BY o.next_attempt_at
+    LIMIT $2::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, project_id, function_id, kind, branch, function_path, edits, commit_message, author_name, author_email, state, attempts, last_error, next_attempt_at, created_by, created_at, updated_at
+`
+
+type ClaimDueFunctionOperationsParams struct {
+	Lease pgtype.Interval
+	Batch int32
+}
+
+// leases due operations to one worker, others skip them until the lease runs out
+func (q *Queries) ClaimDueFunctionOperations(ctx context.Context, arg ClaimDueFunctionOperationsParams) ([]FunctionOperation, error) {
+	rows, err := q.db.Query(ctx, claimDueFunctionOperations, arg.Lease, arg.Batch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FunctionOperation
+	for rows.Next() {
+		var i FunctionOperation
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.FunctionID,
+			&i.Kind,
+			&i.Branch,
+			&i.FunctionPath,
+			&i.Edits,
+			&i.CommitMessage,
+			&i.AuthorName,
+			&i.AuthorEmail,
+			&i.State,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createFunction = `-- name: CreateFunction :one
-INSERT INTO functions (project_id, name, language, path, created_by)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, project_id, name, language, path, created_by, created_at
+INSERT INTO functions (project_id, name, language, path, created_by, content_hash)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, project_id, name, language, path, created_by, created_at, content_hash
 `
 
 type CreateFunctionParams struct {
-	ProjectID pgtype.UUID
-	Name      string
-	Language  string
-	Path      string
-	CreatedBy []byte
+	ProjectID   pgtype.UUID
+	Name        string
+	Language    string
+	Path        string
+	CreatedBy   []byte
+	ContentHash string
 }
 
 func (q *Queries) CreateFunction(ctx context.Context, arg CreateFunctionParams) (Function, error) {
@@ -32,6 +92,7 @@ func (q *Queries) CreateFunction(ctx context.Context, arg CreateFunctionParams)
 		arg.Language,
 		arg.Path,
 		arg.CreatedBy,
+		arg.ContentHash,
 	)
 	var i Function
 	err := row.Scan(
@@ -42,6 +103,66 @@ func (q *Queries) CreateFunction(ctx context.Context, arg CreateFunctionParams)
 		&i.Path,
 		&i.CreatedBy,
 		&i.CreatedAt,
+		&i.ContentHash,
+	)
+	return i, err
+}
+
+const createFunctionOperation = `-- name: CreateFunctionOperation :one
+
+INSERT INTO function_operations (project_id, function_id, kind, branch, function_path, edits, commit_message, author_name, author_email, created_by, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, now() + $11::interval)
+RETURNING id, project_id, function_id, kind, branch, function_path, edits, commit_message, author_name, author_email, state, attempts, last_error, next_attempt_at, created_by, created_at, updated_at
+`
+
+type CreateFunctionOperationParams struct {
+	ProjectID     pgtype.UUID
+	FunctionID    pgtype.UUID
+	Kind          string
+	Branch        string
+	FunctionPath  string
+	Edits         []byte
+	CommitMessage string
+	AuthorName    string
+	AuthorEmail   string
+	CreatedBy     []byte
+	Lease         pgtype.Interval
+}
+
+// FUNCTION OPERATIONS
+func (q *Queries) CreateFunctionOperation(ctx context.Context, arg CreateFunctionOperationParams) (FunctionOperation, error) {
+	row := q.db.QueryRow(ctx, createFunctionOperation,
+		arg.ProjectID,
+		arg.FunctionID,
+		arg.Kind,
+		arg.Branch,
+		arg.FunctionPath,
+		arg.Edits,
+		arg.CommitMessage,
+		arg.AuthorName,
+		arg.AuthorEmail,
+		arg.CreatedBy,
+		arg.Lease,
+	)
+	var i FunctionOperation
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.FunctionID,
+		&i.Kind,
+		&i.Branch,
+		&i.FunctionPath,
+		&i.Edits,
+		&i.CommitMessage,
+		&i.AuthorName,
+		&i.AuthorEmail,
+		&i.State,
+		&i.Attempts,
+		&i.LastError,
+		&i.NextAttemptAt,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
@@ -56,8 +177,71 @@ func (q *Queries) DeleteFunction(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const failFunctionOperation = `-- name: FailFunctionOperation :one
+UPDATE function_operations
+SET attempts = attempts + 1,
+    last_error = $2,
+    next_attempt_at = now() + $3::interval,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, project_id, function_id, kind, branch, function_path, edits, commit_message, author_name, author_email, state, attempts, last_error, next_attempt_at, created_by, created_at, updated_at
+`
+
+type FailFunctionOperationParams struct {
+	ID        pgtype.UUID
+	LastError pgtype.Text
+	Backoff   pgtype.Interval
+}
+
+func (q *Queries) FailFunctionOperation(ctx context.Context, arg FailFunctionOperationParams) (FunctionOperation, error) {
+	row := q.db.QueryRow(ctx, failFunctionOperation, arg.ID, arg.LastError, arg.Backoff)
+	var i FunctionOperation
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.FunctionID,
+		&i.Kind,
+		&i.Branch,
+		&i.FunctionPath,
+		&i.Edits,
+		&i.CommitMessage,
+		&i.AuthorName,
+		&i.AuthorEmail,
+		&i.State,
+		&i.Attempts,
+		&i.LastError,
+		&i.NextAttemptAt,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const finishFunctionOperation = `-- name: FinishFunctionOperation :execrows
+UPDATE function_operations
+SET state = $2,
+    last_error = COALESCE($3, last_error),
+    updated_at = now()
+WHERE id = $1 AND state = 'pending'
+`
+
+type FinishFunctionOperationParams struct {
+	ID        pgtype.UUID
+	State     string
+	LastError pgtype.Text
+}
+
+func (q *Queries) FinishFunctionOperation(ctx context.Context, arg FinishFunctionOperationParams) (int64, error) {
+	result, err := q.db.Exec(ctx, finishFunctionOperation, arg.ID, arg.State, arg.LastError)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getFunctionByID = `-- name: GetFunctionByID :one
-SELECT id, project_id, name, language, path, created_by, created_at
+SELECT id, project_id, name, language, path, created_by, created_at, content_hash
 FROM functions
 WHERE id = $1
 `
@@ -73,12 +257,100 @@ func (q *Queries) GetFunctionByID(ctx context.Context, id pgtype.UUID) (Function
 		&i.Path,
 		&i.CreatedBy,
 		&i.CreatedAt,
+		&i.ContentHash,
 	)
 	return i, err
 }
 
+const getFunctionOperation = `-- name: GetFunctionOperation :one
+SELECT id, project_id, function_id, kind, branch, function_path, edits, commit_message, author_name, author_email, state, attempts, last_error, next_attempt_at, created_by, created_at, updated_at
+FROM function_operations
+WHERE id = $1 AND project_id = $2
+`
+
+type GetFunctionOperationParams struct {
+	ID        pgtype.UUID
+	ProjectID pgtype.UUID
+}
+
+func (q *Queries) GetFunctionOperation(ctx context.Context, arg GetFunctionOperationParams) (FunctionOperation, error) {
+	row := q.db.QueryRow(ctx, getFunctionOperation, arg.ID, arg.ProjectID)
+	var i FunctionOperation
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.FunctionID,
+		&i.Kind,
+		&i.Branch,
+		&i.FunctionPath,
+		&i.Edits,
+		&i.CommitMessage,
+		&i.AuthorName,
+		&i.AuthorEmail,
+		&i.State,
+		&i.Attempts,
+		&i.LastError,
+		&i.NextAttemptAt,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listFunctionOperations = `-- name: ListFunctionOperations :many
+SELECT id, project_id, function_id, kind, branch, function_path, edits, commit_message, author_name, author_email, state, attempts, last_error, next_attempt_at, created_by, created_at, updated_at
+FROM function_operations
+WHERE project_id = $1 AND state = $2
+ORDER BY created_at DESC
+LIMIT 100
+`
+
+type ListFunctionOperationsParams struct {
+	ProjectID pgtype.UUID
+	State     string
+}
+
+func (q *Queries) ListFunctionOperations(ctx context.Context, arg ListFunctionOperationsParams) ([]FunctionOperation, error) {
+	rows, err := q.db.Query(ctx, listFunctionOperations, arg.ProjectID, arg.State)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FunctionOperation
+	for rows.Next() {
+		var i FunctionOperation
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.FunctionID,
+			&i.Kind,
+			&i.Branch,
+			&i.FunctionPath,
+			&i.Edits,
+			&i.CommitMessage,
+			&i.AuthorName,
+			&i.AuthorEmail,
+			&i.State,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listFunctionsForProject = `-- name: ListFunctionsForProject :many
-SELECT id, project_id, name, language, path, created_by, created_at
+SELECT id, project_id, name, language, path, created_by, created_at, content_hash
 FROM functions
 WHERE project_id = $1
 ORDER BY language ASC, created_at DESC
@@ -101,6 +373,7 @@ func (q *Queries) ListFunctionsForProject(ctx context.Context, projectID pgtype.
 			&i.Path,
 			&i.CreatedBy,
 			&i.CreatedAt,
+			&i.ContentHash,
 		); err != nil {
 			return nil, err
 		}
@@ -112,11 +385,106 @@ func (q *Queries) ListFunctionsForProject(ctx context.Context, projectID pgtype.
 	return items, nil
 }
 
+const listPendingCreatePaths = `-- name: ListPendingCreatePaths :many
+SELECT f.path
+FROM functions f
+JOIN function_operations o ON o.function_id = f.id
+WHERE f.project_id = $1 AND o.kind = 'create' AND o.state = 'pending'
+`
+
+func (q *Queries) ListPendingCreatePaths(ctx context.Context, projectID pgtype.UUID) ([]string, error) {
+	rows, err := q.db.Query(ctx, listPendingCreatePaths, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		items = append(items, path)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const retryFunctionOperation = `-- name: RetryFunctionOperation :one
+UPDATE function_operations
+SET next_attempt_at = now() + $1::interval,
+    updated_at = now()
+WHERE id = $2 AND project_id = $3 AND state = 'pending'
+RETURNING id, project_id, function_id, kind, branch, function_path, edits, commit_message, author_name, author_email, state, attempts, last_error, next_attempt_at, created_by, created_at, updated_at
+`
+
+type RetryFunctionOperationParams struct {
+	Lease     pgtype.Interval
+	ID        pgtype.UUID
+	ProjectID pgtype.UUID
+}
+
+// leases a pending operation to the request retrying it
+func (q *Queries) RetryFunctionOperation(ctx context.Context, arg RetryFunctionOperationParams) (FunctionOperation, error) {
+	row := q.db.QueryRow(ctx, retryFunctionOperation, arg.Lease, arg.ID, arg.ProjectID)
+	var i FunctionOperation
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.FunctionID,
+		&i.Kind,
+		&i.Branch,
+		&i.FunctionPath,
+		&i.Edits,
+		&i.CommitMessage,
+		&i.AuthorName,
+		&i.AuthorEmail,
+		&i.State,
+		&i.Attempts,
+		&i.LastError,
+		&i.NextAttemptAt,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateFunctionFromRepo = `-- name: UpdateFunctionFromRepo :exec
+UPDATE functions
+SET name = $2,
+    language = $3,
+    path = $4,
+    content_hash = $5
+WHERE id = $1
+`
+
+type UpdateFunctionFromRepoParams struct {
+	ID          pgtype.UUID
+	Name        string
+	Language    string
+	Path        string
+	ContentHash string
+}
+
+func (q *Queries) UpdateFunctionFromRepo(ctx context.Context, arg UpdateFunctionFromRepoParams) error {
+	_, err := q.db.Exec(ctx, updateFunctionFromRepo,
+		arg.ID,
+		arg.Name,
+		arg.Language,
+		arg.Path,
+		arg.ContentHash,
+	)
+	return err
+}
+
 const updateFunctionPath = `-- name: UpdateFunctionPath :one
 UPDATE functions
 SET path = $2
 WHERE id = $1
-RETURNING id, project_id, name, language, path, created_by, created_at
+RETURNING id, project_id, name, language, path, created_by, created_at, content_hash
 `
 
 type UpdateFunctionPathParams struct {
@@ -135,6 +503,7 @@ func (q *Queries) UpdateFunctionPath(ctx context.Context, arg UpdateFunctionPath
 		&i.Path,
 		&i.CreatedBy,
 		&i.CreatedAt,
+		&i.ContentHash,
 	)
 	return i, err
 }
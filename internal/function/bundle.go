@@ -0,0 +1,111 @@
+package function
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// A function is either a single file, functions/<language>/<name><ext>, or a bundle:
+// a functions/<language>/<name>/ directory holding a manifest next to its entry file
+// and whatever else it needs (requirements.txt, go.mod, helper modules...). Either
+// way the db stores the path of the file the runtime loads. functions/ is the root
+// for projects the portal created, imported ones can keep theirs anywhere.
+
+// DefaultRoot is the directory functions live under in repos the portal creates
+const DefaultRoot = "functions"
+
+// ManifestFile marks a directory under functions/<language>/ as a bundle
+const ManifestFile = "lws.yaml"
+
+// Extensions maps languages to the extension of their source files
+var Extensions = map[string]string{
+	"python":     ".py",
+	"go":         ".go",
+	"rust":       ".rs",
+	"javascript": ".js",
+	"lua":        ".lua",
+}
+
+type Manifest struct {
+	Name     string `yaml:"name"`
+	Language string `yaml:"language"`
+	// Entry is the bundle relative file the runtime loads
+	Entry string `yaml:"entry"`
+}
+
+// NewManifest describes a fresh bundle whose entry is main<ext>
+func NewManifest(name, language string) Manifest {
+	return Manifest{Name: name, Language: language, Entry: "main" + Extensions[language]}
+}
+
+func ParseManifest(data []byte) (Manifest, error) {
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("invalid %s: %w", ManifestFile, err)
+	}
+	if m.Name == "" || m.Entry == "" {
+		return m, fmt.Errorf("invalid %s: name and entry are required", ManifestFile)
+	}
+	if _, ok := Extensions[m.Language]; !ok {
+		return m, fmt.Errorf("invalid %s: unknown language %q", ManifestFile, m.Language)
+	}
+	entry, err := CleanPath(m.Entry)
+	if err != nil || entry == ManifestFile {
+		return m, fmt.Errorf("invalid %s: bad entry %q", ManifestFile, m.Entry)
+	}
+	m.Entry = entry
+	return m, nil
+}
+
+func (m Manifest) YAML() ([]byte, error) {
+	return yaml.Marshal(m)
+}
+
+// SourcePath is where a single file function lives in a repo keeping functions under
+// root, an empty root being the repo's top
+func SourcePath(root, language, name string) string {
+	return path.Join(root, language, name+Extensions[language])
+}
+
+// BundleDir is where a bundle function lives in a repo keeping functions under root
+func BundleDir(root, language, name string) string {
+	return path.Join(root, language, name)
+}
+
+// CleanRoot validates a functions root, "" and "." are the repo's top
+func CleanRoot(root string) (string, error) {
+	root = strings.Trim(root, "/")
+	if root == "" || root == "." {
+		return "", nil
+	}
+	clean, err := CleanPath(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid functions root %q", root)
+	}
+	return clean, nil
+}
+
+// ValidName checks a function name is a single path element, so neither its source
+// file nor its bundle directory can land outside functions/<language>/
+func ValidName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+		return fmt.Errorf("invalid function name %q", name)
+	}
+	return nil
+}
+
+// CleanPath validates a path inside a bundle, returning it cleaned. Absolute paths
+// and anything that climbs out of the bundle are refused
+func CleanPath(p string) (string, error) {
+	if p == "" || strings.HasPrefix(p, "/") || strings.Contains(p, "\\") {
+		return "", fmt.Errorf("invalid file path %q", p)
+	}
+	clean := path.Clean(p)
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("invalid file path %q", p)
+	}
+	return clean, nil
+}
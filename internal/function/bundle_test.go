@@ -0,0 +1,86 @@
+package function
+
+import "testing"
+
+func TestParseManifest(t *testing.T) {
+	m, err := ParseManifest([]byte("name: hello\nlanguage: python\nentry: ./src/main.py\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "hello" || m.Language != "python" || m.Entry != "src/main.py" {
+		t.Errorf("manifest = %+v", m)
+	}
+
+	for _, bad := range []string{
+		"name: hello\nlanguage: python\n",
+		"name: hello\nlanguage: cobol\nentry: main.cob\n",
+		"name: hello\nlanguage: python\nentry: ../main.py\n",
+		"name: hello\nlanguage: python\nentry: lws.yaml\n",
+	} {
+		if _, err := ParseManifest([]byte(bad)); err == nil {
+			t.Errorf("accepted %q", bad)
+		}
+	}
+
+	// what NewManifest writes reads back the same
+	data, err := NewManifest("hello", "lua").YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, err := ParseManifest(data); err != nil || m != NewManifest("hello", "lua") {
+		t.Errorf("round trip = %+v, %v", m, err)
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	ok := map[string]string{
+		"main.py":            "main.py",
+		"lib/../util.py":     "util.py",
+		"./requirements.txt": "requirements.txt",
+	}
+	for in, want := range ok {
+		if got, err := CleanPath(in); err != nil || got != want {
+			t.Errorf("CleanPath(%q) = %q, %v", in, got, err)
+		}
+	}
+	for _, bad := range []string{"", ".", "/etc/passwd", "../x", "lib/../../x", `lib\x`} {
+		if _, err := CleanPath(bad); err == nil {
+			t.Errorf("CleanPath(%q) accepted", bad)
+		}
+	}
+}
+
+func TestCleanRoot(t *testing.T) {
+	ok := map[string]string{
+		"":              "",
+		".":             "",
+		"/":             "",
+		"functions":     "functions",
+		"/src/lws/":     "src/lws",
+		"services/./fn": "services/fn",
+	}
+	for in, want := range ok {
+		if got, err := CleanRoot(in); err != nil || got != want {
+			t.Errorf("CleanRoot(%q) = %q, %v", in, got, err)
+		}
+	}
+	for _, bad := range []string{"..", "../x", `src\fn`} {
+		if _, err := CleanRoot(bad); err == nil {
+			t.Errorf("CleanRoot(%q) accepted", bad)
+		}
+	}
+	if p := SourcePath("", "lua", "hello"); p != "lua/hello.lua" {
+		t.Errorf("SourcePath at the repo top = %q", p)
+	}
+}
+
+func TestValidName(t *testing.T) {
+	if err := ValidName("hello-world"); err != nil {
+		t.Errorf("ValidName(hello-world) = %v", err)
+	}
+	for _, bad := range []string{"", ".", "..", "../x", "a/b", `a\b`} {
+		if err := ValidName(bad); err == nil {
+			t.Errorf("ValidName(%q) accepted", bad)
+		}
+	}
+}
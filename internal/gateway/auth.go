@@ -0,0 +1,90 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/apikey"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ScopePublic  = "public"
+	ScopeSession = "authn"
+	ScopeAPIKey  = "api_key"
+	ScopeJWT     = "jwt"
+)
+
+// KeyAuthenticator resolves an api key to a key of the named project
+type KeyAuthenticator interface {
+	Authenticate(ctx context.Context, project, raw string) (apikey.Key, error)
+}
+
+// authenticate enforces the endpoint's auth mode before anything runs. It returns the
+// identity headers to hand the function, or writes the error response and returns false.
+func (g *Gateway) authenticate(c *gin.Context, project string, route *Route) (map[string]string, bool) {
+	switch route.Scope {
+	case ScopePublic:
+		return nil, true
+
+	case ScopeSession:
+		if !c.GetBool("authenticated") {
+			c.JSON(401, gin.H{"error": "authentication required"})
+			return nil, false
+		}
+		return map[string]string{"x-lws-user": c.GetString("userName")}, true
+
+	case ScopeAPIKey:
+		raw := apikey.FromRequest(c.Request.Header)
+		if raw == "" {
+			c.JSON(401, gin.H{"error": "api key required"})
+			return nil, false
+		}
+		key, err := g.keys.Authenticate(c.Request.Context(), project, raw)
+		if err != nil {
+			if errors.Is(err, apikey.ErrInvalid) || errors.Is(err, apikey.ErrExpired) {
+				c.JSON(401, gin.H{"error": err.Error()})
+				return nil, false
+			}
+			fmt.Printf("[ERROR] gateway api key lookup for %s: %v\n", project, err)
+			c.JSON(500, gin.H{"error": "failed to verify api key"})
+			return nil, false
+		}
+		if !key.HasScope(route.APIKeyScope) {
+			c.JSON(403, gin.H{"error": "api key lacks scope " + route.APIKeyScope})
+			return nil, false
+		}
+		c.Set("apiKeyID", key.ID)
+		return map[string]string{"x-lws-api-key": key.Prefix, "x-lws-api-key-name": key.Name}, true
+
+	case ScopeJWT:
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			c.Header("WWW-Authenticate", `Bearer`)
+			c.JSON(401, gin.H{"error": "bearer token required"})
+			return nil, false
+		}
+		claims, err := g.jwt.Verify(c.Request.Context(), project, route.Branch, route.JWT, strings.TrimSpace(token))
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.JSON(401, gin.H{"error": "invalid token"})
+			return nil, false
+		}
+		headers := map[string]string{}
+		if sub, err := claims.GetSubject(); err == nil && sub != "" {
+			headers["x-lws-user"] = sub
+		}
+		if b, err := json.Marshal(claims); err == nil {
+			headers["x-lws-claims"] = string(b)
+		}
+		return headers, true
+	}
+
+	// a scope this build doesn't know is never served open
+	fmt.Printf("[ERROR] gateway endpoint %s has unknown auth mode %q\n", route.Name, route.Scope)
+	c.JSON(500, gin.H{"error": "endpoint misconfigured"})
+	return nil, false
+}
@@ -0,0 +1,299 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/apikey"
+	"github.com/ashupednekar/litewebservices-portal/internal/jwtauth"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
+	"github.com/ashupednekar/litewebservices-portal/internal/ratelimit"
+	"github.com/ashupednekar/litewebservices-portal/internal/runtime"
+	"github.com/gin-gonic/gin"
+)
+
+const maxRequestBody = 1 << 20
+
+// privateRequestHeaders carry the portal's own credentials, the gateway shares the portal's
+// origin so sessions, tokens and api keys would otherwise reach every project's functions
+var privateRequestHeaders = map[string]bool{
+	"cookie":              true,
+	"authorization":       true,
+	"x-api-key":           true,
+	"proxy-authorization": true,
+}
+
+// blockedResponseHeaders are the gateway's to set: cookies would land on the portal's
+// origin, a function's own csp or cors headers would lift the sandbox it's served in,
+// hop-by-hop and framing headers describe the gateway's own connection. access-control-*
+// is blocked as a prefix
+var blockedResponseHeaders = map[string]bool{
+	"set-cookie":                          true,
+	"content-security-policy":             true,
+	"content-security-policy-report-only": true,
+	"x-content-type-options":              true,
+	"connection":                          true,
+	"keep-alive":                          true,
+	"proxy-authenticate":                  true,
+	"proxy-connection":                    true,
+	"te":                                  true,
+	"trailer":                             true,
+	"transfer-encoding":                   true,
+	"upgrade":                             true,
+	"content-length":                      true,
+}
+
+// SourceFunc returns the source of a function file at a branch of a project's repo
+type SourceFunc func(ctx context.Context, ref repo.Ref, path string) ([]byte, error)
+
+// RepoSource reads function source from the managed clone without pulling, cloning on first use
+func RepoSource(repos *repo.Manager) SourceFunc {
+	return func(ctx context.Context, ref repo.Ref, path string) ([]byte, error) {
+		var data []byte
+		err := repos.View(ref, func(r *repo.GitRepo) error {
+			f, err := r.Fs.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			data, err = io.ReadAll(f)
+			return err
+		})
+		return data, err
+	}
+}
+
+type Gateway struct {
+	Table   *Table
+	source  SourceFunc
+	limiter ratelimit.Store
+	keys    KeyAuthenticator
+	jwt     *jwtauth.Verifier
+	runtime func(language string) (runtime.Runtime, error)
+	limits  func() runtime.Limits
+}
+
+func New(table *Table, source SourceFunc, limiter ratelimit.Store, keys KeyAuthenticator) *Gateway {
+	return &Gateway{
+		Table:   table,
+		source:  source,
+		limiter: limiter,
+		keys:    keys,
+		// JWKS files are read from the branch of the environment serving the request
+		jwt: jwtauth.NewVerifier(func(ctx context.Context, project, branch, path string) ([]byte, error) {
+			return source(ctx, repo.Ref{Project: project, Branch: branch}, path)
+		}),
+		runtime: runtime.New,
+		limits:  runtime.DefaultLimits,
+	}
+}
+
+// Serve handles /x/:project/*path, it expects OptionalAuthMiddleware to have run so authn
+// scoped endpoints can check for a session. api_key and jwt endpoints verify the request's
+// credential themselves. :project may name an environment as project@env, without one
+// the project's default environment serves the request.
+func (g *Gateway) Serve(c *gin.Context) {
+	// functions answer on the portal's origin, where a page they return could use the
+	// visitor's session. Nothing they return may run or be sniffed into running there
+	c.Header("Content-Security-Policy", "sandbox")
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	project, environment, _ := strings.Cut(c.Param("project"), "@")
+	path := c.Param("path")
+	method := c.Request.Method
+
+	route, params, found, err := g.Table.Match(c.Request.Context(), project, environment, method, path)
+	if errors.Is(err, ErrUnknownEnvironment) {
+		c.JSON(404, gin.H{"error": "no such environment"})
+		return
+	}
+	if err != nil {
+		fmt.Printf("[ERROR] gateway route load failed for %s: %v\n", project, err)
+		c.JSON(500, gin.H{"error": "failed to load routes"})
+		return
+	}
+	if route == nil {
+		if found {
+			c.JSON(405, gin.H{"error": "method not allowed"})
+			return
+		}
+		c.JSON(404, gin.H{"error": "no such endpoint"})
+		return
+	}
+
+	// buckets are keyed on who the caller proved to be, so auth runs first
+	identity, ok := g.authenticate(c, project, route)
+	if !ok {
+		return
+	}
+	if !g.allow(c, project, route, identity) {
+		return
+	}
+
+	rt, err := g.runtime(route.Language)
+	if err != nil {
+		c.JSON(501, gin.H{"error": err.Error()})
+		return
+	}
+	source, err := g.source(c.Request.Context(), repo.Ref{Project: project, Branch: route.Branch}, route.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// endpoints are project wide, the function may not have reached this environment yet
+		c.JSON(404, gin.H{"error": "function is not deployed to this environment"})
+		return
+	}
+	if err != nil {
+		fmt.Printf("[ERROR] gateway source for %s/%s: %v\n", project, route.Path, err)
+		c.JSON(502, gin.H{"error": "function source unavailable"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxRequestBody+1))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	if len(body) > maxRequestBody {
+		c.JSON(413, gin.H{"error": "request body too large"})
+		return
+	}
+
+	req := runtime.Request{
+		Method:  method,
+		Path:    path,
+		Params:  params,
+		Headers: flatten(c.Request.Header, true),
+		Query:   flatten(c.Request.URL.Query(), false),
+		Body:    string(body),
+	}
+	// x-lws-* is reserved for identity the gateway vouches for, callers can't supply it.
+	// Credentials were checked above, the function only sees the identity they proved
+	for k := range req.Headers {
+		if strings.HasPrefix(k, "x-lws-") || privateRequestHeaders[k] {
+			delete(req.Headers, k)
+		}
+	}
+	for k, v := range identity {
+		req.Headers[k] = v
+	}
+
+	start := time.Now()
+	resp, err := rt.Invoke(c.Request.Context(), source, req, g.limits())
+	elapsed := time.Since(start)
+	if err != nil {
+		var fnErr *runtime.FunctionError
+		switch {
+		case errors.Is(err, runtime.ErrTimeout):
+			c.JSON(504, gin.H{"error": "function timed out"})
+		case errors.As(err, &fnErr):
+			// logs stay server side, callers of a public endpoint shouldn't see them
+			fmt.Printf("[WARN] gateway %s %s/%s failed: %s %q\n", method, project, route.Name, fnErr.Msg, fnErr.Logs)
+			c.JSON(502, gin.H{"error": "function failed"})
+		default:
+			fmt.Printf("[ERROR] gateway %s %s/%s: %v\n", method, project, route.Name, err)
+			c.JSON(500, gin.H{"error": "runtime error"})
+		}
+		return
+	}
+	fmt.Printf("[INFO] gateway %s %s%s -> %s %d in %s\n", method, project, path, route.FunctionName, resp.Status, elapsed)
+
+	contentType := resp.Headers["content-type"]
+	for k, v := range resp.Headers {
+		if k != "content-type" && !blockedResponseHeaders[k] && !strings.HasPrefix(k, "access-control-") {
+			c.Header(k, v)
+		}
+	}
+	if resp.Status == http.StatusNoContent || len(resp.Body) == 0 {
+		c.Status(resp.Status)
+		return
+	}
+	c.Data(resp.Status, contentType, resp.Body)
+}
+
+// allow takes a token for the caller and sets the RateLimit headers, on deny it writes the
+// 429 itself. A store failure lets the request through, the limiter shouldn't take the
+// gateway down with it.
+func (g *Gateway) allow(c *gin.Context, project string, route *Route, identity map[string]string) bool {
+	p := route.RateLimit
+	if g.limiter == nil || !p.Enabled() {
+		return true
+	}
+	d, err := g.limiter.Take(c.Request.Context(), g.rateKey(c, project, route, identity), p)
+	if err != nil {
+		fmt.Printf("[ERROR] rate limiter for %s: %v\n", route.Name, err)
+		return true
+	}
+	c.Header("RateLimit-Limit", strconv.Itoa(d.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(d.Reset)))
+	c.Header("RateLimit-Policy", p.Header())
+	if d.Allowed {
+		return true
+	}
+	c.Header("Retry-After", strconv.Itoa(ceilSeconds(d.RetryAfter)))
+	c.JSON(429, gin.H{"error": "rate limit exceeded"})
+	return false
+}
+
+// rateKey scopes a bucket to the endpoint and the caller. api_key and user only count
+// credentials that verified, anything else falls back to the client ip so made up keys
+// don't get buckets of their own
+func (g *Gateway) rateKey(c *gin.Context, project string, route *Route, identity map[string]string) string {
+	caller := "ip:" + c.ClientIP()
+	switch route.RateLimit.KeyBy {
+	case ratelimit.KeyAPIKey:
+		if id := g.callerKey(c, project, route); id != "" {
+			caller = "key:" + id
+		}
+	case ratelimit.KeyUser:
+		if route.Scope == ScopeJWT && identity["x-lws-user"] != "" {
+			caller = "sub:" + identity["x-lws-user"]
+		} else if c.GetBool("authenticated") && c.GetString("userName") != "" {
+			caller = "user:" + c.GetString("userName")
+		}
+	}
+	return route.EndpointID + "|" + caller
+}
+
+// callerKey is the id of the request's api key once it verified for the project, api_key
+// endpoints have checked it already
+func (g *Gateway) callerKey(c *gin.Context, project string, route *Route) string {
+	if route.Scope == ScopeAPIKey {
+		return c.GetString("apiKeyID")
+	}
+	raw := apikey.FromRequest(c.Request.Header)
+	if raw == "" || g.keys == nil {
+		return ""
+	}
+	key, err := g.keys.Authenticate(c.Request.Context(), project, raw)
+	if err != nil {
+		return ""
+	}
+	return key.ID
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// flatten keeps the first value per key, header names are lowercased like the runtimes expect
+func flatten(values map[string][]string, lower bool) map[string]string {
+	out := make(map[string]string, len(values))
+	for k, v := range values {
+		if len(v) == 0 {
+			continue
+		}
+		if lower {
+			k = strings.ToLower(k)
+		}
+		out[k] = v[0]
+	}
+	return out
+}
@@ -0,0 +1,394 @@
+package gateway
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/apikey"
+	"github.com/ashupednekar/litewebservices-portal/internal/jwtauth"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
+	"github.com/ashupednekar/litewebservices-portal/internal/ratelimit"
+	"github.com/ashupednekar/litewebservices-portal/internal/runtime"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type fakeLoader struct {
+	mu     sync.Mutex
+	routes map[string][]Route
+	loads  int
+}
+
+func (f *fakeLoader) LoadRoutes(ctx context.Context, project, environment string) ([]Route, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.loads++
+	routes, ok := f.routes[tableKey(project, environment)]
+	if !ok && environment != "" {
+		return nil, ErrUnknownEnvironment
+	}
+	return append([]Route(nil), routes...), nil
+}
+
+func (f *fakeLoader) set(project string, routes []Route) {
+	f.mu.Lock()
+	f.routes[project] = routes
+	f.mu.Unlock()
+}
+
+func TestTableMatch(t *testing.T) {
+	loader := &fakeLoader{routes: map[string][]Route{
+		"shop": {
+			{Name: "/items", Method: "GET"},
+			{Name: "/items/:id", Method: "GET"},
+			{Name: "/items/featured", Method: "GET"},
+			{Name: "/files/*", Method: "GET"},
+			{Name: "/items", Method: "POST"},
+		},
+	}}
+	table := NewTable(loader, time.Minute)
+
+	tests := []struct {
+		method    string
+		path      string
+		wantName  string
+		wantParam map[string]string
+		wantFound bool
+	}{
+		{method: "GET", path: "/items", wantName: "/items", wantFound: true},
+		{method: "GET", path: "/items/", wantName: "/items", wantFound: true},
+		{method: "POST", path: "/items", wantName: "/items", wantFound: true},
+		{method: "GET", path: "/items/42", wantName: "/items/:id", wantParam: map[string]string{"id": "42"}, wantFound: true},
+		{method: "GET", path: "/items/featured", wantName: "/items/featured", wantFound: true},
+		{method: "GET", path: "/files/a/b.txt", wantName: "/files/*", wantParam: map[string]string{"*": "a/b.txt"}, wantFound: true},
+		{method: "DELETE", path: "/items", wantFound: true},
+		{method: "GET", path: "/orders", wantFound: false},
+		{method: "GET", path: "/items/42/reviews", wantFound: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			route, params, found, err := table.Match(context.Background(), "shop", "", tt.method, tt.path)
+			if err != nil {
+				t.Fatalf("Match() error = %v", err)
+			}
+			if found != tt.wantFound {
+				t.Errorf("Match() found = %v, want %v", found, tt.wantFound)
+			}
+			if tt.wantName == "" {
+				if route != nil {
+					t.Errorf("Match() = %s, want no route", route.Name)
+				}
+				return
+			}
+			if route == nil || route.Name != tt.wantName {
+				t.Fatalf("Match() = %v, want %s", route, tt.wantName)
+			}
+			for k, v := range tt.wantParam {
+				if params[k] != v {
+					t.Errorf("param %s = %q, want %q", k, params[k], v)
+				}
+			}
+		})
+	}
+
+	if loader.loads != 1 {
+		t.Errorf("route table loaded %d times, want 1", loader.loads)
+	}
+}
+
+type fakeKeys map[string]apikey.Key
+
+func (f fakeKeys) Authenticate(ctx context.Context, project, raw string) (apikey.Key, error) {
+	key, ok := f[project+"/"+raw]
+	if !ok {
+		return apikey.Key{}, apikey.ErrInvalid
+	}
+	return key, nil
+}
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+func TestGatewayServe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	loader := &fakeLoader{routes: map[string][]Route{
+		"shop": {
+			{Name: "/hello/:name", Method: "GET", Scope: "public", Language: "lua", Path: "functions/lua/hello.lua", FunctionName: "hello"},
+			{Name: "/me", Method: "GET", Scope: "authn", Language: "javascript", Path: "functions/javascript/me.js", FunctionName: "me"},
+			{Name: "/broken", Method: "GET", Scope: "public", Language: "lua", Path: "functions/lua/broken.lua", FunctionName: "broken"},
+			{EndpointID: "limited", Name: "/limited", Method: "GET", Scope: "public", Language: "lua", Path: "functions/lua/hello.lua", FunctionName: "hello",
+				RateLimit: ratelimit.Policy{Limit: 2, Window: time.Minute, KeyBy: ratelimit.KeyUser}},
+			{EndpointID: "keyed", Name: "/keyed", Method: "GET", Scope: "public", Language: "lua", Path: "functions/lua/hello.lua", FunctionName: "hello",
+				RateLimit: ratelimit.Policy{Limit: 1, Window: time.Minute, KeyBy: ratelimit.KeyAPIKey}},
+			{Name: "/orders", Method: "GET", Scope: "api_key", APIKeyScope: "orders:read", Language: "javascript", Path: "functions/javascript/key.js", FunctionName: "key"},
+			{Name: "/claims", Method: "GET", Scope: "jwt", JWT: jwtauth.Config{Secret: testJWTSecret, Audience: "shop"}, Language: "javascript", Path: "functions/javascript/me.js", FunctionName: "me"},
+			{Name: "/echo", Method: "GET", Scope: "public", Language: "javascript", Path: "functions/javascript/me.js", FunctionName: "me"},
+			{Name: "/headers", Method: "GET", Scope: "public", Language: "javascript", Path: "functions/javascript/headers.js", FunctionName: "headers"},
+		},
+		"shop@staging": {
+			{Name: "/hello/:name", Method: "GET", Scope: "public", Language: "lua", Path: "functions/lua/hello.lua", FunctionName: "hello", Branch: "staging"},
+			{Name: "/me", Method: "GET", Scope: "authn", Language: "javascript", Path: "functions/javascript/me.js", FunctionName: "me", Branch: "staging"},
+			{Name: "/who", Method: "GET", Scope: "jwt", JWT: jwtauth.Config{JWKS: "config/jwks.json"}, Language: "javascript", Path: "functions/javascript/who.js", FunctionName: "who", Branch: "staging"},
+		},
+	}}
+	sources := map[string]string{
+		"functions/lua/hello.lua":     `function handle(req) return { status = 201, body = { message = "hello " .. req.params.name } } end`,
+		"functions/javascript/me.js":  `function handle(req) { return "you are " + req.headers["x-lws-user"] }`,
+		"functions/lua/broken.lua":    `function handle(req) error("boom") end`,
+		"functions/javascript/key.js": `function handle(req) { return "key " + req.headers["x-lws-api-key-name"] }`,
+		"functions/javascript/headers.js": `function handle(req) {
+			var seen = ["cookie", "authorization", "x-api-key", "proxy-authorization", "x-trace"].filter(function (h) { return h in req.headers })
+			return { status: 200, headers: {
+				"set-cookie": "lws_session=stolen", "connection": "close", "x-trace": "ok",
+				"content-type": "text/html", "content-security-policy": "default-src *", "x-content-type-options": "sniff",
+				"access-control-allow-origin": "*", "access-control-allow-credentials": "true",
+			}, body: seen.join(",") }
+		}`,
+	}
+	keys := fakeKeys{
+		"shop/lws_reader":  {ID: "k1", Name: "reader", Prefix: "lws_reader", Scopes: []string{"orders:read"}},
+		"shop/lws_writer":  {ID: "k2", Name: "writer", Prefix: "lws_writer", Scopes: []string{"orders:write"}},
+		"other/lws_reader": {ID: "k3", Name: "elsewhere", Prefix: "lws_reader", Scopes: []string{"*"}},
+	}
+	token := func(aud string) string {
+		tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": "grace", "aud": aud, "exp": time.Now().Add(time.Hour).Unix(),
+		}).SignedString([]byte(testJWTSecret))
+		if err != nil {
+			t.Fatalf("failed to sign token: %s", err)
+		}
+		return tok
+	}
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding.EncodeToString
+	stagingJWKS := fmt.Sprintf(`{"keys":[{"kty":"RSA","kid":"s1","n":%q,"e":"AQAB"}]}`, b64(signingKey.N.Bytes()))
+	stagingToken, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"sub": "grace", "exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString(signingKey)
+	if err != nil {
+		t.Fatalf("failed to sign token: %s", err)
+	}
+
+	// staging has its own hello and hasn't got me.js yet, its JWKS is only on its branch
+	branches := map[string]map[string]string{
+		"": sources,
+		"staging": {
+			"functions/lua/hello.lua":     `function handle(req) return "staging says hi " .. req.params.name end`,
+			"functions/javascript/who.js": `function handle(req) { return "you are " + req.headers["x-lws-user"] }`,
+			"config/jwks.json":            stagingJWKS,
+		},
+	}
+	gw := New(NewTable(loader, time.Minute), func(ctx context.Context, ref repo.Ref, path string) ([]byte, error) {
+		src, ok := branches[ref.Branch][path]
+		if !ok {
+			return nil, fmt.Errorf("no such file %s: %w", path, fs.ErrNotExist)
+		}
+		return []byte(src), nil
+	}, ratelimit.NewMemoryStore(), keys)
+	gw.limits = func() runtime.Limits {
+		return runtime.Limits{Timeout: time.Second, MemoryMB: 64, MaxOutput: 1 << 20}
+	}
+
+	router := gin.New()
+	router.Any("/x/:project/*path", func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			c.Set("authenticated", true)
+			c.Set("userName", user)
+		}
+		c.Next()
+	}, gw.Serve)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		user       string
+		header     map[string]string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "public function", method: "GET", path: "/x/shop/hello/ada", wantStatus: 201, wantBody: `{"message":"hello ada"}`},
+		{name: "authn without session", method: "GET", path: "/x/shop/me", wantStatus: 401},
+		{name: "authn with session", method: "GET", path: "/x/shop/me", user: "ada", wantStatus: 200, wantBody: "you are ada"},
+		{name: "wrong method", method: "POST", path: "/x/shop/hello/ada", wantStatus: 405},
+		{name: "unknown path", method: "GET", path: "/x/shop/nope", wantStatus: 404},
+		{name: "unknown project", method: "GET", path: "/x/other/hello/ada", wantStatus: 404},
+		{name: "function error", method: "GET", path: "/x/shop/broken", wantStatus: 502},
+		{name: "api key missing", method: "GET", path: "/x/shop/orders", wantStatus: 401},
+		{name: "api key unknown", method: "GET", path: "/x/shop/orders", header: map[string]string{"X-API-Key": "lws_nope"}, wantStatus: 401},
+		{name: "api key with scope", method: "GET", path: "/x/shop/orders", header: map[string]string{"X-API-Key": "lws_reader"}, wantStatus: 200, wantBody: "key reader"},
+		{name: "api key as bearer", method: "GET", path: "/x/shop/orders", header: map[string]string{"Authorization": "Bearer lws_reader"}, wantStatus: 200, wantBody: "key reader"},
+		{name: "api key without scope", method: "GET", path: "/x/shop/orders", header: map[string]string{"X-API-Key": "lws_writer"}, wantStatus: 403},
+		{name: "session is not an api key", method: "GET", path: "/x/shop/orders", user: "ada", wantStatus: 401},
+		{name: "jwt missing", method: "GET", path: "/x/shop/claims", wantStatus: 401},
+		{name: "jwt valid", method: "GET", path: "/x/shop/claims", header: map[string]string{"Authorization": "Bearer " + token("shop")}, wantStatus: 200, wantBody: "you are grace"},
+		{name: "jwt wrong audience", method: "GET", path: "/x/shop/claims", header: map[string]string{"Authorization": "Bearer " + token("blog")}, wantStatus: 401},
+		{name: "environment", method: "GET", path: "/x/shop@staging/hello/ada", wantStatus: 200, wantBody: "staging says hi ada"},
+		{name: "not in environment yet", method: "GET", path: "/x/shop@staging/me", user: "ada", wantStatus: 404},
+		{name: "jwks from the environment's branch", method: "GET", path: "/x/shop@staging/who", header: map[string]string{"Authorization": "Bearer " + stagingToken}, wantStatus: 200, wantBody: "you are grace"},
+		{name: "unknown environment", method: "GET", path: "/x/shop@nope/hello/ada", wantStatus: 404},
+		{name: "spoofed identity header", method: "GET", path: "/x/shop/echo", header: map[string]string{"X-LWS-User": "root"}, wantStatus: 200, wantBody: "you are undefined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := sendWithHeaders(t, server.URL, tt.method, tt.path, tt.user, tt.header)
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", status, tt.wantStatus, body)
+			}
+			if tt.wantBody != "" && body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("rate limit", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if status, body := send(t, server.URL, "GET", "/x/shop/limited", "ada"); status == 429 {
+				t.Fatalf("request %d limited early: %s", i, body)
+			}
+		}
+		req, _ := http.NewRequest("GET", server.URL+"/x/shop/limited", nil)
+		req.Header.Set("X-Test-User", "ada")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != 429 {
+			t.Fatalf("status = %d, want 429", resp.StatusCode)
+		}
+		if got := resp.Header.Get("Retry-After"); got != "30" {
+			t.Errorf("Retry-After = %q, want 30", got)
+		}
+		if got := resp.Header.Get("RateLimit-Remaining"); got != "0" {
+			t.Errorf("RateLimit-Remaining = %q, want 0", got)
+		}
+		// buckets are per user
+		if status, _ := send(t, server.URL, "GET", "/x/shop/limited", "grace"); status == 429 {
+			t.Error("another user shares the bucket")
+		}
+	})
+
+	t.Run("rate limit by api key", func(t *testing.T) {
+		keyed := func(key string) int {
+			status, _ := sendWithHeaders(t, server.URL, "GET", "/x/shop/keyed", "", map[string]string{"X-API-Key": key})
+			return status
+		}
+		if status := keyed("lws_made_up_1"); status == 429 {
+			t.Fatal("first request limited")
+		}
+		// keys that don't verify share the ip's bucket
+		if status := keyed("lws_made_up_2"); status != 429 {
+			t.Errorf("made up key got its own bucket, status = %d", status)
+		}
+		if status := keyed("lws_reader"); status == 429 {
+			t.Error("a verified key shares the ip's bucket")
+		}
+	})
+
+	t.Run("private headers", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/x/shop/headers", nil)
+		req.Header.Set("Cookie", "lws_session=secret")
+		req.Header.Set("Authorization", "Bearer lws_pat")
+		req.Header.Set("X-API-Key", "lws_reader")
+		req.Header.Set("Proxy-Authorization", "Basic eDp5")
+		req.Header.Set("X-Trace", "abc")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %s", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != "x-trace" {
+			t.Errorf("function saw headers %q, want only x-trace", body)
+		}
+		if got := resp.Header.Values("Set-Cookie"); len(got) > 0 {
+			t.Errorf("function set cookies %q", got)
+		}
+		if resp.Close {
+			t.Error("function set a hop-by-hop header")
+		}
+		if got := resp.Header.Get("X-Trace"); got != "ok" {
+			t.Errorf("X-Trace = %q, want ok", got)
+		}
+		if got := resp.Header.Values("Content-Security-Policy"); len(got) != 1 || got[0] != "sandbox" {
+			t.Errorf("Content-Security-Policy = %q, want only sandbox", got)
+		}
+		if got := resp.Header.Values("X-Content-Type-Options"); len(got) != 1 || got[0] != "nosniff" {
+			t.Errorf("X-Content-Type-Options = %q, want only nosniff", got)
+		}
+		for k := range resp.Header {
+			if strings.HasPrefix(strings.ToLower(k), "access-control-") {
+				t.Errorf("function set cors header %s", k)
+			}
+		}
+	})
+
+	t.Run("sandboxed errors", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/x/shop/nowhere")
+		if err != nil {
+			t.Fatalf("request failed: %s", err)
+		}
+		resp.Body.Close()
+		if resp.Header.Get("Content-Security-Policy") != "sandbox" || resp.Header.Get("X-Content-Type-Options") != "nosniff" {
+			t.Errorf("gateway error answered without the sandbox headers: %v", resp.Header)
+		}
+	})
+
+	t.Run("hot reload", func(t *testing.T) {
+		loader.set("shop", append(loader.routes["shop"], Route{
+			Name: "/new", Method: "GET", Scope: "public", Language: "lua", Path: "functions/lua/hello.lua",
+		}))
+		if status, _ := send(t, server.URL, "GET", "/x/shop/new", ""); status != 404 {
+			t.Fatalf("route served before invalidation, status = %d", status)
+		}
+		gw.Table.Invalidate("shop")
+		if status, _ := send(t, server.URL, "GET", "/x/shop/new", ""); status == 404 {
+			t.Errorf("route not served after invalidation")
+		}
+	})
+}
+
+func send(t *testing.T, base, method, path, user string) (int, string) {
+	t.Helper()
+	return sendWithHeaders(t, base, method, path, user, nil)
+}
+
+func sendWithHeaders(t *testing.T, base, method, path, user string, header map[string]string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, base+path, strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("failed to build request: %s", err)
+	}
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %s", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
@@ -0,0 +1,60 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const routesChannel = "lws_routes"
+
+// Listen keeps a LISTEN on lws_routes and invalidates tables as endpoints change, it
+// reconnects with backoff until ctx is done
+func (t *Table) Listen(ctx context.Context, pool *pgxpool.Pool) {
+	backoff := time.Second
+	for {
+		err := t.listen(ctx, pool)
+		if ctx.Err() != nil {
+			return
+		}
+		fmt.Printf("[WARN] gateway route listener stopped: %v, retrying in %s\n", err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+func (t *Table) listen(ctx context.Context, pool *pgxpool.Pool) error {
+	pooled, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// the connection is taken out of the pool so a LISTEN never leaks back into it
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+routesChannel); err != nil {
+		return err
+	}
+	// anything could have changed while we weren't listening
+	t.InvalidateAll()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		if n.Payload == "" {
+			t.InvalidateAll()
+			continue
+		}
+		t.Invalidate(n.Payload)
+	}
+}
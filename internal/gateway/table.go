@@ -0,0 +1,243 @@
+package gateway
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/endpoint/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/jwtauth"
+	"github.com/ashupednekar/litewebservices-portal/internal/ratelimit"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrUnknownEnvironment is a route lookup for an environment the project doesn't have
+var ErrUnknownEnvironment = errors.New("unknown environment")
+
+// Route is an endpoint resolved together with the function it's bound to
+type Route struct {
+	EndpointID   string
+	Name         string
+	Method       string
+	Scope        string
+	FunctionName string
+	Language     string
+	Path         string
+	RateLimit    ratelimit.Policy
+	APIKeyScope  string
+	JWT          jwtauth.Config
+	// Branch is the environment's branch the function source is read from
+	Branch   string
+	segments []string
+}
+
+// RouteLoader fetches every route of a project by project name as served in one of its
+// environments, an empty environment is the project's default
+type RouteLoader interface {
+	LoadRoutes(ctx context.Context, project, environment string) ([]Route, error)
+}
+
+type dbLoader struct {
+	pool *pgxpool.Pool
+}
+
+func NewDBLoader(pool *pgxpool.Pool) RouteLoader {
+	return &dbLoader{pool: pool}
+}
+
+func (l *dbLoader) LoadRoutes(ctx context.Context, project, environment string) ([]Route, error) {
+	q := adaptors.New(l.pool)
+	env, err := q.GetGatewayEnvironment(ctx, adaptors.GetGatewayEnvironmentParams{
+		Project:     project,
+		Environment: environment,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		if environment == "" {
+			// no such project, it has no routes either
+			return nil, nil
+		}
+		return nil, ErrUnknownEnvironment
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.ListGatewayRoutes(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	routes := make([]Route, 0, len(rows))
+	for _, r := range rows {
+		routes = append(routes, Route{
+			EndpointID:   hex.EncodeToString(r.ID.Bytes[:]),
+			Name:         r.Name,
+			Method:       r.Method,
+			Scope:        r.Scope,
+			FunctionName: r.FunctionName,
+			Language:     r.FunctionLanguage,
+			Path:         r.FunctionPath,
+			RateLimit:    policyOf(r),
+			APIKeyScope:  r.ApiKeyScope,
+			JWT: jwtauth.Config{
+				Secret:   r.JwtSecret,
+				JWKS:     r.JwtJwks,
+				Issuer:   r.JwtIssuer,
+				Audience: r.JwtAudience,
+			},
+			Branch: env.Branch,
+		})
+	}
+	return routes, nil
+}
+
+func policyOf(r adaptors.ListGatewayRoutesRow) ratelimit.Policy {
+	if !r.RateLimit.Valid {
+		return ratelimit.Policy{}
+	}
+	p := ratelimit.Policy{
+		Limit:  int(r.RateLimit.Int32),
+		Window: time.Duration(r.RateWindowSeconds) * time.Second,
+		KeyBy:  r.RateKey,
+	}
+	if r.RateBurst.Valid {
+		p.Burst = int(r.RateBurst.Int32)
+	}
+	return p
+}
+
+type projectRoutes struct {
+	routes   []Route
+	loadedAt time.Time
+}
+
+// Table caches route tables per project and environment. Entries are dropped by
+// Invalidate when the database notifies a change and reloaded on next use; ttl bounds
+// staleness if a notification is ever missed.
+type Table struct {
+	mu       sync.RWMutex
+	loader   RouteLoader
+	ttl      time.Duration
+	projects map[string]*projectRoutes
+}
+
+func NewTable(loader RouteLoader, ttl time.Duration) *Table {
+	return &Table{
+		loader:   loader,
+		ttl:      ttl,
+		projects: make(map[string]*projectRoutes),
+	}
+}
+
+// tableKey names a cached table, the project alone for its default environment
+func tableKey(project, environment string) string {
+	if environment == "" {
+		return project
+	}
+	return project + "@" + environment
+}
+
+func (t *Table) routes(ctx context.Context, project, environment string) ([]Route, error) {
+	key := tableKey(project, environment)
+	t.mu.RLock()
+	cached, ok := t.projects[key]
+	t.mu.RUnlock()
+	if ok && time.Since(cached.loadedAt) < t.ttl {
+		return cached.routes, nil
+	}
+
+	routes, err := t.loader.LoadRoutes(ctx, project, environment)
+	if err != nil {
+		return nil, err
+	}
+	for i := range routes {
+		routes[i].segments = splitPath(routes[i].Name)
+	}
+
+	t.mu.Lock()
+	t.projects[key] = &projectRoutes{routes: routes, loadedAt: time.Now()}
+	t.mu.Unlock()
+	return routes, nil
+}
+
+// Invalidate drops the cached tables of every environment of a project, the next
+// request reloads them
+func (t *Table) Invalidate(project string) {
+	t.mu.Lock()
+	for key := range t.projects {
+		if key == project || strings.HasPrefix(key, project+"@") {
+			delete(t.projects, key)
+		}
+	}
+	t.mu.Unlock()
+}
+
+// InvalidateAll drops every cached table, for deleted projects and listener reconnects
+func (t *Table) InvalidateAll() {
+	t.mu.Lock()
+	t.projects = make(map[string]*projectRoutes)
+	t.mu.Unlock()
+}
+
+// Match resolves method and path to a route of a project's environment. found reports
+// whether any route matched the path at all, so callers can tell a 404 from a 405.
+func (t *Table) Match(ctx context.Context, project, environment, method, path string) (route *Route, params map[string]string, found bool, err error) {
+	routes, err := t.routes(ctx, project, environment)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	segments := splitPath(path)
+	best := -1
+	for i := range routes {
+		p, score, ok := matchSegments(routes[i].segments, segments)
+		if !ok {
+			continue
+		}
+		found = true
+		if routes[i].Method != method || score <= best {
+			continue
+		}
+		best, route, params = score, &routes[i], p
+	}
+	return route, params, found, nil
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// matchSegments supports literal segments, :name params and a trailing * catch-all.
+// The score prefers literal segments over params over the catch-all.
+func matchSegments(pattern, path []string) (map[string]string, int, bool) {
+	params := map[string]string{}
+	score := 0
+	for i, seg := range pattern {
+		if seg == "*" && i == len(pattern)-1 {
+			params["*"] = strings.Join(path[i:], "/")
+			return params, score, true
+		}
+		if i >= len(path) {
+			return nil, 0, false
+		}
+		switch {
+		case strings.HasPrefix(seg, ":"):
+			params[seg[1:]] = path[i]
+			score += 1
+		case seg == path[i]:
+			score += 2
+		default:
+			return nil, 0, false
+		}
+	}
+	if len(path) != len(pattern) {
+		return nil, 0, false
+	}
+	return params, score + 1, true
+}
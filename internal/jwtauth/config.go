@@ -0,0 +1,53 @@
+package jwtauth
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// MinSecretLength keeps HS256 secrets out of brute force range
+const MinSecretLength = 32
+
+// Config is how an endpoint verifies bearer tokens, either with a shared HS256 secret or
+// against a JWKS. Issuer and Audience are checked when set.
+type Config struct {
+	Secret   string
+	JWKS     string
+	Issuer   string
+	Audience string
+}
+
+func (c Config) Validate() error {
+	switch {
+	case c.Secret == "" && c.JWKS == "":
+		return fmt.Errorf("jwt needs a secret or a jwks location")
+	case c.Secret != "" && c.JWKS != "":
+		return fmt.Errorf("jwt takes either a secret or a jwks location, not both")
+	case c.Secret != "" && len(c.Secret) < MinSecretLength:
+		return fmt.Errorf("jwt secret must be at least %d characters", MinSecretLength)
+	case c.JWKS != "":
+		return validLocation(c.JWKS)
+	}
+	return nil
+}
+
+// IsURL tells a JWKS url apart from a path in the project repo
+func IsURL(location string) bool {
+	return strings.Contains(location, "://")
+}
+
+func validLocation(location string) error {
+	if IsURL(location) {
+		u, err := url.Parse(location)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("jwks url must be https")
+		}
+		return nil
+	}
+	if strings.HasPrefix(location, "/") || path.Clean(location) != location || strings.HasPrefix(location, "..") {
+		return fmt.Errorf("jwks path must be relative to the project repo")
+	}
+	return nil
+}
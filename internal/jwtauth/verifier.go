@@ -0,0 +1,158 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	jwksTTL        = 10 * time.Minute
+	jwksMinRefresh = 30 * time.Second
+	maxJWKSSize    = 1 << 20
+	leeway         = 30 * time.Second
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+var (
+	hmacMethods = []string{"HS256", "HS384", "HS512"}
+	keyMethods  = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+)
+
+// FileFunc reads a file from a branch of a project's repo
+type FileFunc func(ctx context.Context, project, branch, path string) ([]byte, error)
+
+type keySet struct {
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+	// checkedAt is the last fetch attempt, failed ones included, so a broken JWKS isn't
+	// refetched on every request
+	checkedAt time.Time
+}
+
+// Verifier checks bearer tokens, JWKS are cached per project, branch and location and refetched
+// when a token names a kid the cached set doesn't have
+type Verifier struct {
+	mu     sync.Mutex
+	files  FileFunc
+	client *http.Client
+	sets   map[string]*keySet
+}
+
+func NewVerifier(files FileFunc) *Verifier {
+	return &Verifier{
+		files:  files,
+		client: &http.Client{Timeout: 5 * time.Second},
+		sets:   make(map[string]*keySet),
+	}
+}
+
+// Verify parses token against cfg and returns its claims, a JWKS in the repo is read from
+// branch, the one serving the request
+func (v *Verifier) Verify(ctx context.Context, project, branch string, cfg Config, token string) (jwt.MapClaims, error) {
+	opts := []jwt.ParserOption{jwt.WithLeeway(leeway), jwt.WithExpirationRequired()}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	var keyfunc jwt.Keyfunc
+	if cfg.Secret != "" {
+		opts = append(opts, jwt.WithValidMethods(hmacMethods))
+		keyfunc = func(*jwt.Token) (any, error) { return []byte(cfg.Secret), nil }
+	} else {
+		opts = append(opts, jwt.WithValidMethods(keyMethods))
+		keyfunc = func(t *jwt.Token) (any, error) {
+			kid, _ := t.Header["kid"].(string)
+			return v.key(ctx, project, branch, cfg.JWKS, kid)
+		}
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.NewParser(opts...).ParseWithClaims(token, claims, keyfunc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return claims, nil
+}
+
+func (v *Verifier) key(ctx context.Context, project, branch, location, kid string) (crypto.PublicKey, error) {
+	cacheKey := project + "@" + branch + "|" + location
+	v.mu.Lock()
+	set := v.sets[cacheKey]
+	v.mu.Unlock()
+
+	now := time.Now()
+	if set == nil {
+		set = v.refresh(ctx, cacheKey, project, branch, location, &keySet{})
+	} else if now.Sub(set.checkedAt) > jwksMinRefresh {
+		// a kid we haven't seen may mean the issuer rotated keys
+		if _, ok := lookup(set, kid); !ok || now.Sub(set.fetchedAt) > jwksTTL {
+			set = v.refresh(ctx, cacheKey, project, branch, location, set)
+		}
+	}
+	key, ok := lookup(set, kid)
+	if !ok {
+		return nil, fmt.Errorf("no key for kid %q", kid)
+	}
+	return key, nil
+}
+
+// lookup finds kid in the set, a token without a kid is accepted when the set has one key
+func lookup(set *keySet, kid string) (crypto.PublicKey, bool) {
+	if key, ok := set.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(set.keys) == 1 {
+		for _, key := range set.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// refresh fetches the set, keeping the stale keys if the fetch fails
+func (v *Verifier) refresh(ctx context.Context, cacheKey, project, branch, location string, stale *keySet) *keySet {
+	data, err := v.fetch(ctx, project, branch, location)
+	var keys map[string]crypto.PublicKey
+	if err == nil {
+		keys, err = parseJWKS(data)
+	}
+	set := &keySet{keys: keys, fetchedAt: time.Now(), checkedAt: time.Now()}
+	if err != nil {
+		fmt.Printf("[WARN] jwks %s for %s@%s: %v\n", location, project, branch, err)
+		set.keys, set.fetchedAt = stale.keys, stale.fetchedAt
+	}
+	v.mu.Lock()
+	v.sets[cacheKey] = set
+	v.mu.Unlock()
+	return set
+}
+
+func (v *Verifier) fetch(ctx context.Context, project, branch, location string) ([]byte, error) {
+	if !IsURL(location) {
+		return v.files(ctx, project, branch, location)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks fetch returned %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+}
@@ -0,0 +1,154 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %s", err)
+	}
+	return s
+}
+
+func claims(extra map[string]any) jwt.MapClaims {
+	c := jwt.MapClaims{"sub": "ada", "exp": time.Now().Add(time.Hour).Unix()}
+	for k, v := range extra {
+		c[k] = v
+	}
+	return c
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	v := NewVerifier(nil)
+	cfg := Config{Secret: testSecret, Issuer: "https://issuer", Audience: "shop"}
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "valid", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(map[string]any{"iss": "https://issuer", "aud": "shop"}))},
+		{name: "wrong secret", token: sign(t, jwt.SigningMethodHS256, []byte("another-secret-another-secret-xx"), "", claims(map[string]any{"iss": "https://issuer", "aud": "shop"})), wantErr: true},
+		{name: "expired", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(map[string]any{"iss": "https://issuer", "aud": "shop", "exp": time.Now().Add(-time.Hour).Unix()})), wantErr: true},
+		{name: "no expiry", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", jwt.MapClaims{"iss": "https://issuer", "aud": "shop"}), wantErr: true},
+		{name: "wrong issuer", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(map[string]any{"iss": "https://evil", "aud": "shop"})), wantErr: true},
+		{name: "wrong audience", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(map[string]any{"iss": "https://issuer", "aud": "blog"})), wantErr: true},
+		{name: "garbage", token: "not.a.token", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Verify(ctx, "shop", "main", cfg, tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got["sub"] != "ada" {
+				t.Errorf("sub = %v, want ada", got["sub"])
+			}
+		})
+	}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func TestVerifyJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecBytes, err := ecKey.PublicKey.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "r1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "e1", "crv": "P-256", "x": b64(ecBytes[1:33]), "y": b64(ecBytes[33:])},
+	}})
+
+	fetches := 0
+	v := NewVerifier(func(ctx context.Context, project, branch, path string) ([]byte, error) {
+		// the environment's branch, projects needn't have a main
+		if project != "shop" || branch != "staging" || path != "config/jwks.json" {
+			return nil, fmt.Errorf("unexpected file %s@%s/%s", project, branch, path)
+		}
+		fetches++
+		return jwks, nil
+	})
+	cfg := Config{JWKS: "config/jwks.json"}
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "rsa", token: sign(t, jwt.SigningMethodRS256, rsaKey, "r1", claims(nil))},
+		{name: "ecdsa", token: sign(t, jwt.SigningMethodES256, ecKey, "e1", claims(nil))},
+		{name: "unknown kid", token: sign(t, jwt.SigningMethodRS256, rsaKey, "r2", claims(nil)), wantErr: true},
+		{name: "kid of another key", token: sign(t, jwt.SigningMethodRS256, rsaKey, "e1", claims(nil)), wantErr: true},
+		// an HMAC token must not be checked with public key material as the secret
+		{name: "alg confusion", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "r1", claims(nil)), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := v.Verify(ctx, "shop", "staging", cfg, tt.token); (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if fetches != 1 {
+		t.Errorf("jwks fetched %d times, want 1", fetches)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "secret", cfg: Config{Secret: testSecret}},
+		{name: "jwks url", cfg: Config{JWKS: "https://issuer/.well-known/jwks.json"}},
+		{name: "jwks path", cfg: Config{JWKS: "config/jwks.json"}},
+		{name: "empty", cfg: Config{}, wantErr: true},
+		{name: "both", cfg: Config{Secret: testSecret, JWKS: "config/jwks.json"}, wantErr: true},
+		{name: "short secret", cfg: Config{Secret: "short"}, wantErr: true},
+		{name: "plain http", cfg: Config{JWKS: "http://issuer/jwks.json"}, wantErr: true},
+		{name: "absolute path", cfg: Config{JWKS: "/etc/passwd"}, wantErr: true},
+		{name: "escaping path", cfg: Config{JWKS: "../jwks.json"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
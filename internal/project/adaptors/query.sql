@@ -1,10 +1,15 @@
 -- PROJECT QUERIES
 
 -- name: CreateProject :one
-INSERT INTO projects (name, description, created_by)
-VALUES ($1, $2, $3)
+INSERT INTO projects (name, description, created_by, webhook_secret, functions_root)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
+-- name: SetProjectRepoCreatedByPortal :exec
+UPDATE projects
+SET repo_created_by_portal = $2
+WHERE id = $1;
+
 -- name: GetProjectByID :one
 SELECT *
 FROM projects
@@ -16,12 +21,16 @@ FROM projects
 WHERE name = $1;
 
 -- name: ListProjectsForUser :many
-SELECT p.*
+SELECT p.*, up.role
 FROM projects p
 JOIN user_projects up ON up.project_id = p.id
 WHERE up.user_id = $1
 ORDER BY p.created_at DESC;
 
+-- name: DeleteProject :exec
+DELETE FROM projects
+WHERE id = $1;
+
 -- name: AddUserToProject :exec
 INSERT INTO user_projects (user_id, project_id, role)
 VALUES ($1, $2, $3)
@@ -32,6 +41,11 @@ SET role = EXCLUDED.role;
 DELETE FROM user_projects
 WHERE user_id = $1 AND project_id = $2;
 
+-- name: GetProjectMember :one
+SELECT *
+FROM user_projects
+WHERE user_id = $1 AND project_id = $2;
+
 -- name: ListProjectMembers :many
 SELECT u.*, up.role, up.created_at
 FROM user_projects up
@@ -39,3 +53,161 @@ JOIN users u ON u.id = up.user_id
 WHERE up.project_id = $1
 ORDER BY u.name;
 
+
+-- name: UpdateProjectMemberRole :one
+UPDATE user_projects
+SET role = $3
+WHERE user_id = $1 AND project_id = $2
+RETURNING *;
+
+-- INVITES
+
+-- name: CreateProjectInvite :one
+INSERT INTO project_invites (project_id, token_hash, role, created_by, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListPendingProjectInvites :many
+SELECT *
+FROM project_invites
+WHERE project_id = $1 AND redeemed_at IS NULL AND expires_at > now()
+ORDER BY created_at DESC;
+
+-- name: DeleteProjectInvite :exec
+DELETE FROM project_invites
+WHERE id = $1 AND project_id = $2;
+
+-- name: RedeemProjectInvite :one
+UPDATE project_invites
+SET redeemed_by = $2, redeemed_at = now()
+WHERE token_hash = $1 AND redeemed_at IS NULL AND expires_at > now()
+RETURNING *;
+
+-- ENVIRONMENTS
+
+-- name: CreateProjectEnvironment :one
+INSERT INTO project_environments (project_id, name, branch, is_default)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListProjectEnvironments :many
+SELECT *
+FROM project_environments
+WHERE project_id = $1
+ORDER BY is_default DESC, created_at ASC;
+
+-- name: GetProjectEnvironment :one
+SELECT *
+FROM project_environments
+WHERE project_id = $1 AND name = $2;
+
+-- name: GetDefaultProjectEnvironment :one
+SELECT *
+FROM project_environments
+WHERE project_id = $1 AND is_default;
+
+-- name: ListTrackedBranches :many
+-- branches functions may live on: the environments' and those of unmerged changes
+SELECT e.branch
+FROM project_environments e
+WHERE e.project_id = $1
+UNION
+SELECT cr.branch
+FROM change_requests cr
+WHERE cr.project_id = $1 AND cr.state IN ('draft', 'open');
+
+-- name: DeleteProjectEnvironment :exec
+DELETE FROM project_environments
+WHERE project_id = $1 AND name = $2 AND NOT is_default;
+
+-- name: SetProjectProtected :one
+UPDATE projects
+SET protected = $2
+WHERE id = $1
+RETURNING *;
+
+-- CHANGE REQUESTS
+
+-- name: CreateChangeRequest :one
+INSERT INTO change_requests (project_id, environment, branch, base_branch, author)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetActiveChangeRequest :one
+SELECT *
+FROM change_requests
+WHERE project_id = $1 AND environment = $2 AND author = $3 AND state IN ('draft', 'open');
+
+-- name: GetChangeRequest :one
+SELECT sqlc.embed(cr), u.name AS author_name
+FROM change_requests cr
+JOIN users u ON u.id = cr.author
+WHERE cr.project_id = $1 AND cr.id = $2;
+
+-- name: ListChangeRequests :many
+SELECT sqlc.embed(cr), u.name AS author_name
+FROM change_requests cr
+JOIN users u ON u.id = cr.author
+WHERE cr.project_id = $1
+ORDER BY cr.created_at DESC
+LIMIT 100;
+
+-- name: OpenChangeRequest :one
+UPDATE change_requests
+SET state = 'open',
+    title = $2,
+    number = $3,
+    url = $4,
+    updated_at = now()
+WHERE id = $1 AND state = 'draft'
+RETURNING *;
+
+-- name: SetChangeRequestState :one
+UPDATE change_requests
+SET state = $2,
+    merged_at = CASE WHEN $2 = 'merged' THEN now() ELSE merged_at END,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- VCS CONNECTIONS
+
+-- name: GetProjectVcsConnection :one
+SELECT *
+FROM project_vcs_connections
+WHERE project_id = $1;
+
+-- name: GetProjectVcsConnectionByName :one
+SELECT c.*
+FROM project_vcs_connections c
+JOIN projects p ON p.id = c.project_id
+WHERE p.name = $1;
+
+-- name: UpsertProjectVcsConnection :one
+INSERT INTO project_vcs_connections (project_id, vendor, base_url, owner, repo_name, auth_mode, token, ssh_key, ssh_key_password, updated_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (project_id) DO UPDATE
+SET vendor = EXCLUDED.vendor,
+    base_url = EXCLUDED.base_url,
+    owner = EXCLUDED.owner,
+    repo_name = EXCLUDED.repo_name,
+    auth_mode = EXCLUDED.auth_mode,
+    token = EXCLUDED.token,
+    ssh_key = EXCLUDED.ssh_key,
+    ssh_key_password = EXCLUDED.ssh_key_password,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = now()
+RETURNING *;
+
+-- name: VcsRepoInUse :one
+SELECT (EXISTS (SELECT 1 FROM projects WHERE lower(name) = lower(sqlc.arg(repo_name)::text))
+    OR EXISTS (
+        SELECT 1
+        FROM project_vcs_connections
+        WHERE lower(owner) = lower(sqlc.arg(owner)::text)
+          AND lower(repo_name) = lower(sqlc.arg(repo_name)::text)
+    ))::bool AS in_use;
+
+-- name: DeleteProjectVcsConnection :exec
+DELETE FROM project_vcs_connections
+WHERE project_id = $1;
@@ -29,22 +29,73 @@ func (q *Queries) AddUserToProject(ctx context.Context, arg AddUserToProjectPara
 	return err
 }
 
+const createChangeRequest = `-- name: CreateChangeRequest :one
+
+INSERT INTO change_requests (project_id, environment, branch, base_branch, author)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, project_id, environment, branch, base_branch, author, state, title, number, url, created_at, updated_at, merged_at
+`
+
+type CreateChangeRequestParams struct {
+	ProjectID   pgtype.UUID
+	Environment string
+	Branch      string
+	BaseBranch  string
+	Author      []byte
+}
+
+// CHANGE REQUESTS
+func (q *Queries) CreateChangeRequest(ctx context.Context, arg CreateChangeRequestParams) (ChangeRequest, error) {
+	row := q.db.QueryRow(ctx, createChangeRequest,
+		arg.ProjectID,
+		arg.Environment,
+		arg.Branch,
+		arg.BaseBranch,
+		arg.Author,
+	)
+	var i ChangeRequest
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Environment,
+		&i.Branch,
+		&i.BaseBranch,
+		&i.Author,
+		&i.State,
+		&i.Title,
+		&i.Number,
+		&i.Url,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MergedAt,
+	)
+	return i, err
+}
+
 const createProject = `-- name: CreateProject :one
 
-INSERT INTO projects (name, description, created_by)
-VALUES ($1, $2, $3)
-RETURNING id, name, description, created_by, created_at
+INSERT INTO projects (name, description, created_by, webhook_secret, functions_root)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, name, description, created_by, created_at, webhook_secret, protected, functions_root, repo_created_by_portal
 `
 
 type CreateProjectParams struct {
-	Name        string
-	Description pgtype.Text
-	CreatedBy   []byte
+	Name          string
+	Description   pgtype.Text
+	CreatedBy     []byte
+	WebhookSecret pgtype.Text
+	FunctionsRoot string
 }
 
 // PROJECT QUERIES
 func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (Project, error) {
-	row := q.db.QueryRow(ctx, createProject, arg.Name, arg.Description, arg.CreatedBy)
+	row := q.db.QueryRow(ctx, createProject,
+		arg.Name,
+		arg.Description,
+		arg.CreatedBy,
+		arg.WebhookSecret,
+		arg.FunctionsRoot,
+	)
 	var i Project
 	err := row.Scan(
 		&i.ID,
@@ -52,12 +103,229 @@ func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (P
 		&i.Description,
 		&i.CreatedBy,
 		&i.CreatedAt,
+		&i.WebhookSecret,
+		&i.Protected,
+		&i.FunctionsRoot,
+		&i.RepoCreatedByPortal,
+	)
+	return i, err
+}
+
+const createProjectEnvironment = `-- name: CreateProjectEnvironment :one
+
+INSERT INTO project_environments (project_id, name, branch, is_default)
+VALUES ($1, $2, $3, $4)
+RETURNING project_id, name, branch, is_default, created_at
+`
+
+type CreateProjectEnvironmentParams struct {
+	ProjectID pgtype.UUID
+	Name      string
+	Branch    string
+	IsDefault bool
+}
+
+// ENVIRONMENTS
+func (q *Queries) CreateProjectEnvironment(ctx context.Context, arg CreateProjectEnvironmentParams) (ProjectEnvironment, error) {
+	row := q.db.QueryRow(ctx, createProjectEnvironment,
+		arg.ProjectID,
+		arg.Name,
+		arg.Branch,
+		arg.IsDefault,
+	)
+	var i ProjectEnvironment
+	err := row.Scan(
+		&i.ProjectID,
+		&i.Name,
+		&i.Branch,
+		&i.IsDefault,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createProjectInvite = `-- name: CreateProjectInvite :one
+
+INSERT INTO project_invites (project_id, token_hash, role, created_by, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, project_id, token_hash, role, created_by, created_at, expires_at, redeemed_by, redeemed_at
+`
+
+type CreateProjectInviteParams struct {
+	ProjectID pgtype.UUID
+	TokenHash string
+	Role      string
+	CreatedBy []byte
+	ExpiresAt pgtype.Timestamptz
+}
+
+// INVITES
+func (q *Queries) CreateProjectInvite(ctx context.Context, arg CreateProjectInviteParams) (ProjectInvite, error) {
+	row := q.db.QueryRow(ctx, createProjectInvite,
+		arg.ProjectID,
+		arg.TokenHash,
+		arg.Role,
+		arg.CreatedBy,
+		arg.ExpiresAt,
+	)
+	var i ProjectInvite
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.TokenHash,
+		&i.Role,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.RedeemedBy,
+		&i.RedeemedAt,
+	)
+	return i, err
+}
+
+const deleteProject = `-- name: DeleteProject :exec
+DELETE FROM projects
+WHERE id = $1
+`
+
+func (q *Queries) DeleteProject(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteProject, id)
+	return err
+}
+
+const deleteProjectEnvironment = `-- name: DeleteProjectEnvironment :exec
+DELETE FROM project_environments
+WHERE project_id = $1 AND name = $2 AND NOT is_default
+`
+
+type DeleteProjectEnvironmentParams struct {
+	ProjectID pgtype.UUID
+	Name      string
+}
+
+func (q *Queries) DeleteProjectEnvironment(ctx context.Context, arg DeleteProjectEnvironmentParams) error {
+	_, err := q.db.Exec(ctx, deleteProjectEnvironment, arg.ProjectID, arg.Name)
+	return err
+}
+
+const deleteProjectInvite = `-- name: DeleteProjectInvite :exec
+DELETE FROM project_invites
+WHERE id = $1 AND project_id = $2
+`
+
+type DeleteProjectInviteParams struct {
+	ID        pgtype.UUID
+	ProjectID pgtype.UUID
+}
+
+func (q *Queries) DeleteProjectInvite(ctx context.Context, arg DeleteProjectInviteParams) error {
+	_, err := q.db.Exec(ctx, deleteProjectInvite, arg.ID, arg.ProjectID)
+	return err
+}
+
+const deleteProjectVcsConnection = `-- name: DeleteProjectVcsConnection :exec
+DELETE FROM project_vcs_connections
+WHERE project_id = $1
+`
+
+func (q *Queries) DeleteProjectVcsConnection(ctx context.Context, projectID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteProjectVcsConnection, projectID)
+	return err
+}
+
+const getActiveChangeRequest = `-- name: GetActiveChangeRequest :one
+SELECT id, project_id, environment, branch, base_branch, author, state, title, number, url, created_at, updated_at, merged_at
+FROM change_requests
+WHERE project_id = $1 AND environment = $2 AND author = $3 AND state IN ('draft', 'open')
+`
+
+type GetActiveChangeRequestParams struct {
+	ProjectID   pgtype.UUID
+	Environment string
+	Author      []byte
+}
+
+func (q *Queries) GetActiveChangeRequest(ctx context.Context, arg GetActiveChangeRequestParams) (ChangeRequest, error) {
+	row := q.db.QueryRow(ctx, getActiveChangeRequest, arg.ProjectID, arg.Environment, arg.Author)
+	var i ChangeRequest
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Environment,
+		&i.Branch,
+		&i.BaseBranch,
+		&i.Author,
+		&i.State,
+		&i.Title,
+		&i.Number,
+		&i.Url,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MergedAt,
+	)
+	return i, err
+}
+
+const getChangeRequest = `-- name: GetChangeRequest :one
+SELECT cr.id, cr.project_id, cr.environment, cr.branch, cr.base_branch, cr.author, cr.state, cr.title, cr.number, cr.url, cr.created_at, cr.updated_at, cr.merged_at, u.name AS author_name
+FROM change_requests cr
+JOIN users u ON u.id = cr.author
+WHERE cr.project_id = $1 AND cr.id = $2
+`
+
+type GetChangeRequestParams struct {
+	ProjectID pgtype.UUID
+	ID        pgtype.UUID
+}
+
+type GetChangeRequestRow struct {
+	ChangeRequest ChangeRequest
+	AuthorName    string
+}
+
+func (q *Queries) GetChangeRequest(ctx context.Context, arg GetChangeRequestParams) (GetChangeRequestRow, error) {
+	row := q.db.QueryRow(ctx, getChangeRequest, arg.ProjectID, arg.ID)
+	var i GetChangeRequestRow
+	err := row.Scan(
+		&i.ChangeRequest.ID,
+		&i.ChangeRequest.ProjectID,
+		&i.ChangeRequest.Environment,
+		&i.ChangeRequest.Branch,
+		&i.ChangeRequest.BaseBranch,
+		&i.ChangeRequest.Author,
+		&i.ChangeRequest.State,
+		&i.ChangeRequest.Title,
+		&i.ChangeRequest.Number,
+		&i.ChangeRequest.Url,
+		&i.ChangeRequest.CreatedAt,
+		&i.ChangeRequest.UpdatedAt,
+		&i.ChangeRequest.MergedAt,
+		&i.AuthorName,
+	)
+	return i, err
+}
+
+const getDefaultProjectEnvironment = `-- name: GetDefaultProjectEnvironment :one
+SELECT project_id, name, branch, is_default, created_at
+FROM project_environments
+WHERE project_id = $1 AND is_default
+`
+
+func (q *Queries) GetDefaultProjectEnvironment(ctx context.Context, projectID pgtype.UUID) (ProjectEnvironment, error) {
+	row := q.db.QueryRow(ctx, getDefaultProjectEnvironment, projectID)
+	var i ProjectEnvironment
+	err := row.Scan(
+		&i.ProjectID,
+		&i.Name,
+		&i.Branch,
+		&i.IsDefault,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const getProjectByID = `-- name: GetProjectByID :one
-SELECT id, name, description, created_by, created_at
+SELECT id, name, description, created_by, created_at, webhook_secret, protected, functions_root, repo_created_by_portal
 FROM projects
 WHERE id = $1
 `
@@ -71,12 +339,16 @@ func (q *Queries) GetProjectByID(ctx context.Context, id pgtype.UUID) (Project,
 		&i.Description,
 		&i.CreatedBy,
 		&i.CreatedAt,
+		&i.WebhookSecret,
+		&i.Protected,
+		&i.FunctionsRoot,
+		&i.RepoCreatedByPortal,
 	)
 	return i, err
 }
 
 const getProjectByName = `-- name: GetProjectByName :one
-SELECT id, name, description, created_by, created_at
+SELECT id, name, description, created_by, created_at, webhook_secret, protected, functions_root, repo_created_by_portal
 FROM projects
 WHERE name = $1
 `
@@ -90,10 +362,235 @@ func (q *Queries) GetProjectByName(ctx context.Context, name string) (Project, e
 		&i.Description,
 		&i.CreatedBy,
 		&i.CreatedAt,
+		&i.WebhookSecret,
+		&i.Protected,
+		&i.FunctionsRoot,
+		&i.RepoCreatedByPortal,
+	)
+	return i, err
+}
+
+const getProjectEnvironment = `-- name: GetProjectEnvironment :one
+SELECT project_id, name, branch, is_default, created_at
+FROM project_environments
+WHERE project_id = $1 AND name = $2
+`
+
+type GetProjectEnvironmentParams struct {
+	ProjectID pgtype.UUID
+	Name      string
+}
+
+func (q *Queries) GetProjectEnvironment(ctx context.Context, arg GetProjectEnvironmentParams) (ProjectEnvironment, error) {
+	row := q.db.QueryRow(ctx, getProjectEnvironment, arg.ProjectID, arg.Name)
+	var i ProjectEnvironment
+	err := row.Scan(
+		&i.ProjectID,
+		&i.Name,
+		&i.Branch,
+		&i.IsDefault,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getProjectMember = `-- name: GetProjectMember :one
+SELECT user_id, project_id, role, created_at
+FROM user_projects
+WHERE user_id = $1 AND project_id = $2
+`
+
+type GetProjectMemberParams struct {
+	UserID    []byte
+	ProjectID pgtype.UUID
+}
+
+func (q *Queries) GetProjectMember(ctx context.Context, arg GetProjectMemberParams) (UserProject, error) {
+	row := q.db.QueryRow(ctx, getProjectMember, arg.UserID, arg.ProjectID)
+	var i UserProject
+	err := row.Scan(
+		&i.UserID,
+		&i.ProjectID,
+		&i.Role,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getProjectVcsConnection = `-- name: GetProjectVcsConnection :one
+
+SELECT project_id, vendor, base_url, owner, repo_name, auth_mode, token, ssh_key, ssh_key_password, updated_by, created_at, updated_at
+FROM project_vcs_connections
+WHERE project_id = $1
+`
+
+// VCS CONNECTIONS
+func (q *Queries) GetProjectVcsConnection(ctx context.Context, projectID pgtype.UUID) (ProjectVcsConnection, error) {
+	row := q.db.QueryRow(ctx, getProjectVcsConnection, projectID)
+	var i ProjectVcsConnection
+	err := row.Scan(
+		&i.ProjectID,
+		&i.Vendor,
+		&i.BaseUrl,
+		&i.Owner,
+		&i.RepoName,
+		&i.AuthMode,
+		&i.Token,
+		&i.SshKey,
+		&i.SshKeyPassword,
+		&i.UpdatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getProjectVcsConnectionByName = `-- name: GetProjectVcsConnectionByName :one
+SELECT c.project_id, c.vendor, c.base_url, c.owner, c.repo_name, c.auth_mode, c.token, c.ssh_key, c.ssh_key_password, c.updated_by, c.created_at, c.updated_at
+FROM project_vcs_connections c
+JOIN projects p ON p.id = c.project_id
+WHERE p.name = $1
+`
+
+func (q *Queries) GetProjectVcsConnectionByName(ctx context.Context, name string) (ProjectVcsConnection, error) {
+	row := q.db.QueryRow(ctx, getProjectVcsConnectionByName, name)
+	var i ProjectVcsConnection
+	err := row.Scan(
+		&i.ProjectID,
+		&i.Vendor,
+		&i.BaseUrl,
+		&i.Owner,
+		&i.RepoName,
+		&i.AuthMode,
+		&i.Token,
+		&i.SshKey,
+		&i.SshKeyPassword,
+		&i.UpdatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
+const listChangeRequests = `-- name: ListChangeRequests :many
+SELECT cr.id, cr.project_id, cr.environment, cr.branch, cr.base_branch, cr.author, cr.state, cr.title, cr.number, cr.url, cr.created_at, cr.updated_at, cr.merged_at, u.name AS author_name
+FROM change_requests cr
+JOIN users u ON u.id = cr.author
+WHERE cr.project_id = $1
+ORDER BY cr.created_at DESC
+LIMIT 100
+`
+
+type ListChangeRequestsRow struct {
+	ChangeRequest ChangeRequest
+	AuthorName    string
+}
+
+func (q *Queries) ListChangeRequests(ctx context.Context, projectID pgtype.UUID) ([]ListChangeRequestsRow, error) {
+	rows, err := q.db.Query(ctx, listChangeRequests, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListChangeRequestsRow
+	for rows.Next() {
+		var i ListChangeRequestsRow
+		if err := rows.Scan(
+			&i.ChangeRequest.ID,
+			&i.ChangeRequest.ProjectID,
+			&i.ChangeRequest.Environment,
+			&i.ChangeRequest.Branch,
+			&i.ChangeRequest.BaseBranch,
+			&i.ChangeRequest.Author,
+			&i.ChangeRequest.State,
+			&i.ChangeRequest.Title,
+			&i.ChangeRequest.Number,
+			&i.ChangeRequest.Url,
+			&i.ChangeRequest.CreatedAt,
+			&i.ChangeRequest.UpdatedAt,
+			&i.ChangeRequest.MergedAt,
+			&i.AuthorName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingProjectInvites = `-- name: ListPendingProjectInvites :many
+SELECT id, project_id, token_hash, role, created_by, created_at, expires_at, redeemed_by, redeemed_at
+FROM project_invites
+WHERE project_id = $1 AND redeemed_at IS NULL AND expires_at > now()
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListPendingProjectInvites(ctx context.Context, projectID pgtype.UUID) ([]ProjectInvite, error) {
+	rows, err := q.db.Query(ctx, listPendingProjectInvites, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProjectInvite
+	for rows.Next() {
+		var i ProjectInvite
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.TokenHash,
+			&i.Role,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.RedeemedBy,
+			&i.RedeemedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProjectEnvironments = `-- name: ListProjectEnvironments :many
+SELECT project_id, name, branch, is_default, created_at
+FROM project_environments
+WHERE project_id = $1
+ORDER BY is_default DESC, created_at ASC
+`
+
+func (q *Queries) ListProjectEnvironments(ctx context.Context, projectID pgtype.UUID) ([]ProjectEnvironment, error) {
+	rows, err := q.db.Query(ctx, listProjectEnvironments, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProjectEnvironment
+	for rows.Next() {
+		var i ProjectEnvironment
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.Name,
+			&i.Branch,
+			&i.IsDefault,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listProjectMembers = `-- name: ListProjectMembers :many
 SELECT u.id, u.name, u.display_name, u.icon, up.role, up.created_at
 FROM user_projects up
@@ -139,28 +636,46 @@ func (q *Queries) ListProjectMembers(ctx context.Context, projectID pgtype.UUID)
 }
 
 const listProjectsForUser = `-- name: ListProjectsForUser :many
-SELECT p.id, p.name, p.description, p.created_by, p.created_at
+SELECT p.id, p.name, p.description, p.created_by, p.created_at, p.webhook_secret, p.protected, p.functions_root, p.repo_created_by_portal, up.role
 FROM projects p
 JOIN user_projects up ON up.project_id = p.id
 WHERE up.user_id = $1
 ORDER BY p.created_at DESC
 `
 
-func (q *Queries) ListProjectsForUser(ctx context.Context, userID []byte) ([]Project, error) {
+type ListProjectsForUserRow struct {
+	ID                  pgtype.UUID
+	Name                string
+	Description         pgtype.Text
+	CreatedBy           []byte
+	CreatedAt           pgtype.Timestamptz
+	WebhookSecret       pgtype.Text
+	Protected           bool
+	FunctionsRoot       string
+	RepoCreatedByPortal bool
+	Role                pgtype.Text
+}
+
+func (q *Queries) ListProjectsForUser(ctx context.Context, userID []byte) ([]ListProjectsForUserRow, error) {
 	rows, err := q.db.Query(ctx, listProjectsForUser, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Project
+	var items []ListProjectsForUserRow
 	for rows.Next() {
-		var i Project
+		var i ListProjectsForUserRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Name,
 			&i.Description,
 			&i.CreatedBy,
 			&i.CreatedAt,
+			&i.WebhookSecret,
+			&i.Protected,
+			&i.FunctionsRoot,
+			&i.RepoCreatedByPortal,
+			&i.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -172,6 +687,110 @@ func (q *Queries) ListProjectsForUser(ctx context.Context, userID []byte) ([]Pro
 	return items, nil
 }
 
+const listTrackedBranches = `-- name: ListTrackedBranches :many
+SELECT e.branch
+FROM project_environments e
+WHERE e.project_id = $1
+UNION
+SELECT cr.branch
+FROM change_requests cr
+WHERE cr.project_id = $1 AND cr.state IN ('draft', 'open')
+`
+
+// branches functions may live on: the environments' and those of unmerged changes
+func (q *Queries) ListTrackedBranches(ctx context.Context, projectID pgtype.UUID) ([]string, error) {
+	rows, err := q.db.Query(ctx, listTrackedBranches, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var branch string
+		if err := rows.Scan(&branch); err != nil {
+			return nil, err
+		}
+		items = append(items, branch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const openChangeRequest = `-- name: OpenChangeRequest :one
+UPDATE change_requests
+SET state = 'open',
+    title = $2,
+    number = $3,
+    url = $4,
+    updated_at = now()
+WHERE id = $1 AND state = 'draft'
+RETURNING id, project_id, environment, branch, base_branch, author, state, title, number, url, created_at, updated_at, merged_at
+`
+
+type OpenChangeRequestParams struct {
+	ID     pgtype.UUID
+	Title  string
+	Number pgtype.Int8
+	Url    pgtype.Text
+}
+
+func (q *Queries) OpenChangeRequest(ctx context.Context, arg OpenChangeRequestParams) (ChangeRequest, error) {
+	row := q.db.QueryRow(ctx, openChangeRequest,
+		arg.ID,
+		arg.Title,
+		arg.Number,
+		arg.Url,
+	)
+	var i ChangeRequest
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Environment,
+		&i.Branch,
+		&i.BaseBranch,
+		&i.Author,
+		&i.State,
+		&i.Title,
+		&i.Number,
+		&i.Url,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MergedAt,
+	)
+	return i, err
+}
+
+const redeemProjectInvite = `-- name: RedeemProjectInvite :one
+UPDATE project_invites
+SET redeemed_by = $2, redeemed_at = now()
+WHERE token_hash = $1 AND redeemed_at IS NULL AND expires_at > now()
+RETURNING id, project_id, token_hash, role, created_by, created_at, expires_at, redeemed_by, redeemed_at
+`
+
+type RedeemProjectInviteParams struct {
+	TokenHash  string
+	RedeemedBy []byte
+}
+
+func (q *Queries) RedeemProjectInvite(ctx context.Context, arg RedeemProjectInviteParams) (ProjectInvite, error) {
+	row := q.db.QueryRow(ctx, redeemProjectInvite, arg.TokenHash, arg.RedeemedBy)
+	var i ProjectInvite
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.TokenHash,
+		&i.Role,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.RedeemedBy,
+		&i.RedeemedAt,
+	)
+	return i, err
+}
+
 const removeUserFromProject = `-- name: RemoveUserFromProject :exec
 DELETE FROM user_projects
 WHERE user_id = $1 AND project_id = $2
@@ -186,3 +805,191 @@ func (q *Queries) RemoveUserFromProject(ctx context.Context, arg RemoveUserFromP
 	_, err := q.db.Exec(ctx, removeUserFromProject, arg.UserID, arg.ProjectID)
 	return err
 }
+
+const setChangeRequestState = `-- name: SetChangeRequestState :one
+UPDATE change_requests
+SET state = $2,
+    merged_at = CASE WHEN $2 = 'merged' THEN now() ELSE merged_at END,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, project_id, environment, branch, base_branch, author, state, title, number, url, created_at, updated_at, merged_at
+`
+
+type SetChangeRequestStateParams struct {
+	ID    pgtype.UUID
+	State string
+}
+
+func (q *Queries) SetChangeRequestState(ctx context.Context, arg SetChangeRequestStateParams) (ChangeRequest, error) {
+	row := q.db.QueryRow(ctx, setChangeRequestState, arg.ID, arg.State)
+	var i ChangeRequest
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Environment,
+		&i.Branch,
+		&i.BaseBranch,
+		&i.Author,
+		&i.State,
+		&i.Title,
+		&i.Number,
+		&i.Url,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MergedAt,
+	)
+	return i, err
+}
+
+const setProjectProtected = `-- name: SetProjectProtected :one
+UPDATE projects
+SET protected = $2
+WHERE id = $1
+RETURNING id, name, description, created_by, created_at, webhook_secret, protected, functions_root, repo_created_by_portal
+`
+
+type SetProjectProtectedParams struct {
+	ID        pgtype.UUID
+	Protected bool
+}
+
+func (q *Queries) SetProjectProtected(ctx context.Context, arg SetProjectProtectedParams) (Project, error) {
+	row := q.db.QueryRow(ctx, setProjectProtected, arg.ID, arg.Protected)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.WebhookSecret,
+		&i.Protected,
+		&i.FunctionsRoot,
+		&i.RepoCreatedByPortal,
+	)
+	return i, err
+}
+
+const setProjectRepoCreatedByPortal = `-- name: SetProjectRepoCreatedByPortal :exec
+UPDATE projects
+SET repo_created_by_portal = $2
+WHERE id = $1
+`
+
+type SetProjectRepoCreatedByPortalParams struct {
+	ID                  pgtype.UUID
+	RepoCreatedByPortal bool
+}
+
+func (q *Queries) SetProjectRepoCreatedByPortal(ctx context.Context, arg SetProjectRepoCreatedByPortalParams) error {
+	_, err := q.db.Exec(ctx, setProjectRepoCreatedByPortal, arg.ID, arg.RepoCreatedByPortal)
+	return err
+}
+
+const updateProjectMemberRole = `-- name: UpdateProjectMemberRole :one
+UPDATE user_projects
+SET role = $3
+WHERE user_id = $1 AND project_id = $2
+RETURNING user_id, project_id, role, created_at
+`
+
+type UpdateProjectMemberRoleParams struct {
+	UserID    []byte
+	ProjectID pgtype.UUID
+	Role      pgtype.Text
+}
+
+func (q *Queries) UpdateProjectMemberRole(ctx context.Context, arg UpdateProjectMemberRoleParams) (UserProject, error) {
+	row := q.db.QueryRow(ctx, updateProjectMemberRole, arg.UserID, arg.ProjectID, arg.Role)
+	var i UserProject
+	err := row.Scan(
+		&i.UserID,
+		&i.ProjectID,
+		&i.Role,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertProjectVcsConnection = `-- name: UpsertProjectVcsConnection :one
+INSERT INTO project_vcs_connections (project_id, vendor, base_url, owner, repo_name, auth_mode, token, ssh_key, ssh_key_password, updated_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (project_id) DO UPDATE
+SET vendor = EXCLUDED.vendor,
+    base_url = EXCLUDED.base_url,
+    owner = EXCLUDED.owner,
+    repo_name = EXCLUDED.repo_name,
+    auth_mode = EXCLUDED.auth_mode,
+    token = EXCLUDED.token,
+    ssh_key = EXCLUDED.ssh_key,
+    ssh_key_password = EXCLUDED.ssh_key_password,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = now()
+RETURNING project_id, vendor, base_url, owner, repo_name, auth_mode, token, ssh_key, ssh_key_password, updated_by, created_at, updated_at
+`
+
+type UpsertProjectVcsConnectionParams struct {
+	ProjectID      pgtype.UUID
+	Vendor         string
+	BaseUrl        string
+	Owner          string
+	RepoName       string
+	AuthMode       string
+	Token          []byte
+	SshKey         []byte
+	SshKeyPassword []byte
+	UpdatedBy      []byte
+}
+
+func (q *Queries) UpsertProjectVcsConnection(ctx context.Context, arg UpsertProjectVcsConnectionParams) (ProjectVcsConnection, error) {
+	row := q.db.QueryRow(ctx, upsertProjectVcsConnection,
+		arg.ProjectID,
+		arg.Vendor,
+		arg.BaseUrl,
+		arg.Owner,
+		arg.RepoName,
+		arg.AuthMode,
+		arg.Token,
+		arg.SshKey,
+		arg.SshKeyPassword,
+		arg.UpdatedBy,
+	)
+	var i ProjectVcsConnection
+	err := row.Scan(
+		&i.ProjectID,
+		&i.Vendor,
+		&i.BaseUrl,
+		&i.Owner,
+		&i.RepoName,
+		&i.AuthMode,
+		&i.Token,
+		&i.SshKey,
+		&i.SshKeyPassword,
+		&i.UpdatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const vcsRepoInUse = `-- name: VcsRepoInUse :one
+SELECT (EXISTS (SELECT 1 FROM projects WHERE lower(name) = lower($1::text))
+    OR EXISTS (
+        SELECT 1
+        FROM project_vcs_connections
+        WHERE lower(owner) = lower($2::text)
+          AND lower(repo_name) = lower($1::text)
+    ))::bool AS in_use
+`
+
+type VcsRepoInUseParams struct {
+	RepoName string
+	Owner    string
+}
+
+func (q *Queries) VcsRepoInUse(ctx context.Context, arg VcsRepoInUseParams) (bool, error) {
+	row := q.db.QueryRow(ctx, vcsRepoInUse, arg.RepoName, arg.Owner)
+	var in_use bool
+	err := row.Scan(&in_use)
+	return in_use, err
+}
@@ -0,0 +1,45 @@
+package project
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// ChangeBranchPrefix namespaces the branches protected projects take portal saves on
+const ChangeBranchPrefix = "changes/"
+
+// Change request states, a change is a draft until its pull request is opened
+const (
+	ChangeDraft  = "draft"
+	ChangeOpen   = "open"
+	ChangeMerged = "merged"
+	ChangeClosed = "closed"
+)
+
+// ChangeBranch names a new change branch for user, a random suffix keeps the user's
+// later changes apart from ones already merged
+func ChangeBranch(user string) (string, error) {
+	var slug strings.Builder
+	for _, r := range strings.ToLower(user) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			slug.WriteRune(r)
+		case slug.Len() > 0 && !strings.HasSuffix(slug.String(), "-"):
+			slug.WriteByte('-')
+		}
+		if slug.Len() >= 24 {
+			break
+		}
+	}
+	name := strings.TrimSuffix(slug.String(), "-")
+	if name == "" {
+		name = "user"
+	}
+
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return ChangeBranchPrefix + name + "-" + hex.EncodeToString(suffix), nil
+}
@@ -0,0 +1,33 @@
+package project
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChangeBranch(t *testing.T) {
+	for user, prefix := range map[string]string{
+		"alice":                              "changes/alice-",
+		"Bob Smith":                          "changes/bob-smith-",
+		"  ünïcode!!":                        "changes/n-code-",
+		"":                                   "changes/user-",
+		"a-very-long-user-name-that-goes-on": "changes/a-very-long-user-name-th-",
+	} {
+		b, err := ChangeBranch(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(b, prefix) || len(b) != len(prefix)+6 {
+			t.Errorf("ChangeBranch(%q) = %q, want %s + 6 hex", user, b, prefix)
+		}
+		if !ValidBranchName(b) {
+			t.Errorf("ChangeBranch(%q) = %q isn't a valid branch", user, b)
+		}
+	}
+
+	a, _ := ChangeBranch("alice")
+	b, _ := ChangeBranch("alice")
+	if a == b {
+		t.Error("a user's change branches should differ")
+	}
+}
@@ -0,0 +1,27 @@
+package project
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v6/plumbing"
+)
+
+// DefaultEnvironment is the environment every project starts with, on the repo's main branch
+const DefaultEnvironment = "production"
+
+var environmentName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// ValidEnvironmentName matches what project_environments accepts, short enough to sit in
+// a gateway url as project@env
+func ValidEnvironmentName(name string) bool {
+	return environmentName.MatchString(name)
+}
+
+// ValidBranchName rejects branch names git would refuse or that read as something else
+func ValidBranchName(name string) bool {
+	if name == "" || name == "HEAD" || strings.HasPrefix(name, "-") || strings.HasPrefix(name, "refs/") {
+		return false
+	}
+	return plumbing.NewBranchReferenceName(name).Validate() == nil
+}
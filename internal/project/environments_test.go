@@ -0,0 +1,39 @@
+package project
+
+import "testing"
+
+func TestValidEnvironmentName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"production": true,
+		"staging-2":  true,
+		"dev":        true,
+		"":           false,
+		"-dev":       false,
+		"Staging":    false,
+		"qa@eu":      false,
+		"a/b":        false,
+	} {
+		if got := ValidEnvironmentName(name); got != want {
+			t.Errorf("ValidEnvironmentName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestValidBranchName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"main":         true,
+		"env/staging":  true,
+		"release-1.2":  true,
+		"":             false,
+		"HEAD":         false,
+		"-x":           false,
+		"refs/heads/x": false,
+		"a..b":         false,
+		"a b":          false,
+		"x.lock":       false,
+	} {
+		if got := ValidBranchName(name); got != want {
+			t.Errorf("ValidBranchName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package project
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	DefaultInviteTTL = 72 * time.Hour
+	MaxInviteTTL     = 30 * 24 * time.Hour
+)
+
+// NewInviteToken returns a url safe token for an invite link along with the hash to persist
+func NewInviteToken() (string, string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, HashInviteToken(token), nil
+}
+
+// HashInviteToken is what invites are looked up by, so a leaked table can't be replayed as links
+func HashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// InviteTTL clamps the requested lifetime of an invite link, zero means the default
+func InviteTTL(hours int) time.Duration {
+	if hours <= 0 {
+		return DefaultInviteTTL
+	}
+	ttl := time.Duration(hours) * time.Hour
+	if ttl > MaxInviteTTL {
+		return MaxInviteTTL
+	}
+	return ttl
+}
@@ -0,0 +1,33 @@
+package project
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInviteToken(t *testing.T) {
+	token, hash, err := NewInviteToken()
+	if err != nil {
+		t.Fatalf("NewInviteToken() error = %v", err)
+	}
+	if HashInviteToken(token) != hash {
+		t.Error("hash doesn't match the issued token")
+	}
+	other, _, _ := NewInviteToken()
+	if other == token {
+		t.Error("tokens should be unique")
+	}
+}
+
+func TestInviteTTL(t *testing.T) {
+	for hours, want := range map[int]time.Duration{
+		0:     DefaultInviteTTL,
+		-5:    DefaultInviteTTL,
+		1:     time.Hour,
+		10000: MaxInviteTTL,
+	} {
+		if got := InviteTTL(hours); got != want {
+			t.Errorf("InviteTTL(%d) = %s, want %s", hours, got, want)
+		}
+	}
+}
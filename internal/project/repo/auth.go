@@ -4,9 +4,8 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"strings"
 
-	"github.com/ashupednekar/litewebservices-portal/pkg"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/vendors"
 	"github.com/go-git/go-git/v6"
 	"github.com/go-git/go-git/v6/plumbing/transport/http"
 	"github.com/go-git/go-git/v6/plumbing/transport/ssh"
@@ -16,8 +15,10 @@ type AuthMode interface {
 	UpdateOptions(options *git.CloneOptions) error
 }
 
+// SshAuth reads the private key from the privKey file unless key holds it
 type SshAuth struct {
 	privKey  string
+	key      []byte
 	password string
 }
 
@@ -26,6 +27,14 @@ type TokenAuth struct {
 }
 
 func (s *SshAuth) UpdateOptions(options *git.CloneOptions) error {
+	if len(s.key) > 0 {
+		publicKeys, err := ssh.NewPublicKeys("git", s.key, s.password)
+		if err != nil {
+			return fmt.Errorf("error getting pubkey: %s", err)
+		}
+		options.Auth = publicKeys
+		return nil
+	}
 	_, err := os.Stat(s.privKey)
 	if err != nil {
 		return fmt.Errorf("read file %s failed %s\n", s.privKey, err.Error())
@@ -43,27 +52,23 @@ func (t *TokenAuth) UpdateOptions(options *git.CloneOptions) error {
 	return nil
 }
 
-func (r *GitRepo) SetupAuth() error {
-	log.Printf("setting up %s auth\n", pkg.Cfg.VcsAuthMode)
-	switch pkg.Cfg.VcsAuthMode {
+// SetupAuth points the clone at conn's repo with conn's credentials
+func (r *GitRepo) SetupAuth(conn vendors.Connection) error {
+	log.Printf("setting up %s auth\n", conn.AuthMode)
+	r.Options.URL = conn.CloneURL()
+	switch conn.AuthMode {
 	case "ssh":
-		vendor := strings.TrimPrefix(strings.TrimPrefix(pkg.Cfg.VcsBaseUrl, "https://"), "http://")
-		r.Options.URL = fmt.Sprintf(
-			"git@%s:%s/%s.git",
-			vendor,
-			pkg.Cfg.VcsUser,
-			r.Project,
-		)
 		auth := &SshAuth{
-			privKey:  pkg.Cfg.VcsPrivKeyPath,
-			password: pkg.Cfg.VcsPrivKeyPassword,
+			privKey:  conn.SSHKeyPath,
+			key:      conn.SSHKey,
+			password: conn.SSHKeyPassword,
 		}
 		auth.UpdateOptions(r.Options)
 	case "token":
-		auth := &TokenAuth{token: pkg.Cfg.VcsToken}
+		auth := &TokenAuth{token: conn.Token}
 		auth.UpdateOptions(r.Options)
 	default:
-		return fmt.Errorf("invalid auth mode: %s", pkg.Cfg.VcsAuthMode)
+		return fmt.Errorf("invalid auth mode: %s", conn.AuthMode)
 	}
 	return nil
 }
@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	format "github.com/go-git/go-git/v6/plumbing/format/config"
+)
+
+// BlobHash is the git blob id of content, functions use it as the ETag of their source
+func BlobHash(data []byte) string {
+	h := plumbing.NewHasher(format.SHA1, plumbing.BlobObject, int64(len(data)))
+	h.Write(data)
+	return h.Sum().String()
+}
+
+// ReadFile returns the content of a worktree file
+func (r *GitRepo) ReadFile(path string) ([]byte, error) {
+	f, err := r.Fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// ReadBlob returns a blob's content by its hex id, false when the clone doesn't hold it
+func (r *GitRepo) ReadBlob(id string) ([]byte, bool) {
+	h, ok := plumbing.FromHex(id)
+	if !ok {
+		return nil, false
+	}
+	blob, err := r.Repo.BlobObject(h)
+	if err != nil {
+		return nil, false
+	}
+	rd, err := blob.Reader()
+	if err != nil {
+		return nil, false
+	}
+	defer rd.Close()
+	data, err := io.ReadAll(rd)
+	return data, err == nil
+}
+
+// Head returns the commit HEAD points at
+func (r *GitRepo) Head() (plumbing.Hash, error) {
+	ref, err := r.Repo.Head()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return ref.Hash(), nil
+}
+
+// ResetHard moves HEAD back to commit discarding worktree changes and untracked files,
+// used to drop a local commit the remote refused or a half applied edit
+func (r *GitRepo) ResetHard(commit plumbing.Hash) error {
+	wt, err := r.Repo.Worktree()
+	if err != nil {
+		return err
+	}
+	if err := wt.Reset(&git.ResetOptions{Commit: commit, Mode: git.HardReset}); err != nil {
+		return fmt.Errorf("reset to %s failed: %w", commit, err)
+	}
+	if err := wt.Clean(&git.CleanOptions{Dir: true}); err != nil {
+		return fmt.Errorf("clean after reset failed: %w", err)
+	}
+	return nil
+}
+
+// IsPushRejected reports whether a push failed because the remote has commits the clone lacks
+func IsPushRejected(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, git.ErrForceNeeded) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "non-fast-forward") || strings.Contains(msg, "fetch first")
+}
@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-git/go-git/v6"
+)
+
+func TestBlobHash(t *testing.T) {
+	// git hash-object of an empty file and of "hello\n"
+	if got := BlobHash(nil); got != "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391" {
+		t.Errorf("empty blob = %s", got)
+	}
+	if got := BlobHash([]byte("hello\n")); got != "ce013625030ba8dba906f756967f9e9ca394464a" {
+		t.Errorf("hello blob = %s", got)
+	}
+}
+
+func TestReadBlobAndResetHard(t *testing.T) {
+	r := localRepo(t, "demo", 4)
+	first, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, err := r.ReadFile("README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, r, "README.md", "changed")
+	if err := r.Commit("README.md"); err != nil {
+		t.Fatal(err)
+	}
+	// the old version stays readable by its hash after it's replaced
+	if data, ok := r.ReadBlob(BlobHash(old)); !ok || string(data) != string(old) {
+		t.Errorf("ReadBlob = %q, %v", data, ok)
+	}
+	if _, ok := r.ReadBlob(BlobHash([]byte("never committed"))); ok {
+		t.Error("ReadBlob found a blob that was never stored")
+	}
+
+	if err := r.ResetHard(first); err != nil {
+		t.Fatal(err)
+	}
+	if head, _ := r.Head(); head != first {
+		t.Errorf("head = %s, want %s", head, first)
+	}
+	if data, _ := r.ReadFile("README.md"); string(data) != string(old) {
+		t.Errorf("worktree after reset = %q", data)
+	}
+}
+
+func TestIsPushRejected(t *testing.T) {
+	if !IsPushRejected(fmt.Errorf("push failed: %w", git.ErrForceNeeded)) {
+		t.Error("wrapped ErrForceNeeded not recognised")
+	}
+	if !IsPushRejected(errors.New("push failed: non-fast-forward update: refs/heads/main")) {
+		t.Error("non-fast-forward not recognised")
+	}
+	if IsPushRejected(errors.New("authentication required")) || IsPushRejected(nil) {
+		t.Error("unrelated error taken for a rejection")
+	}
+}
@@ -0,0 +1,236 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/project/vendors"
+	"github.com/go-git/go-billy/v6/osfs"
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/cache"
+	"github.com/go-git/go-git/v6/storage/filesystem"
+)
+
+// With VCS_STORAGE=disk clones live in a cache directory instead of memory, a worktree
+// per project and branch with its objects under .git. A restart reopens them and only
+// fetches what the remote gained since, and clones nobody touched for a while are
+// collected.
+
+const (
+	StorageMemory = "memory"
+	StorageDisk   = "disk"
+)
+
+// diskObjectCache bounds the objects a disk clone keeps decoded in memory, it's what the
+// Manager's budget counts for it
+const diskObjectCache = 8 * cache.MiByte
+
+// errStaleClone is a clone on disk that can't be reused and is cloned again
+var errStaleClone = errors.New("stale clone")
+
+// CloneDir is where ref's clone lives under dir
+func CloneDir(dir string, ref Ref) string {
+	return filepath.Join(dir, url.PathEscape(ref.Project), url.PathEscape(ref.Branch))
+}
+
+// OpenDiskRepo opens the clone of ref under dir, bringing it level with conn's repo, and
+// clones into dir when there's no usable one yet
+func OpenDiskRepo(dir string, conn vendors.Connection, ref Ref) (*GitRepo, error) {
+	r := &GitRepo{
+		Project: ref.Project,
+		Branch:  ref.Branch,
+		Dir:     CloneDir(dir, ref),
+		Options: &git.CloneOptions{},
+	}
+	if err := r.SetupAuth(conn); err != nil {
+		return nil, fmt.Errorf("auth setup failed: %w", err)
+	}
+	if err := r.openDisk(); err != nil {
+		return nil, fmt.Errorf("%s: %w", ref, err)
+	}
+	return r, nil
+}
+
+// openDisk reuses the clone in r.Dir or clones into it, a failed clone leaves nothing
+// behind
+func (r *GitRepo) openDisk() error {
+	r.mount()
+	err := r.reopen()
+	switch {
+	case err == nil:
+		touch(r.Dir, time.Now())
+		return nil
+	case errors.Is(err, errStaleClone):
+		fmt.Printf("[WARN] discarding the cached clone in %s: %v\n", r.Dir, err)
+	case !errors.Is(err, git.ErrRepositoryNotExists):
+		return fmt.Errorf("reopening failed: %w", err)
+	}
+
+	if err := os.RemoveAll(r.Dir); err != nil {
+		return err
+	}
+	r.mount()
+	if err := r.Clone(); err != nil {
+		os.RemoveAll(r.Dir)
+		return err
+	}
+	touch(r.Dir, time.Now())
+	return nil
+}
+
+// mount points the worktree and object storage at r.Dir
+func (r *GitRepo) mount() {
+	r.Fs = osfs.New(r.Dir, osfs.WithBoundOS())
+	dot, _ := r.Fs.Chroot(git.GitDirName)
+	r.Storage = filesystem.NewStorage(dot, cache.NewObjectLRU(diskObjectCache))
+	r.Repo = nil
+	r.Worktree = nil
+}
+
+// reopen picks up the clone in r.Dir and resets it to the remote's branch. Commits the
+// remote doesn't have are saves that never got pushed, so they go with the reset
+func (r *GitRepo) reopen() error {
+	gr, err := git.Open(r.Storage, r.Fs)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", errStaleClone, err)
+	}
+	remote, err := gr.Remote("origin")
+	if err != nil {
+		return fmt.Errorf("%w: %v", errStaleClone, err)
+	}
+	if urls := remote.Config().URLs; len(urls) == 0 || urls[0] != r.Options.URL {
+		return fmt.Errorf("%w: origin is no longer %s", errStaleClone, r.Options.URL)
+	}
+	head, err := gr.Head()
+	if err != nil || head.Name() != plumbing.NewBranchReferenceName(r.Branch) {
+		return fmt.Errorf("%w: HEAD isn't on %s", errStaleClone, r.Branch)
+	}
+	wt, err := gr.Worktree()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errStaleClone, err)
+	}
+	r.Repo = gr
+	r.Worktree = wt
+
+	theirs, err := r.fetchBranch(r.Branch)
+	if err != nil {
+		return err
+	}
+	if err := r.ResetHard(theirs.Hash); err != nil {
+		return fmt.Errorf("%w: %v", errStaleClone, err)
+	}
+	return nil
+}
+
+// touch marks a clone directory used, CollectIdle goes by its modification time
+func touch(dir string, now time.Time) {
+	os.Chtimes(dir, now, now)
+}
+
+// CollectEvery runs CollectIdle on an interval until ctx is done, it returns right away
+// for managers keeping clones in memory
+func (m *Manager) CollectEvery(ctx context.Context, interval time.Duration) {
+	if m.dir == "" {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := m.CollectIdle()
+			if err != nil {
+				fmt.Printf("[WARN] collecting idle clones failed: %v\n", err)
+			}
+			if n > 0 {
+				fmt.Printf("[INFO] collected %d idle clones\n", n)
+			}
+		}
+	}
+}
+
+// CollectIdle deletes the clones on disk that weren't acquired for longer than the
+// manager's idle time and returns how many went. Clones held in memory are kept however
+// long they've been idle, the budget evicts them first
+func (m *Manager) CollectIdle() (int, error) {
+	if m.dir == "" {
+		return 0, nil
+	}
+	projects, err := os.ReadDir(m.dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	// clones move out of the way under lock and are deleted after, an acquire racing
+	// the collection clones afresh instead of opening a half deleted directory
+	trash, err := os.MkdirTemp(m.dir, ".collect-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(trash)
+
+	now := m.now()
+	var n int
+	for _, p := range projects {
+		if !p.IsDir() || strings.HasPrefix(p.Name(), ".") {
+			continue
+		}
+		project, err := url.PathUnescape(p.Name())
+		if err != nil {
+			continue
+		}
+		branches, err := os.ReadDir(filepath.Join(m.dir, p.Name()))
+		if err != nil {
+			return n, err
+		}
+		for _, b := range branches {
+			branch, err := url.PathUnescape(b.Name())
+			if err != nil || !b.IsDir() {
+				continue
+			}
+			info, err := b.Info()
+			if err != nil || now.Sub(info.ModTime()) < m.idle {
+				continue
+			}
+			moved, err := m.discard(Ref{Project: project, Branch: branch}, filepath.Join(trash, fmt.Sprint(n)))
+			if err != nil {
+				return n, err
+			}
+			if moved {
+				n++
+			}
+		}
+		m.mu.Lock()
+		// fails while other branches are still there
+		os.Remove(filepath.Join(m.dir, p.Name()))
+		m.mu.Unlock()
+	}
+	return n, nil
+}
+
+// discard moves ref's clone to dst unless it's held in memory
+func (m *Manager) discard(ref Ref, dst string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.entries[ref]; ok {
+		return false, nil
+	}
+	if err := os.Rename(CloneDir(m.dir, ref), dst); err != nil {
+		return false, err
+	}
+	return true, nil
+}
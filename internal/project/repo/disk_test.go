@@ -0,0 +1,185 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+)
+
+func openDiskAt(t *testing.T, dir, remote string, ref Ref) *GitRepo {
+	t.Helper()
+	r := &GitRepo{
+		Project: ref.Project,
+		Branch:  ref.Branch,
+		Dir:     CloneDir(dir, ref),
+		Options: &git.CloneOptions{URL: remote},
+	}
+	if err := r.openDisk(); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestDiskRepoReopens(t *testing.T) {
+	const fn = "hello.lua"
+	remote := diskRemote(t, fn, "one\n")
+	dir := t.TempDir()
+	ref := DefaultRef("demo")
+
+	r := openDiskAt(t, dir, remote, ref)
+	if data, _ := r.ReadFile(fn); string(data) != "one\n" {
+		t.Fatalf("cloned %s = %q", fn, data)
+	}
+	marker := filepath.Join(r.Dir, ".git", "marker")
+	if err := os.WriteFile(marker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the remote moves on while the clone holds a save that never got pushed
+	commitPush(t, cloneBranch(t, remote, DefaultBranch), fn, "two\n")
+	writeFile(t, r, fn, "unpushed\n")
+	if err := r.Commit(fn); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, r, "stray.lua", "x")
+
+	r = openDiskAt(t, dir, remote, ref)
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("clone wasn't reused: %v", err)
+	}
+	if data, _ := r.ReadFile(fn); string(data) != "two\n" {
+		t.Errorf("reopened %s = %q", fn, data)
+	}
+	if _, err := r.Fs.Stat("stray.lua"); !os.IsNotExist(err) {
+		t.Errorf("stray file survived the reopen: %v", err)
+	}
+
+	// a clone of another remote is discarded rather than fetched into
+	other := diskRemote(t, fn, "other\n")
+	r = openDiskAt(t, dir, other, ref)
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Errorf("clone of the old remote was reused: %v", err)
+	}
+	if data, _ := r.ReadFile(fn); string(data) != "other\n" {
+		t.Errorf("recloned %s = %q", fn, data)
+	}
+}
+
+func TestDiskRepoFailedClone(t *testing.T) {
+	dir := t.TempDir()
+	r := &GitRepo{
+		Project: "demo",
+		Branch:  DefaultBranch,
+		Dir:     CloneDir(dir, DefaultRef("demo")),
+		Options: &git.CloneOptions{URL: filepath.Join(dir, "missing")},
+	}
+	if err := r.openDisk(); err == nil {
+		t.Fatal("cloning a missing remote succeeded")
+	}
+	if _, err := os.Stat(r.Dir); !os.IsNotExist(err) {
+		t.Errorf("failed clone left %s behind: %v", r.Dir, err)
+	}
+}
+
+func TestManagerCollectIdle(t *testing.T) {
+	remote := diskRemote(t, "hello.lua", "one\n")
+	dir := t.TempDir()
+	// a zero budget evicts clones from memory as soon as they're released
+	m := newManager(0, 0, func(ref Ref) (*GitRepo, error) {
+		return openDiskAt(t, dir, remote, ref), nil
+	}, (*GitRepo).Pull)
+	m.dir = dir
+	m.idle = time.Hour
+
+	idle, held := DefaultRef("idle"), DefaultRef("held")
+	if err := m.View(idle, func(*GitRepo) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	_, release, err := m.Acquire(held, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	if n, err := m.CollectIdle(); err != nil || n != 0 {
+		t.Fatalf("collecting fresh clones = %d, %v", n, err)
+	}
+
+	m.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if n, err := m.CollectIdle(); err != nil || n != 1 {
+		t.Fatalf("CollectIdle = %d, %v", n, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "idle")); !os.IsNotExist(err) {
+		t.Errorf("idle clone is still on disk: %v", err)
+	}
+	if _, err := os.Stat(CloneDir(dir, held)); err != nil {
+		t.Errorf("clone in use was collected: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("cache dir holds %d entries after collecting, want 1", len(entries))
+	}
+
+	// clones reopen once collected
+	if err := m.View(idle, func(*GitRepo) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManagerEvictKeepsRacingClone(t *testing.T) {
+	remote := diskRemote(t, "hello.lua", "one\n")
+	dir := t.TempDir()
+	m := newManager(1<<30, 0, func(ref Ref) (*GitRepo, error) {
+		return openDiskAt(t, dir, remote, ref), nil
+	}, (*GitRepo).Pull)
+	m.dir = dir
+	ref := DefaultRef("demo")
+
+	// a branch only on disk goes too
+	old := CloneDir(dir, Ref{Project: "demo", Branch: "old"})
+	if err := os.MkdirAll(old, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	_, release, err := m.Acquire(ref, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evicted := make(chan struct{})
+	go func() {
+		m.Evict("demo")
+		close(evicted)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	// races Evict for the entry, either reading the old clone before it goes or
+	// cloning afresh once it's gone
+	raced := make(chan struct{})
+	go func() {
+		defer close(raced)
+		if err := m.View(ref, func(*GitRepo) error { return nil }); err != nil {
+			t.Error(err)
+		}
+	}()
+	time.Sleep(20 * time.Millisecond)
+	release()
+	<-evicted
+	<-raced
+
+	err = m.View(ref, func(r *GitRepo) error {
+		_, err := os.Stat(filepath.Join(r.Dir, "hello.lua"))
+		return err
+	})
+	if err != nil {
+		t.Errorf("clone opened around Evict lost its files: %v", err)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("branch only on disk survived Evict: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("cache dir holds %d entries after Evict, want just the project", len(entries))
+	}
+}
@@ -0,0 +1,93 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+)
+
+// Edit is a set of file writes and removals that lands as a single commit
+type Edit struct {
+	Write  map[string][]byte
+	Remove []string
+}
+
+func (e Edit) paths() []string {
+	paths := make([]string, 0, len(e.Write)+len(e.Remove))
+	for p := range e.Write {
+		paths = append(paths, p)
+	}
+	paths = append(paths, e.Remove...)
+	sort.Strings(paths)
+	return paths
+}
+
+// CommitEdit applies e to the worktree and commits it as ch. It's all or nothing: if
+// any write, removal or the commit fails the worktree is put back the way HEAD has it.
+// An edit that changes nothing returns git.ErrEmptyCommit, also leaving HEAD alone
+func (r *GitRepo) CommitEdit(ch Change, e Edit) error {
+	head, err := r.Head()
+	if err != nil {
+		return err
+	}
+	err = r.applyEdit(ch, e)
+	if err == nil {
+		return nil
+	}
+	if resetErr := r.ResetHard(head); resetErr != nil {
+		return errors.Join(err, resetErr)
+	}
+	return err
+}
+
+func (r *GitRepo) applyEdit(ch Change, e Edit) error {
+	for p, data := range e.Write {
+		if err := r.Fs.MkdirAll(path.Dir(p), 0755); err != nil {
+			return fmt.Errorf("creating %s: %w", path.Dir(p), err)
+		}
+		f, err := r.Fs.Create(p)
+		if err != nil {
+			return fmt.Errorf("writing %s: %w", p, err)
+		}
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("writing %s: %w", p, err)
+		}
+	}
+	for _, p := range e.Remove {
+		if err := r.Fs.Remove(p); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing %s: %w", p, err)
+		}
+	}
+	return r.CommitChange(ch, e.paths()...)
+}
+
+// ListFiles returns the files under dir, recursively, as repo paths
+func (r *GitRepo) ListFiles(dir string) ([]string, error) {
+	entries, err := r.Fs.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []string
+	for _, e := range entries {
+		p := path.Join(dir, e.Name())
+		if !e.IsDir() {
+			out = append(out, p)
+			continue
+		}
+		sub, err := r.ListFiles(p)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sub...)
+	}
+	return out, nil
+}
@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"testing"
+)
+
+func TestCommitEdit(t *testing.T) {
+	r := localRepo(t, "demo", 1)
+	before := commitCount(t, r)
+
+	e := Edit{
+		Write: map[string][]byte{
+			"functions/python/hello/lws.yaml":         []byte("name: hello\n"),
+			"functions/python/hello/main.py":          []byte("print(1)\n"),
+			"functions/python/hello/lib/util.py":      []byte("x = 1\n"),
+			"functions/python/hello/requirements.txt": []byte("requests\n"),
+		},
+	}
+	if err := r.CommitEdit(Change{Message: "create hello"}, e); err != nil {
+		t.Fatal(err)
+	}
+	if got := commitCount(t, r); got != before+1 {
+		t.Errorf("%d commits, want %d", got, before+1)
+	}
+
+	files, err := r.ListFiles("functions/python/hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 4 {
+		t.Errorf("ListFiles = %v", files)
+	}
+
+	e = Edit{
+		Write:  map[string][]byte{"functions/python/hello/main.py": []byte("print(2)\n")},
+		Remove: []string{"functions/python/hello/requirements.txt"},
+	}
+	if err := r.CommitEdit(Change{}, e); err != nil {
+		t.Fatal(err)
+	}
+	c, err := r.Resolve("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := c.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 {
+		t.Errorf("commit touched %v, want the write and the removal", stats)
+	}
+	if _, err := r.ReadFile("functions/python/hello/requirements.txt"); err == nil {
+		t.Error("removed file still there")
+	}
+}
+
+func TestCommitEditFailureLeavesHead(t *testing.T) {
+	r := localRepo(t, "demo", 1)
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, _ := r.ReadFile("README.md")
+
+	// a file can't be created under another file, so the second write fails after
+	// the first may already have landed in the worktree
+	e := Edit{Write: map[string][]byte{
+		"README.md":      []byte("changed"),
+		"README.md/x.py": []byte("nope"),
+	}}
+	if err := r.CommitEdit(Change{}, e); err == nil {
+		t.Fatal("edit writing below a file succeeded")
+	}
+	if got, _ := r.Head(); got != head {
+		t.Errorf("head moved to %s", got)
+	}
+	if data, _ := r.ReadFile("README.md"); string(data) != string(old) {
+		t.Errorf("worktree kept the partial edit: %q", data)
+	}
+}
@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrFileNotFound     = errors.New("file not found at revision")
+)
+
+// Revision is a commit as the history api shows it
+type Revision struct {
+	Hash    string    `json:"sha"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	When    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// History lists the commits reachable from HEAD that touched path, or any file under
+// it for a directory, newest first, stopping after limit when it's positive
+func (r *GitRepo) History(path string, limit int) ([]Revision, error) {
+	dir := strings.TrimSuffix(path, "/") + "/"
+	iter, err := r.Repo.Log(&git.LogOptions{PathFilter: func(p string) bool {
+		return p == path || strings.HasPrefix(p, dir)
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("log for %s failed: %w", path, err)
+	}
+	defer iter.Close()
+
+	out := []Revision{}
+	for limit <= 0 || len(out) < limit {
+		c, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("log for %s failed: %w", path, err)
+		}
+		out = append(out, Revision{
+			Hash:    c.Hash.String(),
+			Author:  c.Author.Name,
+			Email:   c.Author.Email,
+			When:    c.Author.When,
+			Message: strings.TrimSpace(c.Message),
+		})
+	}
+	return out, nil
+}
+
+// Resolve turns a full or abbreviated sha, a branch or HEAD into a commit
+func (r *GitRepo) Resolve(rev string) (*object.Commit, error) {
+	h, err := r.Repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrRevisionNotFound, rev)
+	}
+	c, err := r.Repo.CommitObject(*h)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrRevisionNotFound, rev)
+	}
+	return c, nil
+}
+
+// FileAt returns path's content as of commit rev
+func (r *GitRepo) FileAt(rev, path string) ([]byte, *object.Commit, error) {
+	c, err := r.Resolve(rev)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := c.File(path)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, c, fmt.Errorf("%w: %s at %s", ErrFileNotFound, path, c.Hash)
+	}
+	if err != nil {
+		return nil, c, err
+	}
+	content, err := f.Contents()
+	if err != nil {
+		return nil, c, err
+	}
+	return []byte(content), c, nil
+}
+
+// FilesAt returns every file under dir as of commit rev, keyed by repo path
+func (r *GitRepo) FilesAt(rev, dir string) (map[string][]byte, *object.Commit, error) {
+	c, err := r.Resolve(rev)
+	if err != nil {
+		return nil, nil, err
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, c, err
+	}
+	sub, err := tree.Tree(dir)
+	if errors.Is(err, object.ErrDirectoryNotFound) {
+		return nil, c, fmt.Errorf("%w: %s at %s", ErrFileNotFound, dir, c.Hash)
+	}
+	if err != nil {
+		return nil, c, err
+	}
+	files := map[string][]byte{}
+	err = sub.Files().ForEach(func(f *object.File) error {
+		content, err := f.Contents()
+		if err != nil {
+			return err
+		}
+		files[path.Join(dir, f.Name)] = []byte(content)
+		return nil
+	})
+	if err != nil {
+		return nil, c, err
+	}
+	return files, c, nil
+}
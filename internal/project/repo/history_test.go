@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHistoryAndFileAt(t *testing.T) {
+	r := localRepo(t, "demo", 1)
+	writeFile(t, r, "fn.lua", "return 1")
+	if err := r.Commit("fn.lua"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, r, "README.md", "untouched fn.lua")
+	if err := r.Commit("README.md"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, r, "fn.lua", "return 2")
+	if err := r.CommitChange(Change{Message: "bump fn.lua"}, "fn.lua"); err != nil {
+		t.Fatal(err)
+	}
+
+	revs, err := r.History("fn.lua", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 2 || revs[0].Message != "bump fn.lua" || revs[1].Message != "update fn.lua" {
+		t.Fatalf("history = %+v", revs)
+	}
+	if limited, _ := r.History("fn.lua", 1); len(limited) != 1 || limited[0].Hash != revs[0].Hash {
+		t.Errorf("limited history = %+v", limited)
+	}
+
+	// abbreviated shas resolve too
+	data, commit, err := r.FileAt(revs[1].Hash[:7], "fn.lua")
+	if err != nil || string(data) != "return 1" || commit.Hash.String() != revs[1].Hash {
+		t.Errorf("FileAt = %q, %v", data, err)
+	}
+	if _, _, err := r.FileAt("HEAD~3", "fn.lua"); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("before the file existed: %v", err)
+	}
+	if _, _, err := r.FileAt("0123456789abcdef0123456789abcdef01234567", "fn.lua"); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("unknown sha: %v", err)
+	}
+}
+
+func TestBundleHistoryAndFilesAt(t *testing.T) {
+	r := localRepo(t, "demo", 1)
+	writeFile(t, r, "fn/app.py", "print(1)")
+	writeFile(t, r, "fn/lws.yaml", "name: fn")
+	if err := r.CommitChange(Change{}, "fn/app.py", "fn/lws.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, r, "fn.lua", "not in the bundle")
+	if err := r.Commit("fn.lua"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Fs.MkdirAll("fn/lib", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, r, "fn/lib/util.py", "x = 1")
+	if err := r.CommitChange(Change{Message: "add util"}, "fn/lib/util.py"); err != nil {
+		t.Fatal(err)
+	}
+
+	revs, err := r.History("fn", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 2 || revs[0].Message != "add util" {
+		t.Fatalf("history = %+v", revs)
+	}
+
+	files, _, err := r.FilesAt(revs[1].Hash, "fn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || string(files["fn/app.py"]) != "print(1)" || string(files["fn/lws.yaml"]) != "name: fn" {
+		t.Errorf("FilesAt = %q", files)
+	}
+	if files, _, _ := r.FilesAt("HEAD", "fn"); string(files["fn/lib/util.py"]) != "x = 1" {
+		t.Errorf("FilesAt HEAD = %q", files)
+	}
+	if _, _, err := r.FilesAt("HEAD~3", "fn"); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("before the bundle existed: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ashupednekar/litewebservices-portal/pkg"
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+const (
+	defaultCommitterName  = "LiteWebServices Portal"
+	defaultCommitterEmail = "noreply@example.com"
+)
+
+// Author is who a commit is attributed to
+type Author struct {
+	Name  string
+	Email string
+}
+
+// UserAuthor attributes commits to a portal user: their display name at
+// <name>@COMMIT_EMAIL_DOMAIN, the portal doesn't know users' own addresses
+func UserAuthor(name, displayName string) Author {
+	a := Author{Name: displayName}
+	if a.Name == "" {
+		a.Name = name
+	}
+	domain := pkg.Cfg.CommitEmailDomain
+	if domain == "" {
+		domain = "users.noreply." + pkg.Cfg.Fqdn
+	}
+	a.Email = emailLocalPart(name) + "@" + strings.TrimPrefix(domain, "@")
+	return a
+}
+
+// emailLocalPart turns a username into an address's local part, characters outside
+// letters, digits and ._+- become dashes and dots can't lead, trail or repeat
+func emailLocalPart(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '_', r == '+', r == '-':
+			b.WriteRune(r)
+		case r == '.':
+			if s := b.String(); s != "" && !strings.HasSuffix(s, ".") {
+				b.WriteRune(r)
+			}
+		default:
+			b.WriteRune('-')
+		}
+	}
+	local := strings.TrimSuffix(b.String(), ".")
+	if local == "" {
+		return "user"
+	}
+	return local
+}
+
+// Change is what a commit says about itself, zero values fall back to the portal
+// identity and an "update <path>" message
+type Change struct {
+	Author  Author
+	Message string
+	// parents is set for merge commits, otherwise the commit follows HEAD
+	parents []plumbing.Hash
+}
+
+func committer() Author {
+	a := Author{Name: pkg.Cfg.CommitterName, Email: pkg.Cfg.CommitterEmail}
+	if a.Name == "" {
+		a.Name = defaultCommitterName
+	}
+	if a.Email == "" {
+		a.Email = defaultCommitterEmail
+	}
+	return a
+}
+
+func (ch Change) message(paths []string) string {
+	if m := strings.TrimSpace(ch.Message); m != "" {
+		return m
+	}
+	return "update " + strings.Join(paths, ", ")
+}
+
+// options keeps the portal as committer, and signer when a signing key is configured
+func (ch Change) options(when time.Time) (*git.CommitOptions, error) {
+	c := committer()
+	author := ch.Author
+	if author.Name == "" || author.Email == "" {
+		author = c
+	}
+	signer, err := commitSigner()
+	if err != nil {
+		return nil, fmt.Errorf("commit signing unavailable: %w", err)
+	}
+	opts := &git.CommitOptions{
+		Author:    &object.Signature{Name: author.Name, Email: author.Email, When: when},
+		Committer: &object.Signature{Name: c.Name, Email: c.Email, When: when},
+		Parents:   ch.parents,
+		// a merge records the source as merged even when it brings no file changes
+		AllowEmptyCommits: len(ch.parents) > 1,
+	}
+	// a nil *signer in the interface would still count as set
+	if signer != nil {
+		opts.Signer = signer
+	}
+	return opts, nil
+}
@@ -0,0 +1,146 @@
+package repo
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ashupednekar/litewebservices-portal/pkg"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestUserAuthor(t *testing.T) {
+	pkg.Cfg.CommitEmailDomain = "users.example.com"
+	defer func() { pkg.Cfg.CommitEmailDomain = "" }()
+
+	cases := []struct {
+		name, display string
+		want          Author
+	}{
+		{"ada", "Ada Lovelace", Author{"Ada Lovelace", "ada@users.example.com"}},
+		{"ada", "", Author{"ada", "ada@users.example.com"}},
+		{"Ada Lovelace", "", Author{"Ada Lovelace", "Ada-Lovelace@users.example.com"}},
+		{".ada..l<o>@x.", "", Author{".ada..l<o>@x.", "ada.l-o--x@users.example.com"}},
+		{"ädä", "Ada", Author{"Ada", "-d-@users.example.com"}},
+		{"...", "", Author{"...", "user@users.example.com"}},
+	}
+	for _, tc := range cases {
+		if got := UserAuthor(tc.name, tc.display); got != tc.want {
+			t.Errorf("UserAuthor(%q, %q) = %+v, want %+v", tc.name, tc.display, got, tc.want)
+		}
+	}
+}
+
+func TestCommitChangeAttribution(t *testing.T) {
+	r := localRepo(t, "demo", 1)
+	writeFile(t, r, "a.lua", "return 1")
+	writeFile(t, r, "b.lua", "return 2")
+	ch := Change{Author: Author{"Ada Lovelace", "ada@math.org"}}
+	if err := r.CommitChange(ch, "a.lua", "b.lua"); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := r.Resolve("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Author.Name != "Ada Lovelace" || c.Author.Email != "ada@math.org" {
+		t.Errorf("author = %s <%s>", c.Author.Name, c.Author.Email)
+	}
+	if c.Committer.Name != defaultCommitterName || c.Committer.Email != defaultCommitterEmail {
+		t.Errorf("committer = %s <%s>", c.Committer.Name, c.Committer.Email)
+	}
+	if c.Message != "update a.lua, b.lua" {
+		t.Errorf("message = %q", c.Message)
+	}
+}
+
+func TestSSHSigner(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSigner("ssh", pem.EncodeToMemory(block), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	armored, err := s.Sign(strings.NewReader("tree abc\n\nmessage\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := strings.TrimSpace(string(armored))
+	if !strings.HasPrefix(text, "-----BEGIN SSH SIGNATURE-----") || !strings.HasSuffix(text, "-----END SSH SIGNATURE-----") {
+		t.Fatalf("not an armored ssh signature:\n%s", armored)
+	}
+	lines := strings.Split(text, "\n")
+	blob, err := base64.StdEncoding.DecodeString(strings.Join(lines[1:len(lines)-1], ""))
+	if err != nil || !bytes.HasPrefix(blob, []byte("SSHSIG")) {
+		t.Fatalf("bad signature blob: %v", err)
+	}
+	var parsed struct {
+		Version   uint32
+		PublicKey string
+		Namespace string
+		Reserved  string
+		HashAlg   string
+		Signature string
+	}
+	if err := ssh.Unmarshal(blob[6:], &parsed); err != nil {
+		t.Fatal(err)
+	}
+	var sig ssh.Signature
+	if err := ssh.Unmarshal([]byte(parsed.Signature), &sig); err != nil {
+		t.Fatal(err)
+	}
+	sshPub, _ := ssh.NewPublicKey(pub)
+	digest := sha512.Sum512([]byte("tree abc\n\nmessage\n"))
+	if err := sshPub.Verify(sshSignedData(digest[:]), &sig); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+	if parsed.Namespace != "git" || parsed.HashAlg != "sha512" {
+		t.Errorf("namespace %q, hash %q", parsed.Namespace, parsed.HashAlg)
+	}
+}
+
+func TestOpenPGPSigner(t *testing.T) {
+	e, err := openpgp.NewEntity("portal", "", "portal@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var key bytes.Buffer
+	w, _ := armor.Encode(&key, openpgp.PrivateKeyType, nil)
+	if err := e.SerializePrivate(w, nil); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	s, err := NewSigner("openpgp", key.Bytes(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const message = "tree abc\n\nmessage\n"
+	sig, err := s.Sign(strings.NewReader(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = openpgp.CheckArmoredDetachedSignature(openpgp.EntityList{e}, strings.NewReader(message), bytes.NewReader(sig), nil)
+	if err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+
+	if _, err := NewSigner("x509", key.Bytes(), ""); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
@@ -0,0 +1,461 @@
+package repo
+
+import (
+	"container/list"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/project/vendors"
+	"github.com/go-git/go-billy/v6/util"
+	"github.com/go-git/go-git/v6/storage/memory"
+)
+
+// Ref names a clone: a project's repo checked out at one branch
+type Ref struct {
+	Project string
+	Branch  string
+}
+
+// DefaultRef is the project's default branch, where the default environment lives
+func DefaultRef(project string) Ref {
+	return Ref{Project: project, Branch: DefaultBranch}
+}
+
+func (r Ref) String() string {
+	return r.Project + "@" + r.Branch
+}
+
+// Manager owns the in-memory clones of project repos, one per project and branch.
+// Each clone has a read/write lock: writers get the clone to themselves, readers
+// share it, and nothing touches a worktree without holding one or the other. Idle
+// clones are evicted least recently used first once their estimated size exceeds
+// the budget.
+//
+// Clones are pulled only when they may be behind the remote: after MarkStale
+// (a push webhook), once ttl has passed since the last sync, or through Sync.
+//
+// A disk backed Manager keeps the clones it evicts in its cache directory, so
+// opening them again only fetches, see CollectIdle for how they're dropped.
+type Manager struct {
+	mu      sync.Mutex
+	entries map[Ref]*entry
+	lru     *list.List
+	used    int64
+	budget  int64
+	ttl     time.Duration
+	// dir is the clone cache of a disk backed manager, clones not acquired for idle
+	// are collected from it
+	dir  string
+	idle time.Duration
+
+	open func(ref Ref) (*GitRepo, error)
+	pull func(r *GitRepo) error
+	now  func() time.Time
+}
+
+type entry struct {
+	ref  Ref
+	lock sync.RWMutex
+	// repo and syncedAt are set under lock, repo is nil until the first clone succeeds
+	repo     *GitRepo
+	syncedAt time.Time
+	stale    atomic.Bool
+
+	// guarded by Manager.mu
+	elem    *list.Element
+	refs    int
+	size    int64
+	evicted bool
+}
+
+// Connector looks up the connection a project's repo is reached through
+type Connector func(project string) (vendors.Connection, error)
+
+// NewManager clones on first use through connect, budget is the total size in bytes
+// idle clones may take and ttl how long a clone is trusted without a pull, 0 leaves
+// syncing to webhooks and explicit syncs
+func NewManager(budget int64, ttl time.Duration, connect Connector) *Manager {
+	return newManager(budget, ttl, func(ref Ref) (*GitRepo, error) {
+		conn, err := connect(ref.Project)
+		if err != nil {
+			return nil, err
+		}
+		return NewGitRepo(conn, ref.Project, &ref.Branch)
+	}, (*GitRepo).Pull)
+}
+
+// NewDiskManager keeps clones under dir, reopening them across restarts. budget, ttl
+// and connect are as for NewManager, idle is how long an unused clone stays on disk
+func NewDiskManager(dir string, idle time.Duration, budget int64, ttl time.Duration, connect Connector) *Manager {
+	m := newManager(budget, ttl, func(ref Ref) (*GitRepo, error) {
+		conn, err := connect(ref.Project)
+		if err != nil {
+			return nil, err
+		}
+		return OpenDiskRepo(dir, conn, ref)
+	}, (*GitRepo).Pull)
+	m.dir = dir
+	m.idle = idle
+	return m
+}
+
+func newManager(budget int64, ttl time.Duration, open func(Ref) (*GitRepo, error), pull func(*GitRepo) error) *Manager {
+	return &Manager{
+		entries: make(map[Ref]*entry),
+		lru:     list.New(),
+		budget:  budget,
+		ttl:     ttl,
+		open:    open,
+		pull:    pull,
+		now:     time.Now,
+	}
+}
+
+// Acquire returns the clone with its lock held, write takes the lock exclusively.
+// release must be called exactly once when done.
+func (m *Manager) Acquire(ref Ref, write bool) (*GitRepo, func(), error) {
+	return m.acquire(ref, write, false)
+}
+
+// Update runs fn on the clone locked exclusively
+func (m *Manager) Update(ref Ref, fn func(*GitRepo) error) error {
+	r, release, err := m.acquire(ref, true, false)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn(r)
+}
+
+// View runs fn on the clone under a shared lock
+func (m *Manager) View(ref Ref, fn func(*GitRepo) error) error {
+	r, release, err := m.acquire(ref, false, false)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn(r)
+}
+
+// Sync pulls regardless of freshness and runs fn with the clone locked exclusively
+func (m *Manager) Sync(ref Ref, fn func(*GitRepo) error) error {
+	r, release, err := m.acquire(ref, true, true)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn(r)
+}
+
+// MarkStale makes the next acquire pull, it doesn't wait for current holders
+func (m *Manager) MarkStale(ref Ref) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[ref]; ok {
+		e.stale.Store(true)
+	}
+}
+
+// Evict drops the clones of every branch of a project once current holders are
+// done with them, the next Acquire clones afresh. A disk backed manager deletes
+// them from disk too
+func (m *Manager) Evict(project string) {
+	m.mu.Lock()
+	var evicted []*entry
+	for ref, e := range m.entries {
+		if ref.Project == project {
+			evicted = append(evicted, e)
+		}
+	}
+	m.mu.Unlock()
+
+	// as in CollectIdle, clones move out of the way under lock and are deleted after
+	var trash string
+	if m.dir != "" {
+		var err error
+		if trash, err = os.MkdirTemp(m.dir, ".evict-"); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("[WARN] removing the clones of %s failed: %v\n", project, err)
+		}
+		defer os.RemoveAll(trash)
+	}
+	n := 0
+	for _, e := range evicted {
+		// wait out in flight holders so the clone isn't reused after eviction. Acquires
+		// queued behind us find the entry detached and start over on a fresh one, which
+		// can't be created before the clone is off disk
+		e.lock.Lock()
+		m.mu.Lock()
+		if !e.evicted {
+			m.remove(e)
+		}
+		if trash != "" {
+			n++
+			if err := os.Rename(CloneDir(m.dir, e.ref), filepath.Join(trash, fmt.Sprint(n))); err != nil && !os.IsNotExist(err) {
+				fmt.Printf("[WARN] removing the clone of %s failed: %v\n", e.ref, err)
+			}
+		}
+		m.mu.Unlock()
+		e.repo = nil
+		e.lock.Unlock()
+	}
+	if trash == "" {
+		return
+	}
+
+	// branches only on disk, skipping any an acquire opened since
+	branches, err := os.ReadDir(filepath.Join(m.dir, url.PathEscape(project)))
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Printf("[WARN] removing the clones of %s failed: %v\n", project, err)
+	}
+	for _, b := range branches {
+		branch, err := url.PathUnescape(b.Name())
+		if err != nil || !b.IsDir() {
+			continue
+		}
+		n++
+		if _, err := m.discard(Ref{Project: project, Branch: branch}, filepath.Join(trash, fmt.Sprint(n))); err != nil {
+			fmt.Printf("[WARN] removing the clones of %s failed: %v\n", project, err)
+		}
+	}
+	m.mu.Lock()
+	// fails while a branch opened since is still there
+	os.Remove(filepath.Join(m.dir, url.PathEscape(project)))
+	m.mu.Unlock()
+}
+
+// Lease hands out a clone on first use, so requests that never touch files
+// neither lock nor sync it. A lease belongs to a single request goroutine.
+type Lease struct {
+	m       *Manager
+	ref     Ref
+	resolve func() (Ref, error)
+	write   bool
+	repo    *GitRepo
+	err     error
+	release func()
+}
+
+func (m *Manager) Lease(ref Ref, write bool) *Lease {
+	return &Lease{m: m, ref: ref, write: write}
+}
+
+// Ref is the clone the lease hands out, or would without a resolve that hasn't run yet
+func (l *Lease) Ref() Ref {
+	return l.ref
+}
+
+// Resolve has the lease pick its ref with fn on first use instead, for branches that
+// only come into being once a request touches files
+func (l *Lease) Resolve(fn func() (Ref, error)) {
+	l.resolve = fn
+}
+
+// Repo acquires the clone the first time it's called and returns the same one after
+func (l *Lease) Repo() (*GitRepo, error) {
+	if l.repo == nil && l.err == nil && l.resolve != nil {
+		l.ref, l.err = l.resolve()
+		l.resolve = nil
+	}
+	if l.repo == nil && l.err == nil {
+		l.repo, l.release, l.err = l.m.Acquire(l.ref, l.write)
+	}
+	return l.repo, l.err
+}
+
+// Release lets go of the clone if it was acquired, it's safe to call more than once. A
+// later Repo acquires it again
+func (l *Lease) Release() {
+	if l.release != nil {
+		l.release()
+		l.release = nil
+		l.repo = nil
+	}
+}
+
+// Len is the number of clones held
+func (m *Manager) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+func (m *Manager) acquire(ref Ref, write, force bool) (*GitRepo, func(), error) {
+	for {
+		e := m.ref(ref)
+
+		// readers of a clone that's there and current share it straight away
+		if !write && !force {
+			e.lock.RLock()
+			if e.repo != nil && !m.detached(e) && !m.behind(e) {
+				return e.repo, m.releaser(e, false), nil
+			}
+			e.lock.RUnlock()
+		}
+
+		// cloning and pulling rewrite the worktree, so they always run exclusively
+		e.lock.Lock()
+		if m.detached(e) {
+			// evicted while we waited, start over on a fresh entry
+			e.lock.Unlock()
+			m.unref(e, false)
+			continue
+		}
+		switch {
+		case e.repo == nil:
+			r, err := m.open(ref)
+			if err != nil {
+				e.lock.Unlock()
+				m.unref(e, true)
+				return nil, nil, err
+			}
+			e.repo = r
+			e.syncedAt = m.now()
+			e.stale.Store(false)
+		case force || m.behind(e):
+			// clear first so a webhook landing mid pull marks it again
+			e.stale.Store(false)
+			if err := m.pull(e.repo); err != nil {
+				e.stale.Store(true)
+				// readers can live with the last known state, writers would fail to push anyway
+				if write || force {
+					e.lock.Unlock()
+					m.unref(e, false)
+					return nil, nil, fmt.Errorf("pull failed for %s: %w", ref, err)
+				}
+				fmt.Printf("[WARN] pull failed for %s, serving cached clone: %v\n", ref, err)
+			} else {
+				e.syncedAt = m.now()
+			}
+		}
+
+		if write {
+			return e.repo, m.releaser(e, true), nil
+		}
+		m.measure(e)
+		e.lock.Unlock()
+		e.lock.RLock()
+		if e.repo == nil {
+			// Evict got the lock in between
+			e.lock.RUnlock()
+			m.unref(e, false)
+			continue
+		}
+		return e.repo, m.releaser(e, false), nil
+	}
+}
+
+// releaser re-measures after writes while still holding the lock, then lets go
+// of the entry so it becomes evictable
+func (m *Manager) releaser(e *entry, write bool) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			if write {
+				m.measure(e)
+				e.lock.Unlock()
+			} else {
+				e.lock.RUnlock()
+			}
+			m.unref(e, false)
+		})
+	}
+}
+
+func (m *Manager) ref(ref Ref) *entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[ref]
+	if !ok {
+		e = &entry{ref: ref}
+		e.elem = m.lru.PushFront(e)
+		m.entries[ref] = e
+	} else {
+		m.lru.MoveToFront(e.elem)
+	}
+	e.refs++
+	if m.dir != "" {
+		touch(CloneDir(m.dir, ref), m.now())
+	}
+	return e
+}
+
+// unref drops a reference, forget drops an entry whose clone failed unless
+// someone else is already waiting to retry it
+func (m *Manager) unref(e *entry, forget bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e.refs--
+	if forget && e.refs == 0 && !e.evicted {
+		m.remove(e)
+	}
+	m.evict()
+}
+
+// behind is true when the clone may be missing remote commits, the caller holds e.lock
+func (m *Manager) behind(e *entry) bool {
+	return e.stale.Load() || (m.ttl > 0 && m.now().Sub(e.syncedAt) >= m.ttl)
+}
+
+func (m *Manager) detached(e *entry) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return e.evicted
+}
+
+// measure records the clone's size, the caller holds e.lock
+func (m *Manager) measure(e *entry) {
+	size := e.repo.Size()
+	m.mu.Lock()
+	if !e.evicted {
+		m.used += size - e.size
+		e.size = size
+	}
+	m.mu.Unlock()
+}
+
+// evict drops idle clones from the back of the list until usage fits the budget
+func (m *Manager) evict() {
+	for el := m.lru.Back(); el != nil && m.used > m.budget; {
+		e := el.Value.(*entry)
+		el = el.Prev()
+		if e.refs == 0 {
+			m.remove(e)
+		}
+	}
+}
+
+func (m *Manager) remove(e *entry) {
+	m.lru.Remove(e.elem)
+	delete(m.entries, e.ref)
+	m.used -= e.size
+	e.evicted = true
+}
+
+// Size estimates the memory a clone holds: stored git objects plus worktree files, or
+// the object cache for clones on disk
+func (r *GitRepo) Size() int64 {
+	if r.Dir != "" {
+		return int64(diskObjectCache)
+	}
+	var size int64
+	if s, ok := r.Storage.(*memory.Storage); ok {
+		for _, obj := range s.ObjectStorage.Objects {
+			size += obj.Size()
+		}
+	}
+	if r.Fs != nil {
+		util.Walk(r.Fs, "/", func(_ string, info fs.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				size += info.Size()
+			}
+			return nil
+		})
+	}
+	return size
+}
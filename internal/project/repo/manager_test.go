@@ -0,0 +1,517 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v6/memfs"
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/go-git/go-git/v6/storage/memory"
+)
+
+// localRepo builds a repo with no remote holding one committed file of the given size
+func localRepo(t testing.TB, project string, size int) *GitRepo {
+	t.Helper()
+	fs := memfs.New()
+	storage := memory.NewStorage()
+	gr, err := git.Init(storage, git.WithWorkTree(fs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := gr.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &GitRepo{Project: project, Branch: "main", Storage: storage, Fs: fs, Worktree: wt, Repo: gr, Options: &git.CloneOptions{}}
+	writeFile(t, r, "README.md", strings.Repeat("x", size))
+	if err := r.Commit("README.md"); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func writeFile(t testing.TB, r *GitRepo, path, content string) {
+	t.Helper()
+	f, err := r.Fs.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(content))
+	f.Close()
+}
+
+type fakeRemote struct {
+	opens    atomic.Int32
+	pulls    atomic.Int32
+	size     int
+	fail     atomic.Bool
+	failPull atomic.Bool
+}
+
+func (f *fakeRemote) manager(t testing.TB, budget int64) *Manager {
+	return newManager(budget, 0, func(ref Ref) (*GitRepo, error) {
+		f.opens.Add(1)
+		if f.fail.Load() {
+			return nil, errors.New("remote unavailable")
+		}
+		return localRepo(t, ref.Project, f.size), nil
+	}, func(*GitRepo) error {
+		f.pulls.Add(1)
+		if f.failPull.Load() {
+			return errors.New("remote unavailable")
+		}
+		return nil
+	})
+}
+
+func commitCount(t testing.TB, r *GitRepo) int {
+	t.Helper()
+	iter, err := r.Repo.Log(&git.LogOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	iter.ForEach(func(*object.Commit) error { n++; return nil })
+	return n
+}
+
+func TestManagerConcurrentCreateUpdateDelete(t *testing.T) {
+	remote := &fakeRemote{size: 16}
+	m := remote.manager(t, 1<<30)
+
+	const writers, rounds = 8, 18
+	var wg sync.WaitGroup
+	var commits atomic.Int32
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			path := fmt.Sprintf("fn-%d.lua", w)
+			for i := 0; i < rounds; i++ {
+				err := m.Update(DefaultRef("demo"), func(r *GitRepo) error {
+					// create, update a few times, delete every fifth round and start over
+					if i%5 == 4 {
+						if err := r.Fs.Remove(path); err != nil {
+							return err
+						}
+					} else {
+						writeFile(t, r, path, fmt.Sprintf("return %d", i))
+					}
+					if err := r.Commit(path); err != nil {
+						return err
+					}
+					commits.Add(1)
+					return nil
+				})
+				if err != nil {
+					t.Errorf("writer %d round %d: %v", w, i, err)
+					return
+				}
+			}
+		}(w)
+	}
+
+	var readers sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				r, release, err := m.Acquire(DefaultRef("demo"), false)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				entries, _ := r.Fs.ReadDir("/")
+				for _, e := range entries {
+					if f, err := r.Fs.Open(e.Name()); err == nil {
+						io.ReadAll(f)
+						f.Close()
+					}
+				}
+				release()
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(stop)
+	readers.Wait()
+
+	if n := remote.opens.Load(); n != 1 {
+		t.Errorf("cloned %d times, want 1", n)
+	}
+	err := m.View(DefaultRef("demo"), func(r *GitRepo) error {
+		if got, want := commitCount(t, r), int(commits.Load())+1; got != want {
+			t.Errorf("history has %d commits, want %d", got, want)
+		}
+		for w := 0; w < writers; w++ {
+			// the last round rewrote the file
+			f, err := r.Fs.Open(fmt.Sprintf("fn-%d.lua", w))
+			if err != nil {
+				t.Errorf("writer %d: %v", w, err)
+				continue
+			}
+			data, _ := io.ReadAll(f)
+			f.Close()
+			if string(data) != fmt.Sprintf("return %d", rounds-1) {
+				t.Errorf("writer %d: content %q", w, data)
+			}
+		}
+		status, err := r.Worktree.Status()
+		if err != nil {
+			return err
+		}
+		if !status.IsClean() {
+			t.Errorf("worktree left dirty:\n%s", status)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManagerClonesOncePerProject(t *testing.T) {
+	remote := &fakeRemote{size: 16}
+	m := remote.manager(t, 1<<30)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, release, err := m.Acquire(DefaultRef(fmt.Sprintf("p%d", i%2)), i%3 == 0)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			release()
+		}(i)
+	}
+	wg.Wait()
+
+	if n := remote.opens.Load(); n != 2 {
+		t.Errorf("cloned %d times, want once per project", n)
+	}
+	if m.Len() != 2 {
+		t.Errorf("holding %d clones, want 2", m.Len())
+	}
+}
+
+func TestManagerClonesPerBranch(t *testing.T) {
+	remote := &fakeRemote{size: 16}
+	m := remote.manager(t, 1<<30)
+
+	noop := func(*GitRepo) error { return nil }
+	m.View(DefaultRef("demo"), noop)
+	m.View(Ref{Project: "demo", Branch: "staging"}, noop)
+	m.View(DefaultRef("other"), noop)
+	if n := remote.opens.Load(); n != 3 || m.Len() != 3 {
+		t.Fatalf("cloned %d times holding %d, want a clone per branch", n, m.Len())
+	}
+
+	// a stale main doesn't make staging pull
+	m.MarkStale(DefaultRef("demo"))
+	m.View(Ref{Project: "demo", Branch: "staging"}, noop)
+	if n := remote.pulls.Load(); n != 0 {
+		t.Errorf("pulled %d times", n)
+	}
+
+	m.Evict("demo")
+	if m.Len() != 1 {
+		t.Errorf("holding %d clones after Evict, want only the other project's", m.Len())
+	}
+}
+
+func TestManagerEvictsLeastRecentlyUsed(t *testing.T) {
+	remote := &fakeRemote{size: 4 << 10}
+	one := localRepo(t, "sample", remote.size).Size()
+	m := remote.manager(t, 2*one+one/2)
+
+	for _, p := range []string{"a", "b", "a", "c"} {
+		if err := m.View(DefaultRef(p), func(*GitRepo) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.Len() != 2 {
+		t.Fatalf("holding %d clones, want 2", m.Len())
+	}
+	// b was used least recently, so a is still cached and b clones again
+	m.View(DefaultRef("a"), func(*GitRepo) error { return nil })
+	if n := remote.opens.Load(); n != 3 {
+		t.Errorf("a was evicted, %d clones", n)
+	}
+	m.View(DefaultRef("b"), func(*GitRepo) error { return nil })
+	if n := remote.opens.Load(); n != 4 {
+		t.Errorf("b should have been evicted, %d clones", n)
+	}
+}
+
+func TestManagerKeepsClonesInUse(t *testing.T) {
+	remote := &fakeRemote{size: 4 << 10}
+	m := remote.manager(t, 1)
+
+	held, release, err := m.Acquire(DefaultRef("held"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"x", "y"} {
+		m.View(DefaultRef(p), func(*GitRepo) error { return nil })
+	}
+	if m.Len() != 1 {
+		t.Errorf("holding %d clones, want only the one in use", m.Len())
+	}
+	writeFile(t, held, "still.txt", "mine")
+	release()
+
+	if m.Len() != 0 {
+		t.Errorf("idle clone over budget kept after release")
+	}
+}
+
+func TestManagerDoesNotCacheFailedClones(t *testing.T) {
+	remote := &fakeRemote{size: 16}
+	m := remote.manager(t, 1<<30)
+
+	remote.fail.Store(true)
+	if _, _, err := m.Acquire(DefaultRef("demo"), false); err == nil {
+		t.Fatal("expected clone error")
+	}
+	if m.Len() != 0 {
+		t.Errorf("failed clone left an entry behind")
+	}
+	remote.fail.Store(false)
+	if err := m.View(DefaultRef("demo"), func(*GitRepo) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManagerPullsOnlyWhenBehind(t *testing.T) {
+	remote := &fakeRemote{size: 16}
+	m := remote.manager(t, 1<<30)
+	now := time.Unix(1_700_000_000, 0)
+	m.now = func() time.Time { return now }
+	m.ttl = time.Minute
+	noop := func(*GitRepo) error { return nil }
+
+	m.View(DefaultRef("demo"), noop)
+	m.Update(DefaultRef("demo"), noop)
+	_, release, _ := m.Acquire(DefaultRef("demo"), false)
+	release()
+	if n := remote.pulls.Load(); n != 0 {
+		t.Errorf("fresh clone pulled %d times", n)
+	}
+
+	m.MarkStale(DefaultRef("demo"))
+	m.View(DefaultRef("demo"), noop)
+	m.View(DefaultRef("demo"), noop)
+	if n := remote.pulls.Load(); n != 1 {
+		t.Errorf("pulled %d times after MarkStale, want 1", n)
+	}
+
+	now = now.Add(time.Minute)
+	m.Update(DefaultRef("demo"), noop)
+	m.Update(DefaultRef("demo"), noop)
+	if n := remote.pulls.Load(); n != 2 {
+		t.Errorf("pulled %d times after ttl, want 2", n)
+	}
+
+	m.Sync(DefaultRef("demo"), noop)
+	m.Sync(DefaultRef("demo"), noop)
+	if n := remote.pulls.Load(); n != 4 {
+		t.Errorf("Sync pulled %d times in total, want 4", n)
+	}
+
+	// MarkStale on a project that isn't cloned is a no-op
+	m.MarkStale(DefaultRef("other"))
+	if m.Len() != 1 {
+		t.Errorf("MarkStale created an entry")
+	}
+}
+
+func TestManagerZeroTTLLeavesSyncToWebhooks(t *testing.T) {
+	remote := &fakeRemote{size: 16}
+	m := remote.manager(t, 1<<30)
+	now := time.Unix(1_700_000_000, 0)
+	m.now = func() time.Time { return now }
+	noop := func(*GitRepo) error { return nil }
+
+	m.View(DefaultRef("demo"), noop)
+	now = now.Add(24 * time.Hour)
+	m.View(DefaultRef("demo"), noop)
+	if n := remote.pulls.Load(); n != 0 {
+		t.Errorf("pulled %d times with ttl disabled", n)
+	}
+}
+
+func TestManagerFailedPull(t *testing.T) {
+	remote := &fakeRemote{size: 16}
+	m := remote.manager(t, 1<<30)
+	noop := func(*GitRepo) error { return nil }
+	m.View(DefaultRef("demo"), noop)
+
+	remote.failPull.Store(true)
+	m.MarkStale(DefaultRef("demo"))
+	if err := m.View(DefaultRef("demo"), noop); err != nil {
+		t.Errorf("readers should get the cached clone when a pull fails: %v", err)
+	}
+	if err := m.Update(DefaultRef("demo"), noop); err == nil {
+		t.Error("writers should fail when the clone can't be brought up to date")
+	}
+	if err := m.Sync(DefaultRef("demo"), noop); err == nil {
+		t.Error("Sync should report the failed pull")
+	}
+
+	// still stale, so the next acquire retries
+	remote.failPull.Store(false)
+	before := remote.pulls.Load()
+	if err := m.Update(DefaultRef("demo"), noop); err != nil {
+		t.Fatal(err)
+	}
+	if remote.pulls.Load() != before+1 {
+		t.Error("stale flag was lost after a failed pull")
+	}
+}
+
+func TestLeaseAcquiresOnFirstUse(t *testing.T) {
+	remote := &fakeRemote{size: 16}
+	m := remote.manager(t, 1<<30)
+
+	unused := m.Lease(DefaultRef("demo"), true)
+	unused.Release()
+	if remote.opens.Load() != 0 || m.Len() != 0 {
+		t.Fatal("an unused lease cloned the repo")
+	}
+
+	l := m.Lease(DefaultRef("demo"), true)
+	r1, err := l.Repo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, _ := l.Repo()
+	if r1 != r2 || remote.opens.Load() != 1 {
+		t.Error("a lease should hand out one clone")
+	}
+
+	// the write lock is held until Release
+	done := make(chan struct{})
+	go func() {
+		m.View(DefaultRef("demo"), func(*GitRepo) error { return nil })
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("reader got in while the lease held the write lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+	l.Release()
+	l.Release()
+	<-done
+}
+
+func TestLeaseResolvesOnFirstUse(t *testing.T) {
+	remote := &fakeRemote{size: 16}
+	m := remote.manager(t, 1<<30)
+	change := Ref{Project: "demo", Branch: "changes/alice"}
+
+	var calls int
+	unused := m.Lease(DefaultRef("demo"), true)
+	unused.Resolve(func() (Ref, error) { calls++; return change, nil })
+	unused.Release()
+	if calls != 0 {
+		t.Fatal("an unused lease resolved its ref")
+	}
+
+	l := m.Lease(DefaultRef("demo"), true)
+	l.Resolve(func() (Ref, error) { calls++; return change, nil })
+	if _, err := l.Repo(); err != nil {
+		t.Fatal(err)
+	}
+	l.Repo()
+	l.Release()
+	if calls != 1 || l.Ref() != change {
+		t.Errorf("resolved %d times to %v", calls, l.Ref())
+	}
+	// the clone the lease took is the change branch's
+	m.View(change, func(*GitRepo) error { return nil })
+	if remote.opens.Load() != 1 {
+		t.Errorf("%d clones, want the change branch's only", remote.opens.Load())
+	}
+
+	failing := m.Lease(DefaultRef("demo"), false)
+	failing.Resolve(func() (Ref, error) { return Ref{}, errors.New("no branch") })
+	if _, err := failing.Repo(); err == nil {
+		t.Error("a failed resolve should fail the lease")
+	}
+}
+
+func TestManagerEvictWaitsForHolders(t *testing.T) {
+	remote := &fakeRemote{size: 16}
+	m := remote.manager(t, 1<<30)
+
+	_, release, err := m.Acquire(DefaultRef("demo"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		m.Evict("demo")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Evict returned while the clone was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	<-done
+
+	if m.Len() != 0 {
+		t.Errorf("evicted clone still held")
+	}
+	m.View(DefaultRef("demo"), func(*GitRepo) error { return nil })
+	if n := remote.opens.Load(); n != 2 {
+		t.Errorf("cloned %d times, want a fresh clone after Evict", n)
+	}
+}
+
+func TestManagerReadersShareTheClone(t *testing.T) {
+	remote := &fakeRemote{size: 16}
+	m := remote.manager(t, 1<<30)
+	ref := DefaultRef("demo")
+
+	_, release, err := m.Acquire(ref, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	done := make(chan struct{})
+	go func() {
+		_, release, err := m.Acquire(ref, false)
+		if err == nil {
+			release()
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a reader waited for another reader")
+	}
+}
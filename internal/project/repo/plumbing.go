@@ -8,13 +8,12 @@ import (
 
 	"github.com/go-git/go-git/v6"
 	"github.com/go-git/go-git/v6/plumbing"
-	"github.com/go-git/go-git/v6/plumbing/object"
 )
 
 func (r *GitRepo) Clone() error {
 	r.Options.ReferenceName = plumbing.NewBranchReferenceName(r.Branch)
 	r.Options.SingleBranch = true
-	r.Options.Depth = 1
+	// full history, functions expose their past versions
 	log.Printf("Cloning %s (branch=%s)\n", r.Project, r.Branch)
 	repo, err := git.Clone(r.Storage, r.Fs, r.Options)
 	if err != nil {
@@ -30,31 +29,37 @@ func (r *GitRepo) Clone() error {
 }
 
 func (r *GitRepo) Commit(path string) error {
+    return r.CommitChange(Change{}, path)
+}
+
+// CommitChange stages paths, or their removal, and commits them as one commit
+// authored by ch.Author with the portal as committer
+func (r *GitRepo) CommitChange(ch Change, paths ...string) error {
     wt, err := r.Repo.Worktree()
     if err != nil {
         return err
     }
 
-    _, statErr := r.Fs.Stat(path)
-    if os.IsNotExist(statErr) {
-        if _, err := wt.Remove(path); err != nil {
-            return fmt.Errorf("failed to stage deletion: %w", err)
-        }
-    } else if statErr == nil {
-        if _, err := wt.Add(path); err != nil {
-            return fmt.Errorf("failed to stage file update: %w", err)
+    for _, path := range paths {
+        _, statErr := r.Fs.Stat(path)
+        if os.IsNotExist(statErr) {
+            if _, err := wt.Remove(path); err != nil {
+                return fmt.Errorf("failed to stage deletion: %w", err)
+            }
+        } else if statErr == nil {
+            if _, err := wt.Add(path); err != nil {
+                return fmt.Errorf("failed to stage file update: %w", err)
+            }
+        } else {
+            return fmt.Errorf("stat error: %w", statErr)
         }
-    } else {
-        return fmt.Errorf("stat error: %w", statErr)
     }
 
-    _, err = wt.Commit("update "+path, &git.CommitOptions{
-        Author: &object.Signature{
-            Name:  "LiteWebServices Portal",
-            Email: "noreply@example.com",
-            When:  time.Now(),
-        },
-    })
+    opts, err := ch.options(time.Now())
+    if err != nil {
+        return err
+    }
+    _, err = wt.Commit(ch.message(paths), opts)
     return err
 }
 
@@ -96,14 +101,11 @@ func (r *GitRepo) Pull() error {
 		return fmt.Errorf("pull error: %w", err)
 	}
 
-	ref, err := r.Repo.Head()
-	if err != nil {
-		return fmt.Errorf("failed to get HEAD: %w", err)
-	}
-
+	// check out the branch rather than its hash, a detached HEAD would leave later
+	// commits off the branch and pushes would report already up-to-date
 	err = wt.Checkout(&git.CheckoutOptions{
-		Hash:  ref.Hash(),
-		Force: true,
+		Branch: plumbing.NewBranchReferenceName(r.Branch),
+		Force:  true,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to checkout: %w", err)
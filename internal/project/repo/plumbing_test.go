@@ -5,12 +5,13 @@ import (
 	"os"
 	"testing"
 
+	"github.com/ashupednekar/litewebservices-portal/internal/project/vendors"
 	"github.com/ashupednekar/litewebservices-portal/pkg"
 )
 
 func TestVcs(t *testing.T) {
 	pkg.LoadCfg()
-	r, err := NewGitRepo("projone", nil)
+	r, err := NewGitRepo(vendors.DefaultConnection("projone"), "projone", nil)
 	if err != nil {
 		t.Errorf("error in new repo call: %s", err)
 	}
@@ -42,7 +43,7 @@ func TestVcs(t *testing.T) {
       t.Fatalf("push error: %s", err)
   }
 	log.Println("opening existing repo")
-	rOpened, err := NewGitRepo("projone", nil)
+	rOpened, err := NewGitRepo(vendors.DefaultConnection("projone"), "projone", nil)
 	if err != nil {
 		t.Errorf("error in new repo call: %s", err)
 	}
@@ -0,0 +1,260 @@
+package repo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/diff"
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+// Environments are branches of the project repo. Promoting one into another brings the
+// target clone's branch up to the source: a fast-forward when the target hasn't moved
+// on its own, otherwise a merge commit built with the same three-way merge conflicting
+// saves get.
+
+// MergeConflictError is a promote refused because both branches changed Files in ways
+// that don't merge, nothing was committed
+type MergeConflictError struct {
+	From  string
+	Into  string
+	Files []string
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("merging %s into %s conflicts in %s", e.From, e.Into, strings.Join(e.Files, ", "))
+}
+
+type PromoteResult struct {
+	// UpToDate is set when the target already holds every commit of the source
+	UpToDate    bool
+	FastForward bool
+	Commit      plumbing.Hash
+}
+
+// Promote merges branch from into the clone's branch, committing as ch. The caller
+// pushes, and resets to the old head when the push fails
+func (r *GitRepo) Promote(from string, ch Change) (PromoteResult, error) {
+	theirs, err := r.fetchBranch(from)
+	if err != nil {
+		return PromoteResult{}, err
+	}
+	head, err := r.Head()
+	if err != nil {
+		return PromoteResult{}, err
+	}
+	ours, err := r.Repo.CommitObject(head)
+	if err != nil {
+		return PromoteResult{}, err
+	}
+
+	if ours.Hash == theirs.Hash {
+		return PromoteResult{UpToDate: true, Commit: head}, nil
+	}
+	if ok, err := theirs.IsAncestor(ours); err != nil {
+		return PromoteResult{}, err
+	} else if ok {
+		return PromoteResult{UpToDate: true, Commit: head}, nil
+	}
+	if ok, err := ours.IsAncestor(theirs); err != nil {
+		return PromoteResult{}, err
+	} else if ok {
+		if err := r.ResetHard(theirs.Hash); err != nil {
+			return PromoteResult{}, err
+		}
+		return PromoteResult{FastForward: true, Commit: theirs.Hash}, nil
+	}
+
+	bases, err := ours.MergeBase(theirs)
+	if err != nil {
+		return PromoteResult{}, err
+	}
+	if len(bases) == 0 {
+		return PromoteResult{}, fmt.Errorf("%s and %s share no history", from, r.Branch)
+	}
+	e, conflicts, err := mergeTrees(bases[0], ours, theirs, r.Branch, from)
+	if err != nil {
+		return PromoteResult{}, err
+	}
+	if len(conflicts) > 0 {
+		return PromoteResult{}, &MergeConflictError{From: from, Into: r.Branch, Files: conflicts}
+	}
+
+	if strings.TrimSpace(ch.Message) == "" {
+		ch.Message = fmt.Sprintf("merge %s into %s", from, r.Branch)
+	}
+	ch.parents = []plumbing.Hash{ours.Hash, theirs.Hash}
+	if err := r.CommitEdit(ch, e); err != nil {
+		return PromoteResult{}, err
+	}
+	merged, err := r.Head()
+	if err != nil {
+		return PromoteResult{}, err
+	}
+	return PromoteResult{Commit: merged}, nil
+}
+
+// fetchBranch fetches another branch of the remote into the clone, which only tracks
+// its own, and returns its head commit
+func (r *GitRepo) fetchBranch(branch string) (*object.Commit, error) {
+	remoteRef := plumbing.NewRemoteReferenceName("origin", branch)
+	spec := config.RefSpec("+" + plumbing.NewBranchReferenceName(branch).String() + ":" + remoteRef.String())
+	err := r.Repo.Fetch(&git.FetchOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{spec},
+		Auth:       r.Options.Auth,
+	})
+	if errors.Is(err, git.ErrRemoteRefNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrRevisionNotFound, branch)
+	}
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, fmt.Errorf("fetching %s failed: %w", branch, err)
+	}
+	ref, err := r.Repo.Reference(remoteRef, true)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrRevisionNotFound, branch)
+	}
+	return r.Repo.CommitObject(ref.Hash())
+}
+
+// BranchFiles lists the files on another branch of the remote, it fails with
+// ErrRevisionNotFound when the remote has no such branch
+func (r *GitRepo) BranchFiles(branch string) (map[string]bool, error) {
+	head, err := r.fetchBranch(branch)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := head.Tree()
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]bool{}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		files[f.Name] = true
+		return nil
+	})
+	return files, err
+}
+
+// mergeTrees replays the files theirs changed since base onto ours. Files only one side
+// changed take that side, files both changed are merged line by line and land in
+// conflicts when that doesn't work out
+func mergeTrees(base, ours, theirs *object.Commit, oursName, theirsName string) (Edit, []string, error) {
+	baseTree, err := base.Tree()
+	if err != nil {
+		return Edit{}, nil, err
+	}
+	ourTree, err := ours.Tree()
+	if err != nil {
+		return Edit{}, nil, err
+	}
+	theirTree, err := theirs.Tree()
+	if err != nil {
+		return Edit{}, nil, err
+	}
+	changes, err := object.DiffTree(baseTree, theirTree)
+	if err != nil {
+		return Edit{}, nil, err
+	}
+
+	paths := map[string]bool{}
+	for _, c := range changes {
+		if c.From.Name != "" {
+			paths[c.From.Name] = true
+		}
+		if c.To.Name != "" {
+			paths[c.To.Name] = true
+		}
+	}
+
+	e := Edit{Write: map[string][]byte{}}
+	var conflicts []string
+	for p := range paths {
+		b, inBase, err := treeFile(baseTree, p)
+		if err != nil {
+			return Edit{}, nil, err
+		}
+		o, inOurs, err := treeFile(ourTree, p)
+		if err != nil {
+			return Edit{}, nil, err
+		}
+		t, inTheirs, err := treeFile(theirTree, p)
+		if err != nil {
+			return Edit{}, nil, err
+		}
+
+		switch {
+		case inOurs == inTheirs && bytes.Equal(o, t):
+			// both sides ended up the same
+		case inOurs == inBase && bytes.Equal(o, b):
+			if inTheirs {
+				e.Write[p] = t
+			} else {
+				e.Remove = append(e.Remove, p)
+			}
+		case !inOurs || !inTheirs:
+			// removed on one side, changed on the other
+			conflicts = append(conflicts, p)
+		default:
+			merged, n := diff.Merge3(string(b), string(o), string(t), oursName, theirsName)
+			if n > 0 {
+				conflicts = append(conflicts, p)
+				continue
+			}
+			e.Write[p] = []byte(merged)
+		}
+	}
+	sort.Strings(conflicts)
+	return e, conflicts, nil
+}
+
+func treeFile(t *object.Tree, path string) ([]byte, bool, error) {
+	f, err := t.File(path)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	content, err := f.Contents()
+	if err != nil {
+		return nil, false, err
+	}
+	return []byte(content), true, nil
+}
+
+// CreateBranch starts branch on the remote at the clone's head, false when the remote
+// already has it
+func (r *GitRepo) CreateBranch(branch string) (bool, error) {
+	remote, err := r.Repo.Remote("origin")
+	if err != nil {
+		return false, err
+	}
+	refs, err := remote.List(&git.ListOptions{Auth: r.Options.Auth})
+	if err != nil {
+		return false, fmt.Errorf("listing remote branches failed: %w", err)
+	}
+	target := plumbing.NewBranchReferenceName(branch)
+	for _, ref := range refs {
+		if ref.Name() == target {
+			return false, nil
+		}
+	}
+
+	spec := config.RefSpec(plumbing.NewBranchReferenceName(r.Branch).String() + ":" + target.String())
+	err = r.Repo.Push(&git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{spec},
+		Auth:       r.Options.Auth,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return false, fmt.Errorf("creating branch %s failed: %w", branch, err)
+	}
+	return true, nil
+}
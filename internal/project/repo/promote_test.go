@@ -0,0 +1,168 @@
+package repo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-git/go-billy/v6/memfs"
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/storage/memory"
+)
+
+// diskRemote creates a bare repo whose main branch holds path with content
+func diskRemote(t *testing.T, path, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if _, err := git.PlainInit(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	seed := localRepo(t, "demo", 1)
+	writeFile(t, seed, path, content)
+	if err := seed.Commit(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := seed.Repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{dir}}); err != nil {
+		t.Fatal(err)
+	}
+	head, err := seed.Repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := config.RefSpec(head.Name().String() + ":refs/heads/" + DefaultBranch)
+	if err := seed.Repo.Push(&git.PushOptions{RefSpecs: []config.RefSpec{spec}}); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func cloneBranch(t *testing.T, dir, branch string) *GitRepo {
+	t.Helper()
+	r := &GitRepo{
+		Project: "demo",
+		Branch:  branch,
+		Fs:      memfs.New(),
+		Storage: memory.NewStorage(),
+		Options: &git.CloneOptions{URL: dir},
+	}
+	if err := r.Clone(); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func commitPush(t *testing.T, r *GitRepo, path, content string) {
+	t.Helper()
+	writeFile(t, r, path, content)
+	if err := r.Commit(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Push(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPromote(t *testing.T) {
+	const fn = "hello.lua"
+	remote := diskRemote(t, fn, "one\ntwo\nthree\nfour\n")
+	prod := cloneBranch(t, remote, DefaultBranch)
+
+	created, err := prod.CreateBranch("staging")
+	if err != nil || !created {
+		t.Fatalf("CreateBranch = %v, %v", created, err)
+	}
+	if created, err := prod.CreateBranch("staging"); err != nil || created {
+		t.Fatalf("second CreateBranch = %v, %v", created, err)
+	}
+	staging := cloneBranch(t, remote, "staging")
+
+	// prod hasn't moved, so staging's commit fast-forwards it
+	commitPush(t, staging, fn, "one\ntwo\nthree\nFOUR\n")
+	res, err := prod.Promote("staging", Change{})
+	if err != nil || !res.FastForward {
+		t.Fatalf("promote = %+v, %v", res, err)
+	}
+	if data, _ := prod.ReadFile(fn); string(data) != "one\ntwo\nthree\nFOUR\n" {
+		t.Errorf("after fast-forward %s = %q", fn, data)
+	}
+	if err := prod.Push(); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := prod.Promote("staging", Change{}); err != nil || !res.UpToDate {
+		t.Errorf("promote again = %+v, %v", res, err)
+	}
+
+	// both moved on: a hotfix on prod, a feature on staging
+	commitPush(t, prod, fn, "ONE\ntwo\nthree\nFOUR\n")
+	commitPush(t, staging, "util.lua", "return {}\n")
+	res, err = prod.Promote("staging", Change{})
+	if err != nil || res.FastForward || res.UpToDate {
+		t.Fatalf("merge promote = %+v, %v", res, err)
+	}
+	c, err := prod.Resolve("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.NumParents() != 2 || c.Message != "merge staging into main" {
+		t.Errorf("merge commit has %d parents, message %q", c.NumParents(), c.Message)
+	}
+	if data, _ := prod.ReadFile("util.lua"); string(data) != "return {}\n" {
+		t.Errorf("util.lua = %q", data)
+	}
+	if data, _ := prod.ReadFile(fn); string(data) != "ONE\ntwo\nthree\nFOUR\n" {
+		t.Errorf("%s = %q", fn, data)
+	}
+}
+
+func TestPromoteConflict(t *testing.T) {
+	const fn = "hello.lua"
+	remote := diskRemote(t, fn, "one\ntwo\n")
+	prod := cloneBranch(t, remote, DefaultBranch)
+	if _, err := prod.CreateBranch("staging"); err != nil {
+		t.Fatal(err)
+	}
+	staging := cloneBranch(t, remote, "staging")
+
+	commitPush(t, prod, fn, "one\nprod\n")
+	commitPush(t, staging, fn, "one\nstaging\n")
+	head, _ := prod.Head()
+
+	_, err := prod.Promote("staging", Change{})
+	var conflict *MergeConflictError
+	if !errors.As(err, &conflict) || len(conflict.Files) != 1 || conflict.Files[0] != fn {
+		t.Fatalf("promote = %v", err)
+	}
+	if got, _ := prod.Head(); got != head {
+		t.Error("conflicting promote moved HEAD")
+	}
+	if data, _ := prod.ReadFile(fn); string(data) != "one\nprod\n" {
+		t.Errorf("worktree = %q", data)
+	}
+
+	if _, err := prod.Promote("nope", Change{}); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("unknown branch = %v", err)
+	}
+}
+
+func TestBranchFiles(t *testing.T) {
+	remote := diskRemote(t, "hello.lua", "one\n")
+	prod := cloneBranch(t, remote, DefaultBranch)
+	if _, err := prod.CreateBranch("staging"); err != nil {
+		t.Fatal(err)
+	}
+	commitPush(t, cloneBranch(t, remote, "staging"), "util.lua", "return {}\n")
+
+	files, err := prod.BranchFiles("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !files["hello.lua"] || !files["util.lua"] {
+		t.Errorf("staging files = %v", files)
+	}
+	if _, err := prod.ReadFile("util.lua"); err == nil {
+		t.Error("listing staging changed the clone's worktree")
+	}
+	if _, err := prod.BranchFiles("missing"); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("listing a missing branch = %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package repo
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ashupednekar/litewebservices-portal/pkg"
+	"github.com/go-git/go-git/v6"
+	"golang.org/x/crypto/ssh"
+)
+
+// commits are signed with the portal key named by COMMIT_SIGNING_KEY_PATH, an armored
+// openpgp key or an ssh private key depending on COMMIT_SIGNING_FORMAT
+
+var (
+	signerOnce sync.Once
+	signer     git.Signer
+	signerErr  error
+)
+
+// commitSigner loads the configured signing key once, nil when signing is off
+func commitSigner() (git.Signer, error) {
+	signerOnce.Do(func() {
+		if pkg.Cfg.CommitSigningKeyPath == "" {
+			return
+		}
+		data, err := os.ReadFile(pkg.Cfg.CommitSigningKeyPath)
+		if err != nil {
+			signerErr = fmt.Errorf("reading signing key: %w", err)
+			return
+		}
+		signer, signerErr = NewSigner(pkg.Cfg.CommitSigningFormat, data, pkg.Cfg.CommitSigningKeyPassword)
+	})
+	return signer, signerErr
+}
+
+// NewSigner parses a private key for signing commits, format is "openpgp" or "ssh"
+func NewSigner(format string, key []byte, passphrase string) (git.Signer, error) {
+	switch format {
+	case "", "openpgp", "gpg":
+		return newOpenPGPSigner(key, passphrase)
+	case "ssh":
+		return newSSHSigner(key, passphrase)
+	default:
+		return nil, fmt.Errorf("invalid signing format: %s", format)
+	}
+}
+
+type openPGPSigner struct {
+	entity *openpgp.Entity
+}
+
+func newOpenPGPSigner(key []byte, passphrase string) (*openPGPSigner, error) {
+	ring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(key))
+	if err != nil {
+		return nil, fmt.Errorf("parsing openpgp key: %w", err)
+	}
+	if len(ring) == 0 || ring[0].PrivateKey == nil {
+		return nil, fmt.Errorf("openpgp key has no private key")
+	}
+	e := ring[0]
+	if e.PrivateKey.Encrypted {
+		if err := e.DecryptPrivateKeys([]byte(passphrase)); err != nil {
+			return nil, fmt.Errorf("decrypting openpgp key: %w", err)
+		}
+	}
+	return &openPGPSigner{entity: e}, nil
+}
+
+func (s *openPGPSigner) Sign(message io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&buf, s.entity, message, nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sshSigner writes the SSHSIG signatures git makes with gpg.format=ssh
+type sshSigner struct {
+	key ssh.Signer
+}
+
+const sshNamespace = "git"
+
+func newSSHSigner(key []byte, passphrase string) (*sshSigner, error) {
+	var (
+		k   ssh.Signer
+		err error
+	)
+	if passphrase != "" {
+		k, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(passphrase))
+	} else {
+		k, err = ssh.ParsePrivateKey(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing ssh key: %w", err)
+	}
+	return &sshSigner{key: k}, nil
+}
+
+// sshSignedData is what SSHSIG actually signs: the namespace and a digest of the message
+func sshSignedData(digest []byte) []byte {
+	return append([]byte("SSHSIG"), ssh.Marshal(struct {
+		Namespace string
+		Reserved  string
+		HashAlg   string
+		Hash      string
+	}{sshNamespace, "", "sha512", string(digest)})...)
+}
+
+func (s *sshSigner) Sign(message io.Reader) ([]byte, error) {
+	h := sha512.New()
+	if _, err := io.Copy(h, message); err != nil {
+		return nil, err
+	}
+	data := sshSignedData(h.Sum(nil))
+
+	var (
+		sig *ssh.Signature
+		err error
+	)
+	// rsa keys sign with sha-512, the default would be sha-1
+	if as, ok := s.key.(ssh.AlgorithmSigner); ok && s.key.PublicKey().Type() == ssh.KeyAlgoRSA {
+		sig, err = as.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = s.key.Sign(rand.Reader, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	blob := append([]byte("SSHSIG"), ssh.Marshal(struct {
+		Version   uint32
+		PublicKey string
+		Namespace string
+		Reserved  string
+		HashAlg   string
+		Signature string
+	}{1, string(s.key.PublicKey().Marshal()), sshNamespace, "", "sha512", string(ssh.Marshal(sig))})...)
+
+	enc := base64.StdEncoding.EncodeToString(blob)
+	var out bytes.Buffer
+	out.WriteString("-----BEGIN SSH SIGNATURE-----\n")
+	for len(enc) > 70 {
+		out.WriteString(enc[:70] + "\n")
+		enc = enc[70:]
+	}
+	out.WriteString(enc + "\n-----END SSH SIGNATURE-----\n")
+	return out.Bytes(), nil
+}
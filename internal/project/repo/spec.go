@@ -3,10 +3,11 @@ package repo
 import (
 	"fmt"
 
-	"github.com/ashupednekar/litewebservices-portal/pkg"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/vendors"
 	"github.com/go-git/go-billy/v6"
 	"github.com/go-git/go-billy/v6/memfs"
 	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/storage"
 	"github.com/go-git/go-git/v6/storage/memory"
 )
 
@@ -20,51 +21,37 @@ type VCS interface {
 type GitRepo struct {
 	Project  string
 	Branch   string
-	Storage  *memory.Storage
+	Storage  storage.Storer
 	Options  *git.CloneOptions
 	Fs       billy.Filesystem
 	Worktree *git.Worktree
 	Repo     *git.Repository
+	// Dir is the clone's directory when it's kept on disk, empty for clones in memory
+	Dir string
 }
 
-var repos = make(map[string]*GitRepo)
+// DefaultBranch is the branch a project's repo is created with, its default environment
+const DefaultBranch = "main"
 
-func NewGitRepo(project string, branch *string) (*GitRepo, error) {
-	fs := memfs.New()
-	var b string
+// NewGitRepo clones a project's branch from conn into memory, defaulting to main.
+// Callers that serve requests should go through a Manager so clones are shared and locked
+func NewGitRepo(conn vendors.Connection, project string, branch *string) (*GitRepo, error) {
+	b := DefaultBranch
 	if branch != nil {
 		b = *branch
-	} else {
-		b = "main"
 	}
-	repo, ok := repos[project]
-	if ok {
-		fmt.Printf("[DEBUG] Found cached repo for %s, calling Pull()...\n", project)
-		err := repo.Pull()
-		if err != nil {
-			fmt.Printf("[ERROR] Pull() failed: %v\n", err)
-			return nil, err
-		}
-		fmt.Printf("[DEBUG] Pull() completed successfully for %s\n", project)
-		return repo, nil
-	} else {
-		r := GitRepo{
-			Project: project,
-			Branch:  b,
-			Fs:      fs,
-			Storage: memory.NewStorage(),
-			Options: &git.CloneOptions{
-				URL: fmt.Sprintf("%s/%s/%s.git", pkg.Cfg.VcsBaseUrl, pkg.Cfg.VcsUser, project),
-			},
-		}
-		err := r.SetupAuth()
-		if err != nil {
-			return nil, fmt.Errorf("auth setup failed: %w", err)
-		}
-		if err := r.Clone(); err != nil {
-			return nil, fmt.Errorf("clone failed for %s: %w", project, err)
-		}
-		repos[project] = &r
-		return &r, nil
+	r := GitRepo{
+		Project: project,
+		Branch:  b,
+		Fs:      memfs.New(),
+		Storage: memory.NewStorage(),
+		Options: &git.CloneOptions{},
 	}
+	if err := r.SetupAuth(conn); err != nil {
+		return nil, fmt.Errorf("auth setup failed: %w", err)
+	}
+	if err := r.Clone(); err != nil {
+		return nil, fmt.Errorf("clone failed for %s@%s: %w", project, b, err)
+	}
+	return &r, nil
 }
@@ -0,0 +1,51 @@
+package project
+
+// Role is a member's permission level on a project, stored in user_projects.role
+type Role string
+
+const (
+	Owner      Role = "owner"
+	Maintainer Role = "maintainer"
+	Developer  Role = "developer"
+	Viewer     Role = "viewer"
+)
+
+var roleRank = map[Role]int{
+	Viewer:     1,
+	Developer:  2,
+	Maintainer: 3,
+	Owner:      4,
+}
+
+// Roles lists every assignable role from most to least privileged
+var Roles = []Role{Owner, Maintainer, Developer, Viewer}
+
+// ParseRole validates a role name coming from the api
+func ParseRole(s string) (Role, bool) {
+	r := Role(s)
+	_, ok := roleRank[r]
+	return r, ok
+}
+
+// RoleOf maps a stored role to a Role, unknown or missing values get the least privilege
+func RoleOf(s string) Role {
+	if r, ok := ParseRole(s); ok {
+		return r
+	}
+	return Viewer
+}
+
+// AtLeast reports whether r grants everything min does
+func (r Role) AtLeast(min Role) bool {
+	return roleRank[r] >= roleRank[min]
+}
+
+// CanWrite is true for roles allowed to change functions, endpoints and config
+func (r Role) CanWrite() bool {
+	return r.AtLeast(Developer)
+}
+
+// CanManageMembers is reserved for owners, as is deleting the project
+func (r Role) CanManageMembers() bool {
+	return r == Owner
+}
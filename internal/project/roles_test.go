@@ -0,0 +1,57 @@
+package project
+
+import "testing"
+
+func TestRoleAtLeast(t *testing.T) {
+	tests := []struct {
+		role Role
+		min  Role
+		want bool
+	}{
+		{Owner, Owner, true},
+		{Owner, Viewer, true},
+		{Maintainer, Owner, false},
+		{Maintainer, Developer, true},
+		{Developer, Maintainer, false},
+		{Developer, Developer, true},
+		{Viewer, Developer, false},
+		{Viewer, Viewer, true},
+		{Role("admin"), Viewer, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.AtLeast(tt.min); got != tt.want {
+			t.Errorf("%s.AtLeast(%s) = %v, want %v", tt.role, tt.min, got, tt.want)
+		}
+	}
+}
+
+func TestRoleOf(t *testing.T) {
+	for stored, want := range map[string]Role{
+		"owner":      Owner,
+		"maintainer": Maintainer,
+		"developer":  Developer,
+		"viewer":     Viewer,
+		"":           Viewer,
+		"member":     Viewer,
+	} {
+		if got := RoleOf(stored); got != want {
+			t.Errorf("RoleOf(%q) = %s, want %s", stored, got, want)
+		}
+	}
+}
+
+func TestRolePermissions(t *testing.T) {
+	if Viewer.CanWrite() {
+		t.Error("viewer should not be able to write")
+	}
+	if !Developer.CanWrite() {
+		t.Error("developer should be able to write")
+	}
+	if Maintainer.CanManageMembers() {
+		t.Error("only owners should manage members")
+	}
+	if !Owner.CanManageMembers() {
+		t.Error("owner should manage members")
+	}
+}
@@ -0,0 +1,275 @@
+package vendors
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ashupednekar/litewebservices-portal/pkg"
+)
+
+// Connection is how the portal reaches a project's repo: the forge it's on, the owner
+// and name of the repo there, and the credentials used for git and the forge's api.
+// Projects without a connection of their own use the deployment's VCS_* settings.
+type Connection struct {
+	Vendor  string // github or gitea
+	BaseURL string
+	Owner   string // user or org the repo lives under
+	Repo    string
+	// AuthMode is how git authenticates, token or ssh. The api always uses Token
+	AuthMode string
+	Token    string
+	// SSHKey is a PEM private key, SSHKeyPath names one on disk instead for the
+	// deployment's connection
+	SSHKey         []byte
+	SSHKeyPath     string
+	SSHKeyPassword string
+}
+
+// ErrNoCredentialsKey is a project connection saved or read without VCS_CREDENTIALS_KEY set
+var ErrNoCredentialsKey = errors.New("VCS_CREDENTIALS_KEY is not set")
+
+// DefaultConnection is the deployment wide connection, the repo named after the project
+// under VCS_USER
+func DefaultConnection(project string) Connection {
+	return Connection{
+		Vendor:         pkg.Cfg.VcsVendor,
+		BaseURL:        pkg.Cfg.VcsBaseUrl,
+		Owner:          pkg.Cfg.VcsUser,
+		Repo:           project,
+		AuthMode:       pkg.Cfg.VcsAuthMode,
+		Token:          pkg.Cfg.VcsToken,
+		SSHKeyPath:     pkg.Cfg.VcsPrivKeyPath,
+		SSHKeyPassword: pkg.Cfg.VcsPrivKeyPassword,
+	}
+}
+
+// Validate checks a connection is complete enough to clone and call the api with
+func (c Connection) Validate() error {
+	switch c.Vendor {
+	case "github", "gitea":
+	default:
+		return fmt.Errorf("unsupported vendor: %q (supported: github, gitea)", c.Vendor)
+	}
+	u, err := url.Parse(c.BaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("base url must be an http(s) url, got %q", c.BaseURL)
+	}
+	if c.Owner == "" || c.Repo == "" {
+		return errors.New("owner and repo are required")
+	}
+	if c.Token == "" {
+		return errors.New("a token is required for the vcs api")
+	}
+	switch c.AuthMode {
+	case "token":
+	case "ssh":
+		if len(c.SSHKey) == 0 && c.SSHKeyPath == "" {
+			return errors.New("ssh auth needs a private key")
+		}
+	default:
+		return fmt.Errorf("invalid auth mode: %q (supported: token, ssh)", c.AuthMode)
+	}
+	return nil
+}
+
+// CloneURL is the repo's git url for the connection's auth mode
+func (c Connection) CloneURL() string {
+	base := strings.TrimSuffix(c.BaseURL, "/")
+	if c.AuthMode == "ssh" {
+		host := strings.TrimPrefix(strings.TrimPrefix(base, "https://"), "http://")
+		return fmt.Sprintf("git@%s:%s/%s.git", host, c.Owner, c.Repo)
+	}
+	return fmt.Sprintf("%s/%s/%s.git", base, c.Owner, c.Repo)
+}
+
+// ParseRepoURL reads the forge, owner and repo out of a repo's git url, the https form
+// or the scp like git@host:owner/repo.git one. The vendor is filled in when the forge is
+// github.com or the deployment's own, ssh urls select ssh auth
+func ParseRepoURL(raw string) (Connection, error) {
+	raw = strings.TrimSpace(raw)
+	conn := Connection{AuthMode: "token"}
+	var host, repoPath string
+	switch {
+	case strings.HasPrefix(raw, "http://") || strings.HasPrefix(raw, "https://"):
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return conn, fmt.Errorf("invalid repo url %q", raw)
+		}
+		// forges served under a path keep it in the base url
+		prefix, rest := splitOwnerRepo(strings.Trim(u.Path, "/"))
+		conn.BaseURL = u.Scheme + "://" + u.Host
+		if prefix != "" {
+			conn.BaseURL += "/" + prefix
+		}
+		repoPath = rest
+	case strings.HasPrefix(raw, "ssh://"):
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return conn, fmt.Errorf("invalid repo url %q", raw)
+		}
+		host, repoPath = u.Hostname(), strings.Trim(u.Path, "/")
+		conn.AuthMode = "ssh"
+	default:
+		userHost, p, ok := strings.Cut(raw, ":")
+		if !ok {
+			return conn, fmt.Errorf("invalid repo url %q", raw)
+		}
+		_, host, _ = strings.Cut(userHost, "@")
+		if host == "" {
+			host = userHost
+		}
+		repoPath = strings.Trim(p, "/")
+		conn.AuthMode = "ssh"
+	}
+	if host != "" {
+		conn.BaseURL = "https://" + host
+	}
+
+	owner, name, ok := strings.Cut(strings.TrimSuffix(repoPath, ".git"), "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return conn, fmt.Errorf("repo url %q doesn't name an owner/repo", raw)
+	}
+	conn.Owner, conn.Repo = owner, name
+	switch {
+	case conn.BaseURL == "https://github.com":
+		conn.Vendor = "github"
+	case Connection{Vendor: pkg.Cfg.VcsVendor, BaseURL: conn.BaseURL}.OnDeploymentForge():
+		conn.Vendor = pkg.Cfg.VcsVendor
+	}
+	return conn, nil
+}
+
+// splitOwnerRepo splits the last two segments of an url path off what's before them
+func splitOwnerRepo(p string) (prefix, ownerRepo string) {
+	parts := strings.Split(p, "/")
+	if len(parts) <= 2 {
+		return "", p
+	}
+	return strings.Join(parts[:len(parts)-2], "/"), strings.Join(parts[len(parts)-2:], "/")
+}
+
+// OnDeploymentForge is whether the connection points at the forge of the deployment's
+// VCS_* settings, only connections there can borrow its credentials
+func (c Connection) OnDeploymentForge() bool {
+	if c.Vendor != pkg.Cfg.VcsVendor {
+		return false
+	}
+	base := strings.TrimSuffix(pkg.Cfg.VcsBaseUrl, "/")
+	if base == "" && c.Vendor == "github" {
+		base = "https://github.com"
+	}
+	return base != "" && strings.TrimSuffix(c.BaseURL, "/") == base
+}
+
+// CanBorrow is whether the connection may use the deployment's credentials for project,
+// they only reach the project's default repo on the deployment's own account
+func (c Connection) CanBorrow(project string) bool {
+	def := DefaultConnection(project)
+	return c.OnDeploymentForge() && strings.EqualFold(c.Owner, def.Owner) && strings.EqualFold(c.Repo, def.Repo)
+}
+
+// NewVendorClient returns the api client for the forge conn points at
+func NewVendorClient(conn Connection) (VendorClient, error) {
+	switch conn.Vendor {
+	case "github":
+		if conn.Token == "" {
+			return nil, fmt.Errorf("token is required for GitHub")
+		}
+		client := NewGitHubClient(conn.Token)
+		// github enterprise serves its api under the instance
+		if base := strings.TrimSuffix(conn.BaseURL, "/"); base != "" && base != "https://github.com" {
+			client.baseURL = base + "/api/v3"
+		}
+		return client, nil
+	case "gitea":
+		if conn.Token == "" {
+			return nil, fmt.Errorf("token is required for Gitea")
+		}
+		if conn.BaseURL == "" {
+			return nil, fmt.Errorf("baseURL is required for Gitea")
+		}
+		return NewGiteaClient(conn.BaseURL, conn.Token), nil
+	default:
+		return nil, fmt.Errorf("unsupported vendor type: %s (supported: github, gitea)", conn.Vendor)
+	}
+}
+
+// credentialsKey is the AES-256 key project credentials are sealed with, base64 encoded
+// in VCS_CREDENTIALS_KEY
+func credentialsKey() ([]byte, error) {
+	if pkg.Cfg.VcsCredentialsKey == "" {
+		return nil, ErrNoCredentialsKey
+	}
+	key, err := base64.StdEncoding.DecodeString(pkg.Cfg.VcsCredentialsKey)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("VCS_CREDENTIALS_KEY must be 32 bytes, base64 encoded")
+	}
+	return key, nil
+}
+
+// SealCredential encrypts a credential for storage, empty stays empty
+func SealCredential(plaintext []byte) ([]byte, error) {
+	if len(plaintext) == 0 {
+		return nil, nil
+	}
+	key, err := credentialsKey()
+	if err != nil {
+		return nil, err
+	}
+	return seal(key, plaintext)
+}
+
+// OpenCredential decrypts a credential sealed by SealCredential
+func OpenCredential(sealed []byte) ([]byte, error) {
+	if len(sealed) == 0 {
+		return nil, nil
+	}
+	key, err := credentialsKey()
+	if err != nil {
+		return nil, err
+	}
+	return open(key, sealed)
+}
+
+// seal is AES-GCM with the random nonce prepended to the ciphertext
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("sealed credential is truncated")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("credential doesn't decrypt, was VCS_CREDENTIALS_KEY changed?")
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
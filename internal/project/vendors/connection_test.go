@@ -0,0 +1,154 @@
+package vendors
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/ashupednekar/litewebservices-portal/pkg"
+)
+
+func TestSealCredential(t *testing.T) {
+	orig := pkg.Cfg.VcsCredentialsKey
+	defer func() { pkg.Cfg.VcsCredentialsKey = orig }()
+
+	pkg.Cfg.VcsCredentialsKey = ""
+	if _, err := SealCredential([]byte("ghp_secret")); !errors.Is(err, ErrNoCredentialsKey) {
+		t.Fatalf("sealing without a key = %v", err)
+	}
+
+	pkg.Cfg.VcsCredentialsKey = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	sealed, err := SealCredential([]byte("ghp_secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, []byte("ghp_secret")) {
+		t.Error("sealed credential holds the plaintext")
+	}
+	again, _ := SealCredential([]byte("ghp_secret"))
+	if bytes.Equal(sealed, again) {
+		t.Error("sealing twice gave the same ciphertext")
+	}
+	if plain, err := OpenCredential(sealed); err != nil || string(plain) != "ghp_secret" {
+		t.Errorf("OpenCredential() = %q, %v", plain, err)
+	}
+	if plain, err := SealCredential(nil); err != nil || plain != nil {
+		t.Errorf("sealing nothing = %q, %v", plain, err)
+	}
+
+	pkg.Cfg.VcsCredentialsKey = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32))
+	if _, err := OpenCredential(sealed); err == nil {
+		t.Error("opened a credential with another key")
+	}
+	pkg.Cfg.VcsCredentialsKey = "c2hvcnQ="
+	if _, err := SealCredential([]byte("x")); err == nil {
+		t.Error("sealed with a short key")
+	}
+}
+
+func TestConnection(t *testing.T) {
+	conn := Connection{
+		Vendor:   "gitea",
+		BaseURL:  "https://git.example.com/",
+		Owner:    "acme",
+		Repo:     "shop",
+		AuthMode: "token",
+		Token:    "t",
+	}
+	if err := conn.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := conn.CloneURL(); got != "https://git.example.com/acme/shop.git" {
+		t.Errorf("token CloneURL() = %s", got)
+	}
+
+	conn.AuthMode = "ssh"
+	if err := conn.Validate(); err == nil {
+		t.Error("ssh connection without a key validated")
+	}
+	conn.SSHKey = []byte("key")
+	if got := conn.CloneURL(); got != "git@git.example.com:acme/shop.git" {
+		t.Errorf("ssh CloneURL() = %s", got)
+	}
+
+	for name, broken := range map[string]Connection{
+		"vendor":   {Vendor: "bitbucket", BaseURL: "https://x.y", Owner: "o", Repo: "r", AuthMode: "token", Token: "t"},
+		"base url": {Vendor: "github", BaseURL: "github.com", Owner: "o", Repo: "r", AuthMode: "token", Token: "t"},
+		"owner":    {Vendor: "github", BaseURL: "https://github.com", Repo: "r", AuthMode: "token", Token: "t"},
+		"token":    {Vendor: "github", BaseURL: "https://github.com", Owner: "o", Repo: "r", AuthMode: "token"},
+		"auth":     {Vendor: "github", BaseURL: "https://github.com", Owner: "o", Repo: "r", AuthMode: "basic", Token: "t"},
+	} {
+		if err := broken.Validate(); err == nil {
+			t.Errorf("connection with a bad %s validated", name)
+		}
+	}
+}
+
+func TestNewVendorClientEnterprise(t *testing.T) {
+	for base, want := range map[string]string{
+		"https://github.com":          GitHubAPIBaseURL,
+		"":                            GitHubAPIBaseURL,
+		"https://ghe.example.com/":    "https://ghe.example.com/api/v3",
+		"https://ghe.example.com/sub": "https://ghe.example.com/sub/api/v3",
+	} {
+		client, err := NewVendorClient(Connection{Vendor: "github", BaseURL: base, Token: "t"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := client.(*GitHubClient).baseURL; got != want {
+			t.Errorf("api for %q = %s, want %s", base, got, want)
+		}
+	}
+}
+
+func TestParseRepoURL(t *testing.T) {
+	orig := pkg.Cfg
+	defer func() { pkg.Cfg = orig }()
+	pkg.Cfg.VcsVendor = "gitea"
+	pkg.Cfg.VcsBaseUrl = "https://git.example.com/"
+
+	for raw, want := range map[string]Connection{
+		"https://github.com/acme/shop.git":          {Vendor: "github", BaseURL: "https://github.com", Owner: "acme", Repo: "shop", AuthMode: "token"},
+		"https://git.example.com/acme/shop":         {Vendor: "gitea", BaseURL: "https://git.example.com", Owner: "acme", Repo: "shop", AuthMode: "token"},
+		"https://forge.example.com/gitea/acme/shop": {BaseURL: "https://forge.example.com/gitea", Owner: "acme", Repo: "shop", AuthMode: "token"},
+		"git@github.com:acme/shop.git":              {Vendor: "github", BaseURL: "https://github.com", Owner: "acme", Repo: "shop", AuthMode: "ssh"},
+		"ssh://git@git.example.com:2222/acme/shop":  {Vendor: "gitea", BaseURL: "https://git.example.com", Owner: "acme", Repo: "shop", AuthMode: "ssh"},
+	} {
+		got, err := ParseRepoURL(raw)
+		if err != nil {
+			t.Errorf("ParseRepoURL(%q): %v", raw, err)
+			continue
+		}
+		if got.Vendor != want.Vendor || got.BaseURL != want.BaseURL || got.Owner != want.Owner || got.Repo != want.Repo || got.AuthMode != want.AuthMode {
+			t.Errorf("ParseRepoURL(%q) = %+v, want %+v", raw, got, want)
+		}
+	}
+	for _, bad := range []string{"", "https://github.com/acme", "github.com/acme/shop", "git@github.com:shop.git", "https:///acme/shop"} {
+		if _, err := ParseRepoURL(bad); err == nil {
+			t.Errorf("ParseRepoURL(%q) accepted", bad)
+		}
+	}
+}
+
+func TestCanBorrow(t *testing.T) {
+	orig := pkg.Cfg
+	defer func() { pkg.Cfg = orig }()
+	pkg.Cfg.VcsVendor = "gitea"
+	pkg.Cfg.VcsBaseUrl = "https://git.example.com"
+	pkg.Cfg.VcsUser = "lws"
+
+	own := Connection{Vendor: "gitea", BaseURL: "https://git.example.com/", Owner: "lws", Repo: "shop"}
+	if !own.CanBorrow("shop") {
+		t.Error("the project's default repo can't borrow the deployment's credentials")
+	}
+	for name, conn := range map[string]Connection{
+		"other repo":  {Vendor: "gitea", BaseURL: "https://git.example.com", Owner: "lws", Repo: "billing"},
+		"other owner": {Vendor: "gitea", BaseURL: "https://git.example.com", Owner: "acme", Repo: "shop"},
+		"other forge": {Vendor: "github", BaseURL: "https://github.com", Owner: "lws", Repo: "shop"},
+	} {
+		if conn.CanBorrow("shop") {
+			t.Errorf("a connection to an %s borrows the deployment's credentials", name)
+		}
+	}
+}
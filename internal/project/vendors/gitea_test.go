@@ -3,6 +3,7 @@ package vendors
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -360,3 +361,176 @@ func TestGiteaClient_GetActionsProgress(t *testing.T) {
 		})
 	}
 }
+
+func TestGiteaClient_CreatePullRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           PullRequestOptions
+		mockResponse   any
+		mockStatusCode int
+		wantErr        bool
+		wantNumber     int64
+	}{
+		{
+			name: "successful pull request",
+			opts: PullRequestOptions{
+				Title: "update hello",
+				Body:  "changed from the portal",
+				Head:  "changes/alice",
+				Base:  "main",
+			},
+			mockResponse: map[string]any{
+				"number":    7,
+				"title":     "update hello",
+				"state":     "open",
+				"merged":    false,
+				"mergeable": true,
+				"html_url":  "https://gitea.example.com/testuser/test-repo/pull/7",
+				"head":      map[string]any{"ref": "changes/alice"},
+				"base":      map[string]any{"ref": "main"},
+			},
+			mockStatusCode: http.StatusCreated,
+			wantNumber:     7,
+		},
+		{
+			name: "pull request failure - no commits",
+			opts: PullRequestOptions{
+				Title: "nothing",
+				Head:  "main",
+				Base:  "main",
+			},
+			mockResponse: map[string]any{
+				"message": "Validation Failed",
+			},
+			mockStatusCode: http.StatusUnprocessableEntity,
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "POST" {
+					t.Errorf("Expected POST request, got %s", r.Method)
+				}
+				if r.URL.Path != "/api/v1/repos/testuser/test-repo/pulls" {
+					t.Errorf("Expected path /api/v1/repos/testuser/test-repo/pulls, got %s", r.URL.Path)
+				}
+
+				var body map[string]string
+				json.NewDecoder(r.Body).Decode(&body)
+				if body["head"] != tt.opts.Head || body["base"] != tt.opts.Base {
+					t.Errorf("Expected head %s base %s, got %v", tt.opts.Head, tt.opts.Base, body)
+				}
+
+				w.WriteHeader(tt.mockStatusCode)
+				json.NewEncoder(w).Encode(tt.mockResponse)
+			}))
+			defer server.Close()
+
+			client := NewGiteaClient(server.URL, "test-token")
+
+			pr, err := client.CreatePullRequest(context.Background(), "testuser", "test-repo", tt.opts)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreatePullRequest() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				if pr.Number != tt.wantNumber || pr.Head != tt.opts.Head || pr.Base != tt.opts.Base {
+					t.Errorf("CreatePullRequest() = %+v", pr)
+				}
+			}
+		})
+	}
+}
+
+func TestGiteaClient_GetPullRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/repos/testuser/test-repo/pulls/7":
+			json.NewEncoder(w).Encode(map[string]any{
+				"number":    7,
+				"state":     "open",
+				"mergeable": true,
+				"head":      map[string]any{"ref": "changes/alice", "sha": "c2"},
+				"base":      map[string]any{"ref": "main"},
+			})
+		case "/api/v1/repos/testuser/test-repo/pulls/7/reviews":
+			// bob asked for changes then approved, carol only commented, dave asked for changes,
+			// erin approved before the last commit
+			json.NewEncoder(w).Encode([]map[string]any{
+				{"state": "REQUEST_CHANGES", "user": map[string]any{"login": "bob"}},
+				{"state": "APPROVED", "commit_id": "c2", "user": map[string]any{"login": "bob"}},
+				{"state": "APPROVED", "commit_id": "c1", "user": map[string]any{"login": "erin"}},
+				{"state": "COMMENTED", "user": map[string]any{"login": "carol"}},
+				{"state": "REQUEST_CHANGES", "user": map[string]any{"login": "dave"}},
+			})
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewGiteaClient(server.URL, "test-token")
+
+	pr, err := client.GetPullRequest(context.Background(), "testuser", "test-repo", 7)
+	if err != nil {
+		t.Fatalf("GetPullRequest() error = %v", err)
+	}
+	if pr.State != "open" || !pr.Mergeable || pr.Merged {
+		t.Errorf("GetPullRequest() = %+v", pr)
+	}
+	if pr.Approvals != 1 || pr.ChangesRequested != 1 {
+		t.Errorf("GetPullRequest() approvals = %d, changes requested = %d, want 1 and 1", pr.Approvals, pr.ChangesRequested)
+	}
+}
+
+func TestGiteaClient_MergePullRequest(t *testing.T) {
+	tests := []struct {
+		name             string
+		mockStatusCode   int
+		wantErr          bool
+		wantNotMergeable bool
+	}{
+		{name: "successful merge", mockStatusCode: http.StatusOK},
+		{name: "not mergeable", mockStatusCode: http.StatusMethodNotAllowed, wantErr: true, wantNotMergeable: true},
+		{name: "not found", mockStatusCode: http.StatusNotFound, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "POST" {
+					t.Errorf("Expected POST request, got %s", r.Method)
+				}
+				if r.URL.Path != "/api/v1/repos/testuser/test-repo/pulls/7/merge" {
+					t.Errorf("Expected path /api/v1/repos/testuser/test-repo/pulls/7/merge, got %s", r.URL.Path)
+				}
+
+				var body map[string]string
+				json.NewDecoder(r.Body).Decode(&body)
+				if body["Do"] != "merge" {
+					t.Errorf("Expected Do merge, got %v", body)
+				}
+
+				w.WriteHeader(tt.mockStatusCode)
+				w.Write([]byte("{}"))
+			}))
+			defer server.Close()
+
+			client := NewGiteaClient(server.URL, "test-token")
+
+			err := client.MergePullRequest(context.Background(), "testuser", "test-repo", 7, MergeOptions{})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MergePullRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrNotMergeable) != tt.wantNotMergeable {
+				t.Errorf("MergePullRequest() error = %v, wantNotMergeable %v", err, tt.wantNotMergeable)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -32,6 +33,9 @@ func NewGitHubClient(token string) *GitHubClient {
 
 func (c *GitHubClient) CreateRepo(ctx context.Context, opts CreateRepoOptions) (*Repository, error) {
 	url := fmt.Sprintf("%s/user/repos", c.baseURL)
+	if opts.Owner != "" {
+		url = fmt.Sprintf("%s/orgs/%s/repos", c.baseURL, opts.Owner)
+	}
 
 	payload := map[string]any{
 		"name":        opts.Name,
@@ -70,6 +74,11 @@ func (c *GitHubClient) CreateRepo(ctx context.Context, opts CreateRepoOptions) (
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
+	if resp.StatusCode == http.StatusNotFound && opts.Owner != "" {
+		// no such org, the owner is the token's own user
+		opts.Owner = ""
+		return c.CreateRepo(ctx, opts)
+	}
 	if resp.StatusCode != http.StatusCreated {
 		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(respBody))
 	}
@@ -306,3 +315,145 @@ func (c *GitHubClient) DeleteRepo(ctx context.Context, owner, repo string) error
 
 	return nil
 }
+
+type ghPullRequest struct {
+	Number    int64  `json:"number"`
+	Title     string `json:"title"`
+	State     string `json:"state"`
+	Merged    bool   `json:"merged"`
+	Mergeable *bool  `json:"mergeable"` // null while github is still computing it
+	HTMLURL   string `json:"html_url"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	Head      struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+func (pr ghPullRequest) toPullRequest() *PullRequest {
+	return &PullRequest{
+		Number:    pr.Number,
+		Title:     pr.Title,
+		State:     pr.State,
+		Merged:    pr.Merged,
+		Mergeable: pr.Mergeable != nil && *pr.Mergeable,
+		Head:      pr.Head.Ref,
+		Base:      pr.Base.Ref,
+		HeadSHA:   pr.Head.SHA,
+		HTMLURL:   pr.HTMLURL,
+		CreatedAt: pr.CreatedAt,
+		UpdatedAt: pr.UpdatedAt,
+	}
+}
+
+// do sends a github api request, decoding the response into out when it has the wanted status
+func (c *GitHubClient) do(ctx context.Context, method, url string, payload any, want int, out any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewBuffer(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != want {
+		return &statusError{Code: resp.StatusCode, Body: string(respBody)}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+func (c *GitHubClient) CreatePullRequest(ctx context.Context, owner, repo string, opts PullRequestOptions) (*PullRequest, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls", c.baseURL, owner, repo)
+
+	payload := map[string]any{
+		"title": opts.Title,
+		"body":  opts.Body,
+		"head":  opts.Head,
+		"base":  opts.Base,
+	}
+
+	var pr ghPullRequest
+	if err := c.do(ctx, http.MethodPost, url, payload, http.StatusCreated, &pr); err != nil {
+		return nil, err
+	}
+	return pr.toPullRequest(), nil
+}
+
+// GetPullRequest returns the pull request with its reviews tallied
+func (c *GitHubClient) GetPullRequest(ctx context.Context, owner, repo string, number int64) (*PullRequest, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", c.baseURL, owner, repo, number)
+
+	var pr ghPullRequest
+	if err := c.do(ctx, http.MethodGet, url, nil, http.StatusOK, &pr); err != nil {
+		return nil, err
+	}
+
+	var reviews []review
+	if err := c.do(ctx, http.MethodGet, url+"/reviews?per_page=100", nil, http.StatusOK, &reviews); err != nil {
+		return nil, err
+	}
+
+	out := pr.toPullRequest()
+	out.Approvals, out.ChangesRequested = countReviews(reviews, out.HeadSHA, "APPROVED", "CHANGES_REQUESTED")
+	return out, nil
+}
+
+func (c *GitHubClient) MergePullRequest(ctx context.Context, owner, repo string, number int64, opts MergeOptions) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/merge", c.baseURL, owner, repo, number)
+
+	method := opts.Method
+	if method == "" {
+		method = "merge"
+	}
+	payload := map[string]any{
+		"merge_method": method,
+	}
+	if opts.Title != "" {
+		payload["commit_title"] = opts.Title
+	}
+	if opts.SHA != "" {
+		payload["sha"] = opts.SHA
+	}
+
+	err := c.do(ctx, http.MethodPut, url, payload, http.StatusOK, nil)
+	// 405 is a pull request that can't be merged, 409 a head that moved meanwhile
+	var se *statusError
+	if errors.As(err, &se) && (se.Code == http.StatusMethodNotAllowed || se.Code == http.StatusConflict) {
+		return fmt.Errorf("%w: %v", ErrNotMergeable, err)
+	}
+	return err
+}
@@ -3,6 +3,7 @@ package vendors
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -368,3 +369,225 @@ func TestGitHubClient_GetActionsProgress(t *testing.T) {
 		})
 	}
 }
+
+func TestGitHubClient_CreatePullRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           PullRequestOptions
+		mockResponse   interface{}
+		mockStatusCode int
+		wantErr        bool
+		wantNumber     int64
+	}{
+		{
+			name: "successful pull request",
+			opts: PullRequestOptions{
+				Title: "update hello",
+				Body:  "changed from the portal",
+				Head:  "changes/alice",
+				Base:  "main",
+			},
+			mockResponse: map[string]interface{}{
+				"number":    7,
+				"title":     "update hello",
+				"state":     "open",
+				"merged":    false,
+				"mergeable": true,
+				"html_url":  "https://github.com/testuser/test-repo/pull/7",
+				"head":      map[string]interface{}{"ref": "changes/alice"},
+				"base":      map[string]interface{}{"ref": "main"},
+			},
+			mockStatusCode: http.StatusCreated,
+			wantNumber:     7,
+		},
+		{
+			name: "pull request failure - no commits",
+			opts: PullRequestOptions{
+				Title: "nothing",
+				Head:  "main",
+				Base:  "main",
+			},
+			mockResponse: map[string]interface{}{
+				"message": "Validation Failed",
+			},
+			mockStatusCode: http.StatusUnprocessableEntity,
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "POST" {
+					t.Errorf("Expected POST request, got %s", r.Method)
+				}
+				if r.URL.Path != "/repos/testuser/test-repo/pulls" {
+					t.Errorf("Expected path /repos/testuser/test-repo/pulls, got %s", r.URL.Path)
+				}
+
+				var body map[string]string
+				json.NewDecoder(r.Body).Decode(&body)
+				if body["head"] != tt.opts.Head || body["base"] != tt.opts.Base {
+					t.Errorf("Expected head %s base %s, got %v", tt.opts.Head, tt.opts.Base, body)
+				}
+
+				w.WriteHeader(tt.mockStatusCode)
+				json.NewEncoder(w).Encode(tt.mockResponse)
+			}))
+			defer server.Close()
+
+			client := NewGitHubClient("test-token")
+			client.baseURL = server.URL
+
+			pr, err := client.CreatePullRequest(context.Background(), "testuser", "test-repo", tt.opts)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreatePullRequest() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				if pr.Number != tt.wantNumber || pr.Head != tt.opts.Head || pr.Base != tt.opts.Base {
+					t.Errorf("CreatePullRequest() = %+v", pr)
+				}
+			}
+		})
+	}
+}
+
+func TestGitHubClient_GetPullRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/testuser/test-repo/pulls/7":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"number":    7,
+				"state":     "open",
+				"mergeable": true,
+				"head":      map[string]interface{}{"ref": "changes/alice", "sha": "c2"},
+				"base":      map[string]interface{}{"ref": "main"},
+			})
+		case "/repos/testuser/test-repo/pulls/7/reviews":
+			// bob asked for changes then approved, carol only commented, dave asked for changes,
+			// erin approved before the last commit
+			json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"state": "CHANGES_REQUESTED", "user": map[string]interface{}{"login": "bob"}},
+				{"state": "APPROVED", "commit_id": "c2", "user": map[string]interface{}{"login": "bob"}},
+				{"state": "APPROVED", "commit_id": "c1", "user": map[string]interface{}{"login": "erin"}},
+				{"state": "COMMENTED", "user": map[string]interface{}{"login": "carol"}},
+				{"state": "CHANGES_REQUESTED", "user": map[string]interface{}{"login": "dave"}},
+			})
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("test-token")
+	client.baseURL = server.URL
+
+	pr, err := client.GetPullRequest(context.Background(), "testuser", "test-repo", 7)
+	if err != nil {
+		t.Fatalf("GetPullRequest() error = %v", err)
+	}
+	if pr.State != "open" || !pr.Mergeable || pr.Merged {
+		t.Errorf("GetPullRequest() = %+v", pr)
+	}
+	if pr.Approvals != 1 || pr.ChangesRequested != 1 {
+		t.Errorf("GetPullRequest() approvals = %d, changes requested = %d, want 1 and 1", pr.Approvals, pr.ChangesRequested)
+	}
+}
+
+func TestGitHubClient_MergePullRequest(t *testing.T) {
+	tests := []struct {
+		name             string
+		mockStatusCode   int
+		wantErr          bool
+		wantNotMergeable bool
+	}{
+		{name: "successful merge", mockStatusCode: http.StatusOK},
+		{name: "not mergeable", mockStatusCode: http.StatusMethodNotAllowed, wantErr: true, wantNotMergeable: true},
+		{name: "not found", mockStatusCode: http.StatusNotFound, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "PUT" {
+					t.Errorf("Expected PUT request, got %s", r.Method)
+				}
+				if r.URL.Path != "/repos/testuser/test-repo/pulls/7/merge" {
+					t.Errorf("Expected path /repos/testuser/test-repo/pulls/7/merge, got %s", r.URL.Path)
+				}
+
+				var body map[string]string
+				json.NewDecoder(r.Body).Decode(&body)
+				if body["merge_method"] != "merge" {
+					t.Errorf("Expected merge_method merge, got %v", body)
+				}
+
+				w.WriteHeader(tt.mockStatusCode)
+				w.Write([]byte("{}"))
+			}))
+			defer server.Close()
+
+			client := NewGitHubClient("test-token")
+			client.baseURL = server.URL
+
+			err := client.MergePullRequest(context.Background(), "testuser", "test-repo", 7, MergeOptions{})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MergePullRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrNotMergeable) != tt.wantNotMergeable {
+				t.Errorf("MergePullRequest() error = %v, wantNotMergeable %v", err, tt.wantNotMergeable)
+			}
+		})
+	}
+}
+
+func TestGitHubClient_CreateRepoUnderOwner(t *testing.T) {
+	tests := []struct {
+		name      string
+		owner     string
+		orgExists bool
+		wantPaths []string
+	}{
+		{name: "org", owner: "acme", orgExists: true, wantPaths: []string{"/orgs/acme/repos"}},
+		{name: "user owner falls back", owner: "testuser", wantPaths: []string{"/orgs/testuser/repos", "/user/repos"}},
+		{name: "no owner", wantPaths: []string{"/user/repos"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+				if r.URL.Path != "/user/repos" && !tt.orgExists {
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"message": "Not Found"}`))
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"name": "test-repo"}`))
+			}))
+			defer server.Close()
+
+			client := NewGitHubClient("test-token")
+			client.baseURL = server.URL
+
+			repo, err := client.CreateRepo(context.Background(), CreateRepoOptions{Owner: tt.owner, Name: "test-repo"})
+			if err != nil || repo.Name != "test-repo" {
+				t.Fatalf("CreateRepo() = %v, %v", repo, err)
+			}
+			if len(paths) != len(tt.wantPaths) {
+				t.Fatalf("CreateRepo() requested %v, want %v", paths, tt.wantPaths)
+			}
+			for i := range paths {
+				if paths[i] != tt.wantPaths[i] {
+					t.Errorf("CreateRepo() requested %v, want %v", paths, tt.wantPaths)
+				}
+			}
+		})
+	}
+}
@@ -2,20 +2,40 @@ package vendors
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/ashupednekar/litewebservices-portal/pkg"
 )
 
 
+// ErrNotMergeable is a merge the vendor refused, conflicts or branch protection
+var ErrNotMergeable = errors.New("pull request is not mergeable")
+
+
+// statusError is an api answer with a status other than the one a call expects
+type statusError struct {
+	Code int
+	Body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d: %s", e.Code, e.Body)
+}
+
+
 type VendorClient interface {
 	CreateRepo(ctx context.Context, opts CreateRepoOptions) (*Repository, error)
 	DeleteRepo(ctx context.Context, owner, repo string) error
 	AddWebhook(ctx context.Context, owner, repo string, opts WebhookOptions) (*Webhook, error)
 	GetActionsProgress(ctx context.Context, owner, repo string, opts ActionsProgressOptions) (*ActionsProgress, error)
+	CreatePullRequest(ctx context.Context, owner, repo string, opts PullRequestOptions) (*PullRequest, error)
+	GetPullRequest(ctx context.Context, owner, repo string, number int64) (*PullRequest, error)
+	MergePullRequest(ctx context.Context, owner, repo string, number int64, opts MergeOptions) error
 }
 
 
 type CreateRepoOptions struct {
+	// Owner is the org to create the repo under, the token's user when empty
+	Owner         string
 	Name          string
 	Description   string
 	Private       bool
@@ -89,24 +109,72 @@ type WorkflowRun struct {
 }
 
 
+type PullRequestOptions struct {
+	Title string
+	Body  string
+	Head  string // branch holding the changes
+	Base  string // branch they merge into
+}
 
-func NewVendorClient() (VendorClient, error) {
-	switch pkg.Cfg.VcsVendor {
-	case "github":
-		if pkg.Cfg.VcsToken == "" {
-			return nil, fmt.Errorf("token is required for GitHub")
-		}
-		return NewGitHubClient(pkg.Cfg.VcsToken), nil
-	case "gitea":
-		if pkg.Cfg.VcsToken == "" {
-			return nil, fmt.Errorf("token is required for Gitea")
+
+// PullRequest states are open and closed, a merged pull request is closed with Merged set
+type PullRequest struct {
+	Number    int64
+	Title     string
+	State     string
+	Merged    bool
+	Mergeable bool
+	Head      string
+	Base      string
+	// HeadSHA is the commit the head branch is on
+	HeadSHA string
+	HTMLURL string
+	// Approvals counts reviewers whose latest review approves the pull request as it is
+	// now, an approval of an earlier head doesn't count
+	Approvals int
+	// ChangesRequested counts reviewers whose latest review asks for changes
+	ChangesRequested int
+	CreatedAt        string
+	UpdatedAt        string
+}
+
+
+type MergeOptions struct {
+	Title  string
+	Method string // merge, squash or rebase, merge when empty
+	// SHA is the head the merge was decided on, the vcs refuses it once the head moved
+	SHA string
+}
+
+
+
+type review struct {
+	State    string `json:"state"`
+	CommitID string `json:"commit_id"`
+	User     struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+
+// countReviews tallies each reviewer's latest approving or change requesting review,
+// comments don't change a reviewer's verdict. Approvals only count on head, commits pushed
+// after one weren't reviewed. Reviews come oldest first
+func countReviews(reviews []review, head, approved, changesRequested string) (int, int) {
+	latest := map[string]review{}
+	for _, r := range reviews {
+		if r.State == approved || r.State == changesRequested {
+			latest[r.User.Login] = r
 		}
-		if pkg.Cfg.VcsBaseUrl == "" {
-			return nil, fmt.Errorf("baseURL is required for Gitea")
+	}
+	var approvals, changes int
+	for _, r := range latest {
+		switch {
+		case r.State == changesRequested:
+			changes++
+		case r.CommitID == head:
+			approvals++
 		}
-		return NewGiteaClient(pkg.Cfg.VcsBaseUrl, pkg.Cfg.VcsToken), nil
-
-	default:
-		return nil, fmt.Errorf("unsupported vendor type: %s (supported: github, gitea)", pkg.Cfg.VcsVendor)
 	}
+	return approvals, changes
 }
@@ -0,0 +1,83 @@
+package vendors
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	githubSignatureHeader = "X-Hub-Signature-256"
+	giteaSignatureHeader  = "X-Gitea-Signature"
+	githubEventHeader     = "X-GitHub-Event"
+	giteaEventHeader      = "X-Gitea-Event"
+)
+
+// PushEvent is the subset of the github/gitea push payload the portal cares about
+type PushEvent struct {
+	Ref        string `json:"ref"`
+	Before     string `json:"before"`
+	After      string `json:"after"`
+	Repository struct {
+		Name     string `json:"name"`
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// GenerateWebhookSecret creates a random secret to register with the vcs webhook
+func GenerateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// WebhookEvent returns the event name sent by either vendor, gitea also sends the github header
+func WebhookEvent(headers http.Header) string {
+	if e := headers.Get(giteaEventHeader); e != "" {
+		return e
+	}
+	return headers.Get(githubEventHeader)
+}
+
+// VerifyWebhookSignature checks the HMAC-SHA256 of body against the github or gitea signature header
+func VerifyWebhookSignature(headers http.Header, body []byte, secret string) error {
+	if secret == "" {
+		return fmt.Errorf("webhook secret not configured")
+	}
+
+	var sig string
+	if s := headers.Get(giteaSignatureHeader); s != "" {
+		sig = s
+	} else if s := headers.Get(githubSignatureHeader); s != "" {
+		sig = strings.TrimPrefix(s, "sha256=")
+	} else {
+		return fmt.Errorf("missing signature header")
+	}
+
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("malformed signature")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func ParsePushEvent(body []byte) (*PushEvent, error) {
+	var ev PushEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal push event: %w", err)
+	}
+	return &ev, nil
+}
@@ -0,0 +1,87 @@
+package vendors
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/main"}`)
+	secret := "s3cret"
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		secret  string
+		wantErr bool
+	}{
+		{
+			name:    "valid github signature",
+			headers: map[string]string{"X-Hub-Signature-256": "sha256=" + sign(secret, body)},
+			secret:  secret,
+		},
+		{
+			name:    "valid gitea signature",
+			headers: map[string]string{"X-Gitea-Signature": sign(secret, body)},
+			secret:  secret,
+		},
+		{
+			name:    "signed with another secret",
+			headers: map[string]string{"X-Hub-Signature-256": "sha256=" + sign("other", body)},
+			secret:  secret,
+			wantErr: true,
+		},
+		{
+			name:    "malformed signature",
+			headers: map[string]string{"X-Gitea-Signature": "not-hex"},
+			secret:  secret,
+			wantErr: true,
+		},
+		{
+			name:    "missing signature",
+			headers: map[string]string{},
+			secret:  secret,
+			wantErr: true,
+		},
+		{
+			name:    "no secret configured",
+			headers: map[string]string{"X-Gitea-Signature": sign("", body)},
+			secret:  "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.headers {
+				h.Set(k, v)
+			}
+			err := VerifyWebhookSignature(h, body, tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyWebhookSignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhookEvent(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-GitHub-Event", "push")
+	if got := WebhookEvent(h); got != "push" {
+		t.Errorf("WebhookEvent() = %q, want push", got)
+	}
+	h.Set("X-Gitea-Event", "ping")
+	if got := WebhookEvent(h); got != "ping" {
+		t.Errorf("WebhookEvent() = %q, want gitea header to win", got)
+	}
+}
@@ -0,0 +1,18 @@
+-- RATE LIMIT QUERIES
+
+-- name: TakeToken :one
+INSERT INTO rate_limit_buckets (key, tokens, allowed, updated_at)
+VALUES (sqlc.arg(key), sqlc.arg(capacity)::float8 - 1, true, now())
+ON CONFLICT (key) DO UPDATE SET
+    tokens = CASE
+        WHEN LEAST(sqlc.arg(capacity)::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at) * sqlc.arg(rate)::float8) >= 1
+        THEN LEAST(sqlc.arg(capacity)::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at) * sqlc.arg(rate)::float8) - 1
+        ELSE LEAST(sqlc.arg(capacity)::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at) * sqlc.arg(rate)::float8)
+    END,
+    allowed = LEAST(sqlc.arg(capacity)::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at) * sqlc.arg(rate)::float8) >= 1,
+    updated_at = now()
+RETURNING tokens, allowed;
+
+-- name: DeleteIdleBuckets :exec
+DELETE FROM rate_limit_buckets
+WHERE updated_at < sqlc.arg(before);
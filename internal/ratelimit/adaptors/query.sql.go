@@ -0,0 +1,56 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: query.sql
+
+package adaptors
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteIdleBuckets = `-- name: DeleteIdleBuckets :exec
+DELETE FROM rate_limit_buckets
+WHERE updated_at < $1
+`
+
+func (q *Queries) DeleteIdleBuckets(ctx context.Context, before pgtype.Timestamptz) error {
+	_, err := q.db.Exec(ctx, deleteIdleBuckets, before)
+	return err
+}
+
+const takeToken = `-- name: TakeToken :one
+
+INSERT INTO rate_limit_buckets (key, tokens, allowed, updated_at)
+VALUES ($1, $2::float8 - 1, true, now())
+ON CONFLICT (key) DO UPDATE SET
+    tokens = CASE
+        WHEN LEAST($2::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at) * $3::float8) >= 1
+        THEN LEAST($2::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at) * $3::float8) - 1
+        ELSE LEAST($2::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at) * $3::float8)
+    END,
+    allowed = LEAST($2::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at) * $3::float8) >= 1,
+    updated_at = now()
+RETURNING tokens, allowed
+`
+
+type TakeTokenParams struct {
+	Key      string
+	Capacity float64
+	Rate     float64
+}
+
+type TakeTokenRow struct {
+	Tokens  float64
+	Allowed bool
+}
+
+// RATE LIMIT QUERIES
+func (q *Queries) TakeToken(ctx context.Context, arg TakeTokenParams) (TakeTokenRow, error) {
+	row := q.db.QueryRow(ctx, takeToken, arg.Key, arg.Capacity, arg.Rate)
+	var i TakeTokenRow
+	err := row.Scan(&i.Tokens, &i.Allowed)
+	return i, err
+}
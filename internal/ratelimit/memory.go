@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	idle    time.Duration
+}
+
+// MemoryStore keeps buckets in process, good for a single replica
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+	takes   int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, p Policy) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	capacity := p.capacity()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.updated).Seconds()*p.rate())
+	b.updated = now
+	b.idle = time.Duration(capacity/p.rate()*float64(time.Second)) + time.Minute
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	s.takes++
+	if s.takes%1024 == 0 {
+		s.sweep(now)
+	}
+	return decide(p, b.tokens, allowed), nil
+}
+
+// sweep drops buckets that have refilled completely, they'd start full anyway
+func (s *MemoryStore) sweep(now time.Time) {
+	for k, b := range s.buckets {
+		if now.Sub(b.updated) > b.idle {
+			delete(s.buckets, k)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+	p := Policy{Limit: 2, Window: 10 * time.Second, Burst: 3, KeyBy: KeyIP}
+
+	for i := 0; i < 3; i++ {
+		d, _ := s.Take(ctx, "a", p)
+		if !d.Allowed {
+			t.Fatalf("take %d denied within burst", i)
+		}
+		if d.Remaining != 2-i {
+			t.Errorf("take %d remaining = %d, want %d", i, d.Remaining, 2-i)
+		}
+	}
+
+	d, _ := s.Take(ctx, "a", p)
+	if d.Allowed {
+		t.Fatal("take allowed after burst was spent")
+	}
+	// 2 tokens per 10s is one every 5s
+	if d.RetryAfter != 5*time.Second {
+		t.Errorf("retry after = %s, want 5s", d.RetryAfter)
+	}
+
+	if d, _ := s.Take(ctx, "b", p); !d.Allowed {
+		t.Error("separate key shares a bucket")
+	}
+
+	now = now.Add(5 * time.Second)
+	if d, _ := s.Take(ctx, "a", p); !d.Allowed {
+		t.Error("take denied after refill")
+	}
+	if d, _ := s.Take(ctx, "a", p); d.Allowed {
+		t.Error("refill added more than one token")
+	}
+
+	now = now.Add(time.Hour)
+	d, _ = s.Take(ctx, "a", p)
+	if !d.Allowed || d.Remaining != 2 {
+		t.Errorf("bucket refilled past burst, remaining = %d", d.Remaining)
+	}
+}
+
+func TestPolicyDefaults(t *testing.T) {
+	p := Policy{Limit: 60, Window: time.Minute}
+	if p.capacity() != 60 {
+		t.Errorf("capacity = %v, want limit when burst unset", p.capacity())
+	}
+	if p.Header() != "60;w=60;burst=60" {
+		t.Errorf("header = %q", p.Header())
+	}
+	if (Policy{Window: time.Minute}).Enabled() {
+		t.Error("zero limit policy enabled")
+	}
+}
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/ratelimit/adaptors"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore keeps buckets in an unlogged table so every replica sees the same counts,
+// each take is a single upsert so concurrent requests can't both spend the last token
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, p Policy) (Decision, error) {
+	row, err := adaptors.New(s.pool).TakeToken(ctx, adaptors.TakeTokenParams{
+		Key:      key,
+		Capacity: p.capacity(),
+		Rate:     p.rate(),
+	})
+	if err != nil {
+		return Decision{}, err
+	}
+	return decide(p, row.Tokens, row.Allowed), nil
+}
+
+// SweepEvery runs Sweep on an interval until ctx is done
+func (s *PostgresStore) SweepEvery(ctx context.Context, interval, idle time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Sweep(ctx, idle); err != nil {
+				fmt.Printf("[WARN] rate limit sweep failed: %v\n", err)
+			}
+		}
+	}
+}
+
+// Sweep deletes buckets untouched for longer than idle, run it periodically
+func (s *PostgresStore) Sweep(ctx context.Context, idle time.Duration) error {
+	return adaptors.New(s.pool).DeleteIdleBuckets(ctx, pgtype.Timestamptz{Time: time.Now().Add(-idle), Valid: true})
+}
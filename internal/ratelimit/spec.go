@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	KeyIP     = "ip"
+	KeyAPIKey = "api_key"
+	KeyUser   = "user"
+)
+
+// Policy is a token bucket: Limit tokens refill evenly over Window, and up to Burst can
+// be spent at once. Burst defaults to Limit.
+type Policy struct {
+	Limit  int
+	Window time.Duration
+	Burst  int
+	KeyBy  string
+}
+
+func (p Policy) Enabled() bool {
+	return p.Limit > 0 && p.Window > 0
+}
+
+func (p Policy) capacity() float64 {
+	if p.Burst > 0 {
+		return float64(p.Burst)
+	}
+	return float64(p.Limit)
+}
+
+// rate is tokens per second
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Window.Seconds()
+}
+
+// Header renders the policy for the RateLimit-Policy header
+func (p Policy) Header() string {
+	return fmt.Sprintf("%d;w=%d;burst=%d", p.Limit, int(p.Window.Seconds()), int(p.capacity()))
+}
+
+func ValidKeyBy(k string) bool {
+	return k == KeyIP || k == KeyAPIKey || k == KeyUser
+}
+
+// Decision is the outcome of taking a token
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until a token is available, zero when allowed
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// Store holds bucket state. The in-memory store is per process, the postgres store shares
+// buckets between replicas.
+type Store interface {
+	Take(ctx context.Context, key string, p Policy) (Decision, error)
+}
+
+// decide turns the bucket level left after a take into a Decision
+func decide(p Policy, tokens float64, allowed bool) Decision {
+	d := Decision{
+		Allowed:   allowed,
+		Limit:     int(p.capacity()),
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+	}
+	rate := p.rate()
+	if !allowed {
+		d.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	d.Reset = secondsToDuration((p.capacity() - tokens) / rate)
+	return d
+}
+
+func secondsToDuration(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
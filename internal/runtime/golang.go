@@ -0,0 +1,251 @@
+package runtime
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// GoRuntime compiles the function against a generated main and runs the binary per call.
+// The source is package main and defines func Handle(req Request) (any, error); Request and
+// Response are provided by the generated file. Builds are cached by source hash, memory is
+// capped with RLIMIT_AS before the handler runs.
+type GoRuntime struct {
+	Bin string
+}
+
+func (r *GoRuntime) Language() string { return "go" }
+
+const goBuildTimeout = 2 * time.Minute
+
+const goMain = `// Code generated by the lws portal runtime. DO NOT EDIT.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+type Request struct {
+	Method  string            ` + "`json:\"method\"`" + `
+	Path    string            ` + "`json:\"path\"`" + `
+	Params  map[string]string ` + "`json:\"params\"`" + `
+	Headers map[string]string ` + "`json:\"headers\"`" + `
+	Query   map[string]string ` + "`json:\"query\"`" + `
+	Body    string            ` + "`json:\"body\"`" + `
+}
+
+// Bind decodes the json request body into v
+func (r Request) Bind(v any) error {
+	return json.Unmarshal([]byte(r.Body), v)
+}
+
+// Response lets a handler pick the status code and headers
+type Response struct {
+	Status  int               ` + "`json:\"status\"`" + `
+	Headers map[string]string ` + "`json:\"headers,omitempty\"`" + `
+	Body    any               ` + "`json:\"body,omitempty\"`" + `
+}
+
+func main() {
+	if limit, err := strconv.ParseUint(os.Getenv("LWS_MEMORY_BYTES"), 10, 64); err == nil && limit > 0 {
+		if err := syscall.Setrlimit(syscall.RLIMIT_AS, &syscall.Rlimit{Cur: limit, Max: limit}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	data, err := os.ReadFile(os.Getenv("LWS_REQUEST"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	out, err := Handle(req)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	result, err := json.Marshal(map[string]any{"value": out})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(os.Getenv("LWS_RESULT"), result, 0600); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+`
+
+// goCacheMax is how many compiled functions are kept, the least recently used beyond it
+// are removed after each build
+const goCacheMax = 64
+
+var goCacheDir = filepath.Join(os.TempDir(), "lws-go-functions")
+
+// goBuilds tracks the cached builds in use. Builds of one source are serialized on its
+// own lock, and one in use isn't evicted
+var goBuilds = struct {
+	sync.Mutex
+	inUse map[string]*goBuild
+}{inUse: map[string]*goBuild{}}
+
+type goBuild struct {
+	sync.Mutex
+	refs int
+}
+
+func acquireGoBuild(key string) *goBuild {
+	goBuilds.Lock()
+	defer goBuilds.Unlock()
+	b := goBuilds.inUse[key]
+	if b == nil {
+		b = &goBuild{}
+		goBuilds.inUse[key] = b
+	}
+	b.refs++
+	return b
+}
+
+func releaseGoBuild(key string) {
+	goBuilds.Lock()
+	defer goBuilds.Unlock()
+	if b := goBuilds.inUse[key]; b != nil {
+		if b.refs--; b.refs == 0 {
+			delete(goBuilds.inUse, key)
+		}
+	}
+}
+
+func (r *GoRuntime) Invoke(ctx context.Context, source []byte, req Request, limits Limits) (*Response, error) {
+	sum := sha256.Sum256(append([]byte(goMain), source...))
+	key := hex.EncodeToString(sum[:12])
+	// held until the call is done so the binary isn't evicted under it
+	b := acquireGoBuild(key)
+	defer releaseGoBuild(key)
+
+	b.Lock()
+	bin, built, err := r.build(ctx, key, source)
+	b.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if built {
+		evictGoBuilds()
+	}
+
+	sp, err := newSubprocess(limits, req)
+	if err != nil {
+		return nil, err
+	}
+	defer sp.cleanup()
+
+	// GOMEMLIMIT makes the gc work harder as the hard limit nears, rather than dying early
+	return sp.run(ctx, bin, nil, []string{
+		fmt.Sprintf("GOMEMLIMIT=%dMiB", limits.MemoryMB),
+		fmt.Sprintf("LWS_MEMORY_BYTES=%d", limits.MemoryMB<<20),
+	})
+}
+
+// build compiles into a per-source cache dir so repeated invocations skip the compiler,
+// built is false when the cache already had it. The caller holds the source's lock
+func (r *GoRuntime) build(ctx context.Context, key string, source []byte) (bin string, built bool, err error) {
+	dir := filepath.Join(goCacheDir, key)
+	bin = filepath.Join(dir, "function")
+
+	if _, err := os.Stat(bin); err == nil {
+		// the dir's mtime orders eviction
+		now := time.Now()
+		os.Chtimes(dir, now, now)
+		return bin, false, nil
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", false, err
+	}
+	files := map[string]string{
+		"go.mod":      "module lwsfunction\n\ngo 1.22\n",
+		"handler.go":  string(source),
+		"lws_main.go": goMain,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			return "", false, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, goBuildTimeout)
+	defer cancel()
+	// built aside and renamed in, a binary under bin is always complete
+	cmd := exec.CommandContext(ctx, r.Bin, "build", "-o", bin+".tmp", ".")
+	cmd.Dir = dir
+	cmd.Env = append(minimalEnv(), "GOFLAGS=-mod=mod", "CGO_ENABLED=0")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", false, ErrTimeout
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			logs := &logBuffer{}
+			logs.addOutput(string(out))
+			return "", false, &FunctionError{Msg: "build failed", Logs: logs.lines}
+		}
+		return "", false, fmt.Errorf("failed to run go build: %w", err)
+	}
+	if err := os.Rename(bin+".tmp", bin); err != nil {
+		return "", false, err
+	}
+	return bin, true, nil
+}
+
+// evictGoBuilds removes the least recently used builds beyond goCacheMax, skipping the
+// ones a call holds
+func evictGoBuilds() {
+	entries, err := os.ReadDir(goCacheDir)
+	if err != nil || len(entries) <= goCacheMax {
+		return
+	}
+	type cached struct {
+		key  string
+		used time.Time
+	}
+	var builds []cached
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		builds = append(builds, cached{e.Name(), info.ModTime()})
+	}
+	sort.Slice(builds, func(i, j int) bool { return builds[i].used.After(builds[j].used) })
+
+	goBuilds.Lock()
+	defer goBuilds.Unlock()
+	for _, b := range builds[min(goCacheMax, len(builds)):] {
+		if goBuilds.inUse[b.key] != nil {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(goCacheDir, b.key)); err != nil {
+			fmt.Printf("[WARN] evicting go build %s: %v\n", b.key, err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// JSRuntime runs functions in an embedded goja vm. The source must define handle(req), sync or
+// async; req.json() returns the decoded body and Response.json(value, {status, headers}) builds
+// an explicit response.
+type JSRuntime struct{}
+
+func (r *JSRuntime) Language() string { return "javascript" }
+
+// goja runs scripts, not modules, so exports in editor code are unwrapped
+var jsExport = regexp.MustCompile(`(?m)^(\s*)export\s+(default\s+)?`)
+
+const jsPrelude = `
+var Response = {
+  json: function (body, init) {
+    init = init || {};
+    var headers = Object.assign({"content-type": "application/json"}, init.headers || {});
+    return {status: init.status || 200, headers: headers, body: JSON.stringify(body)};
+  },
+  text: function (body, init) {
+    init = init || {};
+    return {status: init.status || 200, headers: init.headers || {}, body: String(body)};
+  }
+};
+`
+
+func (r *JSRuntime) Invoke(ctx context.Context, source []byte, req Request, limits Limits) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	vm := goja.New()
+	vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+	vm.SetMaxCallStackSize(1024)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			vm.Interrupt(ErrTimeout)
+		case <-done:
+		}
+	}()
+
+	logs := &logBuffer{max: limits.MaxOutput}
+	console := vm.NewObject()
+	logFn := func(call goja.FunctionCall) goja.Value {
+		parts := make([]string, 0, len(call.Arguments))
+		for _, a := range call.Arguments {
+			parts = append(parts, jsString(vm, a))
+		}
+		logs.add(strings.Join(parts, " "))
+		return goja.Undefined()
+	}
+	for _, name := range []string{"log", "info", "warn", "error", "debug"} {
+		console.Set(name, logFn)
+	}
+	vm.Set("console", console)
+
+	if _, err := vm.RunString(jsPrelude); err != nil {
+		return nil, fmt.Errorf("js prelude: %w", err)
+	}
+	if _, err := vm.RunString(jsExport.ReplaceAllString(string(source), "$1")); err != nil {
+		return nil, jsError(err, logs)
+	}
+
+	handle, ok := goja.AssertFunction(vm.Get("handle"))
+	if !ok {
+		return nil, &FunctionError{Msg: "source does not define handle(req)", Logs: logs.lines}
+	}
+
+	ret, err := handle(goja.Undefined(), jsRequest(vm, req))
+	if err != nil {
+		return nil, jsError(err, logs)
+	}
+
+	// async handlers settle while the job queue drains at the end of the call
+	if p, ok := ret.Export().(*goja.Promise); ok {
+		switch p.State() {
+		case goja.PromiseStateRejected:
+			return nil, &FunctionError{Msg: jsString(vm, p.Result()), Logs: logs.lines}
+		case goja.PromiseStatePending:
+			return nil, &FunctionError{Msg: "handler promise never settled", Logs: logs.lines}
+		}
+		ret = p.Result()
+	}
+
+	resp, err := normalize(exportJS(ret), limits.MaxOutput)
+	if err != nil {
+		var fe *FunctionError
+		if errors.As(err, &fe) {
+			fe.Logs = logs.lines
+		}
+		return nil, err
+	}
+	resp.Logs = logs.lines
+	return resp, nil
+}
+
+func jsRequest(vm *goja.Runtime, req Request) goja.Value {
+	obj := vm.NewObject()
+	obj.Set("method", req.Method)
+	obj.Set("path", req.Path)
+	obj.Set("params", stringMap(req.Params))
+	obj.Set("headers", stringMap(req.Headers))
+	obj.Set("query", stringMap(req.Query))
+	obj.Set("body", req.Body)
+	obj.Set("text", func() string { return req.Body })
+	obj.Set("json", func() goja.Value {
+		var decoded any
+		if err := json.Unmarshal([]byte(req.Body), &decoded); err != nil {
+			panic(vm.NewTypeError("request body is not valid json"))
+		}
+		return vm.ToValue(decoded)
+	})
+	return obj
+}
+
+// exportJS round trips through JSON so class instances and nested objects become plain maps
+func exportJS(v goja.Value) any {
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		return nil
+	}
+	if s, ok := v.Export().(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v.Export()
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v.Export()
+	}
+	return out
+}
+
+func jsString(vm *goja.Runtime, v goja.Value) string {
+	if v == nil || goja.IsUndefined(v) {
+		return "undefined"
+	}
+	if obj, ok := v.(*goja.Object); ok && obj.ClassName() != "Error" {
+		if data, err := json.Marshal(obj); err == nil {
+			return string(data)
+		}
+	}
+	return v.String()
+}
+
+func jsError(err error, logs *logBuffer) error {
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		return ErrTimeout
+	}
+	var exc *goja.Exception
+	if errors.As(err, &exc) {
+		return &FunctionError{Msg: exc.Error(), Logs: logs.lines}
+	}
+	return &FunctionError{Msg: err.Error(), Logs: logs.lines}
+}
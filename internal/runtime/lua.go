@@ -0,0 +1,185 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// LuaRuntime runs functions in an embedded gopher-lua state. The source must define a global
+// handle(req), req is a table with method, path, params, headers, query, body and the decoded
+// json body.
+type LuaRuntime struct{}
+
+func (r *LuaRuntime) Language() string { return "lua" }
+
+func (r *LuaRuntime) Invoke(ctx context.Context, source []byte, req Request, limits Limits) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	// registry and call stack are the only knobs gopher-lua has for bounding memory
+	registryMax := limits.MemoryMB * 1024
+	L := lua.NewState(lua.Options{
+		SkipOpenLibs:        true,
+		CallStackSize:       256,
+		RegistrySize:        1024,
+		RegistryMaxSize:     registryMax,
+		RegistryGrowStep:    32,
+		MinimizeStackMemory: true,
+	})
+	defer L.Close()
+
+	// no io, os or package so functions can't touch the host
+	for _, lib := range []struct {
+		name string
+		fn   lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.fn))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range []string{"dofile", "loadfile", "load", "loadstring"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	logs := &logBuffer{max: limits.MaxOutput}
+	L.SetGlobal("print", L.NewFunction(func(L *lua.LState) int {
+		parts := make([]string, 0, L.GetTop())
+		for i := 1; i <= L.GetTop(); i++ {
+			parts = append(parts, L.ToStringMeta(L.Get(i)).String())
+		}
+		logs.add(strings.Join(parts, "\t"))
+		return 0
+	}))
+	L.SetContext(ctx)
+
+	if err := L.DoString(string(source)); err != nil {
+		return nil, luaError(ctx, err, logs)
+	}
+	handle, ok := L.GetGlobal("handle").(*lua.LFunction)
+	if !ok {
+		return nil, &FunctionError{Msg: "source does not define handle(req)", Logs: logs.lines}
+	}
+
+	if err := L.CallByParam(lua.P{Fn: handle, NRet: 1, Protect: true}, luaRequest(L, req)); err != nil {
+		return nil, luaError(ctx, err, logs)
+	}
+	ret := L.Get(-1)
+	L.Pop(1)
+
+	resp, err := normalize(fromLua(ret, 0), limits.MaxOutput)
+	if err != nil {
+		var fe *FunctionError
+		if errors.As(err, &fe) {
+			fe.Logs = logs.lines
+		}
+		return nil, err
+	}
+	resp.Logs = logs.lines
+	return resp, nil
+}
+
+func luaError(ctx context.Context, err error, logs *logBuffer) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	return &FunctionError{Msg: err.Error(), Logs: logs.lines}
+}
+
+func luaRequest(L *lua.LState, req Request) *lua.LTable {
+	t := L.NewTable()
+	t.RawSetString("method", lua.LString(req.Method))
+	t.RawSetString("path", lua.LString(req.Path))
+	t.RawSetString("body", lua.LString(req.Body))
+	t.RawSetString("params", toLua(L, stringMap(req.Params)))
+	t.RawSetString("headers", toLua(L, stringMap(req.Headers)))
+	t.RawSetString("query", toLua(L, stringMap(req.Query)))
+
+	var decoded any
+	if err := json.Unmarshal([]byte(req.Body), &decoded); err == nil {
+		t.RawSetString("json", toLua(L, decoded))
+	}
+	return t
+}
+
+func stringMap(m map[string]string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func toLua(L *lua.LState, v any) lua.LValue {
+	switch val := v.(type) {
+	case nil:
+		return lua.LNil
+	case bool:
+		return lua.LBool(val)
+	case float64:
+		return lua.LNumber(val)
+	case string:
+		return lua.LString(val)
+	case []any:
+		t := L.NewTable()
+		for _, item := range val {
+			t.Append(toLua(L, item))
+		}
+		return t
+	case map[string]any:
+		t := L.NewTable()
+		for k, item := range val {
+			t.RawSetString(k, toLua(L, item))
+		}
+		return t
+	default:
+		return lua.LString(fmt.Sprint(val))
+	}
+}
+
+// fromLua converts a return value into plain go values, tables with only 1..n keys become lists
+func fromLua(v lua.LValue, depth int) any {
+	if depth > 64 {
+		return nil
+	}
+	switch val := v.(type) {
+	case *lua.LNilType:
+		return nil
+	case lua.LBool:
+		return bool(val)
+	case lua.LNumber:
+		return float64(val)
+	case lua.LString:
+		return string(val)
+	case *lua.LTable:
+		if n := val.Len(); n > 0 && countKeys(val) == n {
+			list := make([]any, 0, n)
+			for i := 1; i <= n; i++ {
+				list = append(list, fromLua(val.RawGetInt(i), depth+1))
+			}
+			return list
+		}
+		m := map[string]any{}
+		val.ForEach(func(k, item lua.LValue) {
+			m[k.String()] = fromLua(item, depth+1)
+		})
+		return m
+	default:
+		return v.String()
+	}
+}
+
+func countKeys(t *lua.LTable) int {
+	n := 0
+	t.ForEach(func(_, _ lua.LValue) { n++ })
+	return n
+}
@@ -0,0 +1,95 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// PythonRuntime runs handle(request) from the function source in a fresh interpreter per call.
+// handle may be sync or async; request.json() is awaitable like starlette's, request.data is
+// the decoded body for sync handlers. Memory is capped with RLIMIT_AS before the source loads.
+type PythonRuntime struct {
+	Bin string
+}
+
+func (r *PythonRuntime) Language() string { return "python" }
+
+const pythonRunner = `import asyncio, dataclasses, inspect, json, os, resource, sys, importlib.util
+
+class Request:
+    def __init__(self, raw):
+        self.method = raw.get("method", "")
+        self.path = raw.get("path", "")
+        self.params = raw.get("params") or {}
+        self.headers = raw.get("headers") or {}
+        self.query = raw.get("query") or {}
+        self.body = raw.get("body", "")
+        try:
+            self.data = json.loads(self.body) if self.body else None
+        except ValueError:
+            self.data = None
+
+    async def json(self):
+        return json.loads(self.body)
+
+    async def text(self):
+        return self.body
+
+def plain(value):
+    if hasattr(value, "model_dump"):
+        return value.model_dump()
+    if hasattr(value, "dict") and callable(value.dict):
+        return value.dict()
+    if dataclasses.is_dataclass(value) and not isinstance(value, type):
+        return dataclasses.asdict(value)
+    raise TypeError(f"{type(value).__name__} is not serializable")
+
+def main():
+    limit = int(os.environ.get("LWS_MEMORY_BYTES", "0"))
+    if limit > 0:
+        resource.setrlimit(resource.RLIMIT_AS, (limit, limit))
+
+    with open(os.environ["LWS_REQUEST"]) as f:
+        raw = json.load(f)
+
+    spec = importlib.util.spec_from_file_location("handler", sys.argv[1])
+    module = importlib.util.module_from_spec(spec)
+    spec.loader.exec_module(module)
+    handle = getattr(module, "handle", None)
+    if handle is None:
+        sys.exit("source does not define handle(request)")
+
+    result = handle(Request(raw))
+    if inspect.isawaitable(result):
+        async def wait():
+            return await result
+        result = asyncio.run(wait())
+
+    with open(os.environ["LWS_RESULT"], "w") as f:
+        json.dump({"value": result}, f, default=plain)
+
+main()
+`
+
+func (r *PythonRuntime) Invoke(ctx context.Context, source []byte, req Request, limits Limits) (*Response, error) {
+	sp, err := newSubprocess(limits, req)
+	if err != nil {
+		return nil, err
+	}
+	defer sp.cleanup()
+
+	if err := os.WriteFile(filepath.Join(sp.dir, "handler.py"), source, 0600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(sp.dir, "lws_runner.py"), []byte(pythonRunner), 0600); err != nil {
+		return nil, err
+	}
+
+	return sp.run(ctx, r.Bin, []string{"lws_runner.py", "handler.py"}, []string{
+		fmt.Sprintf("LWS_MEMORY_BYTES=%d", limits.MemoryMB<<20),
+		"PYTHONDONTWRITEBYTECODE=1",
+		"PYTHONUNBUFFERED=1",
+	})
+}
@@ -0,0 +1,134 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+var envelopeKeys = map[string]bool{"status": true, "headers": true, "body": true}
+
+// normalize maps a handler's return value onto a Response, the same way for every language:
+//   - nil is a 204
+//   - a string is a text/plain 200
+//   - an object with a numeric status and only status/headers/body keys is taken as the response itself
+//   - anything else is sent as a json 200
+func normalize(v any, maxOutput int) (*Response, error) {
+	resp := &Response{Status: 200, Headers: map[string]string{}}
+
+	switch val := v.(type) {
+	case nil:
+		resp.Status = 204
+		return resp, nil
+	case string:
+		resp.Headers["content-type"] = "text/plain; charset=utf-8"
+		resp.Body = []byte(val)
+	case map[string]any:
+		if isEnvelope(val) {
+			return fromEnvelope(val, maxOutput)
+		}
+		return jsonResponse(resp, val, maxOutput)
+	default:
+		return jsonResponse(resp, val, maxOutput)
+	}
+	return resp, checkSize(resp, maxOutput)
+}
+
+func isEnvelope(m map[string]any) bool {
+	if _, ok := asInt(m["status"]); !ok {
+		return false
+	}
+	for k := range m {
+		if !envelopeKeys[k] {
+			return false
+		}
+	}
+	return true
+}
+
+func fromEnvelope(m map[string]any, maxOutput int) (*Response, error) {
+	status, _ := asInt(m["status"])
+	if status < 100 || status > 599 {
+		return nil, &FunctionError{Msg: fmt.Sprintf("invalid status code %d", status)}
+	}
+	resp := &Response{Status: status, Headers: map[string]string{}}
+	if headers, ok := m["headers"].(map[string]any); ok {
+		for k, v := range headers {
+			resp.Headers[strings.ToLower(k)] = fmt.Sprint(v)
+		}
+	}
+
+	switch body := m["body"].(type) {
+	case nil:
+	case string:
+		resp.Body = []byte(body)
+		if _, ok := resp.Headers["content-type"]; !ok {
+			resp.Headers["content-type"] = "text/plain; charset=utf-8"
+		}
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, &FunctionError{Msg: fmt.Sprintf("response body is not serializable: %s", err)}
+		}
+		resp.Body = data
+		if _, ok := resp.Headers["content-type"]; !ok {
+			resp.Headers["content-type"] = "application/json"
+		}
+	}
+	return resp, checkSize(resp, maxOutput)
+}
+
+func jsonResponse(resp *Response, v any, maxOutput int) (*Response, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, &FunctionError{Msg: fmt.Sprintf("return value is not serializable: %s", err)}
+	}
+	resp.Headers["content-type"] = "application/json"
+	resp.Body = data
+	return resp, checkSize(resp, maxOutput)
+}
+
+func checkSize(resp *Response, maxOutput int) error {
+	if maxOutput > 0 && len(resp.Body) > maxOutput {
+		return &FunctionError{Msg: fmt.Sprintf("response body exceeds %d bytes", maxOutput)}
+	}
+	return nil
+}
+
+// asInt accepts the number types the interpreters and encoding/json hand back
+func asInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		if n == float64(int(n)) {
+			return int(n), true
+		}
+	}
+	return 0, false
+}
+
+// logBuffer collects print/console output, dropping lines past the output limit
+type logBuffer struct {
+	lines []string
+	size  int
+	max   int
+}
+
+func (b *logBuffer) add(line string) {
+	if b.max > 0 && b.size+len(line) > b.max {
+		return
+	}
+	b.size += len(line)
+	b.lines = append(b.lines, line)
+}
+
+func (b *logBuffer) addOutput(out string) {
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		if line != "" {
+			b.add(line)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testLimits() Limits {
+	return Limits{Timeout: 2 * time.Second, MemoryMB: 256, MaxOutput: 1 << 20}
+}
+
+var helloReq = Request{Method: "POST", Path: "/hello", Body: `{"name":"ada"}`}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      any
+		wantStatus int
+		wantBody   string
+		wantType   string
+		wantErr    bool
+	}{
+		{name: "nil", value: nil, wantStatus: 204},
+		{name: "string", value: "hi", wantStatus: 200, wantBody: "hi", wantType: "text/plain; charset=utf-8"},
+		{name: "object", value: map[string]any{"message": "hi"}, wantStatus: 200, wantBody: `{"message":"hi"}`, wantType: "application/json"},
+		{name: "list", value: []any{1.0, 2.0}, wantStatus: 200, wantBody: `[1,2]`, wantType: "application/json"},
+		{
+			name:       "envelope",
+			value:      map[string]any{"status": 201.0, "body": map[string]any{"id": 1.0}},
+			wantStatus: 201,
+			wantBody:   `{"id":1}`,
+			wantType:   "application/json",
+		},
+		{
+			name:       "envelope with string body keeps its content type",
+			value:      map[string]any{"status": 200.0, "headers": map[string]any{"Content-Type": "text/html"}, "body": "<p>hi</p>"},
+			wantStatus: 200,
+			wantBody:   "<p>hi</p>",
+			wantType:   "text/html",
+		},
+		{
+			name:       "status alongside data is just data",
+			value:      map[string]any{"status": 1.0, "message": "ok"},
+			wantStatus: 200,
+			wantBody:   `{"message":"ok","status":1}`,
+			wantType:   "application/json",
+		},
+		{name: "invalid status", value: map[string]any{"status": 42.0}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := normalize(tt.value, 1<<20)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if resp.Status != tt.wantStatus || string(resp.Body) != tt.wantBody || resp.Headers["content-type"] != tt.wantType {
+				t.Errorf("normalize() = %d %q %q, want %d %q %q",
+					resp.Status, resp.Body, resp.Headers["content-type"], tt.wantStatus, tt.wantBody, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestEmbeddedRuntimes(t *testing.T) {
+	tests := []struct {
+		name       string
+		runtime    Runtime
+		source     string
+		wantStatus int
+		wantBody   string
+		wantLogs   int
+		wantErr    error
+	}{
+		{
+			name:    "lua table",
+			runtime: &LuaRuntime{},
+			source: `function handle(req)
+  print("got", req.method)
+  return { message = "hello " .. req.json.name }
+end`,
+			wantStatus: 200,
+			wantBody:   `{"message":"hello ada"}`,
+			wantLogs:   1,
+		},
+		{
+			name:       "lua envelope",
+			runtime:    &LuaRuntime{},
+			source:     `function handle(req) return { status = 201, body = "created" } end`,
+			wantStatus: 201,
+			wantBody:   "created",
+		},
+		{
+			name:    "lua sandboxed",
+			runtime: &LuaRuntime{},
+			source:  `function handle(req) return os.getenv("HOME") end`,
+			wantErr: &FunctionError{},
+		},
+		{
+			name:    "lua timeout",
+			runtime: &LuaRuntime{},
+			source:  `function handle(req) while true do end end`,
+			wantErr: ErrTimeout,
+		},
+		{
+			name:    "javascript async export",
+			runtime: &JSRuntime{},
+			source: `export async function handle(req) {
+  const body = await req.json();
+  console.log("name", body.name);
+  return Response.json({message: "hello " + body.name}, {status: 202});
+}`,
+			wantStatus: 202,
+			wantBody:   `{"message":"hello ada"}`,
+			wantLogs:   1,
+		},
+		{
+			name:    "javascript class instance",
+			runtime: &JSRuntime{},
+			source: `class Output { constructor(m) { this.message = m } }
+function handle(req) { return new Output(req.method) }`,
+			wantStatus: 200,
+			wantBody:   `{"message":"POST"}`,
+		},
+		{
+			name:    "javascript throws",
+			runtime: &JSRuntime{},
+			source:  `async function handle(req) { throw new Error("boom") }`,
+			wantErr: &FunctionError{},
+		},
+		{
+			name:    "javascript missing handler",
+			runtime: &JSRuntime{},
+			source:  `function other() {}`,
+			wantErr: &FunctionError{},
+		},
+		{
+			name:    "javascript timeout",
+			runtime: &JSRuntime{},
+			source:  `function handle(req) { for (;;) {} }`,
+			wantErr: ErrTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits := testLimits()
+			limits.Timeout = 300 * time.Millisecond
+			assertInvoke(t, tt.runtime, tt.source, limits, tt.wantStatus, tt.wantBody, tt.wantLogs, tt.wantErr)
+		})
+	}
+}
+
+func TestPythonRuntime(t *testing.T) {
+	bin, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not installed")
+	}
+	rt := &PythonRuntime{Bin: bin}
+
+	source := `from dataclasses import dataclass
+
+@dataclass
+class Output:
+    message: str
+
+async def handle(request):
+    data = await request.json()
+    print("handling", request.method)
+    return Output(message=f"hello {data['name']}")
+`
+	assertInvoke(t, rt, source, testLimits(), 200, `{"message":"hello ada"}`, 1, nil)
+	assertInvoke(t, rt, "def handle(request):\n    raise ValueError('boom')\n", testLimits(), 0, "", 0, &FunctionError{})
+
+	limits := testLimits()
+	limits.Timeout = 500 * time.Millisecond
+	assertInvoke(t, rt, "import time\ndef handle(request):\n    time.sleep(5)\n", limits, 0, "", 0, ErrTimeout)
+
+	limits = testLimits()
+	limits.MemoryMB = 64
+	assertInvoke(t, rt, "def handle(request):\n    return len(bytearray(512 << 20))\n", limits, 0, "", 0, &FunctionError{})
+}
+
+func TestSubprocessForkedChildren(t *testing.T) {
+	bin, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not installed")
+	}
+	rt := &PythonRuntime{Bin: bin}
+	// the child inherits stdout and outlives the handler
+	fork := "import subprocess, sys, time\n" +
+		"def handle(request):\n" +
+		"    subprocess.Popen([sys.executable, '-c', 'import time; time.sleep(30)'])\n"
+
+	start := time.Now()
+	assertInvoke(t, rt, fork+"    return 'ok'\n", testLimits(), 200, "ok", 0, nil)
+	limits := testLimits()
+	limits.Timeout = 500 * time.Millisecond
+	assertInvoke(t, rt, fork+"    time.sleep(30)\n", limits, 0, "", 0, ErrTimeout)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("forked children kept the calls waiting for %s", elapsed)
+	}
+}
+
+func TestGoRuntime(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles a function")
+	}
+	bin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not installed")
+	}
+	rt := &GoRuntime{Bin: bin}
+
+	source := `package main
+
+type Input struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+func Handle(req Request) (any, error) {
+	var in Input
+	if err := req.Bind(&in); err != nil {
+		return Response{Status: 400, Body: "invalid request"}, nil
+	}
+	return map[string]string{"message": "hello " + in.Name}, nil
+}
+`
+	limits := testLimits()
+	limits.Timeout = 10 * time.Second
+	assertInvoke(t, rt, source, limits, 200, `{"message":"hello ada"}`, 0, nil)
+	assertInvoke(t, rt, "package main\n\nfunc Handle(req Request) (any, error) { return undefined, nil }\n", limits, 0, "", 0, &FunctionError{})
+
+	limits.MemoryMB = 64
+	hog := "package main\n\nfunc Handle(req Request) (any, error) {\n\tb := make([]byte, 512<<20)\n\tfor i := range b {\n\t\tb[i] = 1\n\t}\n\treturn len(b), nil\n}\n"
+	assertInvoke(t, rt, hog, limits, 0, "", 0, &FunctionError{})
+}
+
+func TestEvictGoBuilds(t *testing.T) {
+	orig := goCacheDir
+	defer func() { goCacheDir = orig }()
+	goCacheDir = t.TempDir()
+
+	old := time.Now().Add(-time.Hour)
+	for i := range goCacheMax + 3 {
+		dir := filepath.Join(goCacheDir, fmt.Sprintf("build%02d", i))
+		if err := os.Mkdir(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		// build00 is the least recently used
+		used := old.Add(time.Duration(i) * time.Second)
+		if err := os.Chtimes(dir, used, used); err != nil {
+			t.Fatal(err)
+		}
+	}
+	acquireGoBuild("build01")
+	defer releaseGoBuild("build01")
+
+	evictGoBuilds()
+	entries, err := os.ReadDir(goCacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := map[string]bool{}
+	for _, e := range entries {
+		kept[e.Name()] = true
+	}
+	if len(kept) != goCacheMax+1 || kept["build00"] || kept["build02"] || !kept["build01"] {
+		t.Errorf("kept %d builds %v, want the newest %d and the one in use", len(kept), kept, goCacheMax)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, lang := range []string{"lua", "javascript", "python", "go"} {
+		rt, err := New(lang)
+		if err != nil || rt.Language() != lang {
+			t.Errorf("New(%q) = %v, %v", lang, rt, err)
+		}
+	}
+	if _, err := New("rust"); !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Errorf("New(rust) error = %v, want ErrUnsupportedLanguage", err)
+	}
+}
+
+func assertInvoke(t *testing.T, rt Runtime, source string, limits Limits, wantStatus int, wantBody string, wantLogs int, wantErr error) {
+	t.Helper()
+	resp, err := rt.Invoke(context.Background(), []byte(source), helloReq, limits)
+	if wantErr != nil {
+		var fe *FunctionError
+		if _, isFnErr := wantErr.(*FunctionError); isFnErr {
+			if !errors.As(err, &fe) {
+				t.Fatalf("Invoke() error = %v, want a FunctionError", err)
+			}
+			return
+		}
+		if !errors.Is(err, wantErr) {
+			t.Fatalf("Invoke() error = %v, want %v", err, wantErr)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if resp.Status != wantStatus || string(resp.Body) != wantBody {
+		t.Errorf("Invoke() = %d %q, want %d %q", resp.Status, resp.Body, wantStatus, wantBody)
+	}
+	if len(resp.Logs) != wantLogs {
+		t.Errorf("Invoke() logs = %q, want %d lines", resp.Logs, wantLogs)
+	}
+}
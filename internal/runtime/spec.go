@@ -0,0 +1,111 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ashupednekar/litewebservices-portal/pkg"
+)
+
+var (
+	ErrTimeout             = errors.New("function timed out")
+	ErrUnsupportedLanguage = errors.New("language has no runtime yet")
+)
+
+// Request is what every function receives, whatever the language
+type Request struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Params  map[string]string `json:"params"`
+	Headers map[string]string `json:"headers"`
+	Query   map[string]string `json:"query"`
+	Body    string            `json:"body"`
+}
+
+// Response is the normalized result of an invocation, see normalize for how return values map onto it
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    []byte            `json:"-"`
+	Logs    []string          `json:"logs"`
+}
+
+// Limits bound a single invocation. Subprocess runtimes cap process memory, embedded ones
+// can only bound their call stack, so MemoryMB is best effort there.
+type Limits struct {
+	Timeout   time.Duration
+	MemoryMB  int
+	MaxOutput int
+}
+
+// FunctionError is a failure inside user code, as opposed to the runtime itself breaking
+type FunctionError struct {
+	Msg  string
+	Logs []string
+}
+
+func (e *FunctionError) Error() string {
+	return fmt.Sprintf("function error: %s", e.Msg)
+}
+
+type Runtime interface {
+	Language() string
+	Invoke(ctx context.Context, source []byte, req Request, limits Limits) (*Response, error)
+}
+
+const (
+	maxTimeout       = 30 * time.Second
+	defaultMaxOutput = 1 << 20
+)
+
+// DefaultLimits reads the invocation limits from the portal config
+func DefaultLimits() Limits {
+	timeout, err := time.ParseDuration(pkg.Cfg.RuntimeTimeout)
+	if err != nil || timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	memory := pkg.Cfg.RuntimeMemoryMB
+	if memory <= 0 {
+		memory = 128
+	}
+	return Limits{Timeout: timeout, MemoryMB: memory, MaxOutput: defaultMaxOutput}
+}
+
+// WithTimeout overrides the timeout, capped so a caller can't hold a runtime forever
+func (l Limits) WithTimeout(d time.Duration) Limits {
+	if d <= 0 {
+		return l
+	}
+	if d > maxTimeout {
+		d = maxTimeout
+	}
+	l.Timeout = d
+	return l
+}
+
+// New returns the runtime for one of the languages the portal stores under functions/<lang>/
+func New(language string) (Runtime, error) {
+	switch language {
+	case "lua":
+		return &LuaRuntime{}, nil
+	case "javascript":
+		return &JSRuntime{}, nil
+	case "python":
+		return &PythonRuntime{Bin: orDefault(pkg.Cfg.RuntimePython, "python3")}, nil
+	case "go":
+		return &GoRuntime{Bin: orDefault(pkg.Cfg.RuntimeGo, "go")}, nil
+	case "rust":
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLanguage, language)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLanguage, language)
+	}
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
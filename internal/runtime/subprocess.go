@@ -0,0 +1,127 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// outputWaitDelay is how long a finished or killed handler's output pipes may stay open,
+// a child holding them past that no longer keeps the call waiting
+const outputWaitDelay = time.Second
+
+// subprocess is shared by the runtimes that shell out. The request goes in through the file
+// named by LWS_REQUEST and the handler's return value comes back as {"value": ...} in
+// LWS_RESULT, which leaves stdout and stderr free to be collected as logs.
+type subprocess struct {
+	dir    string
+	limits Limits
+}
+
+func newSubprocess(limits Limits, req Request) (*subprocess, error) {
+	dir, err := os.MkdirTemp("", "lws-invoke-")
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "request.json"), data, 0600); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return &subprocess{dir: dir, limits: limits}, nil
+}
+
+func (s *subprocess) cleanup() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *subprocess) run(ctx context.Context, name string, args []string, env []string) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.limits.Timeout)
+	defer cancel()
+
+	resultPath := filepath.Join(s.dir, "result.json")
+	cmd := exec.CommandContext(ctx, name, args...)
+	ownGroup(cmd)
+	cmd.WaitDelay = outputWaitDelay
+	cmd.Dir = s.dir
+	cmd.Env = append(minimalEnv(),
+		"LWS_REQUEST="+filepath.Join(s.dir, "request.json"),
+		"LWS_RESULT="+resultPath,
+	)
+	cmd.Env = append(cmd.Env, env...)
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	logs := &logBuffer{max: s.limits.MaxOutput}
+	err := cmd.Run()
+	// children the handler left running in the background go with it
+	killGroup(cmd)
+	logs.addOutput(out.String())
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, ErrTimeout
+	}
+	// the handler itself exited cleanly, only a child of it still held the pipes
+	if errors.Is(err, exec.ErrWaitDelay) {
+		err = nil
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, &FunctionError{Msg: fmt.Sprintf("exited with %s", exitErr.ProcessState), Logs: logs.lines}
+		}
+		return nil, fmt.Errorf("failed to start %s: %w", name, err)
+	}
+
+	data, err := os.ReadFile(resultPath)
+	if err != nil {
+		return nil, &FunctionError{Msg: "handler produced no result", Logs: logs.lines}
+	}
+	if s.limits.MaxOutput > 0 && len(data) > 2*s.limits.MaxOutput {
+		return nil, &FunctionError{Msg: "result too large", Logs: logs.lines}
+	}
+	var result struct {
+		Value any `json:"value"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, &FunctionError{Msg: "handler result is not valid json", Logs: logs.lines}
+	}
+
+	resp, err := normalize(result.Value, s.limits.MaxOutput)
+	if err != nil {
+		var fe *FunctionError
+		if errors.As(err, &fe) {
+			fe.Logs = logs.lines
+		}
+		return nil, err
+	}
+	resp.Logs = logs.lines
+	return resp, nil
+}
+
+// minimalEnv passes functions the few variables a toolchain needs instead of the portal's
+// environment. It isn't isolation: functions run as the portal's user, which can still
+// read the portal's environment under /proc
+func minimalEnv() []string {
+	env := []string{}
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		switch key {
+		case "PATH", "HOME", "TMPDIR", "LANG", "GOPATH", "GOCACHE", "GOMODCACHE", "GOPROXY", "GOFLAGS":
+			env = append(env, kv)
+		}
+	}
+	return env
+}
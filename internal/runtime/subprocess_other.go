@@ -0,0 +1,10 @@
+//go:build !unix
+
+package runtime
+
+import "os/exec"
+
+// ownGroup leaves cmd to exec's default of killing only the process itself
+func ownGroup(cmd *exec.Cmd) {}
+
+func killGroup(cmd *exec.Cmd) error { return nil }
@@ -0,0 +1,28 @@
+//go:build unix
+
+package runtime
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// ownGroup starts cmd as the leader of a process group of its own, so whatever the handler
+// forks is killed with it when the context ends
+func ownGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error { return killGroup(cmd) }
+}
+
+// killGroup kills what's left of cmd's process group
+func killGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	if errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+	return err
+}
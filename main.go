@@ -2,10 +2,9 @@ package main
 
 import (
 	"github.com/ashupednekar/litewebservices-portal/cmd"
-	"github.com/ashupednekar/litewebservices-portal/pkg"
 )
 
+// server settings are loaded by the commands that need them, client commands only read their context file
 func main() {
-	pkg.LoadCfg()
 	cmd.Execute()
 }
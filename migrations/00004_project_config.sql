@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-------------------------------------------------------------------------------
+-- PROJECT CONFIGURATION (KEY/VALUE)
+-------------------------------------------------------------------------------
+CREATE TABLE project_configs (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    value_type TEXT NOT NULL CHECK (value_type IN ('string', 'number', 'bool', 'json')),
+    secret BOOLEAN NOT NULL DEFAULT false,   -- masked on read, never committed to git
+    version INTEGER NOT NULL DEFAULT 1,
+    updated_by BYTEA REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (project_id, key)
+);
+
+-------------------------------------------------------------------------------
+-- CONFIG HISTORY (ONE ROW PER CHANGE, USED FOR REVERTS)
+-------------------------------------------------------------------------------
+CREATE TABLE project_config_history (
+    id BIGSERIAL PRIMARY KEY,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    value_type TEXT NOT NULL,
+    secret BOOLEAN NOT NULL,
+    version INTEGER NOT NULL,
+    deleted BOOLEAN NOT NULL DEFAULT false,
+    changed_by BYTEA REFERENCES users(id) ON DELETE SET NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    UNIQUE (project_id, key, version)
+);
+
+CREATE INDEX idx_project_config_history_key ON project_config_history(project_id, key);
+
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS project_config_history;
+DROP TABLE IF EXISTS project_configs;
+
+-- +goose StatementEnd
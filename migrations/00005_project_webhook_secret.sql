@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE projects ADD COLUMN webhook_secret TEXT;   -- hmac secret for inbound vcs webhooks
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE projects DROP COLUMN IF EXISTS webhook_secret;
+-- +goose StatementEnd
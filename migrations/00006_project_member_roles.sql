@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+UPDATE user_projects
+SET role = 'viewer'
+WHERE role IS NULL OR role NOT IN ('owner', 'maintainer', 'developer', 'viewer');
+
+ALTER TABLE user_projects
+    ALTER COLUMN role SET DEFAULT 'viewer',
+    ADD CONSTRAINT user_projects_role_check
+        CHECK (role IN ('owner', 'maintainer', 'developer', 'viewer'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_projects
+    DROP CONSTRAINT IF EXISTS user_projects_role_check,
+    ALTER COLUMN role DROP DEFAULT;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE project_invites (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,           -- sha256 of the link token, the token itself is never stored
+    role TEXT NOT NULL CHECK (role IN ('maintainer', 'developer', 'viewer')),
+    created_by BYTEA NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    redeemed_by BYTEA REFERENCES users(id) ON DELETE SET NULL,
+    redeemed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_project_invites_project_id ON project_invites(project_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS project_invites;
+-- +goose StatementEnd
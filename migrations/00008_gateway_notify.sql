@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the gateway listens on lws_routes and reloads a project's route table when its endpoints
+-- or their bound functions change. The payload is the project name, empty when the project
+-- itself is being deleted, which makes listeners drop every table.
+CREATE OR REPLACE FUNCTION notify_lws_routes() RETURNS trigger AS $$
+DECLARE
+    pid UUID;
+    pname TEXT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        pid := OLD.project_id;
+    ELSE
+        pid := NEW.project_id;
+    END IF;
+    SELECT name INTO pname FROM projects WHERE id = pid;
+    PERFORM pg_notify('lws_routes', COALESCE(pname, ''));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER endpoints_notify_lws_routes
+AFTER INSERT OR UPDATE OR DELETE ON endpoints
+FOR EACH ROW EXECUTE FUNCTION notify_lws_routes();
+
+CREATE TRIGGER functions_notify_lws_routes
+AFTER UPDATE OR DELETE ON functions
+FOR EACH ROW EXECUTE FUNCTION notify_lws_routes();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS functions_notify_lws_routes ON functions;
+DROP TRIGGER IF EXISTS endpoints_notify_lws_routes ON endpoints;
+DROP FUNCTION IF EXISTS notify_lws_routes();
+-- +goose StatementEnd
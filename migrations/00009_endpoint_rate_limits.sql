@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- a NULL rate_limit means the endpoint is unlimited
+ALTER TABLE endpoints
+    ADD COLUMN rate_limit INTEGER CHECK (rate_limit > 0),                     -- requests per window
+    ADD COLUMN rate_window_seconds INTEGER NOT NULL DEFAULT 60 CHECK (rate_window_seconds > 0),
+    ADD COLUMN rate_burst INTEGER CHECK (rate_burst > 0),                     -- bucket size, defaults to rate_limit
+    ADD COLUMN rate_key TEXT NOT NULL DEFAULT 'ip' CHECK (rate_key IN ('ip', 'api_key', 'user'));
+
+-- shared token buckets for running several gateway replicas
+CREATE UNLOGGED TABLE rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS rate_limit_buckets;
+
+ALTER TABLE endpoints
+    DROP COLUMN IF EXISTS rate_key,
+    DROP COLUMN IF EXISTS rate_burst,
+    DROP COLUMN IF EXISTS rate_window_seconds,
+    DROP COLUMN IF EXISTS rate_limit;
+-- +goose StatementEnd
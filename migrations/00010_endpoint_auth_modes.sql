@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE endpoints DROP CONSTRAINT IF EXISTS endpoints_scope_check;
+ALTER TABLE endpoints
+    ADD CONSTRAINT endpoints_scope_check CHECK (scope IN ('public', 'authn', 'api_key', 'jwt')),
+    ADD COLUMN api_key_scope TEXT NOT NULL DEFAULT '',    -- scope a key needs, empty accepts any key of the project
+    ADD COLUMN jwt_secret TEXT NOT NULL DEFAULT '',       -- HS256 shared secret, masked on read
+    ADD COLUMN jwt_jwks TEXT NOT NULL DEFAULT '',         -- JWKS url or path in the project repo
+    ADD COLUMN jwt_issuer TEXT NOT NULL DEFAULT '',
+    ADD COLUMN jwt_audience TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL UNIQUE,               -- shown in the dashboard so keys can be told apart
+    key_hash TEXT NOT NULL UNIQUE,             -- sha256 of the full key, the key itself is never stored
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_by BYTEA REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_api_keys_project_id ON api_keys(project_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS api_keys;
+
+-- fail closed, endpoints that needed a credential keep needing one
+UPDATE endpoints SET scope = 'authn' WHERE scope IN ('api_key', 'jwt');
+
+ALTER TABLE endpoints DROP CONSTRAINT IF EXISTS endpoints_scope_check;
+ALTER TABLE endpoints
+    ADD CONSTRAINT endpoints_scope_check CHECK (scope IN ('public', 'authn')),
+    DROP COLUMN IF EXISTS jwt_audience,
+    DROP COLUMN IF EXISTS jwt_issuer,
+    DROP COLUMN IF EXISTS jwt_jwks,
+    DROP COLUMN IF EXISTS jwt_secret,
+    DROP COLUMN IF EXISTS api_key_scope;
+-- +goose StatementEnd
@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+-- environments name branches of the project repo. Every project has a default one on
+-- main, it's where a project starts and can't be removed
+CREATE TABLE project_environments (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name TEXT NOT NULL CHECK (name ~ '^[a-z0-9][a-z0-9-]*$'),
+    branch TEXT NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (project_id, name),
+    UNIQUE (project_id, branch)
+);
+
+CREATE UNIQUE INDEX idx_project_environments_default ON project_environments(project_id) WHERE is_default;
+
+INSERT INTO project_environments (project_id, name, branch, is_default)
+SELECT id, 'production', 'main', true FROM projects;
+
+-- a key set for an environment overrides the project wide value, which has environment ''
+ALTER TABLE project_configs
+    ADD COLUMN environment TEXT NOT NULL DEFAULT '',
+    DROP CONSTRAINT project_configs_pkey,
+    ADD PRIMARY KEY (project_id, environment, key);
+
+ALTER TABLE project_config_history
+    ADD COLUMN environment TEXT NOT NULL DEFAULT '',
+    DROP CONSTRAINT project_config_history_project_id_key_version_key,
+    ADD CONSTRAINT project_config_history_project_id_environment_key_version_key
+        UNIQUE (project_id, environment, key, version);
+
+DROP INDEX IF EXISTS idx_project_config_history_key;
+CREATE INDEX idx_project_config_history_key ON project_config_history(project_id, environment, key);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM project_config_history WHERE environment <> '';
+DELETE FROM project_configs WHERE environment <> '';
+
+DROP INDEX IF EXISTS idx_project_config_history_key;
+CREATE INDEX idx_project_config_history_key ON project_config_history(project_id, key);
+
+ALTER TABLE project_config_history
+    DROP CONSTRAINT project_config_history_project_id_environment_key_version_key,
+    DROP COLUMN environment,
+    ADD CONSTRAINT project_config_history_project_id_key_version_key UNIQUE (project_id, key, version);
+
+ALTER TABLE project_configs
+    DROP CONSTRAINT project_configs_pkey,
+    DROP COLUMN environment,
+    ADD PRIMARY KEY (project_id, key);
+
+DROP TABLE IF EXISTS project_environments;
+-- +goose StatementEnd
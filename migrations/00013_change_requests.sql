@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- a protected project doesn't take portal saves on its environment branches, they go to
+-- a change branch per user that's merged through a pull request on the vcs
+ALTER TABLE projects ADD COLUMN protected BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE change_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    environment TEXT NOT NULL,
+    branch TEXT NOT NULL,                      -- holds the changes
+    base_branch TEXT NOT NULL,                 -- the environment's branch they merge into
+    author BYTEA NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- draft until the pull request is opened
+    state TEXT NOT NULL DEFAULT 'draft' CHECK (state IN ('draft', 'open', 'merged', 'closed')),
+    title TEXT NOT NULL DEFAULT '',
+    number BIGINT,                             -- pull request number on the vcs
+    url TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    merged_at TIMESTAMPTZ,
+    UNIQUE (project_id, branch)
+);
+
+-- a user has one change in progress per environment, saves keep adding to it
+CREATE UNIQUE INDEX idx_change_requests_active ON change_requests(project_id, environment, author)
+    WHERE state IN ('draft', 'open');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS change_requests;
+ALTER TABLE projects DROP COLUMN IF EXISTS protected;
+-- +goose StatementEnd
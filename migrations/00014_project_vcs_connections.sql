@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- where a project's repo lives when it isn't under the deployment's VCS_* settings,
+-- credentials are AES-GCM sealed with VCS_CREDENTIALS_KEY
+CREATE TABLE project_vcs_connections (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    vendor TEXT NOT NULL CHECK (vendor IN ('github', 'gitea')),
+    base_url TEXT NOT NULL,
+    owner TEXT NOT NULL,                       -- user or org holding the repo
+    repo_name TEXT NOT NULL,
+    auth_mode TEXT NOT NULL CHECK (auth_mode IN ('token', 'ssh')),
+    token BYTEA NOT NULL,                      -- sealed api token, git uses it too in token mode
+    ssh_key BYTEA,                             -- sealed PEM private key for ssh mode
+    ssh_key_password BYTEA,                    -- sealed
+    updated_by BYTEA REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS project_vcs_connections;
+-- +goose StatementEnd
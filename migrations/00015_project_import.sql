@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- imported repos keep their functions wherever they already were, empty is the repo root
+ALTER TABLE projects ADD COLUMN functions_root TEXT NOT NULL DEFAULT 'functions';
+-- a connection without a token borrows the deployment's credentials, for imports of
+-- another repo on the deployment's forge
+ALTER TABLE project_vcs_connections ALTER COLUMN token DROP NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM project_vcs_connections WHERE token IS NULL;
+ALTER TABLE project_vcs_connections ALTER COLUMN token SET NOT NULL;
+ALTER TABLE projects DROP COLUMN IF EXISTS functions_root;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- git blob id of the function's entry file as of the last sync, a file that moved keeps
+-- it and lets sync carry the row over instead of dropping it with its endpoints
+ALTER TABLE functions ADD COLUMN content_hash TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE functions DROP COLUMN IF EXISTS content_hash;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+-- function creates and deletes touch both the repo and the functions table, each is logged
+-- here before either is touched so a push that fails can be retried or undone
+CREATE TABLE function_operations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    function_id UUID REFERENCES functions(id) ON DELETE SET NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('create', 'delete')),
+    branch TEXT NOT NULL,                      -- the clone the push goes to
+    function_path TEXT NOT NULL,
+    edits JSONB NOT NULL,                      -- files the push writes or removes
+    commit_message TEXT NOT NULL,
+    author_name TEXT NOT NULL DEFAULT '',
+    author_email TEXT NOT NULL DEFAULT '',
+    -- pending until the push and the table both hold the change, compensated when it was undone
+    state TEXT NOT NULL DEFAULT 'pending' CHECK (state IN ('pending', 'done', 'compensated')),
+    attempts INT NOT NULL DEFAULT 0,           -- failed pushes so far
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_by BYTEA REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_function_operations_due ON function_operations(next_attempt_at) WHERE state = 'pending';
+CREATE INDEX idx_function_operations_project ON function_operations(project_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS function_operations;
+-- +goose StatementEnd
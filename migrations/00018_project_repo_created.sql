@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- only a repo the portal created is the portal's to delete along with the project,
+-- imported ones and the repos of projects made before this are left alone
+ALTER TABLE projects ADD COLUMN repo_created_by_portal BOOLEAN NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE projects DROP COLUMN IF EXISTS repo_created_by_portal;
+-- +goose StatementEnd
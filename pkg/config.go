@@ -8,23 +8,41 @@ import (
 )
 
 type Settings struct {
-	Port                    int    `env:"LISTEN_PORT" default:"3000"`
-	Fqdn                    string `env:"FQDN" default:"localhost"`
-	DatabaseUrl             string `env:"DATABASE_URL,required"`
-	DatabaseSchema          string `env:"DATABASE_SCHEMA" default:"lwsportal"`
-	DatabaseConnTimeout     string `env:"DATABASE_CONN_TIMEOUT" default:"10s"`
-	DatabaseMaxConns        int32  `env:"DATABASE_MAX_CONNS" default:"20"`
-	DatabaseMinConns        int32  `env:"DATABASE_MIN_CONNS" default:"5"`
-	DatabaseMaxConnLifetime string `env:"DATABASE_MAX_CONN_LIFETIME" default:"1h"`
-	DatabaseMaxConnIdleTime string `env:"DATABASE_MAX_CONN_IDLETIME" default:"10m"`
-	SessionExpiry           string `env:"SESSION_EXPIRY" default:"1h"`
-	VcsAuthMode             string `env:"VCS_AUTH_MODE" default:"ssh"`
-	VcsPrivKeyPath          string `env:"VCS_PRIVATE_KEY_PATH" default:"/app/.ssh/privkey.pem"`
-	VcsPrivKeyPassword      string `env:"VCS_PRIVATE_KEY_PASSWORD"`
-	VcsToken                string `env:"VCS_TOKEN"`
-	VcsUser                 string `env:"VCS_USER"`
-	VcsVendor               string `env:"VCS_VENDOR"`
-	VcsBaseUrl              string `env:"VCS_BASE_URL"`
+	Port                     int    `env:"LISTEN_PORT" default:"3000"`
+	Fqdn                     string `env:"FQDN" default:"localhost"`
+	DatabaseUrl              string `env:"DATABASE_URL,required"`
+	DatabaseSchema           string `env:"DATABASE_SCHEMA" default:"lwsportal"`
+	DatabaseConnTimeout      string `env:"DATABASE_CONN_TIMEOUT" default:"10s"`
+	DatabaseMaxConns         int32  `env:"DATABASE_MAX_CONNS" default:"20"`
+	DatabaseMinConns         int32  `env:"DATABASE_MIN_CONNS" default:"5"`
+	DatabaseMaxConnLifetime  string `env:"DATABASE_MAX_CONN_LIFETIME" default:"1h"`
+	DatabaseMaxConnIdleTime  string `env:"DATABASE_MAX_CONN_IDLETIME" default:"10m"`
+	SessionExpiry            string `env:"SESSION_EXPIRY" default:"1h"`
+	VcsAuthMode              string `env:"VCS_AUTH_MODE" default:"ssh"`
+	VcsPrivKeyPath           string `env:"VCS_PRIVATE_KEY_PATH" default:"/app/.ssh/privkey.pem"`
+	VcsPrivKeyPassword       string `env:"VCS_PRIVATE_KEY_PASSWORD"`
+	VcsToken                 string `env:"VCS_TOKEN"`
+	VcsUser                  string `env:"VCS_USER"`
+	VcsVendor                string `env:"VCS_VENDOR"`
+	VcsBaseUrl               string `env:"VCS_BASE_URL"`
+	VcsCredentialsKey        string `env:"VCS_CREDENTIALS_KEY"`
+	VcsStorage               string `env:"VCS_STORAGE" default:"memory"`
+	VcsCacheDir              string `env:"VCS_CACHE_DIR" default:"/app/cache/repos"`
+	VcsCacheIdle             string `env:"VCS_CACHE_IDLE" default:"168h"`
+	RuntimeTimeout           string `env:"RUNTIME_TIMEOUT" default:"5s"`
+	RuntimeMemoryMB          int    `env:"RUNTIME_MEMORY_MB" default:"128"`
+	RuntimePython            string `env:"RUNTIME_PYTHON" default:"python3"`
+	RuntimeGo                string `env:"RUNTIME_GO" default:"go"`
+	RateLimitStore           string `env:"RATE_LIMIT_STORE" default:"memory"`
+	TrustedProxies           string `env:"TRUSTED_PROXIES"`
+	RepoCacheMB              int64  `env:"REPO_CACHE_MB" default:"256"`
+	RepoSyncTTL              string `env:"REPO_SYNC_TTL" default:"5m"`
+	CommitterName            string `env:"COMMITTER_NAME" default:"LiteWebServices Portal"`
+	CommitterEmail           string `env:"COMMITTER_EMAIL" default:"noreply@example.com"`
+	CommitEmailDomain        string `env:"COMMIT_EMAIL_DOMAIN"`
+	CommitSigningFormat      string `env:"COMMIT_SIGNING_FORMAT" default:"openpgp"`
+	CommitSigningKeyPath     string `env:"COMMIT_SIGNING_KEY_PATH"`
+	CommitSigningKeyPassword string `env:"COMMIT_SIGNING_KEY_PASSWORD"`
 }
 
 var (
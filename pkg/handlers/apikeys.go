@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/apikey"
+	"github.com/ashupednekar/litewebservices-portal/internal/apikey/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/pkg/state"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type APIKeyHandlers struct {
+	state *state.AppState
+}
+
+func NewAPIKeyHandlers(s *state.AppState) *APIKeyHandlers {
+	return &APIKeyHandlers{state: s}
+}
+
+func timeOrNil(t pgtype.Timestamptz) any {
+	if !t.Valid {
+		return nil
+	}
+	return t.Time
+}
+
+func apiKeyJSON(k adaptors.ApiKey) gin.H {
+	return gin.H{
+		"id":           hex.EncodeToString(k.ID.Bytes[:]),
+		"name":         k.Name,
+		"prefix":       k.Prefix,
+		"scopes":       k.Scopes,
+		"created_at":   k.CreatedAt.Time,
+		"expires_at":   timeOrNil(k.ExpiresAt),
+		"last_used_at": timeOrNil(k.LastUsedAt),
+	}
+}
+
+func (h *APIKeyHandlers) ListAPIKeys(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+
+	keys, err := adaptors.New(h.state.DBPool).ListAPIKeys(c.Request.Context(), projectUUID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(keys))
+	for _, k := range keys {
+		item := apiKeyJSON(adaptors.ApiKey{
+			ID:         k.ID,
+			Name:       k.Name,
+			Prefix:     k.Prefix,
+			Scopes:     k.Scopes,
+			CreatedAt:  k.CreatedAt,
+			ExpiresAt:  k.ExpiresAt,
+			LastUsedAt: k.LastUsedAt,
+		})
+		item["created_by"] = k.CreatedByName.String
+		out = append(out, item)
+	}
+	c.JSON(200, out)
+}
+
+// CreateAPIKey returns the key once, only its hash is kept
+func (h *APIKeyHandlers) CreateAPIKey(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	userID := c.MustGet("userID").([]byte)
+
+	var req struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 64 {
+		c.JSON(400, gin.H{"error": "name is required and at most 64 characters"})
+		return
+	}
+	scopes, ok := apikey.NormalizeScopes(req.Scopes)
+	if !ok {
+		c.JSON(400, gin.H{"error": "invalid scope, use lowercase letters, digits and _.:*-"})
+		return
+	}
+	var expiresAt pgtype.Timestamptz
+	if req.ExpiresInDays < 0 {
+		c.JSON(400, gin.H{"error": "expires_in_days must be positive"})
+		return
+	}
+	if req.ExpiresInDays > 0 {
+		ttl := min(time.Duration(req.ExpiresInDays)*24*time.Hour, apikey.MaxExpiry)
+		expiresAt = pgtype.Timestamptz{Time: time.Now().Add(ttl), Valid: true}
+	}
+
+	raw, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to generate key"})
+		return
+	}
+
+	key, err := adaptors.New(h.state.DBPool).CreateAPIKey(c.Request.Context(), adaptors.CreateAPIKeyParams{
+		ProjectID: projectUUID,
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    scopes,
+		CreatedBy: userID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] DB CreateAPIKey: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	out := apiKeyJSON(key)
+	out["key"] = raw
+	c.JSON(201, out)
+}
+
+func (h *APIKeyHandlers) DeleteAPIKey(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	keyID, err := parseHexUUID(c.Param("keyID"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid key id"})
+		return
+	}
+
+	n, err := adaptors.New(h.state.DBPool).DeleteAPIKey(c.Request.Context(), adaptors.DeleteAPIKeyParams{
+		ID:        keyID,
+		ProjectID: projectUUID,
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] DB DeleteAPIKey: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	if n == 0 {
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "deleted"})
+}
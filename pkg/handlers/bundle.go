@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/function"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
+	"github.com/gin-gonic/gin"
+)
+
+// bundleFileRequest is a file of a bundle, path relative to the bundle directory
+type bundleFileRequest struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+	// ETag is the blob hash the edit started from, empty for a new file
+	ETag string `json:"etag"`
+}
+
+// functionBundle returns the directory of the bundle entry belongs to, false when the
+// function is a single file
+func functionBundle(r *repo.GitRepo, entry string) (string, bool) {
+	dir := path.Dir(entry)
+	if strings.Count(dir, "/") != 2 {
+		return "", false
+	}
+	if _, err := r.Fs.Stat(path.Join(dir, function.ManifestFile)); err != nil {
+		return "", false
+	}
+	return dir, true
+}
+
+// bundlePath validates a file path sent for a bundle and joins it onto dir. The
+// manifest is written by the portal only
+func bundlePath(dir, p string) (string, error) {
+	clean, err := function.CleanPath(p)
+	if err != nil {
+		return "", err
+	}
+	if clean == function.ManifestFile {
+		return "", fmt.Errorf("%s is managed by the portal", function.ManifestFile)
+	}
+	return path.Join(dir, clean), nil
+}
+
+// newFunction lays out the files of a create request, a single source file or a
+// bundle, returning the entry path and the files to commit, none of which may exist yet
+func newFunction(root string, req createFunctionRequest) (string, []fileEdit, error) {
+	if err := function.ValidName(req.Name); err != nil {
+		return "", nil, err
+	}
+	if req.Bundle || len(req.Files) > 0 {
+		return newBundle(root, req)
+	}
+	code := req.Code
+	if code == "" {
+		code = "// TODO: implement function\n"
+	}
+	entry := function.SourcePath(root, req.Language, req.Name)
+	return entry, []fileEdit{{Path: entry, Content: []byte(code)}}, nil
+}
+
+// newBundle lays out a bundle for a create request whose name newFunction checked
+func newBundle(root string, req createFunctionRequest) (string, []fileEdit, error) {
+	dir := function.BundleDir(root, req.Language, req.Name)
+	m := function.NewManifest(req.Name, req.Language)
+	manifest, err := m.YAML()
+	if err != nil {
+		return "", nil, err
+	}
+	entry := path.Join(dir, m.Entry)
+
+	code := req.Code
+	if code == "" {
+		code = "// TODO: implement function\n"
+	}
+	edits := []fileEdit{
+		{Path: path.Join(dir, function.ManifestFile), Content: manifest},
+		{Path: entry, Content: []byte(code)},
+	}
+	seen := map[string]bool{entry: true}
+	for _, f := range req.Files {
+		p, err := bundlePath(dir, f.Path)
+		if err != nil {
+			return "", nil, err
+		}
+		if seen[p] {
+			return "", nil, fmt.Errorf("file %s given twice", f.Path)
+		}
+		seen[p] = true
+		edits = append(edits, fileEdit{Path: p, Content: []byte(f.Content)})
+	}
+	return entry, edits, nil
+}
+
+// GetFunctionFiles returns every file of a function with its etag. A single file
+// function answers with just its source
+func (h *FunctionHandlers) GetFunctionFiles(c *gin.Context) {
+	f, r, ok := h.projectFunction(c)
+	if !ok {
+		return
+	}
+
+	dir, bundle := functionBundle(r, f.Path)
+	paths := []string{f.Path}
+	if bundle {
+		var err error
+		if paths, err = r.ListFiles(dir); err != nil {
+			fmt.Printf("[ERROR] listing bundle %s failed: %v\n", dir, err)
+			c.JSON(500, gin.H{"error": "error reading function files"})
+			return
+		}
+	} else {
+		dir = path.Dir(f.Path)
+	}
+
+	files := make([]gin.H, 0, len(paths))
+	for _, p := range paths {
+		data, err := r.ReadFile(p)
+		if err != nil {
+			c.JSON(404, gin.H{"error": "function not found in repo"})
+			return
+		}
+		files = append(files, gin.H{
+			"path":    strings.TrimPrefix(p, dir+"/"),
+			"content": string(data),
+			"etag":    repo.BlobHash(data),
+		})
+	}
+	c.JSON(200, gin.H{
+		"bundle": bundle,
+		"dir":    dir,
+		"entry":  strings.TrimPrefix(f.Path, dir+"/"),
+		"files":  files,
+	})
+}
+
+type updateFilesRequest struct {
+	Files  []bundleFileRequest `json:"files"`
+	Delete []bundleFileRequest `json:"delete"`
+	// Force skips the etag checks and overwrites whatever is there
+	Force   bool   `json:"force"`
+	Message string `json:"message"`
+}
+
+// UpdateFunctionFiles writes and deletes files of a bundle in a single commit. Each
+// file carries the etag it was loaded with, if any of them moved on nothing is
+// written and the 409 lists the stale files with a merge for each
+func (h *FunctionHandlers) UpdateFunctionFiles(c *gin.Context) {
+	var req updateFilesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	if len(req.Files) == 0 && len(req.Delete) == 0 {
+		c.JSON(400, gin.H{"error": "nothing to save"})
+		return
+	}
+	f, r, ok := h.projectFunction(c)
+	if !ok {
+		return
+	}
+	dir, bundle := functionBundle(r, f.Path)
+	if !bundle {
+		c.JSON(400, gin.H{"error": "function is a single file, only bundles hold several files"})
+		return
+	}
+
+	var edits []fileEdit
+	seen := map[string]bool{}
+	add := func(file bundleFileRequest, remove bool) error {
+		p, err := bundlePath(dir, file.Path)
+		if err != nil {
+			return err
+		}
+		if seen[p] {
+			return fmt.Errorf("file %s given twice", file.Path)
+		}
+		if remove && p == f.Path {
+			return fmt.Errorf("the entry file can't be deleted")
+		}
+		seen[p] = true
+		base := file.ETag
+		if req.Force {
+			base = "*"
+		}
+		edits = append(edits, fileEdit{Path: p, Content: []byte(file.Content), Remove: remove, Base: base})
+		return nil
+	}
+	for _, file := range req.Files {
+		if err := add(file, false); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	for _, file := range req.Delete {
+		if err := add(file, true); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ch := commitChange(c, h.state.DBPool, req.Message)
+	if ch.Message == "" {
+		ch.Message = "update " + dir
+	}
+	stale, err := commitEdits(r, edits, ch)
+	if err != nil {
+		saveFailed(c, err)
+		return
+	}
+	if len(stale) > 0 {
+		conflicts := make([]gin.H, 0, len(stale))
+		for _, s := range stale {
+			fc := fileConflict(r, s)
+			fc["path"] = strings.TrimPrefix(s.Path, dir+"/")
+			fc["deleted"] = !s.Exists
+			conflicts = append(conflicts, fc)
+		}
+		c.JSON(409, gin.H{"error": "files changed since they were loaded", "files": conflicts})
+		return
+	}
+
+	saved := make([]gin.H, 0, len(edits))
+	for _, e := range edits {
+		file := gin.H{"path": strings.TrimPrefix(e.Path, dir+"/"), "deleted": e.Remove}
+		if !e.Remove {
+			file["etag"] = repo.BlobHash(e.Content)
+		}
+		saved = append(saved, file)
+	}
+	resp := gin.H{"status": "saved", "files": saved}
+	if head, err := r.Head(); err == nil {
+		resp["commit"] = head.String()
+	}
+	c.JSON(200, resp)
+}
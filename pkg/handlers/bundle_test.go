@@ -0,0 +1,21 @@
+package handlers
+
+import "testing"
+
+func TestNewFunctionRejectsTraversal(t *testing.T) {
+	for _, req := range []createFunctionRequest{
+		{Name: "../x", Language: "lua"},
+		{Name: "..", Language: "lua"},
+		{Name: "../x", Language: "python", Bundle: true},
+		{Name: "..", Language: "python", Files: []bundleFileRequest{{Path: "util.py"}}},
+	} {
+		if p, _, err := newFunction("functions", req); err == nil {
+			t.Errorf("newFunction(%q, bundle=%v) = %s, want an error", req.Name, req.Bundle, p)
+		}
+	}
+
+	p, edits, err := newFunction("functions", createFunctionRequest{Name: "hello", Language: "lua"})
+	if err != nil || p != "functions/lua/hello.lua" || len(edits) != 1 {
+		t.Errorf("newFunction(hello) = %s, %v, %v", p, edits, err)
+	}
+}
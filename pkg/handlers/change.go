@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/project"
+	projectadaptors "github.com/ashupednekar/litewebservices-portal/internal/project/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/vendors"
+	"github.com/ashupednekar/litewebservices-portal/pkg/state"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Protected projects take portal saves on a change branch per user and environment,
+// ProjectMiddleware points the request's clone at it. The change is reviewed as a pull
+// request on the vcs and merged from here once approved.
+
+type ChangeHandlers struct {
+	state *state.AppState
+}
+
+func NewChangeHandlers(s *state.AppState) *ChangeHandlers {
+	return &ChangeHandlers{state: s}
+}
+
+func changeJSON(cr projectadaptors.ChangeRequest, author string) gin.H {
+	out := gin.H{
+		"id":          hex.EncodeToString(cr.ID.Bytes[:]),
+		"environment": cr.Environment,
+		"branch":      cr.Branch,
+		"base":        cr.BaseBranch,
+		"author":      author,
+		"state":       cr.State,
+		"title":       cr.Title,
+		"created_at":  cr.CreatedAt.Time,
+		"updated_at":  cr.UpdatedAt.Time,
+	}
+	if cr.Number.Valid {
+		out["number"] = cr.Number.Int64
+		out["url"] = cr.Url.String
+	}
+	if cr.MergedAt.Valid {
+		out["merged_at"] = cr.MergedAt.Time
+	}
+	return out
+}
+
+func pullRequestJSON(pr *vendors.PullRequest) gin.H {
+	return gin.H{
+		"state":             pr.State,
+		"merged":            pr.Merged,
+		"mergeable":         pr.Mergeable,
+		"approvals":         pr.Approvals,
+		"changes_requested": pr.ChangesRequested,
+		"blocked_by":        mergeBlocker(pr),
+	}
+}
+
+// mergeBlocker is why the portal won't merge pr yet, empty once it's approved without
+// outstanding change requests and merges cleanly
+func mergeBlocker(pr *vendors.PullRequest) string {
+	switch {
+	case pr.Merged:
+		return "pull request is already merged"
+	case pr.State != "open":
+		return "pull request is " + pr.State
+	case pr.ChangesRequested > 0:
+		return "a reviewer requested changes"
+	case pr.Approvals == 0:
+		return "waiting for an approving review"
+	case !pr.Mergeable:
+		return "pull request doesn't merge cleanly into " + pr.Base
+	}
+	return ""
+}
+
+func (h *ChangeHandlers) ListChanges(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+
+	rows, err := projectadaptors.New(h.state.DBPool).ListChangeRequests(c.Request.Context(), projectUUID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	out := make([]gin.H, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, changeJSON(r.ChangeRequest, r.AuthorName))
+	}
+	c.JSON(200, out)
+}
+
+// GetChange returns a change with the live status of its pull request, catching up on
+// pull requests merged or closed on the vcs
+func (h *ChangeHandlers) GetChange(c *gin.Context) {
+	row, ok := h.change(c)
+	if !ok {
+		return
+	}
+	cr := row.ChangeRequest
+	if cr.State != project.ChangeOpen {
+		c.JSON(200, changeJSON(cr, row.AuthorName))
+		return
+	}
+
+	vcs, conn, ok := projectVcs(c, h.state, c.MustGet("projectName").(string))
+	if !ok {
+		return
+	}
+	pr, err := vcs.GetPullRequest(c.Request.Context(), conn.Owner, conn.Repo, cr.Number.Int64)
+	if err != nil {
+		fmt.Printf("[ERROR] VCS GetPullRequest: %v\n", err)
+		c.JSON(502, gin.H{"error": "failed to load the pull request"})
+		return
+	}
+	if cr, err = h.catchUp(c, cr, pr); err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	out := changeJSON(cr, row.AuthorName)
+	out["pull_request"] = pullRequestJSON(pr)
+	c.JSON(200, out)
+}
+
+// OpenChange opens the pull request for the user's change in the selected environment
+func (h *ChangeHandlers) OpenChange(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	projectName := c.MustGet("projectName").(string)
+	env := selectedEnvironment(c)
+
+	var req struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+
+	q := projectadaptors.New(h.state.DBPool)
+	cr, err := q.GetActiveChangeRequest(c.Request.Context(), projectadaptors.GetActiveChangeRequestParams{
+		ProjectID:   projectUUID,
+		Environment: env.Name,
+		Author:      c.MustGet("userID").([]byte),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(404, gin.H{"error": fmt.Sprintf("no changes in progress on %s, save a function first", env.Name)})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	if cr.State == project.ChangeOpen {
+		c.JSON(409, gin.H{"error": "the pull request is already open", "change": changeJSON(cr, c.GetString("userName"))})
+		return
+	}
+
+	if req.Title == "" {
+		req.Title = fmt.Sprintf("Changes to %s from %s", env.Name, c.GetString("userName"))
+	}
+	vcs, conn, ok := projectVcs(c, h.state, projectName)
+	if !ok {
+		return
+	}
+	pr, err := vcs.CreatePullRequest(c.Request.Context(), conn.Owner, conn.Repo, vendors.PullRequestOptions{
+		Title: req.Title,
+		Body:  req.Body,
+		Head:  cr.Branch,
+		Base:  cr.BaseBranch,
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] VCS CreatePullRequest: %v\n", err)
+		c.JSON(502, gin.H{"error": fmt.Sprintf("failed to open pull request: %v", err)})
+		return
+	}
+
+	cr, err = q.OpenChangeRequest(c.Request.Context(), projectadaptors.OpenChangeRequestParams{
+		ID:     cr.ID,
+		Title:  req.Title,
+		Number: pgtype.Int8{Int64: pr.Number, Valid: true},
+		Url:    pgtype.Text{String: pr.HTMLURL, Valid: true},
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] DB OpenChangeRequest: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	c.JSON(201, changeJSON(cr, c.GetString("userName")))
+}
+
+// MergeChange merges an approved pull request and brings the environment up to it
+func (h *ChangeHandlers) MergeChange(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	projectName := c.MustGet("projectName").(string)
+	userID := c.MustGet("userID").([]byte)
+
+	row, ok := h.change(c)
+	if !ok {
+		return
+	}
+	cr := row.ChangeRequest
+	if cr.State != project.ChangeOpen {
+		c.JSON(409, gin.H{"error": fmt.Sprintf("change is %s, only open changes can be merged", cr.State)})
+		return
+	}
+
+	vcs, conn, ok := projectVcs(c, h.state, projectName)
+	if !ok {
+		return
+	}
+	pr, err := vcs.GetPullRequest(c.Request.Context(), conn.Owner, conn.Repo, cr.Number.Int64)
+	if err != nil {
+		fmt.Printf("[ERROR] VCS GetPullRequest: %v\n", err)
+		c.JSON(502, gin.H{"error": "failed to load the pull request"})
+		return
+	}
+	if reason := mergeBlocker(pr); reason != "" {
+		if cr, err = h.catchUp(c, cr, pr); err != nil {
+			c.JSON(500, gin.H{"error": "database error"})
+			return
+		}
+		c.JSON(409, gin.H{"error": reason, "change": changeJSON(cr, row.AuthorName), "pull_request": pullRequestJSON(pr)})
+		return
+	}
+
+	err = vcs.MergePullRequest(c.Request.Context(), conn.Owner, conn.Repo, pr.Number, vendors.MergeOptions{Title: cr.Title, SHA: pr.HeadSHA})
+	if errors.Is(err, vendors.ErrNotMergeable) {
+		c.JSON(409, gin.H{"error": "the vcs refused the merge, check the pull request"})
+		return
+	}
+	if err != nil {
+		fmt.Printf("[ERROR] VCS MergePullRequest: %v\n", err)
+		c.JSON(502, gin.H{"error": "failed to merge the pull request"})
+		return
+	}
+
+	cr, err = projectadaptors.New(h.state.DBPool).SetChangeRequestState(c.Request.Context(), projectadaptors.SetChangeRequestStateParams{
+		ID:    cr.ID,
+		State: project.ChangeMerged,
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] DB SetChangeRequestState: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	// the merge happened on the vcs, pull the environment's branch right away
+	base := repo.Ref{Project: projectName, Branch: cr.BaseBranch}
+	h.state.Repos.MarkStale(base)
+	err = h.state.Repos.Sync(base, func(r *repo.GitRepo) error {
+		return SyncRepoFunctionsToDb(c.Request.Context(), h.state.DBPool, projectUUID, r, userID)
+	})
+	if err != nil {
+		fmt.Printf("[WARN] sync after merging %s failed: %v\n", cr.Branch, err)
+	}
+	c.JSON(200, changeJSON(cr, row.AuthorName))
+}
+
+// catchUp records a pull request merged or closed on the vcs, so the author's next save
+// starts a new change
+func (h *ChangeHandlers) catchUp(c *gin.Context, cr projectadaptors.ChangeRequest, pr *vendors.PullRequest) (projectadaptors.ChangeRequest, error) {
+	state := cr.State
+	switch {
+	case pr.Merged:
+		state = project.ChangeMerged
+	case pr.State == "closed":
+		state = project.ChangeClosed
+	}
+	if state == cr.State {
+		return cr, nil
+	}
+	return projectadaptors.New(h.state.DBPool).SetChangeRequestState(c.Request.Context(), projectadaptors.SetChangeRequestStateParams{
+		ID:    cr.ID,
+		State: state,
+	})
+}
+
+// change loads the :changeID change of the request's project, answering 404 itself
+func (h *ChangeHandlers) change(c *gin.Context) (projectadaptors.GetChangeRequestRow, bool) {
+	id, err := parseHexUUID(c.Param("changeID"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid change id"})
+		return projectadaptors.GetChangeRequestRow{}, false
+	}
+	row, err := projectadaptors.New(h.state.DBPool).GetChangeRequest(c.Request.Context(), projectadaptors.GetChangeRequestParams{
+		ProjectID: c.MustGet("projectUUID").(pgtype.UUID),
+		ID:        id,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(404, gin.H{"error": "change not found"})
+		return row, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return row, false
+	}
+	return row, true
+}
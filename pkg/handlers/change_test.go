@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/project/vendors"
+)
+
+func TestMergeBlocker(t *testing.T) {
+	approved := vendors.PullRequest{State: "open", Mergeable: true, Approvals: 1, Base: "main"}
+	if got := mergeBlocker(&approved); got != "" {
+		t.Fatalf("approved pull request blocked by %q", got)
+	}
+
+	for name, pr := range map[string]vendors.PullRequest{
+		"unreviewed":        {State: "open", Mergeable: true},
+		"changes requested": {State: "open", Mergeable: true, Approvals: 2, ChangesRequested: 1},
+		"conflicting":       {State: "open", Approvals: 1, Base: "main"},
+		"closed":            {State: "closed", Mergeable: true, Approvals: 1},
+		"merged":            {State: "closed", Merged: true, Approvals: 1},
+	} {
+		if got := mergeBlocker(&pr); got == "" {
+			t.Errorf("%s pull request isn't blocked", name)
+		}
+	}
+}
+
+func TestMergeBlockedByCommitAfterApproval(t *testing.T) {
+	head := "c1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/repos/acme/shop/pulls/7":
+			json.NewEncoder(w).Encode(map[string]any{
+				"number": 7, "state": "open", "mergeable": true,
+				"head": map[string]any{"ref": "changes/ada", "sha": head},
+				"base": map[string]any{"ref": "main"},
+			})
+		case "/api/v1/repos/acme/shop/pulls/7/reviews":
+			json.NewEncoder(w).Encode([]map[string]any{
+				{"state": "APPROVED", "commit_id": "c1", "user": map[string]any{"login": "grace"}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	vcs := vendors.NewGiteaClient(server.URL, "t")
+
+	pr, err := vcs.GetPullRequest(context.Background(), "acme", "shop", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mergeBlocker(pr); got != "" {
+		t.Fatalf("approved pull request blocked by %q", got)
+	}
+
+	// a save to the change branch after the approval
+	head = "c2"
+	pr, err = vcs.GetPullRequest(context.Background(), "acme", "shop", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mergeBlocker(pr); got == "" {
+		t.Error("pull request merges on an approval of an earlier commit")
+	}
+}
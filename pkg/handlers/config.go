@@ -0,0 +1,448 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/config"
+	configadaptors "github.com/ashupednekar/litewebservices-portal/internal/config/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/project"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
+	"github.com/ashupednekar/litewebservices-portal/pkg/state"
+	"github.com/gin-gonic/gin"
+	"github.com/go-git/go-git/v6"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ConfigHandlers struct {
+	state *state.AppState
+}
+
+func NewConfigHandlers(s *state.AppState) *ConfigHandlers {
+	return &ConfigHandlers{state: s}
+}
+
+type configEntryRequest struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Type   string `json:"type"`
+	Secret bool   `json:"secret"`
+}
+
+type updateConfigRequest struct {
+	Entries []configEntryRequest `json:"entries"`
+	Delete  []string             `json:"delete"`
+	Commit  bool                 `json:"commit"`
+	// Override applies the change to the selected environment instead of the project wide values
+	Override bool `json:"override"`
+}
+
+// configScope is the environment a config change applies to, "" for the project wide
+// values an environment inherits
+func configScope(c *gin.Context, override bool) string {
+	if !override {
+		return ""
+	}
+	return selectedEnvironment(c).Name
+}
+
+func configJSON(c configadaptors.ProjectConfig) gin.H {
+	return gin.H{
+		"key":         c.Key,
+		"value":       config.Mask(c.Value, c.Secret),
+		"type":        c.ValueType,
+		"secret":      c.Secret,
+		"version":     c.Version,
+		"environment": c.Environment,
+		"updated_at":  c.UpdatedAt.Time,
+	}
+}
+
+// GetProjectConfig lists the config the selected environment sees, each key tells whether
+// it's the environment's own or the project wide value
+func (h *ConfigHandlers) GetProjectConfig(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+
+	q := configadaptors.New(h.state.DBPool)
+	entries, err := q.ListResolvedProjectConfig(c.Request.Context(), configadaptors.ListResolvedProjectConfigParams{
+		ProjectID:   projectUUID,
+		Environment: selectedEnvironment(c).Name,
+	})
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, configJSON(e))
+	}
+	c.JSON(200, out)
+}
+
+// UpdateProjectConfig upserts and deletes keys in one transaction, recording a history row per change
+func (h *ConfigHandlers) UpdateProjectConfig(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	userID := c.MustGet("userID").([]byte)
+
+	var req updateConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	if req.Commit && !c.MustGet("projectRole").(project.Role).AtLeast(project.Maintainer) {
+		c.JSON(403, gin.H{"error": "requires maintainer role or higher to commit"})
+		return
+	}
+	env := configScope(c, req.Override)
+
+	tx, err := h.state.DBPool.Begin(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(c.Request.Context())
+	q := configadaptors.New(h.state.DBPool).WithTx(tx)
+
+	keys := make([]string, 0, len(req.Entries)+len(req.Delete))
+	for _, e := range req.Entries {
+		keys = append(keys, e.Key)
+	}
+	if err := lockConfigKeys(c.Request.Context(), q, projectUUID, env, append(keys, req.Delete...)); err != nil {
+		fmt.Printf("[ERROR] config lock failed: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	updated := make([]gin.H, 0, len(req.Entries))
+	for _, e := range req.Entries {
+		if !config.ValidKey(e.Key) {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("invalid key: %q", e.Key)})
+			return
+		}
+		if e.Type == "" {
+			e.Type = config.TypeString
+		}
+
+		value := e.Value
+		// the masked placeholder coming back from the ui means "keep the current secret"
+		if e.Secret && value == config.MaskedValue {
+			current, err := q.GetProjectConfig(c.Request.Context(), configadaptors.GetProjectConfigParams{
+				ProjectID:   projectUUID,
+				Environment: env,
+				Key:         e.Key,
+			})
+			if err != nil {
+				c.JSON(400, gin.H{"error": fmt.Sprintf("no existing value for secret %s", e.Key)})
+				return
+			}
+			value = current.Value
+		}
+
+		value, err = config.Normalize(e.Type, value)
+		if err != nil {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("%s: %s", e.Key, err)})
+			return
+		}
+
+		entry, err := h.upsert(c, q, projectUUID, env, userID, config.Entry{
+			Key:    e.Key,
+			Value:  value,
+			Type:   e.Type,
+			Secret: e.Secret,
+		})
+		if err != nil {
+			fmt.Printf("[ERROR] config upsert failed for %s: %v\n", e.Key, err)
+			c.JSON(500, gin.H{"error": "database error"})
+			return
+		}
+		updated = append(updated, configJSON(entry))
+	}
+
+	for _, key := range req.Delete {
+		if err := h.delete(c, q, projectUUID, env, userID, key); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				c.JSON(404, gin.H{"error": fmt.Sprintf("key not found: %s", key)})
+				return
+			}
+			fmt.Printf("[ERROR] config delete failed for %s: %v\n", key, err)
+			c.JSON(500, gin.H{"error": "database error"})
+			return
+		}
+	}
+
+	if err := tx.Commit(c.Request.Context()); err != nil {
+		c.JSON(500, gin.H{"error": "failed to commit transaction"})
+		return
+	}
+
+	resp := gin.H{"updated": updated, "deleted": req.Delete, "environment": env}
+	if req.Commit {
+		if err := h.commitToRepo(c, projectUUID); err != nil {
+			fmt.Printf("[ERROR] config commit failed: %v\n", err)
+			c.JSON(500, gin.H{"error": "config saved but commit to repo failed"})
+			return
+		}
+		resp["committed"] = config.RepoPath
+	}
+	c.JSON(200, resp)
+}
+
+// lockConfigKeys holds keys for the rest of q's transaction, upsert and delete expect it
+// so versions read as the latest stay the latest. Keys are taken in order, two edits of
+// the same keys can't each hold one the other waits for
+func lockConfigKeys(ctx context.Context, q *configadaptors.Queries, projectUUID pgtype.UUID, env string, keys []string) error {
+	keys = slices.Clone(keys)
+	slices.Sort(keys)
+	for _, key := range slices.Compact(keys) {
+		err := q.LockConfigKey(ctx, configadaptors.LockConfigKeyParams{ProjectID: projectUUID, Environment: env, Key: key})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *ConfigHandlers) upsert(c *gin.Context, q *configadaptors.Queries, projectUUID pgtype.UUID, env string, userID []byte, e config.Entry) (configadaptors.ProjectConfig, error) {
+	latest, err := q.GetLatestConfigVersion(c.Request.Context(), configadaptors.GetLatestConfigVersionParams{
+		ProjectID:   projectUUID,
+		Environment: env,
+		Key:         e.Key,
+	})
+	if err != nil {
+		return configadaptors.ProjectConfig{}, err
+	}
+	version := latest + 1
+
+	entry, err := q.UpsertProjectConfig(c.Request.Context(), configadaptors.UpsertProjectConfigParams{
+		ProjectID:   projectUUID,
+		Environment: env,
+		Key:         e.Key,
+		Value:       e.Value,
+		ValueType:   e.Type,
+		Secret:      e.Secret,
+		Version:     version,
+		UpdatedBy:   userID,
+	})
+	if err != nil {
+		return configadaptors.ProjectConfig{}, err
+	}
+
+	err = q.InsertConfigHistory(c.Request.Context(), configadaptors.InsertConfigHistoryParams{
+		ProjectID:   projectUUID,
+		Environment: env,
+		Key:         e.Key,
+		Value:       e.Value,
+		ValueType:   e.Type,
+		Secret:      e.Secret,
+		Version:     version,
+		Deleted:     false,
+		ChangedBy:   userID,
+	})
+	return entry, err
+}
+
+func (h *ConfigHandlers) delete(c *gin.Context, q *configadaptors.Queries, projectUUID pgtype.UUID, env string, userID []byte, key string) error {
+	current, err := q.GetProjectConfig(c.Request.Context(), configadaptors.GetProjectConfigParams{
+		ProjectID:   projectUUID,
+		Environment: env,
+		Key:         key,
+	})
+	if err != nil {
+		return err
+	}
+	latest, err := q.GetLatestConfigVersion(c.Request.Context(), configadaptors.GetLatestConfigVersionParams{
+		ProjectID:   projectUUID,
+		Environment: env,
+		Key:         key,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := q.DeleteProjectConfig(c.Request.Context(), configadaptors.DeleteProjectConfigParams{
+		ProjectID:   projectUUID,
+		Environment: env,
+		Key:         key,
+	}); err != nil {
+		return err
+	}
+
+	return q.InsertConfigHistory(c.Request.Context(), configadaptors.InsertConfigHistoryParams{
+		ProjectID:   projectUUID,
+		Environment: env,
+		Key:         key,
+		Value:       current.Value,
+		ValueType:   current.ValueType,
+		Secret:      current.Secret,
+		Version:     latest + 1,
+		Deleted:     true,
+		ChangedBy:   userID,
+	})
+}
+
+// ConfigHistory lists the versions of a project wide key, or with ?override=true of the
+// selected environment's own value
+func (h *ConfigHandlers) ConfigHistory(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	key := c.Query("key")
+	if key == "" {
+		c.JSON(400, gin.H{"error": "key is required"})
+		return
+	}
+
+	q := configadaptors.New(h.state.DBPool)
+	rows, err := q.ListConfigHistory(c.Request.Context(), configadaptors.ListConfigHistoryParams{
+		ProjectID:   projectUUID,
+		Environment: configScope(c, c.Query("override") == "true"),
+		Key:         key,
+	})
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, gin.H{
+			"key":         r.Key,
+			"value":       config.Mask(r.Value, r.Secret),
+			"type":        r.ValueType,
+			"secret":      r.Secret,
+			"version":     r.Version,
+			"deleted":     r.Deleted,
+			"environment": r.Environment,
+			"changed_at":  r.ChangedAt.Time,
+		})
+	}
+	c.JSON(200, out)
+}
+
+// RevertProjectConfig restores a previous version of a key as a new version
+func (h *ConfigHandlers) RevertProjectConfig(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	userID := c.MustGet("userID").([]byte)
+
+	var req struct {
+		Key      string `json:"key"`
+		Version  int32  `json:"version"`
+		Override bool   `json:"override"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	if req.Version < 1 {
+		c.JSON(400, gin.H{"error": "invalid version"})
+		return
+	}
+
+	tx, err := h.state.DBPool.Begin(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(c.Request.Context())
+	q := configadaptors.New(h.state.DBPool).WithTx(tx)
+
+	env := configScope(c, req.Override)
+	if err := lockConfigKeys(c.Request.Context(), q, projectUUID, env, []string{req.Key}); err != nil {
+		fmt.Printf("[ERROR] config lock failed: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	old, err := q.GetConfigHistoryVersion(c.Request.Context(), configadaptors.GetConfigHistoryVersionParams{
+		ProjectID:   projectUUID,
+		Environment: env,
+		Key:         req.Key,
+		Version:     req.Version,
+	})
+	if err != nil {
+		c.JSON(404, gin.H{"error": "version not found"})
+		return
+	}
+	if old.Deleted {
+		c.JSON(400, gin.H{"error": "cannot revert to a deleted version"})
+		return
+	}
+
+	entry, err := h.upsert(c, q, projectUUID, env, userID, config.Entry{
+		Key:    old.Key,
+		Value:  old.Value,
+		Type:   old.ValueType,
+		Secret: old.Secret,
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] config revert failed for %s: %v\n", req.Key, err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	if err := tx.Commit(c.Request.Context()); err != nil {
+		c.JSON(500, gin.H{"error": "failed to commit transaction"})
+		return
+	}
+	c.JSON(200, configJSON(entry))
+}
+
+func (h *ConfigHandlers) CommitProjectConfig(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+
+	if err := h.commitToRepo(c, projectUUID); err != nil {
+		fmt.Printf("[ERROR] config commit failed: %v\n", err)
+		c.JSON(500, gin.H{"error": "commit error"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "committed", "path": config.RepoPath})
+}
+
+// commitToRepo writes the selected environment's config as config/lws.yaml on its branch and
+// pushes it with the functions
+func (h *ConfigHandlers) commitToRepo(c *gin.Context, projectUUID pgtype.UUID) error {
+	r, err := c.MustGet("repo").(*repo.Lease).Repo()
+	if err != nil {
+		return err
+	}
+
+	q := configadaptors.New(h.state.DBPool)
+	rows, err := q.ListResolvedProjectConfig(c.Request.Context(), configadaptors.ListResolvedProjectConfigParams{
+		ProjectID:   projectUUID,
+		Environment: selectedEnvironment(c).Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list config: %w", err)
+	}
+	entries := make([]config.Entry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, config.Entry{
+			Key:    row.Key,
+			Value:  row.Value,
+			Type:   row.ValueType,
+			Secret: row.Secret,
+		})
+	}
+
+	data, err := config.RenderYAML(entries)
+	if err != nil {
+		return err
+	}
+
+	if err := r.Fs.MkdirAll("config", 0755); err != nil {
+		return fmt.Errorf("failed to create config dir: %w", err)
+	}
+	f, err := r.Fs.Create(config.RepoPath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", config.RepoPath, err)
+	}
+	f.Write(data)
+	f.Close()
+
+	ch := commitChange(c, h.state.DBPool, "update "+config.RepoPath)
+	if err := r.CommitChange(ch, config.RepoPath); err != nil && !errors.Is(err, git.ErrEmptyCommit) {
+		return fmt.Errorf("commit error: %w", err)
+	}
+	return r.Push()
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/diff"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/go-git/go-git/v6"
+)
+
+// function sources are versioned by their git blob hash: GetFunction hands it out as
+// the ETag and UpdateFunction only writes when If-Match still names the file's
+// current blob, otherwise the writer gets a 409 with a three-way merge to resolve.
+// Multi-file saves carry the same check per file.
+
+func quoteETag(hash string) string {
+	return `"` + hash + `"`
+}
+
+// parseIfMatch returns the blob hash an If-Match header names, "*" for an unconditional
+// write and "" when the header is missing
+func parseIfMatch(v string) string {
+	v = strings.TrimSpace(v)
+	if v == "*" {
+		return v
+	}
+	v = strings.TrimPrefix(v, "W/")
+	return strings.Trim(v, `"`)
+}
+
+var (
+	errCommit = errors.New("commit error")
+	errPush   = errors.New("push error")
+	errPull   = errors.New("pull error")
+)
+
+// fileEdit is one file of a save, its new content or its removal, with the blob hash
+// the writer started from: "" for a file that shouldn't exist yet, "*" for any
+type fileEdit struct {
+	Path    string
+	Content []byte
+	Remove  bool
+	Base    string
+}
+
+// staleFile is an edit whose base no longer matches the clone
+type staleFile struct {
+	fileEdit
+	Current []byte
+	Exists  bool
+	ETag    string
+}
+
+// commitEdits commits edits as ch in one commit and pushes it when every base still
+// holds, otherwise it writes nothing and returns the stale files. A push the remote
+// rejects drops the local commit and pulls, so the check is made again against
+// what the remote now holds
+func commitEdits(r *repo.GitRepo, edits []fileEdit, ch repo.Change) ([]staleFile, error) {
+	for attempt := 0; ; attempt++ {
+		var stale []staleFile
+		e := repo.Edit{Write: map[string][]byte{}}
+		for _, fe := range edits {
+			current, err := r.ReadFile(fe.Path)
+			exists := err == nil
+			etag := ""
+			if exists {
+				etag = repo.BlobHash(current)
+			}
+			if fe.Base != "*" && fe.Base != etag {
+				stale = append(stale, staleFile{fileEdit: fe, Current: current, Exists: exists, ETag: etag})
+				continue
+			}
+			if !fe.Remove {
+				e.Write[fe.Path] = fe.Content
+			} else if exists {
+				e.Remove = append(e.Remove, fe.Path)
+			}
+		}
+		if len(stale) > 0 {
+			return stale, nil
+		}
+
+		head, err := r.Head()
+		if err != nil {
+			return nil, fmt.Errorf("%w: reading head: %v", errCommit, err)
+		}
+		err = r.CommitEdit(ch, e)
+		if errors.Is(err, git.ErrEmptyCommit) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errCommit, err)
+		}
+
+		err = r.Push()
+		if err == nil {
+			return nil, nil
+		}
+		// the clone has to match the remote again whatever went wrong
+		if resetErr := r.ResetHard(head); resetErr != nil {
+			fmt.Printf("[ERROR] %v\n", resetErr)
+		}
+		if !repo.IsPushRejected(err) || attempt > 0 {
+			return nil, fmt.Errorf("%w: %v", errPush, err)
+		}
+		if err := r.Pull(); err != nil {
+			return nil, fmt.Errorf("%w: after rejected push: %v", errPull, err)
+		}
+	}
+}
+
+// saveFailed answers 500 for a commitEdits error
+func saveFailed(c *gin.Context, err error) {
+	fmt.Printf("[ERROR] save failed: %v\n", err)
+	for _, e := range []error{errCommit, errPush, errPull} {
+		if errors.Is(err, e) {
+			c.JSON(500, gin.H{"error": e.Error()})
+			return
+		}
+	}
+	c.JSON(500, gin.H{"error": "save error"})
+}
+
+// saveSource commits body to path as ch when base is still the file's blob hash
+func saveSource(c *gin.Context, r *repo.GitRepo, path, base string, body []byte, ch repo.Change) {
+	stale, err := commitEdits(r, []fileEdit{{Path: path, Content: body, Base: base}}, ch)
+	if err != nil {
+		saveFailed(c, err)
+		return
+	}
+	if len(stale) > 0 {
+		sourceConflict(c, r, stale[0])
+		return
+	}
+	savedSource(c, r, repo.BlobHash(body))
+}
+
+func savedSource(c *gin.Context, r *repo.GitRepo, etag string) {
+	resp := gin.H{"status": "saved", "etag": etag}
+	if head, err := r.Head(); err == nil {
+		resp["commit"] = head.String()
+	}
+	c.Header("ETag", quoteETag(etag))
+	c.JSON(200, resp)
+}
+
+// fileConflict describes a stale file with what's needed to resolve it: the base the
+// writer started from when the clone still holds it, the current content, the
+// writer's change merged onto it and the change made in between as a unified diff
+func fileConflict(r *repo.GitRepo, s staleFile) gin.H {
+	resp := gin.H{
+		"etag":      s.ETag,
+		"base_etag": s.Base,
+		"current":   string(s.Current),
+		"base":      nil,
+	}
+
+	// without the base every line reads as changed on both sides, a single conflict
+	baseData, ok := r.ReadBlob(s.Base)
+	if ok {
+		resp["base"] = string(baseData)
+	}
+	yours := string(s.Content)
+	if s.Remove {
+		yours = ""
+	}
+	merged, conflicts := diff.Merge3(string(baseData), yours, string(s.Current), "yours", "current")
+	resp["merged"] = merged
+	resp["conflicts"] = conflicts
+	if ok {
+		resp["diff"] = diff.Unified("base", "current", string(baseData), string(s.Current))
+	} else {
+		resp["diff"] = diff.Unified("yours", "current", yours, string(s.Current))
+	}
+	return resp
+}
+
+// sourceConflict answers 409 for a single file save
+func sourceConflict(c *gin.Context, r *repo.GitRepo, s staleFile) {
+	resp := fileConflict(r, s)
+	resp["error"] = "function changed since it was loaded"
+	if !s.Exists {
+		resp["error"] = "function source was removed from the repo"
+	} else {
+		c.Header("ETag", quoteETag(s.ETag))
+	}
+	c.JSON(409, resp)
+}
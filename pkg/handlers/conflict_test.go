@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/go-git/go-billy/v6/memfs"
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/go-git/go-git/v6/storage/memory"
+)
+
+const conflictPath = "functions/lua/hello.lua"
+
+// bareRemote creates an on-disk bare repo whose main branch holds conflictPath with content
+func bareRemote(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if _, err := git.PlainInit(dir, true); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := memfs.New()
+	seed, err := git.Init(memory.NewStorage(), git.WithWorkTree(fs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, _ := fs.Create(conflictPath)
+	f.Write([]byte(content))
+	f.Close()
+	wt, _ := seed.Worktree()
+	wt.Add(conflictPath)
+	sig := &object.Signature{Name: "seed", Email: "seed@example.com", When: time.Now()}
+	if _, err := wt.Commit("seed", &git.CommitOptions{Author: sig}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := seed.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{dir}}); err != nil {
+		t.Fatal(err)
+	}
+	head, err := seed.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := config.RefSpec(head.Name().String() + ":refs/heads/main")
+	if err := seed.Push(&git.PushOptions{RefSpecs: []config.RefSpec{spec}}); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func cloneRemote(t *testing.T, dir string) *repo.GitRepo {
+	t.Helper()
+	r := &repo.GitRepo{
+		Project: "demo",
+		Branch:  "main",
+		Fs:      memfs.New(),
+		Storage: memory.NewStorage(),
+		Options: &git.CloneOptions{URL: dir},
+	}
+	if err := r.Clone(); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func putSource(t *testing.T, r *repo.GitRepo, base, body string) (int, map[string]any) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("PUT", "/", nil)
+	saveSource(c, r, conflictPath, base, []byte(body), repo.Change{})
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, resp
+}
+
+func TestSaveSourceStaleClone(t *testing.T) {
+	base := "one\ntwo\nthree\nfour\n"
+	remote := bareRemote(t, base)
+	alice, bob := cloneRemote(t, remote), cloneRemote(t, remote)
+	etag := repo.BlobHash([]byte(base))
+
+	code, resp := putSource(t, alice, etag, "one\ntwo\nthree\nFOUR\n")
+	if code != 200 || resp["etag"] != repo.BlobHash([]byte("one\ntwo\nthree\nFOUR\n")) {
+		t.Fatalf("first save = %d %v", code, resp)
+	}
+
+	// bob's clone hasn't seen alice's push, the remote refuses his and he gets the merge
+	code, resp = putSource(t, bob, etag, "ONE\ntwo\nthree\nfour\n")
+	if code != 409 {
+		t.Fatalf("stale save = %d %v", code, resp)
+	}
+	if resp["merged"] != "ONE\ntwo\nthree\nFOUR\n" || resp["conflicts"] != float64(0) {
+		t.Errorf("merged = %q with %v conflicts", resp["merged"], resp["conflicts"])
+	}
+	if resp["base"] != base || resp["current"] != "one\ntwo\nthree\nFOUR\n" {
+		t.Errorf("conflict body = %v", resp)
+	}
+
+	code, resp = putSource(t, bob, resp["etag"].(string), resp["merged"].(string))
+	if code != 200 {
+		t.Fatalf("resolved save = %d %v", code, resp)
+	}
+	fresh := cloneRemote(t, remote)
+	data, err := fresh.ReadFile(conflictPath)
+	if err != nil || string(data) != "ONE\ntwo\nthree\nFOUR\n" {
+		t.Errorf("remote holds %q, %v", data, err)
+	}
+	// clones carry the whole history: the seed and both saves
+	if revs, err := fresh.History(conflictPath, 0); err != nil || len(revs) != 3 {
+		t.Errorf("history = %+v, %v", revs, err)
+	}
+}
+
+func TestSaveSourceConflictMarkers(t *testing.T) {
+	base := "one\ntwo\n"
+	r := cloneRemote(t, bareRemote(t, base))
+	etag := repo.BlobHash([]byte(base))
+
+	if code, resp := putSource(t, r, etag, "one\nmine\n"); code != 200 {
+		t.Fatalf("save = %d %v", code, resp)
+	}
+	code, resp := putSource(t, r, etag, "one\nyours\n")
+	if code != 409 || resp["conflicts"] != float64(1) {
+		t.Fatalf("stale save = %d %v", code, resp)
+	}
+	want := "one\n<<<<<<< yours\nyours\n||||||| base\ntwo\n=======\nmine\n>>>>>>> current\n"
+	if resp["merged"] != want {
+		t.Errorf("merged = %q", resp["merged"])
+	}
+
+	// * overwrites whatever is there
+	if code, resp := putSource(t, r, "*", "forced\n"); code != 200 {
+		t.Fatalf("forced save = %d %v", code, resp)
+	}
+}
+
+func TestCommitEditsAllOrNothing(t *testing.T) {
+	base := "one\n"
+	remote := bareRemote(t, base)
+	r := cloneRemote(t, remote)
+	etag := repo.BlobHash([]byte(base))
+	head, _ := r.Head()
+
+	// the new file is fine but the source is stale, so neither lands
+	edits := []fileEdit{
+		{Path: "functions/lua/util.lua", Content: []byte("return {}\n")},
+		{Path: conflictPath, Content: []byte("two\n"), Base: repo.BlobHash([]byte("older\n"))},
+	}
+	stale, err := commitEdits(r, edits, repo.Change{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || stale[0].Path != conflictPath || stale[0].ETag != etag {
+		t.Fatalf("stale = %+v", stale)
+	}
+	if got, _ := r.Head(); got != head {
+		t.Error("stale save committed")
+	}
+	if _, err := r.ReadFile("functions/lua/util.lua"); err == nil {
+		t.Error("stale save wrote util.lua")
+	}
+
+	edits[1].Base = etag
+	edits = append(edits, fileEdit{Path: conflictPath + ".bak", Remove: true, Base: "*"})
+	if stale, err := commitEdits(r, edits, repo.Change{Message: "split util"}); err != nil || len(stale) > 0 {
+		t.Fatalf("save = %v, %v", stale, err)
+	}
+	fresh := cloneRemote(t, remote)
+	if revs, err := fresh.History(conflictPath, 0); err != nil || len(revs) != 2 || revs[0].Message != "split util" {
+		t.Errorf("history = %+v, %v", revs, err)
+	}
+	if data, _ := fresh.ReadFile("functions/lua/util.lua"); string(data) != "return {}\n" {
+		t.Errorf("util.lua = %q", data)
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	for in, want := range map[string]string{"": "", "*": "*", `"abc"`: "abc", `W/"abc"`: "abc", " abc ": "abc"} {
+		if got := parseIfMatch(in); got != want {
+			t.Errorf("parseIfMatch(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,472 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/apikey"
+	"github.com/ashupednekar/litewebservices-portal/internal/config"
+	endpointadaptors "github.com/ashupednekar/litewebservices-portal/internal/endpoint/adaptors"
+	functionadaptors "github.com/ashupednekar/litewebservices-portal/internal/function/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/gateway"
+	"github.com/ashupednekar/litewebservices-portal/internal/jwtauth"
+	"github.com/ashupednekar/litewebservices-portal/internal/ratelimit"
+	"github.com/ashupednekar/litewebservices-portal/pkg/state"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type EndpointHandlers struct {
+	state *state.AppState
+}
+
+func NewEndpointHandlers(s *state.AppState) *EndpointHandlers {
+	return &EndpointHandlers{state: s}
+}
+
+var endpointMethods = map[string]bool{
+	"GET":    true,
+	"POST":   true,
+	"PUT":    true,
+	"PATCH":  true,
+	"DELETE": true,
+}
+
+var endpointScopes = map[string]bool{
+	gateway.ScopePublic:  true,
+	gateway.ScopeSession: true,
+	gateway.ScopeAPIKey:  true,
+	gateway.ScopeJWT:     true,
+}
+
+type rateLimitRequest struct {
+	Limit         int32  `json:"limit"`
+	WindowSeconds int32  `json:"window_seconds"`
+	Burst         int32  `json:"burst"`
+	Key           string `json:"key"`
+}
+
+type jwtRequest struct {
+	Secret   string `json:"secret"`
+	JWKS     string `json:"jwks"`
+	Issuer   string `json:"issuer"`
+	Audience string `json:"audience"`
+}
+
+type endpointRequest struct {
+	Name        string            `json:"name"`
+	Method      string            `json:"method"`
+	Scope       string            `json:"scope"`
+	FunctionID  string            `json:"function_id"`
+	RateLimit   *rateLimitRequest `json:"rate_limit"`
+	APIKeyScope *string           `json:"api_key_scope"`
+	JWT         *jwtRequest       `json:"jwt"`
+}
+
+// authColumns resolves the api key scope and jwt settings of a request on top of the
+// current ones, nil fields keep what's stored and a masked secret keeps the stored secret
+func (req *endpointRequest) authColumns(apiKeyScope string, current jwtauth.Config) (string, jwtauth.Config, error) {
+	if req.APIKeyScope != nil {
+		apiKeyScope = strings.ToLower(strings.TrimSpace(*req.APIKeyScope))
+		if apiKeyScope != "" && !apikey.ValidScope(apiKeyScope) {
+			return "", current, fmt.Errorf("invalid api key scope")
+		}
+	}
+	cfg := current
+	if req.JWT != nil {
+		cfg = jwtauth.Config{
+			Secret:   req.JWT.Secret,
+			JWKS:     strings.TrimSpace(req.JWT.JWKS),
+			Issuer:   strings.TrimSpace(req.JWT.Issuer),
+			Audience: strings.TrimSpace(req.JWT.Audience),
+		}
+		if cfg.Secret == config.MaskedValue {
+			cfg.Secret = current.Secret
+		}
+	}
+	if req.Scope == gateway.ScopeJWT {
+		if err := cfg.Validate(); err != nil {
+			return "", current, err
+		}
+	}
+	return apiKeyScope, cfg, nil
+}
+
+func jwtJSON(cfg jwtauth.Config) gin.H {
+	if cfg.Secret == "" && cfg.JWKS == "" {
+		return nil
+	}
+	out := gin.H{
+		"jwks":     cfg.JWKS,
+		"issuer":   cfg.Issuer,
+		"audience": cfg.Audience,
+		"secret":   "",
+	}
+	if cfg.Secret != "" {
+		out["secret"] = config.MaskedValue
+	}
+	return out
+}
+
+// rateLimitColumns is the stored form of a rate limit, a null limit means unlimited
+type rateLimitColumns struct {
+	limit  pgtype.Int4
+	window int32
+	burst  pgtype.Int4
+	key    string
+}
+
+const maxRateWindowSeconds = 24 * 60 * 60
+
+func (r *rateLimitRequest) columns() (rateLimitColumns, error) {
+	cols := rateLimitColumns{window: 60, key: ratelimit.KeyIP}
+	if r == nil || r.Limit == 0 {
+		return cols, nil
+	}
+	if r.Limit < 0 || r.Burst < 0 {
+		return cols, fmt.Errorf("rate limit and burst must be positive")
+	}
+	if r.WindowSeconds != 0 {
+		if r.WindowSeconds < 1 || r.WindowSeconds > maxRateWindowSeconds {
+			return cols, fmt.Errorf("rate limit window must be between 1s and 24h")
+		}
+		cols.window = r.WindowSeconds
+	}
+	if r.Key != "" {
+		if !ratelimit.ValidKeyBy(r.Key) {
+			return cols, fmt.Errorf("invalid rate limit key, expected ip, api_key or user")
+		}
+		cols.key = r.Key
+	}
+	cols.limit = pgtype.Int4{Int32: r.Limit, Valid: true}
+	if r.Burst > 0 {
+		cols.burst = pgtype.Int4{Int32: r.Burst, Valid: true}
+	}
+	return cols, nil
+}
+
+func rateLimitJSON(limit pgtype.Int4, window int32, burst pgtype.Int4, key string) gin.H {
+	if !limit.Valid {
+		return nil
+	}
+	out := gin.H{
+		"limit":          limit.Int32,
+		"window_seconds": window,
+		"burst":          limit.Int32,
+		"key":            key,
+	}
+	if burst.Valid {
+		out["burst"] = burst.Int32
+	}
+	return out
+}
+
+func endpointJWT(secret, jwks, issuer, audience string) jwtauth.Config {
+	return jwtauth.Config{Secret: secret, JWKS: jwks, Issuer: issuer, Audience: audience}
+}
+
+// parseHexUUID decodes the hex encoded ids used across the api into a pgtype.UUID
+func parseHexUUID(s string) (pgtype.UUID, error) {
+	b, err := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+	if err != nil || len(b) != 16 {
+		return pgtype.UUID{}, fmt.Errorf("invalid id: %s", s)
+	}
+	id := pgtype.UUID{Valid: true}
+	copy(id.Bytes[:], b)
+	return id, nil
+}
+
+func normalizeEndpointName(name string) string {
+	name = strings.TrimSpace(name)
+	if name != "" && !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+	return name
+}
+
+// validate checks the request and resolves the bound function, making sure it belongs to the project
+func (h *EndpointHandlers) validate(c *gin.Context, req *endpointRequest, projectUUID pgtype.UUID) (pgtype.UUID, error) {
+	req.Name = normalizeEndpointName(req.Name)
+	req.Method = strings.ToUpper(strings.TrimSpace(req.Method))
+	req.Scope = strings.ToLower(strings.TrimSpace(req.Scope))
+
+	if req.Name == "" || strings.ContainsAny(req.Name, " \t\n?#") {
+		return pgtype.UUID{}, fmt.Errorf("invalid endpoint name")
+	}
+	if !endpointMethods[req.Method] {
+		return pgtype.UUID{}, fmt.Errorf("invalid method")
+	}
+	if !endpointScopes[req.Scope] {
+		return pgtype.UUID{}, fmt.Errorf("invalid scope, expected public, authn, api_key or jwt")
+	}
+
+	fnID, err := parseHexUUID(req.FunctionID)
+	if err != nil {
+		return pgtype.UUID{}, fmt.Errorf("invalid function id")
+	}
+	fn, err := functionadaptors.New(h.state.DBPool).GetFunctionByID(c.Request.Context(), fnID)
+	if err != nil || fn.ProjectID != projectUUID {
+		return pgtype.UUID{}, fmt.Errorf("function not found in project")
+	}
+	return fnID, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func endpointJSON(id, functionID pgtype.UUID, name, method, scope string) gin.H {
+	return gin.H{
+		"id":          hex.EncodeToString(id.Bytes[:]),
+		"name":        name,
+		"method":      method,
+		"scope":       scope,
+		"function_id": hex.EncodeToString(functionID.Bytes[:]),
+	}
+}
+
+func (h *EndpointHandlers) CreateEndpoint(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+
+	var req endpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	if req.Scope == "" {
+		req.Scope = "public"
+	}
+
+	fnID, err := h.validate(c, &req, projectUUID)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	rl, err := req.RateLimit.columns()
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	keyScope, jwtCfg, err := req.authColumns("", jwtauth.Config{})
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	q := endpointadaptors.New(h.state.DBPool)
+	ep, err := q.CreateEndpoint(c.Request.Context(), endpointadaptors.CreateEndpointParams{
+		ProjectID:         projectUUID,
+		Name:              req.Name,
+		Method:            req.Method,
+		Scope:             req.Scope,
+		FunctionID:        fnID,
+		RateLimit:         rl.limit,
+		RateWindowSeconds: rl.window,
+		RateBurst:         rl.burst,
+		RateKey:           rl.key,
+		ApiKeyScope:       keyScope,
+		JwtSecret:         jwtCfg.Secret,
+		JwtJwks:           jwtCfg.JWKS,
+		JwtIssuer:         jwtCfg.Issuer,
+		JwtAudience:       jwtCfg.Audience,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			c.JSON(409, gin.H{"error": "endpoint already exists"})
+			return
+		}
+		fmt.Printf("[ERROR] CreateEndpoint DB failed: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	out := endpointJSON(ep.ID, ep.FunctionID, ep.Name, ep.Method, ep.Scope)
+	out["rate_limit"] = rateLimitJSON(ep.RateLimit, ep.RateWindowSeconds, ep.RateBurst, ep.RateKey)
+	out["api_key_scope"] = ep.ApiKeyScope
+	out["jwt"] = jwtJSON(endpointJWT(ep.JwtSecret, ep.JwtJwks, ep.JwtIssuer, ep.JwtAudience))
+	c.JSON(201, out)
+}
+
+func (h *EndpointHandlers) ListEndpoints(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+
+	q := endpointadaptors.New(h.state.DBPool)
+	eps, err := q.ListEndpointsForProject(c.Request.Context(), projectUUID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(eps))
+	for _, e := range eps {
+		item := endpointJSON(e.ID, e.FunctionID, e.Name, e.Method, e.Scope)
+		item["function_name"] = e.FunctionName
+		item["function_language"] = e.FunctionLanguage
+		item["rate_limit"] = rateLimitJSON(e.RateLimit, e.RateWindowSeconds, e.RateBurst, e.RateKey)
+		item["api_key_scope"] = e.ApiKeyScope
+		item["jwt"] = jwtJSON(endpointJWT(e.JwtSecret, e.JwtJwks, e.JwtIssuer, e.JwtAudience))
+		out = append(out, item)
+	}
+
+	c.JSON(200, out)
+}
+
+func (h *EndpointHandlers) GetEndpoint(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	epID, err := parseHexUUID(c.Param("epID"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid endpoint id"})
+		return
+	}
+
+	q := endpointadaptors.New(h.state.DBPool)
+	e, err := q.GetEndpointByID(c.Request.Context(), endpointadaptors.GetEndpointByIDParams{
+		ID:        epID,
+		ProjectID: projectUUID,
+	})
+	if err != nil {
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+
+	out := endpointJSON(e.ID, e.FunctionID, e.Name, e.Method, e.Scope)
+	out["function_name"] = e.FunctionName
+	out["function_language"] = e.FunctionLanguage
+	out["rate_limit"] = rateLimitJSON(e.RateLimit, e.RateWindowSeconds, e.RateBurst, e.RateKey)
+	out["api_key_scope"] = e.ApiKeyScope
+	out["jwt"] = jwtJSON(endpointJWT(e.JwtSecret, e.JwtJwks, e.JwtIssuer, e.JwtAudience))
+	c.JSON(200, out)
+}
+
+func (h *EndpointHandlers) UpdateEndpoint(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	epID, err := parseHexUUID(c.Param("epID"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid endpoint id"})
+		return
+	}
+
+	q := endpointadaptors.New(h.state.DBPool)
+	existing, err := q.GetEndpointByID(c.Request.Context(), endpointadaptors.GetEndpointByIDParams{
+		ID:        epID,
+		ProjectID: projectUUID,
+	})
+	if err != nil {
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+
+	var req endpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	// unset fields keep their current values
+	if req.Name == "" {
+		req.Name = existing.Name
+	}
+	if req.Method == "" {
+		req.Method = existing.Method
+	}
+	if req.Scope == "" {
+		req.Scope = existing.Scope
+	}
+	if req.FunctionID == "" {
+		req.FunctionID = hex.EncodeToString(existing.FunctionID.Bytes[:])
+	}
+
+	fnID, err := h.validate(c, &req, projectUUID)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	rl := rateLimitColumns{
+		limit:  existing.RateLimit,
+		window: existing.RateWindowSeconds,
+		burst:  existing.RateBurst,
+		key:    existing.RateKey,
+	}
+	if req.RateLimit != nil {
+		if rl, err = req.RateLimit.columns(); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	keyScope, jwtCfg, err := req.authColumns(existing.ApiKeyScope,
+		endpointJWT(existing.JwtSecret, existing.JwtJwks, existing.JwtIssuer, existing.JwtAudience))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	ep, err := q.UpdateEndpoint(c.Request.Context(), endpointadaptors.UpdateEndpointParams{
+		ID:                epID,
+		ProjectID:         projectUUID,
+		Name:              req.Name,
+		Method:            req.Method,
+		Scope:             req.Scope,
+		FunctionID:        fnID,
+		RateLimit:         rl.limit,
+		RateWindowSeconds: rl.window,
+		RateBurst:         rl.burst,
+		RateKey:           rl.key,
+		ApiKeyScope:       keyScope,
+		JwtSecret:         jwtCfg.Secret,
+		JwtJwks:           jwtCfg.JWKS,
+		JwtIssuer:         jwtCfg.Issuer,
+		JwtAudience:       jwtCfg.Audience,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(404, gin.H{"error": "not found"})
+			return
+		}
+		if isUniqueViolation(err) {
+			c.JSON(409, gin.H{"error": "endpoint already exists"})
+			return
+		}
+		fmt.Printf("[ERROR] UpdateEndpoint DB failed: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	out := endpointJSON(ep.ID, ep.FunctionID, ep.Name, ep.Method, ep.Scope)
+	out["rate_limit"] = rateLimitJSON(ep.RateLimit, ep.RateWindowSeconds, ep.RateBurst, ep.RateKey)
+	out["api_key_scope"] = ep.ApiKeyScope
+	out["jwt"] = jwtJSON(endpointJWT(ep.JwtSecret, ep.JwtJwks, ep.JwtIssuer, ep.JwtAudience))
+	c.JSON(200, out)
+}
+
+func (h *EndpointHandlers) DeleteEndpoint(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	epID, err := parseHexUUID(c.Param("epID"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid endpoint id"})
+		return
+	}
+
+	q := endpointadaptors.New(h.state.DBPool)
+	if _, err := q.GetEndpointByID(c.Request.Context(), endpointadaptors.GetEndpointByIDParams{
+		ID:        epID,
+		ProjectID: projectUUID,
+	}); err != nil {
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+
+	if err := q.DeleteEndpoint(c.Request.Context(), endpointadaptors.DeleteEndpointParams{
+		ID:        epID,
+		ProjectID: projectUUID,
+	}); err != nil {
+		fmt.Printf("[ERROR] DeleteEndpoint DB failed: %v\n", err)
+		c.JSON(500, gin.H{"error": "db delete error"})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "deleted"})
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/config"
+	"github.com/ashupednekar/litewebservices-portal/internal/jwtauth"
+)
+
+func TestEndpointAuthColumns(t *testing.T) {
+	const secret = "0123456789abcdef0123456789abcdef"
+	stored := jwtauth.Config{Secret: secret, Audience: "shop"}
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name      string
+		req       endpointRequest
+		wantScope string
+		wantJWT   jwtauth.Config
+		wantErr   bool
+	}{
+		{name: "unset keeps stored", req: endpointRequest{Scope: "jwt"}, wantScope: "orders:read", wantJWT: stored},
+		{name: "masked secret keeps stored", req: endpointRequest{Scope: "jwt", JWT: &jwtRequest{Secret: config.MaskedValue, Audience: "blog"}},
+			wantScope: "orders:read", wantJWT: jwtauth.Config{Secret: secret, Audience: "blog"}},
+		{name: "switch to jwks", req: endpointRequest{Scope: "jwt", JWT: &jwtRequest{JWKS: "config/jwks.json"}},
+			wantScope: "orders:read", wantJWT: jwtauth.Config{JWKS: "config/jwks.json"}},
+		{name: "jwt without key material", req: endpointRequest{Scope: "jwt", JWT: &jwtRequest{Issuer: "x"}}, wantErr: true},
+		{name: "cleared jwt on another scope", req: endpointRequest{Scope: "public", JWT: &jwtRequest{}}, wantScope: "orders:read"},
+		{name: "api key scope", req: endpointRequest{Scope: "api_key", APIKeyScope: str(" Orders:Write ")}, wantScope: "orders:write", wantJWT: stored},
+		{name: "invalid api key scope", req: endpointRequest{Scope: "api_key", APIKeyScope: str("no spaces")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope, cfg, err := tt.req.authColumns("orders:read", stored)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("authColumns() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if scope != tt.wantScope || cfg != tt.wantJWT {
+				t.Errorf("authColumns() = %q, %+v, want %q, %+v", scope, cfg, tt.wantScope, tt.wantJWT)
+			}
+		})
+	}
+}
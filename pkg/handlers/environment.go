@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	configadaptors "github.com/ashupednekar/litewebservices-portal/internal/config/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/project"
+	projectadaptors "github.com/ashupednekar/litewebservices-portal/internal/project/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/vendors"
+	"github.com/ashupednekar/litewebservices-portal/pkg/state"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Environments are named branches of the project repo. Requests pick one with the
+// X-LWS-Environment header or the lws_env cookie, the gateway serves one at
+// /x/<project>@<env>/, and promoting merges one environment's branch into another's.
+
+type EnvironmentHandlers struct {
+	state *state.AppState
+}
+
+func NewEnvironmentHandlers(s *state.AppState) *EnvironmentHandlers {
+	return &EnvironmentHandlers{state: s}
+}
+
+func environmentJSON(projectName string, e projectadaptors.ProjectEnvironment, selected bool) gin.H {
+	gateway := "/x/" + projectName + "/"
+	if !e.IsDefault {
+		gateway = "/x/" + projectName + "@" + e.Name + "/"
+	}
+	return gin.H{
+		"name":       e.Name,
+		"branch":     e.Branch,
+		"default":    e.IsDefault,
+		"selected":   selected,
+		"gateway":    gateway,
+		"created_at": e.CreatedAt.Time,
+	}
+}
+
+func (h *EnvironmentHandlers) ListEnvironments(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	projectName := c.MustGet("projectName").(string)
+
+	envs, err := projectadaptors.New(h.state.DBPool).ListProjectEnvironments(c.Request.Context(), projectUUID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	selected := selectedEnvironment(c).Name
+	out := make([]gin.H, 0, len(envs))
+	for _, e := range envs {
+		out = append(out, environmentJSON(projectName, e, e.Name == selected))
+	}
+	c.JSON(200, out)
+}
+
+// CreateEnvironment adds an environment on branch, which is started from another
+// environment's branch unless the repo already has it
+func (h *EnvironmentHandlers) CreateEnvironment(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	projectName := c.MustGet("projectName").(string)
+
+	var req struct {
+		Name   string `json:"name"`
+		Branch string `json:"branch"`
+		// From is the environment the branch starts from, the default one when empty
+		From string `json:"from"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	if !project.ValidEnvironmentName(req.Name) {
+		c.JSON(400, gin.H{"error": "environment names are lowercase letters, digits and dashes"})
+		return
+	}
+	if req.Branch == "" {
+		req.Branch = req.Name
+	}
+	if !project.ValidBranchName(req.Branch) {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("invalid branch name: %q", req.Branch)})
+		return
+	}
+
+	pq := projectadaptors.New(h.state.DBPool)
+	from, ok := h.environment(c, pq, projectUUID, req.From)
+	if !ok {
+		return
+	}
+
+	tx, err := h.state.DBPool.Begin(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(c.Request.Context())
+
+	env, err := pq.WithTx(tx).CreateProjectEnvironment(c.Request.Context(), projectadaptors.CreateProjectEnvironmentParams{
+		ProjectID: projectUUID,
+		Name:      req.Name,
+		Branch:    req.Branch,
+		IsDefault: false,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			c.JSON(409, gin.H{"error": "an environment with that name or branch already exists"})
+			return
+		}
+		fmt.Printf("[ERROR] DB CreateProjectEnvironment: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	var created bool
+	err = h.state.Repos.Update(repo.Ref{Project: projectName, Branch: from.Branch}, func(r *repo.GitRepo) error {
+		created, err = r.CreateBranch(req.Branch)
+		return err
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] creating branch %s for %s: %v\n", req.Branch, projectName, err)
+		c.JSON(500, gin.H{"error": "failed to create branch"})
+		return
+	}
+
+	if err := tx.Commit(c.Request.Context()); err != nil {
+		c.JSON(500, gin.H{"error": "failed to commit transaction"})
+		return
+	}
+	resp := environmentJSON(projectName, env, false)
+	resp["branch_created"] = created
+	c.JSON(201, resp)
+}
+
+// DeleteEnvironment drops an environment and its config overrides, the branch stays in
+// the repo
+func (h *EnvironmentHandlers) DeleteEnvironment(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+
+	pq := projectadaptors.New(h.state.DBPool)
+	env, ok := h.environment(c, pq, projectUUID, c.Param("env"))
+	if !ok {
+		return
+	}
+	if env.IsDefault {
+		c.JSON(400, gin.H{"error": "the default environment can't be deleted"})
+		return
+	}
+
+	tx, err := h.state.DBPool.Begin(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(c.Request.Context())
+
+	cq := configadaptors.New(h.state.DBPool).WithTx(tx)
+	err = cq.DeleteEnvironmentConfig(c.Request.Context(), configadaptors.DeleteEnvironmentConfigParams{
+		ProjectID:   projectUUID,
+		Environment: env.Name,
+	})
+	if err == nil {
+		err = cq.DeleteEnvironmentConfigHistory(c.Request.Context(), configadaptors.DeleteEnvironmentConfigHistoryParams{
+			ProjectID:   projectUUID,
+			Environment: env.Name,
+		})
+	}
+	if err == nil {
+		err = pq.WithTx(tx).DeleteProjectEnvironment(c.Request.Context(), projectadaptors.DeleteProjectEnvironmentParams{
+			ProjectID: projectUUID,
+			Name:      env.Name,
+		})
+	}
+	if err != nil {
+		fmt.Printf("[ERROR] deleting environment %s: %v\n", env.Name, err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	if err := tx.Commit(c.Request.Context()); err != nil {
+		c.JSON(500, gin.H{"error": "failed to commit transaction"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "deleted", "branch": env.Branch})
+}
+
+// PromoteEnvironment brings the target environment's branch up to the source's, a
+// fast-forward when the target has no commits of its own and a merge commit otherwise.
+// Both branches changing the same lines is a 409 listing the files, nothing is pushed.
+// Protected projects get a pull request instead, nothing lands unreviewed
+func (h *EnvironmentHandlers) PromoteEnvironment(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	projectName := c.MustGet("projectName").(string)
+	userID := c.MustGet("userID").([]byte)
+
+	var req struct {
+		From    string `json:"from"`
+		To      string `json:"to"`
+		Message string `json:"message"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.From == "" {
+		c.JSON(400, gin.H{"error": "from is required"})
+		return
+	}
+
+	pq := projectadaptors.New(h.state.DBPool)
+	from, ok := h.environment(c, pq, projectUUID, req.From)
+	if !ok {
+		return
+	}
+	to, ok := h.environment(c, pq, projectUUID, req.To)
+	if !ok {
+		return
+	}
+	if from.Name == to.Name {
+		c.JSON(400, gin.H{"error": "can't promote an environment into itself"})
+		return
+	}
+
+	if c.GetBool("projectProtected") {
+		h.promoteForReview(c, projectName, from, to, req.Message)
+		return
+	}
+
+	ch := commitChange(c, h.state.DBPool, req.Message)
+	if ch.Message == "" {
+		ch.Message = fmt.Sprintf("promote %s to %s", from.Name, to.Name)
+	}
+
+	var res repo.PromoteResult
+	err := h.state.Repos.Update(repo.Ref{Project: projectName, Branch: to.Branch}, func(r *repo.GitRepo) error {
+		head, err := r.Head()
+		if err != nil {
+			return err
+		}
+		if res, err = r.Promote(from.Branch, ch); err != nil || res.UpToDate {
+			return err
+		}
+		if err := r.Push(); err != nil {
+			if resetErr := r.ResetHard(head); resetErr != nil {
+				fmt.Printf("[ERROR] %v\n", resetErr)
+			}
+			return fmt.Errorf("%w: %v", errPush, err)
+		}
+		// functions added on the source are new to the db when it's the first branch to see them
+		if err := SyncRepoFunctionsToDb(c.Request.Context(), h.state.DBPool, projectUUID, r, userID); err != nil {
+			fmt.Printf("[WARN] Failed to sync promoted functions: %v\n", err)
+		}
+		return nil
+	})
+
+	var conflict *repo.MergeConflictError
+	switch {
+	case errors.As(err, &conflict):
+		c.JSON(409, gin.H{
+			"error": fmt.Sprintf("%s and %s changed the same lines", from.Name, to.Name),
+			"files": conflict.Files,
+		})
+		return
+	case errors.Is(err, repo.ErrRevisionNotFound):
+		c.JSON(404, gin.H{"error": fmt.Sprintf("branch %s not found in the repo", from.Branch)})
+		return
+	case errors.Is(err, errPush) && repo.IsPushRejected(err):
+		c.JSON(409, gin.H{"error": to.Name + " changed during the promote, try again"})
+		return
+	case err != nil:
+		fmt.Printf("[ERROR] promote %s to %s failed: %v\n", from.Name, to.Name, err)
+		c.JSON(500, gin.H{"error": "promote failed"})
+		return
+	}
+
+	status := "promoted"
+	if res.UpToDate {
+		status = "up-to-date"
+	}
+	c.JSON(200, gin.H{
+		"status":       status,
+		"from":         from.Name,
+		"to":           to.Name,
+		"fast_forward": res.FastForward,
+		"commit":       res.Commit.String(),
+	})
+}
+
+// promoteForReview opens the pull request merging from's branch into to's, the push
+// webhook syncs the environment once it's merged on the vcs
+func (h *EnvironmentHandlers) promoteForReview(c *gin.Context, projectName string, from, to projectadaptors.ProjectEnvironment, title string) {
+	if title == "" {
+		title = fmt.Sprintf("Promote %s to %s", from.Name, to.Name)
+	}
+	vcs, conn, ok := projectVcs(c, h.state, projectName)
+	if !ok {
+		return
+	}
+	pr, err := vcs.CreatePullRequest(c.Request.Context(), conn.Owner, conn.Repo, vendors.PullRequestOptions{
+		Title: title,
+		Body:  fmt.Sprintf("Promotes %s (%s) into %s (%s), opened from the portal by %s.", from.Name, from.Branch, to.Name, to.Branch, c.GetString("userName")),
+		Head:  from.Branch,
+		Base:  to.Branch,
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] VCS CreatePullRequest: %v\n", err)
+		c.JSON(502, gin.H{"error": fmt.Sprintf("failed to open pull request: %v", err)})
+		return
+	}
+	c.JSON(202, gin.H{
+		"status": "review",
+		"from":   from.Name,
+		"to":     to.Name,
+		"number": pr.Number,
+		"url":    pr.HTMLURL,
+	})
+}
+
+// environment looks up a project environment by name, the default one for "", answering
+// 404 itself when there's no such environment
+func (h *EnvironmentHandlers) environment(c *gin.Context, pq *projectadaptors.Queries, projectUUID pgtype.UUID, name string) (projectadaptors.ProjectEnvironment, bool) {
+	var env projectadaptors.ProjectEnvironment
+	var err error
+	if name == "" {
+		env, err = pq.GetDefaultProjectEnvironment(c.Request.Context(), projectUUID)
+	} else {
+		env, err = pq.GetProjectEnvironment(c.Request.Context(), projectadaptors.GetProjectEnvironmentParams{
+			ProjectID: projectUUID,
+			Name:      name,
+		})
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(404, gin.H{"error": fmt.Sprintf("environment %q not found", name)})
+		return env, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return env, false
+	}
+	return env, true
+}
@@ -2,16 +2,19 @@ package handlers
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
+	"github.com/ashupednekar/litewebservices-portal/internal/function"
 	functionadaptors "github.com/ashupednekar/litewebservices-portal/internal/function/adaptors"
 	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
+	"github.com/ashupednekar/litewebservices-portal/internal/runtime"
 	"github.com/ashupednekar/litewebservices-portal/pkg/state"
 	"github.com/gin-gonic/gin"
-	"github.com/go-git/go-git/v6"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -26,22 +29,23 @@ func NewFunctionHandlers(s *state.AppState) *FunctionHandlers {
 	return &FunctionHandlers{state: s}
 }
 
-var langExt = map[string]string{
-	"python":     ".py",
-	"go":         ".go",
-	"rust":       ".rs",
-	"javascript": ".js",
-	"lua":        ".lua",
-}
-
 type createFunctionRequest struct {
 	Name     string `json:"name"`
 	Language string `json:"language"`
 	Code     string `json:"path"`
+	// Message is the commit message, "create <path>" when empty
+	Message string `json:"message"`
+	// Bundle creates <root>/<language>/<name>/ with a manifest and Code as its
+	// entry, Files are committed next to it. Sending files implies a bundle
+	Bundle bool                `json:"bundle"`
+	Files  []bundleFileRequest `json:"files"`
 }
 
 func (h *FunctionHandlers) CreateFunction(c *gin.Context) {
-	r := c.MustGet("repo").(*repo.GitRepo)
+	r, ok := projectRepo(c)
+	if !ok {
+		return
+	}
 	userID := c.MustGet("userID").([]byte)
 
 	var req createFunctionRequest
@@ -50,73 +54,88 @@ func (h *FunctionHandlers) CreateFunction(c *gin.Context) {
 		return
 	}
 
-	ext := langExt[req.Language]
+	ext := function.Extensions[req.Language]
 	if ext == "" {
 		c.JSON(400, gin.H{"error": "invalid language"})
 		return
 	}
 
-	path := fmt.Sprintf("functions/%s/%s%s", req.Language, req.Name, ext)
-
-	dirParts := strings.Split(path, "/")
-	cur := ""
-	for _, p := range dirParts[:len(dirParts)-1] {
-		cur += "/" + p
-		r.Fs.MkdirAll(cur, 0755)
-	}
-
-	f, err := r.Fs.Create(path)
+	// every file must not exist yet, so an existing function is never overwritten
+	path, edits, err := newFunction(functionsRoot(c), req)
 	if err != nil {
-		fmt.Printf("[ERROR] r.Fs.Create failed for path %s: %v\n", path, err)
-		c.JSON(500, gin.H{"error": "file create error"})
+		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
-	codeContent := req.Code
-	if codeContent == "" {
-		codeContent = "// TODO: implement function\n"
-	}
-	f.Write([]byte(codeContent))
-	f.Close()
 
-	if err := r.Commit(path); err != nil {
-		if !strings.Contains(err.Error(), "clean working tree") {
-			fmt.Printf("[ERROR] r.Commit failed: %v\n", err)
-			c.JSON(500, gin.H{"error": "commit error"})
-			return
+	ch := commitChange(c, h.state.DBPool, req.Message)
+	if ch.Message == "" {
+		ch.Message = "create " + path
+	}
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	var hash string
+	for _, e := range edits {
+		if e.Path == path {
+			hash = repo.BlobHash(e.Content)
 		}
 	}
 
-	if err := r.Push(); err != nil {
-		fmt.Printf("[ERROR] r.Push failed: %v\n", err)
-		c.JSON(500, gin.H{"error": "push error"})
+	// the row and its operation go in together, the push follows
+	tx, err := h.state.DBPool.Begin(c.Request.Context())
+	if err != nil {
+		fmt.Printf("[ERROR] DB Begin: %v\n", err)
+		c.JSON(500, gin.H{"error": "failed to start transaction"})
 		return
 	}
-
-	q := functionadaptors.New(h.state.DBPool)
-
-	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	defer tx.Rollback(c.Request.Context())
+	q := functionadaptors.New(h.state.DBPool).WithTx(tx)
 	fn, err := q.CreateFunction(
 		c.Request.Context(),
 		functionadaptors.CreateFunctionParams{
-			ProjectID: projectUUID,
-			Name:      req.Name,
-			Language:  req.Language,
-			Path:      path,
-			CreatedBy: userID,
+			ProjectID:   projectUUID,
+			Name:        req.Name,
+			Language:    req.Language,
+			Path:        path,
+			CreatedBy:   userID,
+			ContentHash: hash,
 		},
 	)
+	if isUniqueViolation(err) {
+		c.JSON(409, gin.H{"error": "a function with this name already exists"})
+		return
+	}
 	if err != nil {
 		fmt.Printf("[ERROR] CreateFunction DB failed: %v\n", err)
 		c.JSON(500, gin.H{"error": "database error"})
 		return
 	}
+	op, err := logOperation(c.Request.Context(), q, opCreate, fn, r.Branch, edits, ch, userID)
+	if err != nil {
+		fmt.Printf("[ERROR] DB CreateFunctionOperation: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	if err := tx.Commit(c.Request.Context()); err != nil {
+		fmt.Printf("[ERROR] DB Commit: %v\n", err)
+		c.JSON(500, gin.H{"error": "failed to commit transaction"})
+		return
+	}
 
-	c.JSON(201, gin.H{
+	out := gin.H{
 		"id":       hex.EncodeToString(fn.ID.Bytes[:]),
 		"name":     fn.Name,
 		"language": fn.Language,
 		"path":     fn.Path,
-	})
+	}
+	stale, err := runOperation(c.Request.Context(), h.state.DBPool, r, op, false)
+	if len(stale) > 0 {
+		c.JSON(409, gin.H{"error": "function already exists in the repo", "path": stale[0].Path})
+		return
+	}
+	if err != nil {
+		operationPending(c, op, err, out)
+		return
+	}
+	c.JSON(201, out)
 }
 
 func (h *FunctionHandlers) ListFunctions(c *gin.Context) {
@@ -143,100 +162,56 @@ func (h *FunctionHandlers) ListFunctions(c *gin.Context) {
 }
 
 func (h *FunctionHandlers) GetFunction(c *gin.Context) {
-	fnHex := c.Param("fnID")
-	fnID, err := hex.DecodeString(fnHex)
-	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid function id"})
-		return
-	}
-	pgFnId := pgtype.UUID{Valid: true}
-	copy(pgFnId.Bytes[:], fnID)
-
-	q := functionadaptors.New(h.state.DBPool)
-
-	f, err := q.GetFunctionByID(c.Request.Context(), pgFnId)
-	if err != nil {
-		c.JSON(404, gin.H{"error": "not found"})
+	f, r, ok := h.projectFunction(c)
+	if !ok {
 		return
 	}
 
-	r := c.MustGet("repo").(*repo.GitRepo)
-
-	fmt.Println(f.Path)
-	file, err := r.Fs.Open(f.Path)
+	data, err := r.ReadFile(f.Path)
 	if err != nil {
 		c.JSON(404, gin.H{
 			"msg": "function not found in repo",
 		})
 		return
 	}
-	data, err := io.ReadAll(file)
+	etag := repo.BlobHash(data)
+	head, err := r.Head()
 	if err != nil {
-		c.JSON(404, gin.H{
-			"msg": "error reading file data",
-		})
+		fmt.Printf("[ERROR] reading head failed: %v\n", err)
+		c.JSON(500, gin.H{"error": "error reading repo head"})
 		return
 	}
 
-	//TODO: read file contents
+	c.Header("ETag", quoteETag(etag))
 	c.JSON(200, gin.H{
 		"id":       hex.EncodeToString(f.ID.Bytes[:]),
 		"name":     f.Name,
 		"language": f.Language,
 		"path":     f.Path,
 		"content":  string(data),
+		"etag":     etag,
+		"commit":   head.String(),
 	})
 }
 
 func (h *FunctionHandlers) UpdateFunction(c *gin.Context) {
-	fnHex := c.Param("fnID")
-	fnID, err := hex.DecodeString(fnHex)
-	if err != nil || len(fnID) != 16 {
-		c.JSON(400, gin.H{"error": "invalid function id"})
+	f, r, ok := h.projectFunction(c)
+	if !ok {
 		return
 	}
-	pgFnId := pgtype.UUID{Valid: true}
-	copy(pgFnId.Bytes[:], fnID)
-
-	q := functionadaptors.New(h.state.DBPool)
-
-	f, err := q.GetFunctionByID(c.Request.Context(), pgFnId)
-	if err != nil {
-		c.JSON(404, gin.H{"error": "function not found"})
-		return
-	}
-
-	r := c.MustGet("repo").(*repo.GitRepo)
 
 	if c.ContentType() == "text/plain" {
-		body, err := io.ReadAll(c.Request.Body)
-		if err != nil {
-			c.JSON(400, gin.H{"error": "invalid body"})
+		base := parseIfMatch(c.GetHeader("If-Match"))
+		if base == "" {
+			c.JSON(428, gin.H{"error": "If-Match required, send the etag the function was loaded with or * to overwrite"})
 			return
 		}
-
-		fh, err := r.Fs.Create(f.Path)
+		body, err := io.ReadAll(c.Request.Body)
 		if err != nil {
-			c.JSON(500, gin.H{"error": "write error"})
-			return
-		}
-		fh.Write(body)
-		fh.Close()
-
-		fmt.Printf("commiting file: %s\n", f.Path)
-		err = r.Commit(f.Path)
-		if err != nil && !errors.Is(err, git.ErrEmptyCommit) {
-			fmt.Printf("error commiting file: %s\n", err)
-			c.JSON(500, gin.H{"error": "commit error"})
-			return
-		}
-
-		if err := r.Push(); err != nil {
-			c.JSON(500, gin.H{"error": "push error"})
+			c.JSON(400, gin.H{"error": "invalid body"})
 			return
 		}
-
-		c.JSON(200, gin.H{"status": "saved"})
+		saveSource(c, r, f.Path, base, body, commitChange(c, h.state.DBPool, c.Query("message")))
 		return
 	}
 
@@ -252,10 +227,10 @@ func (h *FunctionHandlers) UpdateFunction(c *gin.Context) {
 	resp := gin.H{}
 
 	if req.Path != "" {
-		upd, err := q.UpdateFunctionPath(
+		upd, err := functionadaptors.New(h.state.DBPool).UpdateFunctionPath(
 			c.Request.Context(),
 			functionadaptors.UpdateFunctionPathParams{
-				ID:   pgtype.UUID{Bytes: [16]byte(fnID)},
+				ID:   f.ID,
 				Path: req.Path,
 			},
 		)
@@ -268,78 +243,146 @@ func (h *FunctionHandlers) UpdateFunction(c *gin.Context) {
 }
 
 func (h *FunctionHandlers) DeleteFunction(c *gin.Context) {
-	fnHex := c.Param("fnID")
-	fnID, err := hex.DecodeString(fnHex)
+	f, r, ok := h.projectFunction(c)
+	if !ok {
+		return
+	}
+	userID := c.MustGet("userID").([]byte)
+
+	// a bundle goes with its whole directory, in one commit
+	paths := []string{f.Path}
+	if dir, ok := functionBundle(r, f.Path); ok {
+		var err error
+		if paths, err = r.ListFiles(dir); err != nil {
+			fmt.Printf("[ERROR] listing bundle %s failed: %v\n", dir, err)
+		}
+	}
+	edits := make([]fileEdit, 0, len(paths))
+	for _, p := range paths {
+		edits = append(edits, fileEdit{Path: p, Remove: true, Base: "*"})
+	}
+
+	ch := commitChange(c, h.state.DBPool, c.Query("message"))
+	if ch.Message == "" {
+		ch.Message = "delete " + f.Path
+	}
+	// the row stays until the files are gone, so a failed push leaves nothing to restore
+	op, err := logOperation(c.Request.Context(), functionadaptors.New(h.state.DBPool), opDelete, f, r.Branch, edits, ch, userID)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid function id"})
+		fmt.Printf("[ERROR] DB CreateFunctionOperation: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	if _, err := runOperation(c.Request.Context(), h.state.DBPool, r, op, false); err != nil {
+		operationPending(c, op, err, gin.H{"id": hex.EncodeToString(f.ID.Bytes[:])})
 		return
 	}
-	pgFnId := pgtype.UUID{Valid: true}
-	copy(pgFnId.Bytes[:], fnID)
 
-	q := functionadaptors.New(h.state.DBPool)
+	c.JSON(200, gin.H{"status": "deleted"})
+}
 
-	f, err := q.GetFunctionByID(c.Request.Context(), pgFnId)
+type invokeFunctionRequest struct {
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	Headers   map[string]string `json:"headers"`
+	Query     map[string]string `json:"query"`
+	Input     json.RawMessage   `json:"input"`
+	Source    *string           `json:"source"`
+	TimeoutMs int               `json:"timeout_ms"`
+}
+
+// InvokeFunction runs the function once with a test request. The editor can pass unsaved
+// source to try it before committing, otherwise the version in the repo runs.
+func (h *FunctionHandlers) InvokeFunction(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	fnID, err := parseHexUUID(c.Param("fnID"))
 	if err != nil {
-		c.JSON(404, gin.H{"error": "not found"})
+		c.JSON(400, gin.H{"error": "invalid function id"})
 		return
 	}
 
-	if err := q.DeleteFunction(c.Request.Context(), pgFnId); err != nil {
-		fmt.Printf("[ERROR] db delete failed: %v\n", err)
-		c.JSON(500, gin.H{"error": "db delete error"})
+	var req invokeFunctionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
 		return
 	}
 
-	r := c.MustGet("repo").(*repo.GitRepo)
+	q := functionadaptors.New(h.state.DBPool)
+	f, err := q.GetFunctionByID(c.Request.Context(), fnID)
+	if err != nil || f.ProjectID != projectUUID {
+		c.JSON(404, gin.H{"error": "function not found"})
+		return
+	}
 
-	if err := r.Fs.Remove(f.Path); err != nil {
-		fmt.Printf("[ERROR] failed to remove file %s: %v\n", f.Path, err)
-		_, rollbackErr := q.CreateFunction(c.Request.Context(), functionadaptors.CreateFunctionParams{
-			ProjectID: f.ProjectID,
-			Name:      f.Name,
-			Language:  f.Language,
-			Path:      f.Path,
-			CreatedBy: f.CreatedBy,
-		})
-		if rollbackErr != nil {
-			fmt.Printf("[ERROR] rollback failed: %v\n", rollbackErr)
-		}
-		c.JSON(500, gin.H{"error": "file remove error"})
+	rt, err := runtime.New(f.Language)
+	if err != nil {
+		c.JSON(501, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := r.Commit(f.Path); err != nil {
-		fmt.Printf("[ERROR] commit failed: %v\n", err)
-		_, rollbackErr := q.CreateFunction(c.Request.Context(), functionadaptors.CreateFunctionParams{
-			ProjectID: f.ProjectID,
-			Name:      f.Name,
-			Language:  f.Language,
-			Path:      f.Path,
-			CreatedBy: f.CreatedBy,
-		})
-		if rollbackErr != nil {
-			fmt.Printf("[ERROR] rollback failed: %v\n", rollbackErr)
+	var source []byte
+	if req.Source != nil {
+		source = []byte(*req.Source)
+	} else {
+		r, ok := projectRepo(c)
+		if !ok {
+			return
 		}
-		c.JSON(500, gin.H{"error": "commit error"})
-		return
+		file, err := r.Fs.Open(f.Path)
+		if err != nil {
+			c.JSON(404, gin.H{"error": "function not found in repo"})
+			return
+		}
+		source, err = io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			c.JSON(500, gin.H{"error": "error reading file data"})
+			return
+		}
+		// the run can take the whole timeout and a build, the clone isn't needed for it
+		c.MustGet("repo").(*repo.Lease).Release()
 	}
 
-	if err := r.Push(); err != nil {
-		fmt.Printf("[ERROR] push failed: %v\n", err)
-		_, rollbackErr := q.CreateFunction(c.Request.Context(), functionadaptors.CreateFunctionParams{
-			ProjectID: f.ProjectID,
-			Name:      f.Name,
-			Language:  f.Language,
-			Path:      f.Path,
-			CreatedBy: f.CreatedBy,
-		})
-		if rollbackErr != nil {
-			fmt.Printf("[ERROR] rollback failed: %v\n", rollbackErr)
+	// a json string input is passed through as the raw body, anything else as its json text
+	body := string(req.Input)
+	var s string
+	if err := json.Unmarshal(req.Input, &s); err == nil {
+		body = s
+	}
+	if req.Method == "" {
+		req.Method = "POST"
+	}
+
+	limits := runtime.DefaultLimits().WithTimeout(time.Duration(req.TimeoutMs) * time.Millisecond)
+	start := time.Now()
+	resp, err := rt.Invoke(c.Request.Context(), source, runtime.Request{
+		Method:  strings.ToUpper(req.Method),
+		Path:    req.Path,
+		Headers: req.Headers,
+		Query:   req.Query,
+		Body:    body,
+	}, limits)
+	elapsed := time.Since(start).Milliseconds()
+
+	if err != nil {
+		var fnErr *runtime.FunctionError
+		switch {
+		case errors.Is(err, runtime.ErrTimeout):
+			c.JSON(504, gin.H{"error": err.Error(), "duration_ms": elapsed})
+		case errors.As(err, &fnErr):
+			c.JSON(422, gin.H{"error": fnErr.Msg, "logs": fnErr.Logs, "duration_ms": elapsed})
+		default:
+			fmt.Printf("[ERROR] invoke %s failed: %v\n", f.Name, err)
+			c.JSON(500, gin.H{"error": "runtime error"})
 		}
-		c.JSON(500, gin.H{"error": "push error"})
 		return
 	}
 
-	c.JSON(200, gin.H{"status": "deleted"})
+	c.JSON(200, gin.H{
+		"status":      resp.Status,
+		"headers":     resp.Headers,
+		"body":        string(resp.Body),
+		"logs":        resp.Logs,
+		"duration_ms": elapsed,
+	})
 }
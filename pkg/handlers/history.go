@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/diff"
+	functionadaptors "github.com/ashupednekar/litewebservices-portal/internal/function/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// projectFunction loads the :fnID function of the request's project and its clone,
+// answering 4xx/5xx itself when either can't be had
+func (h *FunctionHandlers) projectFunction(c *gin.Context) (functionadaptors.Function, *repo.GitRepo, bool) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	fnID, err := parseHexUUID(c.Param("fnID"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid function id"})
+		return functionadaptors.Function{}, nil, false
+	}
+	f, err := functionadaptors.New(h.state.DBPool).GetFunctionByID(c.Request.Context(), fnID)
+	if err != nil || f.ProjectID != projectUUID {
+		c.JSON(404, gin.H{"error": "function not found"})
+		return f, nil, false
+	}
+	r, ok := projectRepo(c)
+	return f, r, ok
+}
+
+// revisionError answers for a failed FileAt/Resolve
+func revisionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repo.ErrRevisionNotFound):
+		c.JSON(404, gin.H{"error": "revision not found"})
+	case errors.Is(err, repo.ErrFileNotFound):
+		c.JSON(404, gin.H{"error": "function did not exist at that revision"})
+	default:
+		fmt.Printf("[ERROR] reading revision failed: %v\n", err)
+		c.JSON(500, gin.H{"error": "error reading repo history"})
+	}
+}
+
+// functionFiles is what a function's history covers: its bundle directory, or its
+// source file when it's a single file function
+func functionFiles(r *repo.GitRepo, f functionadaptors.Function) (string, bool) {
+	if dir, ok := functionBundle(r, f.Path); ok {
+		return dir, true
+	}
+	return f.Path, false
+}
+
+// filesAt reads target as of rev, every file of it for a bundle
+func filesAt(r *repo.GitRepo, rev, target string, bundle bool) (map[string][]byte, *object.Commit, error) {
+	if bundle {
+		return r.FilesAt(rev, target)
+	}
+	data, commit, err := r.FileAt(rev, target)
+	if err != nil {
+		return nil, commit, err
+	}
+	return map[string][]byte{target: data}, commit, nil
+}
+
+// FunctionHistory lists the commits that touched the function's files, newest first
+func (h *FunctionHandlers) FunctionHistory(c *gin.Context) {
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(400, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = n
+	}
+
+	f, r, ok := h.projectFunction(c)
+	if !ok {
+		return
+	}
+	target, _ := functionFiles(r, f)
+	revs, err := r.History(target, limit)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		c.JSON(500, gin.H{"error": "error reading repo history"})
+		return
+	}
+	c.JSON(200, revs)
+}
+
+// FunctionVersion returns the function's source as of a commit
+func (h *FunctionHandlers) FunctionVersion(c *gin.Context) {
+	f, r, ok := h.projectFunction(c)
+	if !ok {
+		return
+	}
+	data, commit, err := r.FileAt(c.Param("sha"), f.Path)
+	if err != nil {
+		revisionError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"sha":     commit.Hash.String(),
+		"path":    f.Path,
+		"content": string(data),
+		"etag":    repo.BlobHash(data),
+	})
+}
+
+// FunctionDiff renders a unified diff of the function's files between ?from and ?to,
+// to defaults to HEAD. A file that didn't exist on a side reads as empty
+func (h *FunctionHandlers) FunctionDiff(c *gin.Context) {
+	from, to := c.Query("from"), c.DefaultQuery("to", "HEAD")
+	if from == "" {
+		c.JSON(400, gin.H{"error": "from is required"})
+		return
+	}
+	f, r, ok := h.projectFunction(c)
+	if !ok {
+		return
+	}
+	target, bundle := functionFiles(r, f)
+
+	side := func(rev string) (string, map[string][]byte, bool) {
+		files, commit, err := filesAt(r, rev, target, bundle)
+		if err != nil && !errors.Is(err, repo.ErrFileNotFound) {
+			revisionError(c, err)
+			return "", nil, false
+		}
+		return commit.Hash.String(), files, true
+	}
+	fromSHA, a, ok := side(from)
+	if !ok {
+		return
+	}
+	toSHA, b, ok := side(to)
+	if !ok {
+		return
+	}
+
+	paths := slices.Collect(maps.Keys(a))
+	for p := range b {
+		if _, ok := a[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	slices.Sort(paths)
+	var out strings.Builder
+	for _, p := range paths {
+		out.WriteString(diff.Unified(p+"@"+fromSHA[:7], p+"@"+toSHA[:7], string(a[p]), string(b[p])))
+	}
+
+	c.JSON(200, gin.H{
+		"from": fromSHA,
+		"to":   toSHA,
+		"diff": out.String(),
+	})
+}
+
+// RollbackFunction writes the files a function had at a commit back as one new commit,
+// a bundle loses the files added since. Like a save it honours If-Match for the entry,
+// without one it applies over whatever is current
+func (h *FunctionHandlers) RollbackFunction(c *gin.Context) {
+	var req struct {
+		SHA     string `json:"sha"`
+		Message string `json:"message"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.SHA == "" {
+		c.JSON(400, gin.H{"error": "sha is required"})
+		return
+	}
+	f, r, ok := h.projectFunction(c)
+	if !ok {
+		return
+	}
+	target, bundle := functionFiles(r, f)
+	old, commit, err := filesAt(r, req.SHA, target, bundle)
+	if err == nil && old[f.Path] == nil {
+		err = fmt.Errorf("%w: %s at %s", repo.ErrFileNotFound, f.Path, commit.Hash)
+	}
+	if err != nil {
+		revisionError(c, err)
+		return
+	}
+
+	base := parseIfMatch(c.GetHeader("If-Match"))
+	if base == "" {
+		base = "*"
+	}
+	edits := []fileEdit{{Path: f.Path, Content: old[f.Path], Base: base}}
+	for _, p := range slices.Sorted(maps.Keys(old)) {
+		if p != f.Path {
+			edits = append(edits, fileEdit{Path: p, Content: old[p], Base: "*"})
+		}
+	}
+	if bundle {
+		current, err := r.ListFiles(target)
+		if err != nil {
+			fmt.Printf("[ERROR] listing bundle %s failed: %v\n", target, err)
+			c.JSON(500, gin.H{"error": "error reading bundle"})
+			return
+		}
+		for _, p := range current {
+			if _, ok := old[p]; !ok {
+				edits = append(edits, fileEdit{Path: p, Remove: true, Base: "*"})
+			}
+		}
+	}
+
+	ch := commitChange(c, h.state.DBPool, req.Message)
+	if ch.Message == "" {
+		ch.Message = fmt.Sprintf("rollback %s to %s", target, commit.Hash.String()[:7])
+	}
+	stale, err := commitEdits(r, edits, ch)
+	if err != nil {
+		saveFailed(c, err)
+		return
+	}
+	if len(stale) > 0 {
+		sourceConflict(c, r, stale[0])
+		return
+	}
+	savedSource(c, r, repo.BlobHash(old[f.Path]))
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/auth"
+	"github.com/ashupednekar/litewebservices-portal/internal/function"
+	projectaccess "github.com/ashupednekar/litewebservices-portal/internal/project"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/vendors"
+	"github.com/ashupednekar/litewebservices-portal/pkg"
+	"github.com/gin-gonic/gin"
+)
+
+// Importing adopts a repo that already exists, on any branch and with its functions
+// under any directory, where CreateProject makes a fresh one. Nothing is pushed to it,
+// the functions found there are registered and reported along with the files that
+// weren't functions and the ones whose names clash.
+
+type importProjectRequest struct {
+	// URL is the repo's https or ssh git url, ssh urls select ssh auth
+	URL string `json:"url"`
+	// Name defaults to the repo's
+	Name   string `json:"name"`
+	Branch string `json:"branch"`
+	// Root is the directory holding <language>/<name> functions, empty for the repo's top
+	Root string `json:"root"`
+	// Vendor is needed for forges other than github.com and the deployment's own
+	Vendor   string `json:"vendor"`
+	AuthMode string `json:"auth_mode"`
+	// without a token the deployment's credentials are used, only for a repo of its own
+	// account that no project uses yet, imported under the repo's name
+	Token          string `json:"token"`
+	SSHKey         string `json:"ssh_key"`
+	SSHKeyPassword string `json:"ssh_key_password"`
+}
+
+// ImportProject creates a project on an existing repo. The branch is cloned first, so
+// the credentials and the functions root are known to work before anything is stored
+func (h *ProjectHandlers) ImportProject(c *gin.Context) {
+	var req importProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	userID := c.MustGet("userID").([]byte)
+	if token, ok := c.Get("accessToken"); ok && len(token.(auth.AccessToken).ProjectIDs) > 0 {
+		c.JSON(403, gin.H{"error": "project scoped tokens can't create projects"})
+		return
+	}
+
+	conn, err := vendors.ParseRepoURL(req.URL)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Vendor != "" {
+		conn.Vendor = req.Vendor
+	}
+	if req.AuthMode != "" {
+		conn.AuthMode = req.AuthMode
+	}
+	conn.Token = req.Token
+	conn.SSHKeyPassword = req.SSHKeyPassword
+	if req.SSHKey != "" {
+		conn.SSHKey = []byte(req.SSHKey)
+	}
+	name := req.Name
+	if name == "" {
+		name = conn.Repo
+	}
+	borrowed := conn.Token == ""
+	if borrowed {
+		// the deployment's credentials reach every repo of its account, borrowing them is
+		// only for one that becomes this project's default repo
+		if !conn.CanBorrow(name) {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("a token is required, the deployment's credentials only import %s/<repo> as a project of the same name", pkg.Cfg.VcsUser)})
+			return
+		}
+		inUse, err := adaptors.New(h.state.DBPool).VcsRepoInUse(c.Request.Context(), adaptors.VcsRepoInUseParams{Owner: conn.Owner, RepoName: conn.Repo})
+		if err != nil {
+			fmt.Printf("[ERROR] DB VcsRepoInUse: %v\n", err)
+			c.JSON(500, gin.H{"error": "database error"})
+			return
+		}
+		if inUse {
+			c.JSON(400, gin.H{"error": "another project uses this repo, send a token of your own to import it"})
+			return
+		}
+		def := vendors.DefaultConnection(name)
+		conn.Token = def.Token
+		if len(conn.SSHKey) == 0 {
+			conn.SSHKeyPath = def.SSHKeyPath
+			conn.SSHKeyPassword = def.SSHKeyPassword
+		}
+	}
+	if err := conn.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	branch := req.Branch
+	if branch == "" {
+		branch = repo.DefaultBranch
+	}
+	if !projectaccess.ValidBranchName(branch) {
+		c.JSON(400, gin.H{"error": "invalid branch name"})
+		return
+	}
+	root, err := function.CleanRoot(req.Root)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	r, err := repo.NewGitRepo(conn, name, &branch)
+	if err != nil {
+		fmt.Printf("[ERROR] Import clone of %s: %v\n", req.URL, err)
+		c.JSON(400, gin.H{"error": fmt.Sprintf("couldn't clone %s@%s: %v", req.URL, branch, err)})
+		return
+	}
+	if root != "" {
+		if info, err := r.Fs.Stat(root); err != nil || !info.IsDir() {
+			if err != nil && !os.IsNotExist(err) {
+				fmt.Printf("[ERROR] Import stat %s: %v\n", root, err)
+			}
+			c.JSON(400, gin.H{"error": fmt.Sprintf("%s has no %s directory", branch, root)})
+			return
+		}
+	}
+
+	tx, err := h.state.DBPool.Begin(c.Request.Context())
+	if err != nil {
+		fmt.Printf("[ERROR] DB Begin: %v\n", err)
+		c.JSON(500, gin.H{"error": "failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(c.Request.Context())
+	q := adaptors.New(h.state.DBPool).WithTx(tx)
+
+	project, ok := insertProject(c, q, name, root, branch, userID)
+	if !ok {
+		return
+	}
+	params := adaptors.UpsertProjectVcsConnectionParams{
+		ProjectID: project.ID,
+		UpdatedBy: userID,
+	}
+	if err := sealVcsConnection(conn, borrowed, &params); err != nil {
+		fmt.Printf("[ERROR] sealing vcs credentials: %v\n", err)
+		c.JSON(500, gin.H{"error": "the portal can't store vcs credentials, is VCS_CREDENTIALS_KEY set?"})
+		return
+	}
+	if _, err := q.UpsertProjectVcsConnection(c.Request.Context(), params); err != nil {
+		fmt.Printf("[ERROR] DB UpsertProjectVcsConnection: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	if err := tx.Commit(c.Request.Context()); err != nil {
+		fmt.Printf("[ERROR] DB Commit: %v\n", err)
+		c.JSON(500, gin.H{"error": "failed to commit transaction"})
+		return
+	}
+
+	out := gin.H{
+		"id":     hex.EncodeToString(project.ID.Bytes[:]),
+		"name":   project.Name,
+		"branch": branch,
+		"root":   root,
+	}
+	// the project exists from here on, what fails now is reported rather than undone
+	var warnings []string
+	plan, err := syncFunctions(c.Request.Context(), h.state.DBPool, project.ID, r, userID, root, false)
+	if err != nil {
+		fmt.Printf("[ERROR] Import sync of %s: %v\n", name, err)
+		warnings = append(warnings, fmt.Sprintf("registering functions failed, sync the project to retry: %v", err))
+	}
+	out["report"] = plan
+
+	vcs, err := vendors.NewVendorClient(conn)
+	if err == nil {
+		err = addProjectWebhook(c.Request.Context(), vcs, conn, project)
+	}
+	if err != nil {
+		fmt.Printf("[WARN] Import webhook for %s: %v\n", name, err)
+		warnings = append(warnings, fmt.Sprintf("no push webhook, changes pushed to the repo need a manual sync: %v", err))
+	}
+	if len(warnings) > 0 {
+		out["warnings"] = warnings
+	}
+	c.JSON(201, out)
+}
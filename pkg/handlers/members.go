@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	authadaptors "github.com/ashupednekar/litewebservices-portal/internal/auth/adaptors"
+	projectaccess "github.com/ashupednekar/litewebservices-portal/internal/project"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/pkg"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ownerProject is memberProject for routes that only owners may use
+func (h *ProjectHandlers) ownerProject(c *gin.Context) (adaptors.Project, bool) {
+	project, member, ok := h.memberProject(c)
+	if !ok {
+		return adaptors.Project{}, false
+	}
+	if !projectaccess.RoleOf(member.Role.String).CanManageMembers() {
+		c.JSON(403, gin.H{"error": "only project owners can manage members"})
+		return adaptors.Project{}, false
+	}
+	return project, true
+}
+
+func (h *ProjectHandlers) ListMembers(c *gin.Context) {
+	project, _, ok := h.memberProject(c)
+	if !ok {
+		return
+	}
+
+	q := adaptors.New(h.state.DBPool)
+	members, err := q.ListProjectMembers(c.Request.Context(), project.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(members))
+	for _, m := range members {
+		out = append(out, gin.H{
+			"user_id":      hex.EncodeToString(m.ID),
+			"name":         m.Name,
+			"display_name": m.DisplayName,
+			"role":         projectaccess.RoleOf(m.Role.String),
+			"joined_at":    m.CreatedAt.Time,
+		})
+	}
+	c.JSON(200, out)
+}
+
+// AddMember adds an already registered user by username
+func (h *ProjectHandlers) AddMember(c *gin.Context) {
+	project, ok := h.ownerProject(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Username string `json:"username"`
+		Role     string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	role, ok := projectaccess.ParseRole(req.Role)
+	if !ok {
+		c.JSON(400, gin.H{"error": "invalid role"})
+		return
+	}
+
+	user, err := authadaptors.New(h.state.DBPool).GetUserByName(c.Request.Context(), strings.TrimSpace(req.Username))
+	if err != nil {
+		c.JSON(404, gin.H{"error": "user not found"})
+		return
+	}
+
+	q := adaptors.New(h.state.DBPool)
+	if _, err := q.GetProjectMember(c.Request.Context(), adaptors.GetProjectMemberParams{
+		UserID:    user.ID,
+		ProjectID: project.ID,
+	}); err == nil {
+		c.JSON(409, gin.H{"error": "user is already a member"})
+		return
+	}
+
+	if err := q.AddUserToProject(c.Request.Context(), adaptors.AddUserToProjectParams{
+		UserID:    user.ID,
+		ProjectID: project.ID,
+		Role:      pgtype.Text{String: string(role), Valid: true},
+	}); err != nil {
+		fmt.Printf("[ERROR] DB AddUser: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"user_id": hex.EncodeToString(user.ID),
+		"name":    user.Name,
+		"role":    role,
+	})
+}
+
+// countOwners reports how many owners the project has and the current role of userID, if a member
+func countOwners(members []adaptors.ListProjectMembersRow, userID []byte) (int, projectaccess.Role, bool) {
+	owners, found := 0, false
+	var role projectaccess.Role
+	for _, m := range members {
+		r := projectaccess.RoleOf(m.Role.String)
+		if r == projectaccess.Owner {
+			owners++
+		}
+		if bytes.Equal(m.ID, userID) {
+			found, role = true, r
+		}
+	}
+	return owners, role, found
+}
+
+func (h *ProjectHandlers) UpdateMemberRole(c *gin.Context) {
+	project, ok := h.ownerProject(c)
+	if !ok {
+		return
+	}
+
+	userID, err := hex.DecodeString(c.Param("userID"))
+	if err != nil || len(userID) == 0 {
+		c.JSON(400, gin.H{"error": "invalid user id"})
+		return
+	}
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	role, ok := projectaccess.ParseRole(req.Role)
+	if !ok {
+		c.JSON(400, gin.H{"error": "invalid role"})
+		return
+	}
+
+	q := adaptors.New(h.state.DBPool)
+	members, err := q.ListProjectMembers(c.Request.Context(), project.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	owners, current, found := countOwners(members, userID)
+	if !found {
+		c.JSON(404, gin.H{"error": "member not found"})
+		return
+	}
+	if current == projectaccess.Owner && role != projectaccess.Owner && owners == 1 {
+		c.JSON(409, gin.H{"error": "cannot demote the last owner"})
+		return
+	}
+
+	member, err := q.UpdateProjectMemberRole(c.Request.Context(), adaptors.UpdateProjectMemberRoleParams{
+		UserID:    userID,
+		ProjectID: project.ID,
+		Role:      pgtype.Text{String: string(role), Valid: true},
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] DB UpdateProjectMemberRole: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"user_id": hex.EncodeToString(member.UserID),
+		"role":    projectaccess.RoleOf(member.Role.String),
+	})
+}
+
+// RemoveMember is owner only, and refuses to leave a project without an owner
+func (h *ProjectHandlers) RemoveMember(c *gin.Context) {
+	project, ok := h.ownerProject(c)
+	if !ok {
+		return
+	}
+
+	userID, err := hex.DecodeString(c.Param("userID"))
+	if err != nil || len(userID) == 0 {
+		c.JSON(400, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	q := adaptors.New(h.state.DBPool)
+	members, err := q.ListProjectMembers(c.Request.Context(), project.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	owners, role, found := countOwners(members, userID)
+	if !found {
+		c.JSON(404, gin.H{"error": "member not found"})
+		return
+	}
+	if role == projectaccess.Owner && owners == 1 {
+		c.JSON(409, gin.H{"error": "cannot remove the last owner"})
+		return
+	}
+
+	if err := q.RemoveUserFromProject(c.Request.Context(), adaptors.RemoveUserFromProjectParams{
+		UserID:    userID,
+		ProjectID: project.ID,
+	}); err != nil {
+		fmt.Printf("[ERROR] DB RemoveUserFromProject: %v\n", err)
+		c.JSON(500, gin.H{"error": "db delete error"})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "removed"})
+}
+
+func inviteJSON(inv adaptors.ProjectInvite) gin.H {
+	return gin.H{
+		"id":         hex.EncodeToString(inv.ID.Bytes[:]),
+		"role":       inv.Role,
+		"created_at": inv.CreatedAt.Time,
+		"expires_at": inv.ExpiresAt.Time,
+	}
+}
+
+// CreateInvite issues a single use invite link, the token is only ever returned here
+func (h *ProjectHandlers) CreateInvite(c *gin.Context) {
+	project, ok := h.ownerProject(c)
+	if !ok {
+		return
+	}
+	userID := c.MustGet("userID").([]byte)
+
+	var req struct {
+		Role           string `json:"role"`
+		ExpiresInHours int    `json:"expires_in_hours"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	role, ok := projectaccess.ParseRole(req.Role)
+	if !ok || role == projectaccess.Owner {
+		c.JSON(400, gin.H{"error": "invalid role, invites can grant maintainer, developer or viewer"})
+		return
+	}
+
+	token, hash, err := projectaccess.NewInviteToken()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to generate invite"})
+		return
+	}
+
+	q := adaptors.New(h.state.DBPool)
+	inv, err := q.CreateProjectInvite(c.Request.Context(), adaptors.CreateProjectInviteParams{
+		ProjectID: project.ID,
+		TokenHash: hash,
+		Role:      string(role),
+		CreatedBy: userID,
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(projectaccess.InviteTTL(req.ExpiresInHours)), Valid: true},
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] DB CreateProjectInvite: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	out := inviteJSON(inv)
+	out["token"] = token
+	out["url"] = fmt.Sprintf("https://%s/invite/%s/", pkg.Cfg.Fqdn, token)
+	c.JSON(201, out)
+}
+
+func (h *ProjectHandlers) ListInvites(c *gin.Context) {
+	project, ok := h.ownerProject(c)
+	if !ok {
+		return
+	}
+
+	q := adaptors.New(h.state.DBPool)
+	invites, err := q.ListPendingProjectInvites(c.Request.Context(), project.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(invites))
+	for _, inv := range invites {
+		out = append(out, inviteJSON(inv))
+	}
+	c.JSON(200, out)
+}
+
+func (h *ProjectHandlers) RevokeInvite(c *gin.Context) {
+	project, ok := h.ownerProject(c)
+	if !ok {
+		return
+	}
+	inviteID, err := parseHexUUID(c.Param("inviteID"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid invite id"})
+		return
+	}
+
+	q := adaptors.New(h.state.DBPool)
+	if err := q.DeleteProjectInvite(c.Request.Context(), adaptors.DeleteProjectInviteParams{
+		ID:        inviteID,
+		ProjectID: project.ID,
+	}); err != nil {
+		fmt.Printf("[ERROR] DB DeleteProjectInvite: %v\n", err)
+		c.JSON(500, gin.H{"error": "db delete error"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "revoked"})
+}
+
+// RedeemInvite consumes an invite link for the logged in user and makes the project active
+func (h *ProjectHandlers) RedeemInvite(c *gin.Context) {
+	userID := c.MustGet("userID").([]byte)
+	hash := projectaccess.HashInviteToken(c.Param("token"))
+
+	tx, err := h.state.DBPool.Begin(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(c.Request.Context())
+	q := adaptors.New(h.state.DBPool).WithTx(tx)
+
+	// the conditional update is what makes the link single use under concurrent redeems
+	inv, err := q.RedeemProjectInvite(c.Request.Context(), adaptors.RedeemProjectInviteParams{
+		TokenHash:  hash,
+		RedeemedBy: userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(410, gin.H{"error": "invite is invalid, expired or already used"})
+			return
+		}
+		fmt.Printf("[ERROR] DB RedeemProjectInvite: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	if _, err := q.GetProjectMember(c.Request.Context(), adaptors.GetProjectMemberParams{
+		UserID:    userID,
+		ProjectID: inv.ProjectID,
+	}); err == nil {
+		// rolling back leaves the link usable for whoever it was really meant for
+		c.JSON(409, gin.H{"error": "already a member of this project"})
+		return
+	}
+
+	if err := q.AddUserToProject(c.Request.Context(), adaptors.AddUserToProjectParams{
+		UserID:    userID,
+		ProjectID: inv.ProjectID,
+		Role:      pgtype.Text{String: inv.Role, Valid: true},
+	}); err != nil {
+		fmt.Printf("[ERROR] DB AddUser: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	project, err := q.GetProjectByID(c.Request.Context(), inv.ProjectID)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "project not found"})
+		return
+	}
+
+	if err := tx.Commit(c.Request.Context()); err != nil {
+		c.JSON(500, gin.H{"error": "failed to commit transaction"})
+		return
+	}
+
+	projectID := hex.EncodeToString(project.ID.Bytes[:])
+	c.SetCookie("lws_project", projectID, 0, "/", "", false, false)
+	c.JSON(200, gin.H{
+		"id":   projectID,
+		"name": project.Name,
+		"role": inv.Role,
+	})
+}
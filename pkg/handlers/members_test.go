@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"testing"
+
+	projectaccess "github.com/ashupednekar/litewebservices-portal/internal/project"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/adaptors"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestCountOwners(t *testing.T) {
+	member := func(id byte, role string) adaptors.ListProjectMembersRow {
+		return adaptors.ListProjectMembersRow{ID: []byte{id}, Role: pgtype.Text{String: role, Valid: true}}
+	}
+	members := []adaptors.ListProjectMembersRow{
+		member(1, "owner"),
+		member(2, "developer"),
+		member(3, "owner"),
+		member(4, ""),
+	}
+
+	owners, role, found := countOwners(members, []byte{2})
+	if owners != 2 || role != projectaccess.Developer || !found {
+		t.Errorf("countOwners() = %d, %s, %v, want 2, developer, true", owners, role, found)
+	}
+	if _, role, _ := countOwners(members, []byte{4}); role != projectaccess.Viewer {
+		t.Errorf("member without a stored role = %s, want viewer", role)
+	}
+	if _, _, found := countOwners(members, []byte{9}); found {
+		t.Error("countOwners() found a non-member")
+	}
+}
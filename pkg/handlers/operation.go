@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	functionadaptors "github.com/ashupednekar/litewebservices-portal/internal/function/adaptors"
+	projectadaptors "github.com/ashupednekar/litewebservices-portal/internal/project/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
+	"github.com/ashupednekar/litewebservices-portal/pkg/state"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Creating or deleting a function changes both the repo and the functions table, which
+// can't share a transaction. Each one is logged as an operation before either is touched
+// and finished once both hold the change. A push that fails leaves the operation pending,
+// the worker retries it with backoff until it lands or the attempts run out, when it's
+// compensated: a create drops its row, a delete keeps it. Maintainers see the operations
+// that are stuck and can retry or abandon them.
+
+const (
+	opCreate = "create"
+	opDelete = "delete"
+
+	opPending     = "pending"
+	opDone        = "done"
+	opCompensated = "compensated"
+
+	// opLease keeps the worker off an operation while a request or another worker runs it
+	opLease = 5 * time.Minute
+	// opMaxAttempts failed pushes compensate an operation
+	opMaxAttempts = 8
+	opMaxBackoff  = time.Hour
+	opBatch       = 20
+)
+
+// opEdit is one file of an operation's push as stored in its edits
+type opEdit struct {
+	Path    string `json:"path"`
+	Content []byte `json:"content,omitempty"`
+	Remove  bool   `json:"remove,omitempty"`
+}
+
+func interval(d time.Duration) pgtype.Interval {
+	return pgtype.Interval{Microseconds: d.Microseconds(), Valid: true}
+}
+
+// opBackoff is the wait before retrying an operation that failed attempts times
+func opBackoff(attempts int32) time.Duration {
+	if attempts >= 6 {
+		return opMaxBackoff
+	}
+	return min(time.Minute<<attempts, opMaxBackoff)
+}
+
+// logOperation records the operation pushing edits as ch, leased to the caller, which runs
+// it straight away. q may be in the caller's transaction
+func logOperation(ctx context.Context, q *functionadaptors.Queries, kind string, f functionadaptors.Function, branch string, edits []fileEdit, ch repo.Change, userID []byte) (functionadaptors.FunctionOperation, error) {
+	stored := make([]opEdit, 0, len(edits))
+	for _, e := range edits {
+		stored = append(stored, opEdit{Path: e.Path, Content: e.Content, Remove: e.Remove})
+	}
+	body, err := json.Marshal(stored)
+	if err != nil {
+		return functionadaptors.FunctionOperation{}, err
+	}
+	return q.CreateFunctionOperation(ctx, functionadaptors.CreateFunctionOperationParams{
+		ProjectID:     f.ProjectID,
+		FunctionID:    f.ID,
+		Kind:          kind,
+		Branch:        branch,
+		FunctionPath:  f.Path,
+		Edits:         body,
+		CommitMessage: ch.Message,
+		AuthorName:    ch.Author.Name,
+		AuthorEmail:   ch.Author.Email,
+		CreatedBy:     userID,
+		Lease:         interval(opLease),
+	})
+}
+
+// operationEdits turns the stored edits into the push: a create's files must not exist
+// yet, a delete removes whatever is there. A retry leaves out the files already as the
+// operation wants them, an earlier attempt may have pushed before failing
+func operationEdits(r *repo.GitRepo, op functionadaptors.FunctionOperation, retry bool) ([]fileEdit, error) {
+	var stored []opEdit
+	if err := json.Unmarshal(op.Edits, &stored); err != nil {
+		return nil, fmt.Errorf("reading the operation's edits: %w", err)
+	}
+	edits := make([]fileEdit, 0, len(stored))
+	for _, e := range stored {
+		if retry {
+			current, err := r.ReadFile(e.Path)
+			exists := err == nil
+			if e.Remove && !exists || !e.Remove && exists && repo.BlobHash(current) == repo.BlobHash(e.Content) {
+				continue
+			}
+		}
+		fe := fileEdit{Path: e.Path, Content: e.Content, Remove: e.Remove}
+		if e.Remove {
+			fe.Base = "*"
+		}
+		edits = append(edits, fe)
+	}
+	return edits, nil
+}
+
+// runOperation pushes op to r and finishes it. A push that fails is recorded for a
+// retry and its error returned; files a create finds already taken compensate it and
+// come back as stale
+func runOperation(ctx context.Context, pool *pgxpool.Pool, r *repo.GitRepo, op functionadaptors.FunctionOperation, retry bool) ([]staleFile, error) {
+	if op.Kind == opCreate && !op.FunctionID.Valid {
+		// the row went while the push was pending, pushing now would bring it back
+		return nil, compensateOperation(ctx, pool, op, "the function was deleted before its files were pushed")
+	}
+	edits, err := operationEdits(r, op, retry)
+	if err != nil {
+		return nil, failOperation(ctx, pool, op, err)
+	}
+	if len(edits) > 0 {
+		ch := repo.Change{Message: op.CommitMessage, Author: repo.Author{Name: op.AuthorName, Email: op.AuthorEmail}}
+		stale, err := commitEdits(r, edits, ch)
+		if err != nil {
+			return nil, failOperation(ctx, pool, op, err)
+		}
+		if len(stale) > 0 {
+			return stale, compensateOperation(ctx, pool, op, stale[0].Path+" already exists in the repo")
+		}
+	}
+	return nil, finishOperation(ctx, pool, op)
+}
+
+// finishOperation brings the table in line with a pushed operation and marks it done.
+// When this fails the push stays and the operation pending, the next run only finishes it
+func finishOperation(ctx context.Context, pool *pgxpool.Pool, op functionadaptors.FunctionOperation) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	q := functionadaptors.New(pool).WithTx(tx)
+	if op.Kind == opDelete && op.FunctionID.Valid {
+		if err := q.DeleteFunction(ctx, op.FunctionID); err != nil {
+			return err
+		}
+	}
+	if _, err := q.FinishFunctionOperation(ctx, functionadaptors.FinishFunctionOperationParams{ID: op.ID, State: opDone}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// compensateOperation undoes op's side in the table: a create drops the row it added, a
+// delete never removed its row so there's nothing to undo
+func compensateOperation(ctx context.Context, pool *pgxpool.Pool, op functionadaptors.FunctionOperation, reason string) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	q := functionadaptors.New(pool).WithTx(tx)
+	if op.Kind == opCreate && op.FunctionID.Valid {
+		if err := q.DeleteFunction(ctx, op.FunctionID); err != nil {
+			return err
+		}
+	}
+	_, err = q.FinishFunctionOperation(ctx, functionadaptors.FinishFunctionOperationParams{
+		ID:        op.ID,
+		State:     opCompensated,
+		LastError: pgtype.Text{String: reason, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	fmt.Printf("[WARN] function operation %x compensated: %s\n", op.ID.Bytes, reason)
+	return nil
+}
+
+// failOperation records a failed attempt and returns cause, compensating op once it has
+// used up its attempts
+func failOperation(ctx context.Context, pool *pgxpool.Pool, op functionadaptors.FunctionOperation, cause error) error {
+	failed, err := functionadaptors.New(pool).FailFunctionOperation(ctx, functionadaptors.FailFunctionOperationParams{
+		ID:        op.ID,
+		LastError: pgtype.Text{String: cause.Error(), Valid: true},
+		Backoff:   interval(opBackoff(op.Attempts)),
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] recording failed function operation %x: %v\n", op.ID.Bytes, err)
+		return cause
+	}
+	if failed.Attempts >= opMaxAttempts {
+		reason := fmt.Sprintf("gave up after %d attempts: %v", failed.Attempts, cause)
+		if err := compensateOperation(ctx, pool, failed, reason); err != nil {
+			fmt.Printf("[ERROR] compensating function operation %x: %v\n", op.ID.Bytes, err)
+		}
+	}
+	return cause
+}
+
+// operationPending answers 202 for an operation whose push failed and is left to the worker
+func operationPending(c *gin.Context, op functionadaptors.FunctionOperation, err error, out gin.H) {
+	fmt.Printf("[ERROR] function operation %x failed, retrying later: %v\n", op.ID.Bytes, err)
+	out["status"] = opPending
+	out["operation"] = hex.EncodeToString(op.ID.Bytes[:])
+	out["error"] = "the repo couldn't be updated, the change is retried in the background"
+	c.JSON(202, out)
+}
+
+// RetryFunctionOperationsEvery runs the due function operations on an interval until ctx is done
+func RetryFunctionOperationsEvery(ctx context.Context, s *state.AppState, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := retryFunctionOperations(ctx, s); err != nil {
+				fmt.Printf("[WARN] function operation retries failed: %v\n", err)
+			}
+		}
+	}
+}
+
+func retryFunctionOperations(ctx context.Context, s *state.AppState) error {
+	ops, err := functionadaptors.New(s.DBPool).ClaimDueFunctionOperations(ctx, functionadaptors.ClaimDueFunctionOperationsParams{
+		Lease: interval(opLease),
+		Batch: opBatch,
+	})
+	if err != nil {
+		return err
+	}
+	for _, op := range ops {
+		if err := retryOperation(ctx, s, op); err != nil {
+			fmt.Printf("[WARN] function operation %x: %v\n", op.ID.Bytes, err)
+		}
+	}
+	return nil
+}
+
+// retryOperation runs op on its branch's clone, pulled first so a push that landed after
+// all is seen. A clone that can't be had counts as a failed attempt too
+func retryOperation(ctx context.Context, s *state.AppState, op functionadaptors.FunctionOperation) error {
+	project, err := projectadaptors.New(s.DBPool).GetProjectByID(ctx, op.ProjectID)
+	if err != nil {
+		return err
+	}
+	ran := false
+	err = s.Repos.Sync(repo.Ref{Project: project.Name, Branch: op.Branch}, func(r *repo.GitRepo) error {
+		ran = true
+		_, err := runOperation(ctx, s.DBPool, r, op, true)
+		return err
+	})
+	if err != nil && !ran {
+		return failOperation(ctx, s.DBPool, op, err)
+	}
+	return err
+}
+
+func operationJSON(op functionadaptors.FunctionOperation) gin.H {
+	out := gin.H{
+		"id":              hex.EncodeToString(op.ID.Bytes[:]),
+		"kind":            op.Kind,
+		"function_id":     nil,
+		"path":            op.FunctionPath,
+		"branch":          op.Branch,
+		"message":         op.CommitMessage,
+		"state":           op.State,
+		"attempts":        op.Attempts,
+		"last_error":      op.LastError.String,
+		"next_attempt_at": op.NextAttemptAt.Time,
+		"created_at":      op.CreatedAt.Time,
+		"updated_at":      op.UpdatedAt.Time,
+	}
+	if op.FunctionID.Valid {
+		out["function_id"] = hex.EncodeToString(op.FunctionID.Bytes[:])
+	}
+	return out
+}
+
+// ListOperations lists the project's function operations in ?state, pending by default,
+// so the ones stuck retrying can be looked into
+func (h *FunctionHandlers) ListOperations(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	st := c.DefaultQuery("state", opPending)
+	if st != opPending && st != opDone && st != opCompensated {
+		c.JSON(400, gin.H{"error": "state must be pending, done or compensated"})
+		return
+	}
+	ops, err := functionadaptors.New(h.state.DBPool).ListFunctionOperations(c.Request.Context(), functionadaptors.ListFunctionOperationsParams{
+		ProjectID: projectUUID,
+		State:     st,
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] DB ListFunctionOperations: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	resp := make([]gin.H, 0, len(ops))
+	for _, op := range ops {
+		resp = append(resp, operationJSON(op))
+	}
+	c.JSON(200, resp)
+}
+
+// RetryOperation runs a pending operation now instead of waiting for its next attempt
+func (h *FunctionHandlers) RetryOperation(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	opID, err := parseHexUUID(c.Param("opID"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid operation id"})
+		return
+	}
+	q := functionadaptors.New(h.state.DBPool)
+	op, err := q.RetryFunctionOperation(c.Request.Context(), functionadaptors.RetryFunctionOperationParams{
+		Lease:     interval(opLease),
+		ID:        opID,
+		ProjectID: projectUUID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(404, gin.H{"error": "no pending operation with this id"})
+		return
+	}
+	if err != nil {
+		fmt.Printf("[ERROR] DB RetryFunctionOperation: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	if err := retryOperation(c.Request.Context(), h.state, op); err != nil {
+		fmt.Printf("[WARN] retrying function operation %x: %v\n", op.ID.Bytes, err)
+	}
+	if op, err = q.GetFunctionOperation(c.Request.Context(), functionadaptors.GetFunctionOperationParams{ID: opID, ProjectID: projectUUID}); err != nil {
+		fmt.Printf("[ERROR] DB GetFunctionOperation: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	c.JSON(200, operationJSON(op))
+}
+
+// AbandonOperation stops retrying a pending operation and compensates it. A create whose
+// push did land leaves its files in the repo, the next sync registers them again
+func (h *FunctionHandlers) AbandonOperation(c *gin.Context) {
+	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
+	opID, err := parseHexUUID(c.Param("opID"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid operation id"})
+		return
+	}
+	q := functionadaptors.New(h.state.DBPool)
+	op, err := q.GetFunctionOperation(c.Request.Context(), functionadaptors.GetFunctionOperationParams{ID: opID, ProjectID: projectUUID})
+	if err != nil || op.State != opPending {
+		c.JSON(404, gin.H{"error": "no pending operation with this id"})
+		return
+	}
+	if err := compensateOperation(c.Request.Context(), h.state.DBPool, op, "abandoned by "+c.GetString("userName")); err != nil {
+		fmt.Printf("[ERROR] compensating function operation %x: %v\n", op.ID.Bytes, err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	if op, err = q.GetFunctionOperation(c.Request.Context(), functionadaptors.GetFunctionOperationParams{ID: opID, ProjectID: projectUUID}); err != nil {
+		fmt.Printf("[ERROR] DB GetFunctionOperation: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	c.JSON(200, operationJSON(op))
+}
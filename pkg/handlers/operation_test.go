@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	functionadaptors "github.com/ashupednekar/litewebservices-portal/internal/function/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
+	"github.com/go-git/go-billy/v6/memfs"
+	"github.com/go-git/go-billy/v6/util"
+)
+
+func TestOperationEditsSkipsWhatARetryFindsDone(t *testing.T) {
+	r := &repo.GitRepo{Fs: memfs.New()}
+	for p, content := range map[string]string{
+		"functions/python/api/app.py":   "print(1)\n",
+		"functions/python/api/lws.yaml": "stale manifest\n",
+		"functions/lua/old.lua":         "return 1\n",
+	} {
+		if err := util.WriteFile(r.Fs, p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stored, err := json.Marshal([]opEdit{
+		{Path: "functions/python/api/app.py", Content: []byte("print(1)\n")},
+		{Path: "functions/python/api/lws.yaml", Content: []byte("name: api\n")},
+		{Path: "functions/python/api/util.py", Content: []byte("x = 1\n")},
+		{Path: "functions/lua/old.lua", Remove: true},
+		{Path: "functions/lua/gone.lua", Remove: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := functionadaptors.FunctionOperation{Edits: stored}
+
+	first, err := operationEdits(r, op, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 5 || first[0].Base != "" || first[3].Base != "*" {
+		t.Errorf("first run edits %+v, want every edit with creates based on no file", first)
+	}
+
+	retry, err := operationEdits(r, op, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, e := range retry {
+		paths = append(paths, e.Path)
+	}
+	// a file with other content is left in, the push then finds it stale
+	want := []string{"functions/python/api/lws.yaml", "functions/python/api/util.py", "functions/lua/old.lua"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("retry edits %v, want %v", paths, want)
+	}
+}
+
+func TestOpBackoff(t *testing.T) {
+	for attempts, want := range map[int32]time.Duration{
+		0:  time.Minute,
+		1:  2 * time.Minute,
+		5:  32 * time.Minute,
+		6:  time.Hour,
+		60: time.Hour,
+	} {
+		if got := opBackoff(attempts); got != want {
+			t.Errorf("opBackoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -4,21 +4,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func ListProjects(c *gin.Context)  { c.JSON(200, "TODO") }
-func GetProject(c *gin.Context)    { c.JSON(200, "TODO") }
-func DeleteProject(c *gin.Context) { c.JSON(200, "TODO") }
-
 func CreateFunction(c *gin.Context) { c.JSON(200, "TODO") }
 func ListFunctions(c *gin.Context)  { c.JSON(200, "TODO") }
 func GetFunction(c *gin.Context)    { c.JSON(200, "TODO") }
 func UpdateFunction(c *gin.Context) { c.JSON(200, "TODO") }
 func DeleteFunction(c *gin.Context) { c.JSON(200, "TODO") }
-
-func CreateEndpoint(c *gin.Context) { c.JSON(200, "TODO") }
-func ListEndpoints(c *gin.Context)  { c.JSON(200, "TODO") }
-func GetEndpoint(c *gin.Context)    { c.JSON(200, "TODO") }
-func UpdateEndpoint(c *gin.Context) { c.JSON(200, "TODO") }
-func DeleteEndpoint(c *gin.Context) { c.JSON(200, "TODO") }
-
-func GetProjectConfig(c *gin.Context)    { c.JSON(200, "TODO") }
-func UpdateProjectConfig(c *gin.Context) { c.JSON(200, "TODO") }
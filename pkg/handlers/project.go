@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"encoding/hex"
 	"fmt"
 	"strings"
 
+	"github.com/ashupednekar/litewebservices-portal/internal/auth"
+	"github.com/ashupednekar/litewebservices-portal/internal/function"
+	projectaccess "github.com/ashupednekar/litewebservices-portal/internal/project"
 	"github.com/ashupednekar/litewebservices-portal/internal/project/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
 	"github.com/ashupednekar/litewebservices-portal/internal/project/vendors"
-	"github.com/ashupednekar/litewebservices-portal/pkg"
 	"github.com/ashupednekar/litewebservices-portal/pkg/state"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -23,6 +27,8 @@ func NewProjectHandlers(s *state.AppState) *ProjectHandlers {
 func (h *ProjectHandlers) CreateProject(c *gin.Context) {
 	var req struct {
 		Name string `json:"name"`
+		// VCS puts the repo on a connection of its own instead of the deployment's
+		VCS *vcsConnectionRequest `json:"vcs"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": "invalid request"})
@@ -34,14 +40,42 @@ func (h *ProjectHandlers) CreateProject(c *gin.Context) {
 		c.JSON(401, gin.H{"error": "unauthorized"})
 		return
 	}
+	if token, ok := c.Get("accessToken"); ok && len(token.(auth.AccessToken).ProjectIDs) > 0 {
+		c.JSON(403, gin.H{"error": "project scoped tokens can't create projects"})
+		return
+	}
 
-	fmt.Printf("[DEBUG] Creating project: %s, User: %s, Vendor: %s\n", req.Name, pkg.Cfg.VcsUser, pkg.Cfg.VcsVendor)
-	vcsClient, err := vendors.NewVendorClient()
+	conn := vendors.DefaultConnection(req.Name)
+	repoOwner := ""
+	if req.VCS != nil {
+		conn = req.VCS.connection(req.Name)
+		if err := conn.Validate(); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		repoOwner = conn.Owner
+	}
+	fmt.Printf("[DEBUG] Creating project: %s, Owner: %s, Vendor: %s\n", req.Name, conn.Owner, conn.Vendor)
+	vcsClient, err := vendors.NewVendorClient(conn)
 	if err != nil {
 		fmt.Printf("[ERROR] VCS Init: %v\n", err)
 		c.JSON(500, gin.H{"error": fmt.Sprintf("failed to init vcs client: %v", err)})
 		return
 	}
+	// the deployment's credentials reach every repo of its account, one some project
+	// already points at isn't handed to a new project
+	if req.VCS == nil || conn.CanBorrow(req.Name) {
+		inUse, err := adaptors.New(h.state.DBPool).VcsRepoInUse(c.Request.Context(), adaptors.VcsRepoInUseParams{Owner: conn.Owner, RepoName: conn.Repo})
+		if err != nil {
+			fmt.Printf("[ERROR] DB VcsRepoInUse: %v\n", err)
+			c.JSON(500, gin.H{"error": "database error"})
+			return
+		}
+		if inUse {
+			c.JSON(409, gin.H{"error": fmt.Sprintf("another project already uses %s/%s", conn.Owner, conn.Repo)})
+			return
+		}
+	}
 
 	tx, err := h.state.DBPool.Begin(c.Request.Context())
 	if err != nil {
@@ -53,66 +87,56 @@ func (h *ProjectHandlers) CreateProject(c *gin.Context) {
 
 	q := adaptors.New(h.state.DBPool).WithTx(tx)
 
-	project, err := q.CreateProject(c.Request.Context(), adaptors.CreateProjectParams{
-		Name:        req.Name,
-		Description: pgtype.Text{Valid: false},
-		CreatedBy:   userID.([]byte),
-	})
-	if err != nil {
-		fmt.Printf("[ERROR] DB CreateProject: %v\n", err)
-		c.JSON(500, gin.H{"error": err.Error()})
+	project, ok := insertProject(c, q, req.Name, function.DefaultRoot, repo.DefaultBranch, userID.([]byte))
+	if !ok {
 		return
 	}
 
-	err = q.AddUserToProject(c.Request.Context(), adaptors.AddUserToProjectParams{
-		UserID:    userID.([]byte),
-		ProjectID: project.ID,
-		Role:      pgtype.Text{String: "owner", Valid: true},
-	})
-	if err != nil {
-		fmt.Printf("[ERROR] DB AddUser: %v\n", err)
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
+	if req.VCS != nil {
+		params := adaptors.UpsertProjectVcsConnectionParams{
+			ProjectID: project.ID,
+			UpdatedBy: userID.([]byte),
+		}
+		if err := sealVcsConnection(conn, false, &params); err != nil {
+			fmt.Printf("[ERROR] sealing vcs credentials: %v\n", err)
+			c.JSON(500, gin.H{"error": "the portal can't store vcs credentials, is VCS_CREDENTIALS_KEY set?"})
+			return
+		}
+		if _, err := q.UpsertProjectVcsConnection(c.Request.Context(), params); err != nil {
+			fmt.Printf("[ERROR] DB UpsertProjectVcsConnection: %v\n", err)
+			c.JSON(500, gin.H{"error": "database error"})
+			return
+		}
 	}
 
-	repo, err := vcsClient.CreateRepo(c.Request.Context(), vendors.CreateRepoOptions{
-		Name:        req.Name,
+	vcsRepo, err := vcsClient.CreateRepo(c.Request.Context(), vendors.CreateRepoOptions{
+		Owner:       repoOwner,
+		Name:        conn.Repo,
 		Description: "Created via LiteWebServices Portal",
 		Private:     true,
 		AutoInit:    true,
 	})
 	if err != nil {
+		// a repo the portal didn't create isn't adopted here, importing it is explicit
 		if strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "409") {
-			fmt.Printf("[INFO] Repo already exists, will sync existing functions\n")
-		} else {
-			fmt.Printf("[ERROR] VCS CreateRepo: %v\n", err)
-			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to create repo: %v", err)})
+			c.JSON(409, gin.H{"error": fmt.Sprintf("%s/%s already exists, import it instead", conn.Owner, conn.Repo)})
 			return
 		}
+		fmt.Printf("[ERROR] VCS CreateRepo: %v\n", err)
+		c.JSON(500, gin.H{"error": fmt.Sprintf("failed to create repo: %v", err)})
+		return
 	}
-
-	repoName := req.Name
-	if repo != nil {
-		repoName = repo.Name
+	conn.Repo = vcsRepo.Name
+	if err := q.SetProjectRepoCreatedByPortal(c.Request.Context(), adaptors.SetProjectRepoCreatedByPortalParams{ID: project.ID, RepoCreatedByPortal: true}); err != nil {
+		fmt.Printf("[ERROR] DB SetProjectRepoCreatedByPortal: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
 	}
 
-	webhookURL := fmt.Sprintf("https://%s/api/webhooks/vcs", pkg.Cfg.Fqdn)
-	_, err = vcsClient.AddWebhook(c.Request.Context(), pkg.Cfg.VcsUser, repoName, vendors.WebhookOptions{
-		URL:         webhookURL,
-		ContentType: "json",
-		Secret:      "TODO_GENERATE_SECRET",
-		Events:      []string{"push", "pull_request"},
-		Active:      true,
-		InsecureSSL: true,
-	})
-	if err != nil {
-		if strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "409") {
-			fmt.Printf("[INFO] Webhook already exists\n")
-		} else {
-			fmt.Printf("[ERROR] VCS AddWebhook: %v\n", err)
-			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to add webhook: %v", err)})
-			return
-		}
+	if err := addProjectWebhook(c.Request.Context(), vcsClient, conn, project); err != nil {
+		fmt.Printf("[ERROR] VCS AddWebhook: %v\n", err)
+		c.JSON(500, gin.H{"error": fmt.Sprintf("failed to add webhook: %v", err)})
+		return
 	}
 
 	if err := tx.Commit(c.Request.Context()); err != nil {
@@ -121,23 +145,270 @@ func (h *ProjectHandlers) CreateProject(c *gin.Context) {
 		return
 	}
 
-	if err := SyncRepoFunctionsToDb(c, h.state.DBPool, project.ID, req.Name, userID.([]byte)); err != nil {
+	err = h.state.Repos.Update(repo.DefaultRef(req.Name), func(r *repo.GitRepo) error {
+		return SyncRepoFunctionsToDb(c.Request.Context(), h.state.DBPool, project.ID, r, userID.([]byte))
+	})
+	if err != nil {
 		fmt.Printf("[WARN] Failed to sync repo functions: %v\n", err)
 	}
 
 	c.JSON(201, gin.H{"id": project.ID, "name": project.Name})
 }
 
+// insertProject adds a project with userID as its owner and the default environment on
+// branch, answering the error response itself
+func insertProject(c *gin.Context, q *adaptors.Queries, name, root, branch string, userID []byte) (adaptors.Project, bool) {
+	webhookSecret, err := vendors.GenerateWebhookSecret()
+	if err != nil {
+		fmt.Printf("[ERROR] Webhook secret: %v\n", err)
+		c.JSON(500, gin.H{"error": "failed to generate webhook secret"})
+		return adaptors.Project{}, false
+	}
+
+	project, err := q.CreateProject(c.Request.Context(), adaptors.CreateProjectParams{
+		Name:          name,
+		Description:   pgtype.Text{Valid: false},
+		CreatedBy:     userID,
+		WebhookSecret: pgtype.Text{String: webhookSecret, Valid: true},
+		FunctionsRoot: root,
+	})
+	if isUniqueViolation(err) {
+		c.JSON(409, gin.H{"error": "a project with this name already exists"})
+		return adaptors.Project{}, false
+	}
+	if err != nil {
+		fmt.Printf("[ERROR] DB CreateProject: %v\n", err)
+		c.JSON(500, gin.H{"error": err.Error()})
+		return adaptors.Project{}, false
+	}
+
+	err = q.AddUserToProject(c.Request.Context(), adaptors.AddUserToProjectParams{
+		UserID:    userID,
+		ProjectID: project.ID,
+		Role:      pgtype.Text{String: "owner", Valid: true},
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] DB AddUser: %v\n", err)
+		c.JSON(500, gin.H{"error": err.Error()})
+		return adaptors.Project{}, false
+	}
+
+	_, err = q.CreateProjectEnvironment(c.Request.Context(), adaptors.CreateProjectEnvironmentParams{
+		ProjectID: project.ID,
+		Name:      projectaccess.DefaultEnvironment,
+		Branch:    branch,
+		IsDefault: true,
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] DB CreateProjectEnvironment: %v\n", err)
+		c.JSON(500, gin.H{"error": err.Error()})
+		return adaptors.Project{}, false
+	}
+	return project, true
+}
+
+// SyncProject reconciles the functions table with the selected environment's branch and
+// answers with the plan, ?dry_run=true only works the plan out
 func (h *ProjectHandlers) SyncProject(c *gin.Context) {
 	projectUUID := c.MustGet("projectUUID").(pgtype.UUID)
-	projectName := c.MustGet("projectName").(string)
 	userID := c.MustGet("userID").([]byte)
+	ref := c.MustGet("repo").(*repo.Lease).Ref()
+	dryRun := c.Query("dry_run") == "true"
 
-	if err := SyncRepoFunctionsToDb(c, h.state.DBPool, projectUUID, projectName, userID); err != nil {
+	// an explicit sync pulls the selected environment's branch no matter how fresh the clone is
+	var plan SyncPlan
+	err := h.state.Repos.Sync(ref, func(r *repo.GitRepo) error {
+		var err error
+		plan, err = syncFunctions(c.Request.Context(), h.state.DBPool, projectUUID, r, userID, functionsRoot(c), dryRun)
+		return err
+	})
+	if err != nil {
 		fmt.Printf("[ERROR] Sync failed: %v\n", err)
-		c.JSON(500, gin.H{"error": "sync failed"})
+		c.JSON(500, gin.H{"error": fmt.Sprintf("sync failed: %v", err)})
+		return
+	}
+
+	c.JSON(200, plan)
+}
+
+func (h *ProjectHandlers) ListProjects(c *gin.Context) {
+	userID := c.MustGet("userID").([]byte)
+
+	q := adaptors.New(h.state.DBPool)
+	projects, err := q.ListProjectsForUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
 		return
 	}
 
-	c.JSON(200, gin.H{"status": "synced"})
+	out := make([]gin.H, 0, len(projects))
+	for _, p := range projects {
+		if !tokenAllows(c, p.ID) {
+			continue
+		}
+		out = append(out, gin.H{
+			"id":          hex.EncodeToString(p.ID.Bytes[:]),
+			"name":        p.Name,
+			"description": p.Description.String,
+			"role":        p.Role.String,
+			"protected":   p.Protected,
+			"created_at":  p.CreatedAt.Time,
+		})
+	}
+	c.JSON(200, out)
+}
+
+// tokenAllows is false when the request carries an access token scoped to other projects
+func tokenAllows(c *gin.Context, projectID pgtype.UUID) bool {
+	token, ok := c.Get("accessToken")
+	return !ok || token.(auth.AccessToken).AllowsProject(projectID)
+}
+
+// memberProject resolves the :id param and makes sure the current user belongs to that project
+func (h *ProjectHandlers) memberProject(c *gin.Context) (adaptors.Project, adaptors.UserProject, bool) {
+	userID := c.MustGet("userID").([]byte)
+
+	projectUUID, err := parseHexUUID(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid project id"})
+		return adaptors.Project{}, adaptors.UserProject{}, false
+	}
+
+	q := adaptors.New(h.state.DBPool)
+	member, err := q.GetProjectMember(c.Request.Context(), adaptors.GetProjectMemberParams{
+		UserID:    userID,
+		ProjectID: projectUUID,
+	})
+	if err != nil {
+		// non-members get the same answer as a missing project
+		c.JSON(404, gin.H{"error": "project not found"})
+		return adaptors.Project{}, adaptors.UserProject{}, false
+	}
+	if !tokenAllows(c, projectUUID) {
+		c.JSON(403, gin.H{"error": "token is not scoped to this project"})
+		return adaptors.Project{}, adaptors.UserProject{}, false
+	}
+
+	project, err := q.GetProjectByID(c.Request.Context(), projectUUID)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "project not found"})
+		return adaptors.Project{}, adaptors.UserProject{}, false
+	}
+	return project, member, true
+}
+
+func (h *ProjectHandlers) GetProject(c *gin.Context) {
+	project, member, ok := h.memberProject(c)
+	if !ok {
+		return
+	}
+
+	q := adaptors.New(h.state.DBPool)
+	members, err := q.ListProjectMembers(c.Request.Context(), project.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"id":           hex.EncodeToString(project.ID.Bytes[:]),
+		"name":         project.Name,
+		"description":  project.Description.String,
+		"role":         member.Role.String,
+		"member_count": len(members),
+		"protected":    project.Protected,
+		"created_at":   project.CreatedAt.Time,
+	})
+}
+
+// SetProtected switches a project's pull request workflow on or off. Protected projects
+// take portal saves on change branches that land through reviewed pull requests
+func (h *ProjectHandlers) SetProtected(c *gin.Context) {
+	project, member, ok := h.memberProject(c)
+	if !ok {
+		return
+	}
+	if !projectaccess.RoleOf(member.Role.String).CanManageMembers() {
+		c.JSON(403, gin.H{"error": "only project owners can change protection"})
+		return
+	}
+
+	var req struct {
+		Protected *bool `json:"protected"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Protected == nil {
+		c.JSON(400, gin.H{"error": "protected is required"})
+		return
+	}
+
+	project, err := adaptors.New(h.state.DBPool).SetProjectProtected(c.Request.Context(), adaptors.SetProjectProtectedParams{
+		ID:        project.ID,
+		Protected: *req.Protected,
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] DB SetProjectProtected: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	c.JSON(200, gin.H{"name": project.Name, "protected": project.Protected})
+}
+
+// DeleteProject removes the project row, the cached clone and, unless ?keep_repo=true, the remote repo
+func (h *ProjectHandlers) DeleteProject(c *gin.Context) {
+	project, member, ok := h.memberProject(c)
+	if !ok {
+		return
+	}
+	if !projectaccess.RoleOf(member.Role.String).CanManageMembers() {
+		c.JSON(403, gin.H{"error": "only project owners can delete a project"})
+		return
+	}
+	// a repo the portal created goes with the project unless ?keep_repo=true, an imported
+	// or connected one only when ?delete_repo=true asks for that explicitly
+	deleteRepo := c.Query("keep_repo") != "true" && (project.RepoCreatedByPortal || c.Query("delete_repo") == "true")
+
+	tx, err := h.state.DBPool.Begin(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(c.Request.Context())
+
+	q := adaptors.New(h.state.DBPool).WithTx(tx)
+	if err := q.DeleteProject(c.Request.Context(), project.ID); err != nil {
+		fmt.Printf("[ERROR] DB DeleteProject: %v\n", err)
+		c.JSON(500, gin.H{"error": "db delete error"})
+		return
+	}
+
+	// the remote goes first so a vcs failure leaves the project intact in the db
+	if deleteRepo {
+		vcsClient, conn, ok := projectVcs(c, h.state, project.Name)
+		if !ok {
+			return
+		}
+		if err := vcsClient.DeleteRepo(c.Request.Context(), conn.Owner, conn.Repo); err != nil {
+			if strings.Contains(err.Error(), "404") {
+				fmt.Printf("[INFO] Repo %s already gone\n", project.Name)
+			} else {
+				fmt.Printf("[ERROR] VCS DeleteRepo: %v\n", err)
+				c.JSON(502, gin.H{"error": fmt.Sprintf("failed to delete repo: %v", err)})
+				return
+			}
+		}
+	}
+
+	if err := tx.Commit(c.Request.Context()); err != nil {
+		fmt.Printf("[ERROR] DB Commit: %v\n", err)
+		c.JSON(500, gin.H{"error": "failed to commit transaction"})
+		return
+	}
+
+	h.state.Repos.Evict(project.Name)
+
+	if active, err := c.Cookie("lws_project"); err == nil && active == hex.EncodeToString(project.ID.Bytes[:]) {
+		c.SetCookie("lws_project", "", -1, "/", "", false, false)
+	}
+
+	c.JSON(200, gin.H{"status": "deleted", "repo_deleted": deleteRepo})
 }
@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
 
+	authadaptors "github.com/ashupednekar/litewebservices-portal/internal/auth/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/function"
 	functionadaptors "github.com/ashupednekar/litewebservices-portal/internal/function/adaptors"
+	projectadaptors "github.com/ashupednekar/litewebservices-portal/internal/project/adaptors"
 	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -20,63 +26,406 @@ var extLang = map[string]string{
 	".lua": "lua",
 }
 
-func SyncRepoFunctionsToDb(c *gin.Context, pool *pgxpool.Pool, projectUUID pgtype.UUID, projectName string, userID []byte) error {
-	r, err := repo.NewGitRepo(projectName, nil)
+// selectedEnvironment is the environment ProjectMiddleware resolved for the request
+func selectedEnvironment(c *gin.Context) projectadaptors.ProjectEnvironment {
+	return c.MustGet("environment").(projectadaptors.ProjectEnvironment)
+}
+
+// projectRepo takes the request's clone from the lease ProjectMiddleware set, acquiring it on
+// first use. It answers 500 itself when the clone can't be had
+func projectRepo(c *gin.Context) (*repo.GitRepo, bool) {
+	r, err := c.MustGet("repo").(*repo.Lease).Repo()
+	if err != nil {
+		fmt.Printf("[ERROR] repo acquire failed: %v\n", err)
+		c.JSON(500, gin.H{"error": "error instantiating repo"})
+		return nil, false
+	}
+	return r, true
+}
+
+// commitChange attributes a commit to the request's user, an empty message falls back
+// to the default for the commit
+func commitChange(c *gin.Context, pool *pgxpool.Pool, message string) repo.Change {
+	ch := repo.Change{Message: message}
+	userID, ok := c.Get("userID")
+	if !ok {
+		return ch
+	}
+	u, err := authadaptors.New(pool).GetUserCommitIdentity(c.Request.Context(), userID.([]byte))
 	if err != nil {
-		return fmt.Errorf("failed to clone repo: %w", err)
+		fmt.Printf("[WARN] commit identity lookup failed, using the user name: %v\n", err)
+		ch.Author = repo.UserAuthor(c.GetString("userName"), "")
+		return ch
+	}
+	ch.Author = repo.UserAuthor(u.Name, u.DisplayName)
+	return ch
+}
+
+// functionsRoot is the directory the request's project keeps its functions under
+func functionsRoot(c *gin.Context) string {
+	return c.GetString("functionsRoot")
+}
+
+// Syncing reconciles the functions table with the functions found in a clone: new files
+// are added, rows follow their files when the manifest changes a bundle's name or
+// language, files that moved carry their row along when the content is unchanged and rows
+// whose file is gone are removed. A file is only gone once no environment or open change
+// has it, the table is shared by all the project's branches.
+
+// SyncPlan is what a sync changes in the functions table, Applied is false for dry runs
+type SyncPlan struct {
+	Add       []SyncedFunction `json:"add"`
+	Update    []FunctionChange `json:"update"`
+	Rename    []FunctionChange `json:"rename"`
+	Remove    []SyncedFunction `json:"remove"`
+	Skipped   []SkippedFile    `json:"skipped"`
+	Conflicts []SyncConflict   `json:"conflicts"`
+	Applied   bool             `json:"applied"`
+
+	// rows whose file changed in place, their content hash is brought up to date
+	refresh []FunctionChange
+}
+
+type SyncedFunction struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	Path     string `json:"path"`
+	// Hash is the entry file's blob id, what renames are matched by
+	Hash string `json:"-"`
+
+	id pgtype.UUID
+}
+
+// FunctionChange moves an existing row from what the db holds to what the repo has
+type FunctionChange struct {
+	ID   string         `json:"id"`
+	From SyncedFunction `json:"from"`
+	To   SyncedFunction `json:"to"`
+
+	row functionadaptors.Function
+}
+
+// SkippedFile is a file under the functions root that isn't a function
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// SyncConflict is a function whose name is already taken by the function at With,
+// names are unique within a project
+type SyncConflict struct {
+	SyncedFunction
+	With string `json:"with"`
+}
+
+func newSyncPlan() SyncPlan {
+	return SyncPlan{
+		Add:       []SyncedFunction{},
+		Update:    []FunctionChange{},
+		Rename:    []FunctionChange{},
+		Remove:    []SyncedFunction{},
+		Skipped:   []SkippedFile{},
+		Conflicts: []SyncConflict{},
 	}
+}
 
-	q := functionadaptors.New(pool)
+// empty is a plan that leaves the table as it is
+func (p SyncPlan) empty() bool {
+	return len(p.Add)+len(p.Update)+len(p.Rename)+len(p.Remove)+len(p.refresh) == 0
+}
 
-	existingFns, err := q.ListFunctionsForProject(c.Request.Context(), projectUUID)
+// SyncRepoFunctionsToDb reconciles the functions table with the clone, the caller holds
+// the clone's lock
+func SyncRepoFunctionsToDb(ctx context.Context, pool *pgxpool.Pool, projectUUID pgtype.UUID, r *repo.GitRepo, userID []byte) error {
+	project, err := projectadaptors.New(pool).GetProjectByID(ctx, projectUUID)
 	if err != nil {
-		return fmt.Errorf("failed to list existing functions: %w", err)
+		return fmt.Errorf("failed to load project: %w", err)
+	}
+	plan, err := syncFunctions(ctx, pool, projectUUID, r, userID, project.FunctionsRoot, false)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[DEBUG] Synced %s@%s: %d added, %d updated, %d renamed, %d removed\n",
+		project.Name, r.Branch, len(plan.Add), len(plan.Update), len(plan.Rename), len(plan.Remove))
+	for _, cf := range plan.Conflicts {
+		fmt.Printf("[WARN] Not syncing %s, its name %q is taken by %s\n", cf.Path, cf.Name, cf.With)
+	}
+	return nil
+}
+
+// syncFunctions plans the reconciliation of the functions under root in the clone and,
+// unless dryRun, applies it in one transaction
+func syncFunctions(ctx context.Context, pool *pgxpool.Pool, projectUUID pgtype.UUID, r *repo.GitRepo, userID []byte, root string, dryRun bool) (SyncPlan, error) {
+	found, skipped, err := repoFunctions(r, root)
+	if err != nil {
+		return SyncPlan{}, fmt.Errorf("failed to walk functions: %w", err)
+	}
+	existing, err := functionadaptors.New(pool).ListFunctionsForProject(ctx, projectUUID)
+	if err != nil {
+		return SyncPlan{}, fmt.Errorf("failed to list existing functions: %w", err)
+	}
+	elsewhere, err := filesElsewhere(ctx, pool, projectUUID, r, existing, found)
+	if err != nil {
+		return SyncPlan{}, err
 	}
 
-	existingPaths := make(map[string]bool)
-	for _, fn := range existingFns {
-		existingPaths[fn.Path] = true
+	plan := planSync(found, existing, elsewhere)
+	plan.Skipped = append(plan.Skipped, skipped...)
+	if dryRun || plan.empty() {
+		return plan, nil
 	}
 
-	err = walkFunctions(r, "/functions", func(path string) error {
-		if existingPaths[path] {
-			fmt.Printf("[DEBUG] Function already exists in DB: %s\n", path)
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return plan, err
+	}
+	defer tx.Rollback(ctx)
+	if err := applySync(ctx, functionadaptors.New(pool).WithTx(tx), projectUUID, userID, plan); err != nil {
+		return plan, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return plan, err
+	}
+	plan.Applied = true
+	return plan, nil
+}
+
+// repoFunctions lists the functions under root in the clone with their entry file's hash
+func repoFunctions(r *repo.GitRepo, root string) ([]SyncedFunction, []SkippedFile, error) {
+	var found []SyncedFunction
+	var skipped []SkippedFile
+	err := walkFunctions(r, "/"+root, func(path, name, lang string) error {
+		data, err := r.ReadFile(path)
+		if err != nil {
+			skipped = append(skipped, SkippedFile{Path: path, Reason: "bundle entry file is missing"})
 			return nil
 		}
+		found = append(found, SyncedFunction{Name: name, Language: lang, Path: path, Hash: repo.BlobHash(data)})
+		return nil
+	}, func(path, reason string) {
+		skipped = append(skipped, SkippedFile{Path: path, Reason: reason})
+	})
+	return found, skipped, err
+}
 
-		ext := filepath.Ext(path)
-		lang, ok := extLang[ext]
+// filesElsewhere lists the files on the project's other branches when some rows' files
+// aren't in the clone, those rows are only gone when no other branch has them either.
+// The rows of creates still being retried count as elsewhere, their push hasn't landed
+func filesElsewhere(ctx context.Context, pool *pgxpool.Pool, projectUUID pgtype.UUID, r *repo.GitRepo, existing []functionadaptors.Function, found []SyncedFunction) (map[string]bool, error) {
+	here := make(map[string]bool, len(found))
+	for _, fn := range found {
+		here[fn.Path] = true
+	}
+	missing := false
+	for _, row := range existing {
+		missing = missing || !here[row.Path]
+	}
+	if !missing {
+		return nil, nil
+	}
+
+	pending, err := functionadaptors.New(pool).ListPendingCreatePaths(ctx, projectUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending creates: %w", err)
+	}
+	elsewhere := make(map[string]bool, len(pending))
+	for _, p := range pending {
+		elsewhere[p] = true
+	}
+
+	branches, err := projectadaptors.New(pool).ListTrackedBranches(ctx, projectUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	for _, branch := range branches {
+		if branch == r.Branch {
+			continue
+		}
+		files, err := r.BranchFiles(branch)
+		if errors.Is(err, repo.ErrRevisionNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("listing the files on %s: %w", branch, err)
+		}
+		for p := range files {
+			elsewhere[p] = true
+		}
+	}
+	return elsewhere, nil
+}
+
+// planSync works out how the rows in existing follow the functions found in a clone.
+// Rows that keep their name hold it, then updates, renames and additions claim theirs in
+// that order, and one whose name is already claimed is a conflict instead. The row of a
+// file that moved to a conflicting name is removed
+func planSync(found []SyncedFunction, existing []functionadaptors.Function, elsewhere map[string]bool) SyncPlan {
+	plan := newSyncPlan()
+	rows := make(map[string]functionadaptors.Function, len(existing))
+	for _, row := range existing {
+		rows[row.Path] = row
+	}
+
+	names := map[string]string{}
+	here := make(map[string]bool, len(found))
+	var changed []FunctionChange
+	var fresh []SyncedFunction
+	for _, fn := range found {
+		here[fn.Path] = true
+		row, ok := rows[fn.Path]
 		if !ok {
-			fmt.Printf("[DEBUG] Skipping unknown extension: %s\n", path)
-			return nil
+			fresh = append(fresh, fn)
+			continue
+		}
+		// changed rows hold their old name until they're updated
+		names[row.Name] = row.Path
+		ch := functionChange(row, fn)
+		switch {
+		case row.Name != fn.Name || row.Language != fn.Language:
+			changed = append(changed, ch)
+		case row.ContentHash != fn.Hash:
+			plan.refresh = append(plan.refresh, ch)
+		}
+	}
+	var gone []functionadaptors.Function
+	for _, row := range existing {
+		switch {
+		case here[row.Path]:
+		case elsewhere[row.Path]:
+			names[row.Name] = row.Path
+		default:
+			gone = append(gone, row)
 		}
+	}
 
-		name := strings.TrimSuffix(filepath.Base(path), ext)
+	claim := func(fn SyncedFunction) bool {
+		if other, taken := names[fn.Name]; taken && other != fn.Path {
+			plan.Conflicts = append(plan.Conflicts, SyncConflict{SyncedFunction: fn, With: other})
+			return false
+		}
+		names[fn.Name] = fn.Path
+		return true
+	}
 
-		_, err := q.CreateFunction(c.Request.Context(), functionadaptors.CreateFunctionParams{
-			ProjectID: projectUUID,
-			Name:      name,
-			Language:  lang,
-			Path:      path,
-			CreatedBy: userID,
-		})
-		if err != nil {
-			fmt.Printf("[WARN] Failed to create function %s in DB: %v\n", path, err)
-			return nil
+	for _, ch := range changed {
+		if !claim(ch.To) {
+			continue
 		}
+		if ch.From.Name != ch.To.Name {
+			delete(names, ch.From.Name)
+		}
+		plan.Update = append(plan.Update, ch)
+	}
 
-		fmt.Printf("[DEBUG] Synced function to DB: %s (%s)\n", name, lang)
-		return nil
-	})
+	moved := map[string]bool{}
+	for _, fn := range fresh {
+		row, ok := movedFrom(fn, gone, moved)
+		if !ok {
+			if claim(fn) {
+				plan.Add = append(plan.Add, fn)
+			}
+			continue
+		}
+		if claim(fn) {
+			moved[row.Path] = true
+			plan.Rename = append(plan.Rename, functionChange(row, fn))
+		}
+	}
 
-	if err != nil {
-		return fmt.Errorf("failed to walk functions: %w", err)
+	for _, row := range gone {
+		if !moved[row.Path] {
+			plan.Remove = append(plan.Remove, syncedRow(row))
+		}
+	}
+	return plan
+}
+
+// movedFrom finds the gone row fn's file was moved from, one with the same content that
+// isn't taken by another rename, preferring one of the same name
+func movedFrom(fn SyncedFunction, gone []functionadaptors.Function, taken map[string]bool) (functionadaptors.Function, bool) {
+	var match functionadaptors.Function
+	ok := false
+	for _, row := range gone {
+		if row.ContentHash == "" || row.ContentHash != fn.Hash || taken[row.Path] {
+			continue
+		}
+		if !ok || row.Name == fn.Name {
+			match, ok = row, true
+		}
+		if row.Name == fn.Name {
+			break
+		}
 	}
+	return match, ok
+}
+
+func syncedRow(row functionadaptors.Function) SyncedFunction {
+	return SyncedFunction{
+		ID:       hex.EncodeToString(row.ID.Bytes[:]),
+		Name:     row.Name,
+		Language: row.Language,
+		Path:     row.Path,
+		Hash:     row.ContentHash,
+		id:       row.ID,
+	}
+}
 
+func functionChange(row functionadaptors.Function, fn SyncedFunction) FunctionChange {
+	return FunctionChange{ID: hex.EncodeToString(row.ID.Bytes[:]), From: syncedRow(row), To: fn, row: row}
+}
+
+// applySync carries a plan out. Removals go first and updates before renames, the same
+// order planSync claimed names in, so no statement trips the unique name
+func applySync(ctx context.Context, q *functionadaptors.Queries, projectUUID pgtype.UUID, userID []byte, plan SyncPlan) error {
+	for _, fn := range plan.Remove {
+		if err := q.DeleteFunction(ctx, fn.id); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", fn.Path, err)
+		}
+	}
+	changes := append(append(append([]FunctionChange{}, plan.Update...), plan.Rename...), plan.refresh...)
+	for _, ch := range changes {
+		err := q.UpdateFunctionFromRepo(ctx, functionadaptors.UpdateFunctionFromRepoParams{
+			ID:          ch.row.ID,
+			Name:        ch.To.Name,
+			Language:    ch.To.Language,
+			Path:        ch.To.Path,
+			ContentHash: ch.To.Hash,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update %s: %w", ch.To.Path, err)
+		}
+	}
+	for _, fn := range plan.Add {
+		_, err := q.CreateFunction(ctx, functionadaptors.CreateFunctionParams{
+			ProjectID:   projectUUID,
+			Name:        fn.Name,
+			Language:    fn.Language,
+			Path:        fn.Path,
+			CreatedBy:   userID,
+			ContentHash: fn.Hash,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", fn.Path, err)
+		}
+	}
 	return nil
 }
 
-func walkFunctions(r *repo.GitRepo, dir string, fn func(path string) error) error {
+// walkFunctions calls fn for every function under dir: each source file with a known
+// extension, and each bundle once, with its entry file's path and the manifest's name
+// and language. Files that aren't functions go to skip with the reason why, hidden
+// directories aren't walked
+func walkFunctions(r *repo.GitRepo, dir string, fn func(path, name, lang string) error, skip func(path, reason string)) error {
+	if data, err := r.ReadFile(strings.TrimPrefix(filepath.Join(dir, function.ManifestFile), "/")); err == nil {
+		m, err := function.ParseManifest(data)
+		if err != nil {
+			skip(strings.TrimPrefix(dir, "/"), fmt.Sprintf("invalid bundle: %v", err))
+			return nil
+		}
+		return fn(strings.TrimPrefix(filepath.Join(dir, m.Entry), "/"), m.Name, m.Language)
+	}
+
 	entries, err := r.Fs.ReadDir(dir)
 	if err != nil {
 		return nil
@@ -86,14 +435,24 @@ func walkFunctions(r *repo.GitRepo, dir string, fn func(path string) error) erro
 		fullPath := filepath.Join(dir, e.Name())
 
 		if e.IsDir() {
-			if err := walkFunctions(r, fullPath, fn); err != nil {
-				return err
+			if strings.HasPrefix(e.Name(), ".") {
+				continue
 			}
-		} else {
-			relPath := strings.TrimPrefix(fullPath, "/")
-			if err := fn(relPath); err != nil {
+			if err := walkFunctions(r, fullPath, fn, skip); err != nil {
 				return err
 			}
+			continue
+		}
+
+		ext := filepath.Ext(fullPath)
+		lang, ok := extLang[ext]
+		if !ok {
+			skip(strings.TrimPrefix(fullPath, "/"), "unknown extension")
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), ext)
+		if err := fn(strings.TrimPrefix(fullPath, "/"), name, lang); err != nil {
+			return err
 		}
 	}
 
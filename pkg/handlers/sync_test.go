@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	functionadaptors "github.com/ashupednekar/litewebservices-portal/internal/function/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
+	"github.com/go-git/go-billy/v6/memfs"
+	"github.com/go-git/go-billy/v6/util"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestWalkFunctionsReportsSkips(t *testing.T) {
+	r := &repo.GitRepo{Fs: memfs.New()}
+	for p, content := range map[string]string{
+		"src/lws/lua/hello.lua":        "return 1\n",
+		"src/lws/lua/README.md":        "# hello\n",
+		"src/lws/python/api/lws.yaml":  "name: api\nlanguage: python\nentry: app.py\n",
+		"src/lws/python/api/app.py":    "print(1)\n",
+		"src/lws/python/api/notes.txt": "bundle files aren't walked\n",
+		"src/lws/go/broken/lws.yaml":   "language: go\n",
+		"src/lws/.cache/x.lua":         "hidden\n",
+		"elsewhere/other.lua":          "outside the root\n",
+	} {
+		if err := util.WriteFile(r.Fs, p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var found []SyncedFunction
+	var skipped []SkippedFile
+	err := walkFunctions(r, "/src/lws", func(path, name, lang string) error {
+		found = append(found, SyncedFunction{Name: name, Language: lang, Path: path})
+		return nil
+	}, func(path, reason string) {
+		skipped = append(skipped, SkippedFile{Path: path, Reason: reason})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantFound := []SyncedFunction{
+		{Name: "hello", Language: "lua", Path: "src/lws/lua/hello.lua"},
+		{Name: "api", Language: "python", Path: "src/lws/python/api/app.py"},
+	}
+	if !reflect.DeepEqual(found, wantFound) {
+		t.Errorf("found %+v, want %+v", found, wantFound)
+	}
+	if len(skipped) != 2 || skipped[0].Path != "src/lws/go/broken" || skipped[1] != (SkippedFile{Path: "src/lws/lua/README.md", Reason: "unknown extension"}) {
+		t.Errorf("skipped %+v", skipped)
+	}
+}
+
+func syncRow(id byte, name, lang, path, hash string) functionadaptors.Function {
+	return functionadaptors.Function{
+		ID:          pgtype.UUID{Bytes: [16]byte{id}, Valid: true},
+		Name:        name,
+		Language:    lang,
+		Path:        path,
+		ContentHash: hash,
+	}
+}
+
+func TestPlanSync(t *testing.T) {
+	existing := []functionadaptors.Function{
+		syncRow(1, "hello", "lua", "functions/lua/hello.lua", "h1"),
+		syncRow(2, "api", "python", "functions/python/api/main.py", "h2"),
+		syncRow(3, "moved", "lua", "functions/lua/moved.lua", "h3"),
+		syncRow(4, "gone", "lua", "functions/lua/gone.lua", "h4"),
+		syncRow(5, "staged", "go", "functions/go/staged.go", "h5"),
+	}
+	found := []SyncedFunction{
+		// edited in place
+		{Name: "hello", Language: "lua", Path: "functions/lua/hello.lua", Hash: "h1b"},
+		// the bundle's manifest renamed it
+		{Name: "backend", Language: "python", Path: "functions/python/api/main.py", Hash: "h2"},
+		// moved.lua moved to another directory unchanged
+		{Name: "moved", Language: "lua", Path: "functions/lua/sub/moved.lua", Hash: "h3"},
+		{Name: "fresh", Language: "go", Path: "functions/go/fresh.go", Hash: "h6"},
+		// taken by a function still on another branch
+		{Name: "staged", Language: "lua", Path: "functions/lua/staged.lua", Hash: "h7"},
+		// taken by the bundle's new name
+		{Name: "backend", Language: "go", Path: "functions/go/backend.go", Hash: "h8"},
+	}
+	plan := planSync(found, existing, map[string]bool{"functions/go/staged.go": true})
+
+	paths := func(fns []SyncedFunction) []string {
+		var out []string
+		for _, fn := range fns {
+			out = append(out, fn.Path)
+		}
+		return out
+	}
+	if got := paths(plan.Add); !reflect.DeepEqual(got, []string{"functions/go/fresh.go"}) {
+		t.Errorf("add = %v", got)
+	}
+	if len(plan.Update) != 1 || plan.Update[0].row.ID != existing[1].ID || plan.Update[0].To.Name != "backend" {
+		t.Errorf("update = %+v", plan.Update)
+	}
+	if len(plan.Rename) != 1 || plan.Rename[0].row.ID != existing[2].ID || plan.Rename[0].To.Path != "functions/lua/sub/moved.lua" {
+		t.Errorf("rename = %+v", plan.Rename)
+	}
+	if got := paths(plan.Remove); !reflect.DeepEqual(got, []string{"functions/lua/gone.lua"}) {
+		t.Errorf("remove = %v", got)
+	}
+	if len(plan.refresh) != 1 || plan.refresh[0].To.Hash != "h1b" {
+		t.Errorf("refresh = %+v", plan.refresh)
+	}
+	wantConflicts := []SyncConflict{
+		{SyncedFunction: found[4], With: "functions/go/staged.go"},
+		{SyncedFunction: found[5], With: "functions/python/api/main.py"},
+	}
+	if !reflect.DeepEqual(plan.Conflicts, wantConflicts) {
+		t.Errorf("conflicts = %+v, want %+v", plan.Conflicts, wantConflicts)
+	}
+
+	// a second sync of the same tree has nothing left to do
+	var synced []functionadaptors.Function
+	for _, fn := range found[:4] {
+		synced = append(synced, functionadaptors.Function{Name: fn.Name, Language: fn.Language, Path: fn.Path, ContentHash: fn.Hash})
+	}
+	synced = append(synced, existing[4])
+	if again := planSync(found, synced, map[string]bool{"functions/go/staged.go": true}); !again.empty() || len(again.Conflicts) != 2 {
+		t.Errorf("resync = %+v", again)
+	}
+}
+
+func TestPlanSyncRenamePrefersSameName(t *testing.T) {
+	existing := []functionadaptors.Function{
+		syncRow(1, "a", "lua", "functions/lua/a.lua", "same"),
+		syncRow(2, "b", "lua", "functions/lua/b.lua", "same"),
+	}
+	found := []SyncedFunction{{Name: "b", Language: "lua", Path: "functions/lua/x/b.lua", Hash: "same"}}
+	plan := planSync(found, existing, nil)
+	if len(plan.Rename) != 1 || plan.Rename[0].row.ID != existing[1].ID {
+		t.Errorf("rename = %+v", plan.Rename)
+	}
+	if len(plan.Remove) != 1 || plan.Remove[0].Path != "functions/lua/a.lua" {
+		t.Errorf("remove = %+v", plan.Remove)
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/auth"
+	authadaptors "github.com/ashupednekar/litewebservices-portal/internal/auth/adaptors"
+	projectadaptors "github.com/ashupednekar/litewebservices-portal/internal/project/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/pkg/state"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type TokenHandlers struct {
+	state *state.AppState
+}
+
+func NewTokenHandlers(s *state.AppState) *TokenHandlers {
+	return &TokenHandlers{state: s}
+}
+
+func accessTokenJSON(t authadaptors.PersonalAccessToken) gin.H {
+	projects := make([]string, 0, len(t.ProjectIds))
+	for _, id := range t.ProjectIds {
+		projects = append(projects, hex.EncodeToString(id.Bytes[:]))
+	}
+	return gin.H{
+		"id":           hex.EncodeToString(t.ID.Bytes[:]),
+		"name":         t.Name,
+		"prefix":       t.Prefix,
+		"access":       t.Access,
+		"projects":     projects,
+		"created_at":   t.CreatedAt.Time,
+		"expires_at":   timeOrNil(t.ExpiresAt),
+		"last_used_at": timeOrNil(t.LastUsedAt),
+	}
+}
+
+func (h *TokenHandlers) ListTokens(c *gin.Context) {
+	userID := c.MustGet("userID").([]byte)
+
+	tokens, err := authadaptors.New(h.state.DBPool).ListAccessTokens(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	out := make([]gin.H, 0, len(tokens))
+	for _, t := range tokens {
+		out = append(out, accessTokenJSON(t))
+	}
+	c.JSON(200, out)
+}
+
+// CreateToken returns the token once, only its hash is kept
+func (h *TokenHandlers) CreateToken(c *gin.Context) {
+	userID := c.MustGet("userID").([]byte)
+
+	var req struct {
+		Name          string   `json:"name"`
+		Access        string   `json:"access"`
+		Projects      []string `json:"projects"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 64 {
+		c.JSON(400, gin.H{"error": "name is required and at most 64 characters"})
+		return
+	}
+	if req.Access == "" {
+		req.Access = auth.AccessRead
+	}
+	if req.Access != auth.AccessRead && req.Access != auth.AccessWrite {
+		c.JSON(400, gin.H{"error": "access must be read or write"})
+		return
+	}
+	if req.ExpiresInDays < 0 {
+		c.JSON(400, gin.H{"error": "expires_in_days must be positive"})
+		return
+	}
+
+	// a token can only be scoped to projects its user belongs to
+	pq := projectadaptors.New(h.state.DBPool)
+	projectIDs := make([]pgtype.UUID, 0, len(req.Projects))
+	for _, p := range req.Projects {
+		id, err := parseHexUUID(p)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid project id " + p})
+			return
+		}
+		if _, err := pq.GetProjectMember(c.Request.Context(), projectadaptors.GetProjectMemberParams{
+			UserID:    userID,
+			ProjectID: id,
+		}); err != nil {
+			c.JSON(404, gin.H{"error": "project not found " + p})
+			return
+		}
+		projectIDs = append(projectIDs, id)
+	}
+
+	var expiresAt pgtype.Timestamptz
+	if req.ExpiresInDays > 0 {
+		ttl := min(time.Duration(req.ExpiresInDays)*24*time.Hour, auth.MaxAccessTokenTTL)
+		expiresAt = pgtype.Timestamptz{Time: time.Now().Add(ttl), Valid: true}
+	}
+
+	raw, prefix, hash, err := auth.NewAccessToken()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "failed to generate token"})
+		return
+	}
+	token, err := authadaptors.New(h.state.DBPool).CreateAccessToken(c.Request.Context(), authadaptors.CreateAccessTokenParams{
+		UserID:     userID,
+		Name:       req.Name,
+		Prefix:     prefix,
+		TokenHash:  hash,
+		Access:     req.Access,
+		ProjectIds: projectIDs,
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] DB CreateAccessToken: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+
+	out := accessTokenJSON(token)
+	out["token"] = raw
+	c.JSON(201, out)
+}
+
+func (h *TokenHandlers) RevokeToken(c *gin.Context) {
+	userID := c.MustGet("userID").([]byte)
+	tokenID, err := parseHexUUID(c.Param("tokenID"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid token id"})
+		return
+	}
+
+	n, err := authadaptors.New(h.state.DBPool).DeleteAccessToken(c.Request.Context(), authadaptors.DeleteAccessTokenParams{
+		ID:     tokenID,
+		UserID: userID,
+	})
+	if err != nil {
+		fmt.Printf("[ERROR] DB DeleteAccessToken: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	if n == 0 {
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "revoked"})
+}
@@ -5,8 +5,13 @@ import (
 	"encoding/hex"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	authAdaptors "github.com/ashupednekar/litewebservices-portal/internal/auth/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/config"
+	configAdaptors "github.com/ashupednekar/litewebservices-portal/internal/config/adaptors"
+	endpointAdaptors "github.com/ashupednekar/litewebservices-portal/internal/endpoint/adaptors"
 	functionAdaptors "github.com/ashupednekar/litewebservices-portal/internal/function/adaptors"
 	projectAdaptors "github.com/ashupednekar/litewebservices-portal/internal/project/adaptors"
 	"github.com/ashupednekar/litewebservices-portal/pkg/state"
@@ -38,6 +43,11 @@ func (h *UIHandlers) Home(ctx *gin.Context) {
 	}
 }
 
+// Invite sends invite links to the home page, which redeems the token once the user registers or logs in
+func (h *UIHandlers) Invite(ctx *gin.Context) {
+	ctx.Redirect(http.StatusFound, "/?invite="+url.QueryEscape(ctx.Param("token")))
+}
+
 func (h *UIHandlers) Dashboard(ctx *gin.Context) {
 	userID, _ := ctx.Get("userID")
 	activeProjectID, _ := ctx.Cookie("lws_project")
@@ -130,8 +140,51 @@ func (h *UIHandlers) Data(ctx *gin.Context) {
 }
 
 func (h *UIHandlers) Endpoints(ctx *gin.Context) {
+	projUUID := ctx.MustGet("projectUUID").(pgtype.UUID)
+
+	var endpoints []templates.Endpoint
+	eq := endpointAdaptors.New(h.state.DBPool)
+	if dbEps, err := eq.ListEndpointsForProject(ctx.Request.Context(), projUUID); err == nil {
+		for _, e := range dbEps {
+			ep := templates.Endpoint{
+				ID:           hex.EncodeToString(e.ID.Bytes[:]),
+				Name:         e.Name,
+				Method:       e.Method,
+				Scope:        e.Scope,
+				FunctionID:   hex.EncodeToString(e.FunctionID.Bytes[:]),
+				FunctionName: e.FunctionName,
+				RateWindow:   strconv.Itoa(int(e.RateWindowSeconds)),
+				RateKey:      e.RateKey,
+				APIKeyScope:  e.ApiKeyScope,
+				JWTHasSecret: e.JwtSecret != "",
+				JWTJWKS:      e.JwtJwks,
+				JWTIssuer:    e.JwtIssuer,
+				JWTAudience:  e.JwtAudience,
+			}
+			if e.RateLimit.Valid {
+				ep.RateLimit = strconv.Itoa(int(e.RateLimit.Int32))
+			}
+			if e.RateBurst.Valid {
+				ep.RateBurst = strconv.Itoa(int(e.RateBurst.Int32))
+			}
+			endpoints = append(endpoints, ep)
+		}
+	}
+
+	var functions []templates.Function
+	fq := functionAdaptors.New(h.state.DBPool)
+	if dbFns, err := fq.ListFunctionsForProject(ctx.Request.Context(), projUUID); err == nil {
+		for _, f := range dbFns {
+			functions = append(functions, templates.Function{
+				ID:       hex.EncodeToString(f.ID.Bytes[:]),
+				Name:     f.Name,
+				Language: f.Language,
+			})
+		}
+	}
+
 	page := templates.BaseLayout(
-		templates.EndpointsContent(),
+		templates.EndpointsContent(endpoints, functions),
 	)
 
 	if err := page.Render(ctx, ctx.Writer); err != nil {
@@ -140,8 +193,42 @@ func (h *UIHandlers) Endpoints(ctx *gin.Context) {
 }
 
 func (h *UIHandlers) Configuration(ctx *gin.Context) {
+	projUUID := ctx.MustGet("projectUUID").(pgtype.UUID)
+
+	var entries []templates.ConfigEntry
+	q := configAdaptors.New(h.state.DBPool)
+	dbEntries, err := q.ListResolvedProjectConfig(ctx.Request.Context(), configAdaptors.ListResolvedProjectConfigParams{
+		ProjectID:   projUUID,
+		Environment: selectedEnvironment(ctx).Name,
+	})
+	if err == nil {
+		for _, e := range dbEntries {
+			entries = append(entries, templates.ConfigEntry{
+				Key:         e.Key,
+				Value:       config.Mask(e.Value, e.Secret),
+				Type:        e.ValueType,
+				Secret:      e.Secret,
+				Version:     e.Version,
+				Environment: e.Environment,
+			})
+		}
+	}
+
+	var envs []templates.Environment
+	selected := selectedEnvironment(ctx).Name
+	if dbEnvs, err := projectAdaptors.New(h.state.DBPool).ListProjectEnvironments(ctx.Request.Context(), projUUID); err == nil {
+		for _, e := range dbEnvs {
+			envs = append(envs, templates.Environment{
+				Name:     e.Name,
+				Branch:   e.Branch,
+				Default:  e.IsDefault,
+				Selected: e.Name == selected,
+			})
+		}
+	}
+
 	page := templates.BaseLayout(
-		templates.ConfigurationContent(),
+		templates.ConfigurationContent(entries, envs),
 	)
 
 	if err := page.Render(ctx, ctx.Writer); err != nil {
@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	projectaccess "github.com/ashupednekar/litewebservices-portal/internal/project"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/vendors"
+	"github.com/ashupednekar/litewebservices-portal/pkg"
+	"github.com/ashupednekar/litewebservices-portal/pkg/state"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// A project's repo can live on its own forge, org and credentials instead of the
+// deployment's VCS_* settings. Credentials are sealed with VCS_CREDENTIALS_KEY before
+// they're stored and never leave the server again.
+
+type vcsConnectionRequest struct {
+	Vendor   string `json:"vendor"`
+	BaseURL  string `json:"base_url"`
+	Owner    string `json:"owner"`
+	Repo     string `json:"repo"`
+	AuthMode string `json:"auth_mode"`
+	// credentials left empty keep the stored ones when updating a connection
+	Token          string `json:"token"`
+	SSHKey         string `json:"ssh_key"`
+	SSHKeyPassword string `json:"ssh_key_password"`
+}
+
+func (r vcsConnectionRequest) connection(project string) vendors.Connection {
+	conn := vendors.Connection{
+		Vendor:         r.Vendor,
+		BaseURL:        strings.TrimSuffix(r.BaseURL, "/"),
+		Owner:          r.Owner,
+		Repo:           r.Repo,
+		AuthMode:       r.AuthMode,
+		Token:          r.Token,
+		SSHKeyPassword: r.SSHKeyPassword,
+	}
+	if conn.Repo == "" {
+		conn.Repo = project
+	}
+	if conn.AuthMode == "" {
+		conn.AuthMode = "token"
+	}
+	if r.SSHKey != "" {
+		conn.SSHKey = []byte(r.SSHKey)
+	}
+	return conn
+}
+
+func vcsConnectionJSON(conn vendors.Connection, own bool) gin.H {
+	return gin.H{
+		"vendor":      conn.Vendor,
+		"base_url":    conn.BaseURL,
+		"owner":       conn.Owner,
+		"repo":        conn.Repo,
+		"auth_mode":   conn.AuthMode,
+		"has_ssh_key": len(conn.SSHKey) > 0 || (!own && conn.AuthMode == "ssh"),
+		"default":     !own,
+	}
+}
+
+// sealVcsConnection fills the stored row's fields from conn, credentials encrypted. A
+// connection borrowing the deployment's credentials stores no token, and no ssh key
+// password unless it has a key of its own
+func sealVcsConnection(conn vendors.Connection, borrowed bool, params *adaptors.UpsertProjectVcsConnectionParams) error {
+	if borrowed {
+		conn.Token = ""
+		if len(conn.SSHKey) == 0 {
+			conn.SSHKeyPassword = ""
+		}
+	}
+	var err error
+	if params.Token, err = vendors.SealCredential([]byte(conn.Token)); err != nil {
+		return err
+	}
+	if params.SshKey, err = vendors.SealCredential(conn.SSHKey); err != nil {
+		return err
+	}
+	if params.SshKeyPassword, err = vendors.SealCredential([]byte(conn.SSHKeyPassword)); err != nil {
+		return err
+	}
+	params.Vendor = conn.Vendor
+	params.BaseUrl = conn.BaseURL
+	params.Owner = conn.Owner
+	params.RepoName = conn.Repo
+	params.AuthMode = conn.AuthMode
+	return nil
+}
+
+// projectVcs is the api client and connection of project's repo, answering 500 itself
+func projectVcs(c *gin.Context, s *state.AppState, project string) (vendors.VendorClient, vendors.Connection, bool) {
+	conn, err := s.VcsConnection(c.Request.Context(), project)
+	if err != nil {
+		fmt.Printf("[ERROR] VCS connection: %v\n", err)
+		c.JSON(500, gin.H{"error": "failed to load the project's vcs connection"})
+		return nil, conn, false
+	}
+	vcs, err := vendors.NewVendorClient(conn)
+	if err != nil {
+		fmt.Printf("[ERROR] VCS Init: %v\n", err)
+		c.JSON(500, gin.H{"error": fmt.Sprintf("failed to init vcs client: %v", err)})
+		return nil, conn, false
+	}
+	return vcs, conn, true
+}
+
+// addProjectWebhook registers the portal's push webhook on the project's repo, one
+// that's already there is fine
+func addProjectWebhook(ctx context.Context, vcs vendors.VendorClient, conn vendors.Connection, project adaptors.Project) error {
+	webhookURL := fmt.Sprintf("https://%s/api/webhooks/vcs?project=%s", pkg.Cfg.Fqdn, hex.EncodeToString(project.ID.Bytes[:]))
+	_, err := vcs.AddWebhook(ctx, conn.Owner, conn.Repo, vendors.WebhookOptions{
+		URL:         webhookURL,
+		ContentType: "json",
+		Secret:      project.WebhookSecret.String,
+		Events:      []string{"push", "pull_request"},
+		Active:      true,
+		InsecureSSL: true,
+	})
+	if err != nil && (strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "409")) {
+		fmt.Printf("[INFO] Webhook already exists\n")
+		return nil
+	}
+	return err
+}
+
+// GetVcsConnection shows where the project's repo lives, without its credentials
+func (h *ProjectHandlers) GetVcsConnection(c *gin.Context) {
+	project, _, ok := h.memberProject(c)
+	if !ok {
+		return
+	}
+	row, err := adaptors.New(h.state.DBPool).GetProjectVcsConnection(c.Request.Context(), project.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(200, vcsConnectionJSON(vendors.DefaultConnection(project.Name), false))
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	out := vcsConnectionJSON(vendors.Connection{
+		Vendor:   row.Vendor,
+		BaseURL:  row.BaseUrl,
+		Owner:    row.Owner,
+		Repo:     row.RepoName,
+		AuthMode: row.AuthMode,
+		SSHKey:   row.SshKey,
+	}, true)
+	out["deployment_credentials"] = len(row.Token) == 0
+	out["updated_at"] = row.UpdatedAt.Time
+	c.JSON(200, out)
+}
+
+// SetVcsConnection moves the project onto its own connection, or updates it. The repo
+// must already exist there, the portal registers its webhook on it before saving
+func (h *ProjectHandlers) SetVcsConnection(c *gin.Context) {
+	project, member, ok := h.memberProject(c)
+	if !ok {
+		return
+	}
+	if !projectaccess.RoleOf(member.Role.String).CanManageMembers() {
+		c.JSON(403, gin.H{"error": "only project owners can change the vcs connection"})
+		return
+	}
+	var req vcsConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "invalid request"})
+		return
+	}
+	conn := req.connection(project.Name)
+
+	q := adaptors.New(h.state.DBPool)
+	current, err := q.GetProjectVcsConnection(c.Request.Context(), project.ID)
+	borrowed := false
+	prev := vendors.DefaultConnection(project.Name)
+	switch {
+	case err == nil:
+		stored, err := state.OpenVcsConnection(current, project.Name)
+		if err != nil {
+			fmt.Printf("[ERROR] VCS connection of %s: %v\n", project.Name, err)
+			c.JSON(500, gin.H{"error": "stored credentials can't be decrypted, send all of them again"})
+			return
+		}
+		prev = stored
+		if conn.Token == "" {
+			conn.Token = stored.Token
+			borrowed = len(current.Token) == 0
+		}
+		if len(conn.SSHKey) == 0 {
+			conn.SSHKey = stored.SSHKey
+			conn.SSHKeyPath = stored.SSHKeyPath
+			conn.SSHKeyPassword = stored.SSHKeyPassword
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	if borrowed {
+		// the deployment's credentials reach every repo of its account, so they stay on
+		// the one this project started with
+		def := vendors.DefaultConnection(project.Name)
+		if !conn.CanBorrow(project.Name) {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("the deployment's credentials only reach %s/%s, send a token to connect another repo", def.Owner, def.Repo)})
+			return
+		}
+		conn.Token = def.Token
+		if len(conn.SSHKey) == 0 {
+			conn.SSHKeyPath = def.SSHKeyPath
+			conn.SSHKeyPassword = def.SSHKeyPassword
+		}
+	}
+	if err := conn.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	params := adaptors.UpsertProjectVcsConnectionParams{
+		ProjectID: project.ID,
+		UpdatedBy: c.MustGet("userID").([]byte),
+	}
+	if err := sealVcsConnection(conn, borrowed, &params); err != nil {
+		fmt.Printf("[ERROR] sealing vcs credentials: %v\n", err)
+		if errors.Is(err, vendors.ErrNoCredentialsKey) {
+			c.JSON(500, gin.H{"error": "the portal isn't set up to store vcs credentials"})
+			return
+		}
+		c.JSON(500, gin.H{"error": "failed to encrypt credentials"})
+		return
+	}
+
+	vcs, err := vendors.NewVendorClient(conn)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := addProjectWebhook(c.Request.Context(), vcs, conn, project); err != nil {
+		fmt.Printf("[ERROR] VCS AddWebhook: %v\n", err)
+		c.JSON(502, gin.H{"error": fmt.Sprintf("couldn't reach %s/%s with these credentials: %v", conn.Owner, conn.Repo, err)})
+		return
+	}
+
+	if !sameRepo(prev, conn) && !forgetCreatedRepo(c, q, project) {
+		return
+	}
+	row, err := q.UpsertProjectVcsConnection(c.Request.Context(), params)
+	if err != nil {
+		fmt.Printf("[ERROR] DB UpsertProjectVcsConnection: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	// clones still point at the old remote
+	h.state.Repos.Evict(project.Name)
+
+	out := vcsConnectionJSON(conn, true)
+	out["updated_at"] = row.UpdatedAt.Time
+	c.JSON(200, out)
+}
+
+// DeleteVcsConnection moves the project back onto the deployment's connection
+func (h *ProjectHandlers) DeleteVcsConnection(c *gin.Context) {
+	project, member, ok := h.memberProject(c)
+	if !ok {
+		return
+	}
+	if !projectaccess.RoleOf(member.Role.String).CanManageMembers() {
+		c.JSON(403, gin.H{"error": "only project owners can change the vcs connection"})
+		return
+	}
+	q := adaptors.New(h.state.DBPool)
+	current, err := q.GetProjectVcsConnection(c.Request.Context(), project.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	def := vendors.DefaultConnection(project.Name)
+	moved := err == nil && !sameRepo(vendors.Connection{Vendor: current.Vendor, BaseURL: current.BaseUrl, Owner: current.Owner, Repo: current.RepoName}, def)
+	if moved && !forgetCreatedRepo(c, q, project) {
+		return
+	}
+	if err := q.DeleteProjectVcsConnection(c.Request.Context(), project.ID); err != nil {
+		c.JSON(500, gin.H{"error": "database error"})
+		return
+	}
+	h.state.Repos.Evict(project.Name)
+	c.JSON(200, vcsConnectionJSON(def, false))
+}
+
+// sameRepo is whether two connections name the same repo on the same forge
+func sameRepo(a, b vendors.Connection) bool {
+	return a.Vendor == b.Vendor && strings.TrimSuffix(a.BaseURL, "/") == strings.TrimSuffix(b.BaseURL, "/") &&
+		strings.EqualFold(a.Owner, b.Owner) && strings.EqualFold(a.Repo, b.Repo)
+}
+
+// forgetCreatedRepo marks the project's repo as not the portal's once the project moves
+// off the one the portal created, deleting the project leaves the new one alone. It
+// answers the error response itself
+func forgetCreatedRepo(c *gin.Context, q *adaptors.Queries, project adaptors.Project) bool {
+	if !project.RepoCreatedByPortal {
+		return true
+	}
+	err := q.SetProjectRepoCreatedByPortal(c.Request.Context(), adaptors.SetProjectRepoCreatedByPortalParams{ID: project.ID, RepoCreatedByPortal: false})
+	if err != nil {
+		fmt.Printf("[ERROR] DB SetProjectRepoCreatedByPortal: %v\n", err)
+		c.JSON(500, gin.H{"error": "database error"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/project/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/vendors"
+	"github.com/ashupednekar/litewebservices-portal/pkg/state"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// maxWebhookBody caps inbound payloads, push events for large pushes stay well below this
+const maxWebhookBody = 5 << 20
+
+type webhookProjectStore interface {
+	GetProjectByID(ctx context.Context, id pgtype.UUID) (adaptors.Project, error)
+	GetProjectByName(ctx context.Context, name string) (adaptors.Project, error)
+	ListProjectEnvironments(ctx context.Context, projectID pgtype.UUID) ([]adaptors.ProjectEnvironment, error)
+}
+
+type WebhookHandlers struct {
+	projects webhookProjectStore
+	sync     func(ctx context.Context, project adaptors.Project, branch string) error
+	queue    syncQueue
+}
+
+func NewWebhookHandlers(s *state.AppState) *WebhookHandlers {
+	return &WebhookHandlers{
+		projects: adaptors.New(s.DBPool),
+		sync: func(ctx context.Context, project adaptors.Project, branch string) error {
+			// the remote moved, pull now so the next requests don't have to
+			return s.Repos.Sync(repo.Ref{Project: project.Name, Branch: branch}, func(r *repo.GitRepo) error {
+				return SyncRepoFunctionsToDb(ctx, s.DBPool, project.ID, r, project.CreatedBy)
+			})
+		},
+	}
+}
+
+// syncQueue runs webhook syncs in the background, one at a time per project. Pushes
+// landing while a project syncs fold into a single rerun per branch after it, so
+// redeliveries and bursts of pushes don't pile up clones and reconciles
+type syncQueue struct {
+	mu sync.Mutex
+	// pending holds the branches waiting for each project with a worker running
+	pending map[string]map[string]bool
+}
+
+// add queues branch for project, starting a worker that calls run for each queued
+// branch unless the project already has one
+func (q *syncQueue) add(project, branch string, run func(branch string)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if branches, ok := q.pending[project]; ok {
+		branches[branch] = true
+		return
+	}
+	if q.pending == nil {
+		q.pending = make(map[string]map[string]bool)
+	}
+	q.pending[project] = map[string]bool{branch: true}
+	go q.drain(project, run)
+}
+
+func (q *syncQueue) drain(project string, run func(branch string)) {
+	for {
+		q.mu.Lock()
+		branches := q.pending[project]
+		if len(branches) == 0 {
+			delete(q.pending, project)
+			q.mu.Unlock()
+			return
+		}
+		q.pending[project] = map[string]bool{}
+		q.mu.Unlock()
+		for branch := range branches {
+			run(branch)
+		}
+	}
+}
+
+// ReceiveVCS handles github/gitea webhooks, a verified push to an environment's branch re-syncs
+// that environment. The sync runs after the answer, vendors time deliveries out and
+// redeliver long before a large clone and reconcile are done
+func (h *WebhookHandlers) ReceiveVCS(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+
+	ev, err := vendors.ParsePushEvent(body)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	var project adaptors.Project
+	if projectID := c.Query("project"); projectID != "" {
+		projectUUID, err := parseHexUUID(projectID)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid project id"})
+			return
+		}
+		project, err = h.projects.GetProjectByID(c.Request.Context(), projectUUID)
+		if err != nil {
+			c.JSON(404, gin.H{"error": "project not found"})
+			return
+		}
+	} else {
+		project, err = h.projects.GetProjectByName(c.Request.Context(), ev.Repository.Name)
+		if err != nil {
+			c.JSON(404, gin.H{"error": "project not found"})
+			return
+		}
+	}
+
+	if err := vendors.VerifyWebhookSignature(c.Request.Header, body, project.WebhookSecret.String); err != nil {
+		fmt.Printf("[WARN] webhook rejected for %s: %v\n", project.Name, err)
+		c.JSON(401, gin.H{"error": "invalid signature"})
+		return
+	}
+
+	switch event := vendors.WebhookEvent(c.Request.Header); event {
+	case "ping":
+		c.JSON(200, gin.H{"status": "pong"})
+	case "push":
+		branch, tracked := strings.CutPrefix(ev.Ref, "refs/heads/")
+		if tracked {
+			envs, err := h.projects.ListProjectEnvironments(c.Request.Context(), project.ID)
+			if err != nil {
+				fmt.Printf("[ERROR] webhook environments for %s: %v\n", project.Name, err)
+				c.JSON(500, gin.H{"error": "database error"})
+				return
+			}
+			tracked = false
+			for _, env := range envs {
+				tracked = tracked || env.Branch == branch
+			}
+		}
+		if !tracked {
+			c.JSON(202, gin.H{"status": "ignored", "reason": "untracked ref " + ev.Ref})
+			return
+		}
+		h.queue.add(project.Name, branch, func(branch string) {
+			if err := h.sync(context.Background(), project, branch); err != nil {
+				fmt.Printf("[ERROR] webhook sync failed for %s@%s: %v\n", project.Name, branch, err)
+				return
+			}
+			fmt.Printf("[INFO] webhook synced %s@%s\n", project.Name, branch)
+		})
+		c.JSON(202, gin.H{"status": "queued"})
+	default:
+		c.JSON(202, gin.H{"status": "ignored", "reason": "unhandled event " + event})
+	}
+}
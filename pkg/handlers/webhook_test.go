@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/project/adaptors"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type fakeWebhookStore struct {
+	project adaptors.Project
+}
+
+func (f *fakeWebhookStore) GetProjectByID(ctx context.Context, id pgtype.UUID) (adaptors.Project, error) {
+	if id != f.project.ID {
+		return adaptors.Project{}, fmt.Errorf("not found")
+	}
+	return f.project, nil
+}
+
+func (f *fakeWebhookStore) GetProjectByName(ctx context.Context, name string) (adaptors.Project, error) {
+	if name != f.project.Name {
+		return adaptors.Project{}, fmt.Errorf("not found")
+	}
+	return f.project, nil
+}
+
+func (f *fakeWebhookStore) ListProjectEnvironments(ctx context.Context, projectID pgtype.UUID) ([]adaptors.ProjectEnvironment, error) {
+	return []adaptors.ProjectEnvironment{
+		{ProjectID: projectID, Name: "production", Branch: "main", IsDefault: true},
+		{ProjectID: projectID, Name: "staging", Branch: "env/staging"},
+	}, nil
+}
+
+func TestWebhookHandlers_ReceiveVCS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secret := "webhook-secret"
+	projectID := pgtype.UUID{Bytes: [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, Valid: true}
+	project := adaptors.Project{
+		ID:            projectID,
+		Name:          "projone",
+		WebhookSecret: pgtype.Text{String: secret, Valid: true},
+	}
+
+	push := []byte(`{"ref":"refs/heads/main","after":"abc123","repository":{"name":"projone","full_name":"lwsrepos/projone"}}`)
+	devPush := []byte(`{"ref":"refs/heads/dev","repository":{"name":"projone"}}`)
+	stagingPush := []byte(`{"ref":"refs/heads/env/staging","repository":{"name":"projone"}}`)
+
+	sign := func(body []byte) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		body       []byte
+		headers    map[string]string
+		wantStatus int
+		wantSync   bool
+		wantBranch string
+	}{
+		{
+			name:       "github push syncs",
+			body:       push,
+			headers:    map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(push)},
+			wantStatus: http.StatusAccepted,
+			wantSync:   true,
+			wantBranch: "main",
+		},
+		{
+			name:       "environment branch push syncs it",
+			body:       stagingPush,
+			headers:    map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(stagingPush)},
+			wantStatus: http.StatusAccepted,
+			wantSync:   true,
+			wantBranch: "env/staging",
+		},
+		{
+			name:       "gitea push resolved by project query",
+			query:      "?project=" + hex.EncodeToString(projectID.Bytes[:]),
+			body:       push,
+			headers:    map[string]string{"X-Gitea-Event": "push", "X-Gitea-Signature": sign(push)},
+			wantStatus: http.StatusAccepted,
+			wantSync:   true,
+		},
+		{
+			name:       "bad signature rejected",
+			body:       push,
+			headers:    map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign([]byte("tampered"))},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "ping answered",
+			body:       push,
+			headers:    map[string]string{"X-GitHub-Event": "ping", "X-Hub-Signature-256": "sha256=" + sign(push)},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "untracked branch ignored",
+			body:       devPush,
+			headers:    map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(devPush)},
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "unknown project",
+			query:      "?project=" + hex.EncodeToString(make([]byte, 16)),
+			body:       push,
+			headers:    map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(push)},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			synced := make(chan string, 1)
+			h := &WebhookHandlers{
+				projects: &fakeWebhookStore{project: project},
+				sync: func(ctx context.Context, p adaptors.Project, branch string) error {
+					synced <- branch
+					return nil
+				},
+			}
+			router := gin.New()
+			router.POST("/api/webhooks/vcs", h.ReceiveVCS)
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			req, err := http.NewRequest("POST", server.URL+"/api/webhooks/vcs"+tt.query, bytes.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("failed to build request: %s", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %s", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("ReceiveVCS() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			// the sync runs after the answer
+			wait := 100 * time.Millisecond
+			if tt.wantSync {
+				wait = 5 * time.Second
+			}
+			select {
+			case branch := <-synced:
+				if !tt.wantSync {
+					t.Errorf("ReceiveVCS() synced %q, want no sync", branch)
+				}
+				if tt.wantBranch != "" && branch != tt.wantBranch {
+					t.Errorf("ReceiveVCS() synced branch %q, want %q", branch, tt.wantBranch)
+				}
+			case <-time.After(wait):
+				if tt.wantSync {
+					t.Error("ReceiveVCS() didn't sync")
+				}
+			}
+		})
+	}
+}
+
+func TestSyncQueueFoldsPushes(t *testing.T) {
+	var q syncQueue
+	var mu sync.Mutex
+	var runs []string
+	started, unblock, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	run := func(branch string) {
+		mu.Lock()
+		runs = append(runs, branch)
+		n := len(runs)
+		mu.Unlock()
+		switch n {
+		case 1:
+			close(started)
+			<-unblock
+		case 2:
+			close(done)
+		}
+	}
+
+	q.add("projone", "main", run)
+	<-started
+	// redeliveries while main syncs fold into one more run
+	for range 3 {
+		q.add("projone", "main", run)
+	}
+	close(unblock)
+	<-done
+
+	// the worker is gone once its branches are drained
+	for {
+		q.mu.Lock()
+		_, running := q.pending["projone"]
+		q.mu.Unlock()
+		if !running {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(runs) != 2 {
+		t.Errorf("synced %v, want main twice", runs)
+	}
+}
@@ -57,3 +57,59 @@ func OptionalAuthMiddleware(sessionStore auth.SessionStore) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// APIAuthMiddleware authenticates /api/ requests with a personal access token in
+// Authorization: Bearer, falling back to the session cookie. Failures are JSON since
+// scripts have nowhere to be redirected to.
+func APIAuthMiddleware(sessionStore auth.SessionStore, tokenStore auth.TokenStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if raw, ok := auth.BearerToken(c.Request.Header); ok {
+			token, found, err := tokenStore.GetAccessToken(raw)
+			if err != nil {
+				log.Printf("[ERROR] Error retrieving access token: %v", err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to verify token"})
+				return
+			}
+			if !found {
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+				return
+			}
+			if !token.CanWrite() && !isReadMethod(c.Request.Method) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "token is read only"})
+				return
+			}
+			c.Set("userID", token.UserID)
+			c.Set("userName", token.UserName)
+			c.Set("accessToken", token)
+			c.Next()
+			return
+		}
+
+		sessionID, err := c.Cookie(auth.SessionCookieName)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		userName, userID, found, err := sessionStore.GetUserSession(sessionID)
+		if err != nil || !found {
+			c.SetCookie(auth.SessionCookieName, "", -1, "/", "", false, true)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session expired"})
+			return
+		}
+		c.Set("userID", userID)
+		c.Set("userName", userName)
+		c.Next()
+	}
+}
+
+// RequireSession keeps routes like token management out of reach of tokens themselves
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("accessToken"); ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "sign in to the dashboard to manage tokens"})
+			return
+		}
+		c.Next()
+	}
+}
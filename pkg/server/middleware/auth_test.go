@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeAuthStore struct {
+	sessions map[string]string
+	tokens   map[string]auth.AccessToken
+}
+
+func (f fakeAuthStore) CreateUserSession([]byte, string, time.Time, string, string) error {
+	return nil
+}
+
+func (f fakeAuthStore) GetUserSession(sessionID string) (string, []byte, bool, error) {
+	name, ok := f.sessions[sessionID]
+	return name, []byte(name), ok, nil
+}
+
+func (f fakeAuthStore) DeleteUserSession(string) error     { return nil }
+func (f fakeAuthStore) DeleteAllUserSessions([]byte) error { return nil }
+
+func (f fakeAuthStore) GetAccessToken(raw string) (auth.AccessToken, bool, error) {
+	t, ok := f.tokens[raw]
+	return t, ok, nil
+}
+
+func TestAPIAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := fakeAuthStore{
+		sessions: map[string]string{"s1": "ada"},
+		tokens: map[string]auth.AccessToken{
+			"lwsp_read":  {UserID: []byte("grace"), UserName: "grace", Access: auth.AccessRead},
+			"lwsp_write": {UserID: []byte("grace"), UserName: "grace", Access: auth.AccessWrite},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		cookie     string
+		bearer     string
+		wantStatus int
+		wantUser   string
+	}{
+		{name: "session cookie", method: "GET", cookie: "s1", wantStatus: 200, wantUser: "ada"},
+		{name: "expired session", method: "GET", cookie: "gone", wantStatus: 401},
+		{name: "nothing", method: "GET", wantStatus: 401},
+		{name: "read token reads", method: "GET", bearer: "lwsp_read", wantStatus: 200, wantUser: "grace"},
+		{name: "read token writes", method: "POST", bearer: "lwsp_read", wantStatus: 403},
+		{name: "write token writes", method: "POST", bearer: "lwsp_write", wantStatus: 200, wantUser: "grace"},
+		{name: "unknown token", method: "GET", bearer: "lwsp_nope", wantStatus: 401},
+		// a bad bearer doesn't fall back to the cookie
+		{name: "unknown token with cookie", method: "GET", cookie: "s1", bearer: "lwsp_nope", wantStatus: 401},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUser string
+			router := gin.New()
+			router.Handle(tt.method, "/api/", APIAuthMiddleware(store, store), func(c *gin.Context) {
+				gotUser = c.GetString("userName")
+				c.Status(200)
+			})
+
+			req := httptest.NewRequest(tt.method, "/api/", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: auth.SessionCookieName, Value: tt.cookie})
+			}
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if w.Code >= 400 && w.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+				t.Errorf("error response is %q, want json", w.Header().Get("Content-Type"))
+			}
+			if gotUser != tt.wantUser {
+				t.Errorf("userName = %q, want %q", gotUser, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestRequireSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/", func(c *gin.Context) {
+		if c.GetHeader("X-Token") != "" {
+			c.Set("accessToken", auth.AccessToken{Access: auth.AccessWrite})
+		}
+	}, RequireSession(), func(c *gin.Context) { c.Status(200) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	if w.Code != 200 {
+		t.Errorf("session request status = %d, want 200", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-Token", "1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != 403 {
+		t.Errorf("token request status = %d, want 403", w.Code)
+	}
+}
@@ -2,50 +2,234 @@ package middleware
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 
+	"github.com/ashupednekar/litewebservices-portal/internal/auth"
+	"github.com/ashupednekar/litewebservices-portal/internal/project"
 	projectadaptors "github.com/ashupednekar/litewebservices-portal/internal/project/adaptors"
 	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
 	"github.com/ashupednekar/litewebservices-portal/pkg/state"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// ProjectHeader selects the project for api clients that don't carry the lws_project cookie,
+// it takes a project id or name
+const ProjectHeader = "X-LWS-Project"
+
+// EnvironmentHeader selects the project's environment the same way, over the lws_env
+// cookie. Without either requests work on the default environment
+const EnvironmentHeader = "X-LWS-Environment"
+
 func ProjectMiddleware(s *state.AppState) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		projectIDHex, err := c.Cookie("lws_project")
-		if err != nil {
-			c.JSON(400, gin.H{"error": "project cookie missing"})
+		pq := projectadaptors.New(s.DBPool)
+		proj, ok := selectedProject(c, pq)
+		if !ok {
 			return
 		}
+		projectUUID := proj.ID
 
-		projectIDBytes, err := hex.DecodeString(projectIDHex)
-		if err != nil || len(projectIDBytes) != 16 {
-			c.JSON(400, gin.H{"error": "invalid project id in cookie"})
+		member, err := pq.GetProjectMember(c.Request.Context(), projectadaptors.GetProjectMemberParams{
+			UserID:    c.MustGet("userID").([]byte),
+			ProjectID: projectUUID,
+		})
+		if err != nil {
+			// non-members get the same answer as a missing project
+			c.AbortWithStatusJSON(404, gin.H{"error": "project not found"})
 			return
 		}
-		var projectUUID pgtype.UUID
-		copy(projectUUID.Bytes[:], projectIDBytes)
-		projectUUID.Valid = true
+		if token, ok := c.Get("accessToken"); ok && !token.(auth.AccessToken).AllowsProject(projectUUID) {
+			c.AbortWithStatusJSON(403, gin.H{"error": "token is not scoped to this project"})
+			return
+		}
+		role := project.RoleOf(member.Role.String)
 
-		pq := projectadaptors.New(s.DBPool)
-		proj, err := pq.GetProjectByID(c.Request.Context(), projectUUID)
-		if err != nil {
-			c.JSON(404, gin.H{"error": "project not found"})
+		// viewers are read only no matter which group the route sits in
+		if !role.CanWrite() && !isReadMethod(c.Request.Method) {
+			c.AbortWithStatusJSON(403, gin.H{"error": "viewers have read only access"})
 			return
 		}
+
 		projectName := proj.Name
+		env, ok := selectedEnvironment(c, pq, projectUUID)
+		if !ok {
+			return
+		}
 
-		r, err := repo.NewGitRepo(projectName, nil)
-		if err != nil {
-			fmt.Printf("[ERROR] repo.NewGitRepo failed: %v\n", err)
-			c.JSON(500, gin.H{"error": "error instantiating repo"})
+		// handlers that touch files take the clone of the environment's branch from the
+		// lease, it stays locked until the request is done, shared for reads and exclusive
+		// for writes
+		lease := s.Repos.Lease(repo.Ref{Project: projectName, Branch: env.Branch}, !readsOnly(c))
+		defer lease.Release()
+		if proj.Protected && !useChangeBranch(c, s, pq, lease, env) {
 			return
 		}
-		c.Set("repo", r)
+		c.Set("repo", lease)
+		c.Set("environment", env)
 		c.Set("projectName", projectName)
 		c.Set("projectUUID", projectUUID)
+		c.Set("functionsRoot", proj.FunctionsRoot)
+		c.Set("projectProtected", proj.Protected)
+		c.Set("projectRole", role)
 		c.Next()
 	}
 }
 
+// selectedProject resolves the project from the X-LWS-Project header, falling back to the
+// lws_project cookie set by the dashboard
+func selectedProject(c *gin.Context, pq *projectadaptors.Queries) (projectadaptors.Project, bool) {
+	ref := strings.TrimSpace(c.GetHeader(ProjectHeader))
+	if ref == "" {
+		cookie, err := c.Cookie("lws_project")
+		if err != nil {
+			c.AbortWithStatusJSON(400, gin.H{"error": "no project selected, set the lws_project cookie or " + ProjectHeader + " header"})
+			return projectadaptors.Project{}, false
+		}
+		if ref = cookie; !isHexID(ref) {
+			c.AbortWithStatusJSON(400, gin.H{"error": "invalid project id in cookie"})
+			return projectadaptors.Project{}, false
+		}
+	}
+
+	var proj projectadaptors.Project
+	var err error
+	if isHexID(ref) {
+		var projectUUID pgtype.UUID
+		projectIDBytes, _ := hex.DecodeString(ref)
+		copy(projectUUID.Bytes[:], projectIDBytes)
+		projectUUID.Valid = true
+		proj, err = pq.GetProjectByID(c.Request.Context(), projectUUID)
+	} else {
+		proj, err = pq.GetProjectByName(c.Request.Context(), ref)
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(404, gin.H{"error": "project not found"})
+		return projectadaptors.Project{}, false
+	}
+	return proj, true
+}
+
+// selectedEnvironment resolves the environment from the X-LWS-Environment header or the
+// lws_env cookie, falling back to the project's default. A cookie left over from another
+// project falls back too, a header naming an unknown environment is an error
+func selectedEnvironment(c *gin.Context, pq *projectadaptors.Queries, projectUUID pgtype.UUID) (projectadaptors.ProjectEnvironment, bool) {
+	name := strings.TrimSpace(c.GetHeader(EnvironmentHeader))
+	fromHeader := name != ""
+	if !fromHeader {
+		name, _ = c.Cookie("lws_env")
+	}
+	if name != "" {
+		env, err := pq.GetProjectEnvironment(c.Request.Context(), projectadaptors.GetProjectEnvironmentParams{
+			ProjectID: projectUUID,
+			Name:      name,
+		})
+		if err == nil {
+			return env, true
+		}
+		if fromHeader {
+			c.AbortWithStatusJSON(404, gin.H{"error": "environment not found"})
+			return projectadaptors.ProjectEnvironment{}, false
+		}
+	}
+	env, err := pq.GetDefaultProjectEnvironment(c.Request.Context(), projectUUID)
+	if err != nil {
+		fmt.Printf("[ERROR] no default environment for project: %v\n", err)
+		c.AbortWithStatusJSON(500, gin.H{"error": "project has no default environment"})
+		return projectadaptors.ProjectEnvironment{}, false
+	}
+	return env, true
+}
+
+func isHexID(s string) bool {
+	b, err := hex.DecodeString(s)
+	return err == nil && len(b) == 16
+}
+
+// RequireRole rejects requests from members below min, it must run after ProjectMiddleware
+func RequireRole(min project.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, ok := c.Get("projectRole")
+		if !ok {
+			c.AbortWithStatusJSON(403, gin.H{"error": "no project role"})
+			return
+		}
+		if !role.(project.Role).AtLeast(min) {
+			c.AbortWithStatusJSON(403, gin.H{"error": fmt.Sprintf("requires %s role or higher", min)})
+			return
+		}
+		c.Next()
+	}
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// readsOnly is whether the request leaves the clone as it is: reads, and test invokes that
+// only load the function's source
+func readsOnly(c *gin.Context) bool {
+	return isReadMethod(c.Request.Method) || strings.HasSuffix(c.FullPath(), "/invoke/")
+}
+
+// useChangeBranch points the lease of a protected project at the user's change branch.
+// Without a change in progress reads stay on the environment's branch, and the first
+// request that touches files cuts a new change branch from it
+func useChangeBranch(c *gin.Context, s *state.AppState, pq *projectadaptors.Queries, lease *repo.Lease, env projectadaptors.ProjectEnvironment) bool {
+	base := lease.Ref()
+	userID := c.MustGet("userID").([]byte)
+	active := projectadaptors.GetActiveChangeRequestParams{
+		ProjectID:   env.ProjectID,
+		Environment: env.Name,
+		Author:      userID,
+	}
+
+	cr, err := pq.GetActiveChangeRequest(c.Request.Context(), active)
+	switch {
+	case err == nil:
+		lease.Resolve(func() (repo.Ref, error) {
+			return repo.Ref{Project: base.Project, Branch: cr.Branch}, nil
+		})
+		return true
+	case !errors.Is(err, pgx.ErrNoRows):
+		fmt.Printf("[ERROR] change request lookup failed: %v\n", err)
+		c.AbortWithStatusJSON(500, gin.H{"error": "database error"})
+		return false
+	case readsOnly(c):
+		return true
+	}
+
+	lease.Resolve(func() (repo.Ref, error) {
+		branch, err := project.ChangeBranch(c.GetString("userName"))
+		if err != nil {
+			return repo.Ref{}, err
+		}
+		err = s.Repos.Update(base, func(r *repo.GitRepo) error {
+			_, err := r.CreateBranch(branch)
+			return err
+		})
+		if err != nil {
+			return repo.Ref{}, fmt.Errorf("creating change branch %s: %w", branch, err)
+		}
+		_, err = pq.CreateChangeRequest(c.Request.Context(), projectadaptors.CreateChangeRequestParams{
+			ProjectID:   env.ProjectID,
+			Environment: env.Name,
+			Branch:      branch,
+			BaseBranch:  env.Branch,
+			Author:      userID,
+		})
+		if err != nil {
+			// a concurrent request of the same user got there first, their branch wins
+			if cr, lookupErr := pq.GetActiveChangeRequest(c.Request.Context(), active); lookupErr == nil {
+				return repo.Ref{Project: base.Project, Branch: cr.Branch}, nil
+			}
+			return repo.Ref{}, fmt.Errorf("recording change branch %s: %w", branch, err)
+		}
+		return repo.Ref{Project: base.Project, Branch: branch}, nil
+	})
+	return true
+}
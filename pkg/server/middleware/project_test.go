@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/project"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		role       project.Role
+		setRole    bool
+		min        project.Role
+		wantStatus int
+	}{
+		{name: "owner passes maintainer route", role: project.Owner, setRole: true, min: project.Maintainer, wantStatus: 200},
+		{name: "developer passes developer route", role: project.Developer, setRole: true, min: project.Developer, wantStatus: 200},
+		{name: "developer blocked on maintainer route", role: project.Developer, setRole: true, min: project.Maintainer, wantStatus: 403},
+		{name: "viewer blocked on developer route", role: project.Viewer, setRole: true, min: project.Developer, wantStatus: 403},
+		{name: "missing role blocked", setRole: false, min: project.Viewer, wantStatus: 403},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/", func(c *gin.Context) {
+				if tt.setRole {
+					c.Set("projectRole", tt.role)
+				}
+				c.Next()
+			}, RequireRole(tt.min), func(c *gin.Context) {
+				c.Status(200)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("RequireRole(%s) status = %d, want %d", tt.min, w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestReadsOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		method, route string
+		want          bool
+	}{
+		{"GET", "/api/functions/:fnID/", true},
+		{"PUT", "/api/functions/:fnID/", false},
+		{"POST", "/api/functions/", false},
+		{"POST", "/api/functions/:fnID/invoke/", true},
+		{"POST", "/api/functions/:fnID/rollback/", false},
+	}
+	for _, tt := range tests {
+		var got bool
+		router := gin.New()
+		router.Handle(tt.method, tt.route, func(c *gin.Context) {
+			got = readsOnly(c)
+		})
+		path := strings.ReplaceAll(tt.route, ":fnID", "abc")
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, path, nil))
+		if got != tt.want {
+			t.Errorf("readsOnly(%s %s) = %v, want %v", tt.method, tt.route, got, tt.want)
+		}
+	}
+}
@@ -3,7 +3,11 @@ package server
 import (
 	"io/fs"
 	"net/http"
+	"time"
 
+	"github.com/ashupednekar/litewebservices-portal/internal/apikey"
+	"github.com/ashupednekar/litewebservices-portal/internal/gateway"
+	"github.com/ashupednekar/litewebservices-portal/internal/project"
 	"github.com/ashupednekar/litewebservices-portal/pkg/handlers"
 	"github.com/ashupednekar/litewebservices-portal/pkg/server/middleware"
 	"github.com/ashupednekar/litewebservices-portal/static"
@@ -31,9 +35,21 @@ func (s *Server) BuildRoutes() {
 	s.router.GET("/logout/", auth.Logout)
 	s.router.POST("/logout/", auth.Logout)
 
+	webhooks := handlers.NewWebhookHandlers(s.state)
+	s.router.POST("/api/webhooks/vcs", webhooks.ReceiveVCS)
+
+	// data plane for project endpoints, route tables reload on lws_routes notifications
+	s.gateway = gateway.New(gateway.NewTable(gateway.NewDBLoader(s.state.DBPool), time.Minute), gateway.RepoSource(s.state.Repos), s.limiter, apikey.NewStore(s.state.DBPool))
+	gw := s.router.Group("/x/:project")
+	gw.Use(middleware.OptionalAuthMiddleware(auth.GetStore()))
+	{
+		gw.Any("/*path", s.gateway.Serve)
+	}
+
 	ui := handlers.NewUIHandlers(s.state)
 
 	s.router.GET("/", ui.Home)
+	s.router.GET("/invite/:token/", ui.Invite)
 
 	dashboard := s.router.Group("/")
 	dashboard.Use(middleware.AuthMiddleware(auth.GetStore()))
@@ -55,33 +71,114 @@ func (s *Server) BuildRoutes() {
 
 	projectHandlers := handlers.NewProjectHandlers(s.state)
 	functionHandlers := handlers.NewFunctionHandlers(s.state)
+	endpointHandlers := handlers.NewEndpointHandlers(s.state)
+	configHandlers := handlers.NewConfigHandlers(s.state)
+	apiKeyHandlers := handlers.NewAPIKeyHandlers(s.state)
+	environmentHandlers := handlers.NewEnvironmentHandlers(s.state)
+	changeHandlers := handlers.NewChangeHandlers(s.state)
+
+	tokenHandlers := handlers.NewTokenHandlers(s.state)
+
+	// the api takes a personal access token or the session cookie and answers with JSON 401s
+	apiAuth := middleware.APIAuthMiddleware(auth.GetStore(), auth.GetStore())
+
+	// project management doesn't need an active project selected
+	projects := s.router.Group("/api/projects/")
+	projects.Use(apiAuth)
+	{
+		projects.POST("/", projectHandlers.CreateProject)
+		projects.POST("/import/", projectHandlers.ImportProject)
+		projects.GET("/", projectHandlers.ListProjects)
+		projects.GET("/:id/", projectHandlers.GetProject)
+		projects.DELETE("/:id/", projectHandlers.DeleteProject)
+		projects.PUT("/:id/protected/", projectHandlers.SetProtected)
+		projects.GET("/:id/vcs/", projectHandlers.GetVcsConnection)
+		projects.PUT("/:id/vcs/", projectHandlers.SetVcsConnection)
+		projects.DELETE("/:id/vcs/", projectHandlers.DeleteVcsConnection)
+		projects.GET("/:id/members/", projectHandlers.ListMembers)
+		projects.POST("/:id/members/", projectHandlers.AddMember)
+		projects.PUT("/:id/members/:userID/", projectHandlers.UpdateMemberRole)
+		projects.DELETE("/:id/members/:userID/", projectHandlers.RemoveMember)
+
+		projects.GET("/:id/invites/", projectHandlers.ListInvites)
+		projects.POST("/:id/invites/", projectHandlers.CreateInvite)
+		projects.DELETE("/:id/invites/:inviteID/", projectHandlers.RevokeInvite)
+	}
 
+	invites := s.router.Group("/api/invites/")
+	invites.Use(apiAuth, middleware.RequireSession())
+	{
+		invites.POST("/:token/redeem/", projectHandlers.RedeemInvite)
+	}
+
+	tokens := s.router.Group("/api/tokens/")
+	tokens.Use(apiAuth, middleware.RequireSession())
+	{
+		tokens.GET("/", tokenHandlers.ListTokens)
+		tokens.POST("/", tokenHandlers.CreateToken)
+		tokens.DELETE("/:tokenID/", tokenHandlers.RevokeToken)
+	}
 
 	api := s.router.Group("/api/")
 	api.Use(
-		middleware.AuthMiddleware(auth.GetStore()),
+		apiAuth,
 		middleware.ProjectMiddleware(s.state),
 	)
 	{
-		api.POST("/projects/", projectHandlers.CreateProject)
-		api.GET("/projects/", handlers.ListProjects)
-		api.GET("/projects/:id/", handlers.GetProject)
-		api.DELETE("/projects/:id/", handlers.DeleteProject)
-		api.POST("/projects/sync/", projectHandlers.SyncProject)
-
-		api.POST("/functions/", functionHandlers.CreateFunction)
 		api.GET("/functions/", functionHandlers.ListFunctions)
 		api.GET("/functions/:fnID/", functionHandlers.GetFunction)
-		api.PUT("/functions/:fnID/", functionHandlers.UpdateFunction)
-		api.DELETE("/functions/:fnID/", functionHandlers.DeleteFunction)
+		api.GET("/functions/:fnID/history/", functionHandlers.FunctionHistory)
+		api.GET("/functions/:fnID/versions/:sha/", functionHandlers.FunctionVersion)
+		api.GET("/functions/:fnID/diff/", functionHandlers.FunctionDiff)
+		api.GET("/functions/:fnID/files/", functionHandlers.GetFunctionFiles)
+		api.GET("/endpoints/", endpointHandlers.ListEndpoints)
+		api.GET("/endpoints/:epID/", endpointHandlers.GetEndpoint)
+		api.GET("/config/", configHandlers.GetProjectConfig)
+		api.GET("/config/history/", configHandlers.ConfigHistory)
+		api.GET("/apikeys/", apiKeyHandlers.ListAPIKeys)
+		api.GET("/environments/", environmentHandlers.ListEnvironments)
+		api.GET("/changes/", changeHandlers.ListChanges)
+		api.GET("/changes/:changeID/", changeHandlers.GetChange)
+	}
+
+	develop := api.Group("/", middleware.RequireRole(project.Developer))
+	{
+		develop.POST("/functions/", functionHandlers.CreateFunction)
+		develop.PUT("/functions/:fnID/", functionHandlers.UpdateFunction)
+		develop.PUT("/functions/:fnID/files/", functionHandlers.UpdateFunctionFiles)
+		develop.DELETE("/functions/:fnID/", functionHandlers.DeleteFunction)
+		develop.POST("/functions/:fnID/invoke/", functionHandlers.InvokeFunction)
+		develop.POST("/functions/:fnID/rollback/", functionHandlers.RollbackFunction)
+
+		develop.POST("/endpoints/", endpointHandlers.CreateEndpoint)
+		develop.PUT("/endpoints/:epID/", endpointHandlers.UpdateEndpoint)
+		develop.DELETE("/endpoints/:epID/", endpointHandlers.DeleteEndpoint)
+
+		develop.PUT("/config/", configHandlers.UpdateProjectConfig)
+
+		develop.POST("/changes/", changeHandlers.OpenChange)
+	}
+
+	// maintainers control what lands in the repo
+	maintain := api.Group("/", middleware.RequireRole(project.Maintainer))
+	{
+		maintain.POST("/projects/sync/", projectHandlers.SyncProject)
+		maintain.POST("/config/revert/", configHandlers.RevertProjectConfig)
+		maintain.POST("/config/commit/", configHandlers.CommitProjectConfig)
+
+		maintain.POST("/apikeys/", apiKeyHandlers.CreateAPIKey)
+		maintain.DELETE("/apikeys/:keyID/", apiKeyHandlers.DeleteAPIKey)
+
+		maintain.POST("/environments/", environmentHandlers.CreateEnvironment)
+		maintain.DELETE("/environments/:env/", environmentHandlers.DeleteEnvironment)
+		maintain.POST("/environments/promote/", environmentHandlers.PromoteEnvironment)
 
-		api.POST("/endpoints/", handlers.CreateEndpoint)
-		api.GET("/endpoints/", handlers.ListEndpoints)
-		api.GET("/endpoints/:epID/", handlers.GetEndpoint)
-		api.PUT("/endpoints/:epID/", handlers.UpdateEndpoint)
-		api.DELETE("/endpoints/:epID/", handlers.DeleteEndpoint)
+		// approval happens on the vcs, merging from the portal also syncs the environment
+		maintain.POST("/changes/:changeID/merge/", changeHandlers.MergeChange)
 
-		api.GET("/config/", handlers.GetProjectConfig)
-		api.PUT("/config/", handlers.UpdateProjectConfig)
+		// function creates and deletes whose push is still pending or was given up on
+		maintain.GET("/operations/", functionHandlers.ListOperations)
+		maintain.POST("/operations/:opID/retry/", functionHandlers.RetryOperation)
+		maintain.POST("/operations/:opID/abandon/", functionHandlers.AbandonOperation)
 	}
 }
@@ -1,17 +1,25 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/ashupednekar/litewebservices-portal/internal/gateway"
+	"github.com/ashupednekar/litewebservices-portal/internal/ratelimit"
 	"github.com/ashupednekar/litewebservices-portal/pkg"
+	"github.com/ashupednekar/litewebservices-portal/pkg/handlers"
 	"github.com/ashupednekar/litewebservices-portal/pkg/state"
 	"github.com/gin-gonic/gin"
 )
 
 type Server struct {
-	Port   int
-	router *gin.Engine
-	state  *state.AppState
+	Port    int
+	router  *gin.Engine
+	state   *state.AppState
+	gateway *gateway.Gateway
+	limiter ratelimit.Store
 }
 
 func NewServer() (*Server, error) {
@@ -24,10 +32,39 @@ func NewServer() (*Server, error) {
 		router: gin.Default(),
 		state:  state,
 	}
+	// X-Forwarded-For is only believed from the proxies listed, client ips key rate limits
+	if err := s.router.SetTrustedProxies(trustedProxies(pkg.Cfg.TrustedProxies)); err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+	switch pkg.Cfg.RateLimitStore {
+	case "memory":
+		s.limiter = ratelimit.NewMemoryStore()
+	case "postgres":
+		s.limiter = ratelimit.NewPostgresStore(state.DBPool)
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_STORE %q, expected memory or postgres", pkg.Cfg.RateLimitStore)
+	}
 	s.BuildRoutes()
 	return s, nil
 }
 
+// trustedProxies splits the comma separated TRUSTED_PROXIES, empty trusts none
+func trustedProxies(raw string) []string {
+	var out []string
+	for _, p := range strings.Split(raw, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
 func (s *Server) Start() {
+	go s.gateway.Table.Listen(context.Background(), s.state.DBPool)
+	if store, ok := s.limiter.(*ratelimit.PostgresStore); ok {
+		go store.SweepEvery(context.Background(), 10*time.Minute, time.Hour)
+	}
+	go s.state.Repos.CollectEvery(context.Background(), time.Hour)
+	go handlers.RetryFunctionOperationsEvery(context.Background(), s.state, time.Minute)
 	s.router.Run(fmt.Sprintf("0.0.0.0:%d", s.Port))
 }
@@ -1,9 +1,15 @@
 package state
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/ashupednekar/litewebservices-portal/internal/auth"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/repo"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/vendors"
+	"github.com/ashupednekar/litewebservices-portal/pkg"
 	"github.com/ashupednekar/litewebservices-portal/pkg/state/connections"
 	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -12,6 +18,7 @@ import (
 type AppState struct {
 	Authn  *webauthn.WebAuthn
 	DBPool *pgxpool.Pool
+	Repos  *repo.Manager
 }
 
 func NewState() (*AppState, error) {
@@ -19,6 +26,32 @@ func NewState() (*AppState, error) {
 	if err != nil {
 		return nil, fmt.Errorf("couldn't initialize state - webauthn: %s", err)
 	}
+	syncTTL, err := time.ParseDuration(pkg.Cfg.RepoSyncTTL)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't initialize state - invalid REPO_SYNC_TTL: %s", err)
+	}
 	connections.ConnectDB()
-	return &AppState{Authn: authn, DBPool: connections.DBPool}, nil
+	s := &AppState{
+		Authn:  authn,
+		DBPool: connections.DBPool,
+	}
+	connect := func(project string) (vendors.Connection, error) {
+		return s.VcsConnection(context.Background(), project)
+	}
+	switch pkg.Cfg.VcsStorage {
+	case repo.StorageMemory:
+		s.Repos = repo.NewManager(pkg.Cfg.RepoCacheMB<<20, syncTTL, connect)
+	case repo.StorageDisk:
+		idle, err := time.ParseDuration(pkg.Cfg.VcsCacheIdle)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't initialize state - invalid VCS_CACHE_IDLE: %s", err)
+		}
+		if err := os.MkdirAll(pkg.Cfg.VcsCacheDir, 0o755); err != nil {
+			return nil, fmt.Errorf("couldn't initialize state - VCS_CACHE_DIR: %s", err)
+		}
+		s.Repos = repo.NewDiskManager(pkg.Cfg.VcsCacheDir, idle, pkg.Cfg.RepoCacheMB<<20, syncTTL, connect)
+	default:
+		return nil, fmt.Errorf("unknown VCS_STORAGE %q, expected memory or disk", pkg.Cfg.VcsStorage)
+	}
+	return s, nil
 }
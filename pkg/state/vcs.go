@@ -0,0 +1,58 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ashupednekar/litewebservices-portal/internal/project/adaptors"
+	"github.com/ashupednekar/litewebservices-portal/internal/project/vendors"
+	"github.com/jackc/pgx/v5"
+)
+
+// VcsConnection is the connection a project's repo is reached through, its own when it has
+// one and the deployment's VCS_* settings otherwise
+func (s *AppState) VcsConnection(ctx context.Context, project string) (vendors.Connection, error) {
+	row, err := adaptors.New(s.DBPool).GetProjectVcsConnectionByName(ctx, project)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return vendors.DefaultConnection(project), nil
+	}
+	if err != nil {
+		return vendors.Connection{}, fmt.Errorf("loading the vcs connection of %s: %w", project, err)
+	}
+	return OpenVcsConnection(row, project)
+}
+
+// OpenVcsConnection decrypts a stored connection's credentials, one stored without a
+// token borrows the deployment's when it's still on project's default repo
+func OpenVcsConnection(row adaptors.ProjectVcsConnection, project string) (vendors.Connection, error) {
+	conn := vendors.Connection{
+		Vendor:   row.Vendor,
+		BaseURL:  row.BaseUrl,
+		Owner:    row.Owner,
+		Repo:     row.RepoName,
+		AuthMode: row.AuthMode,
+	}
+	token, err := vendors.OpenCredential(row.Token)
+	if err != nil {
+		return conn, fmt.Errorf("vcs token: %w", err)
+	}
+	if conn.SSHKey, err = vendors.OpenCredential(row.SshKey); err != nil {
+		return conn, fmt.Errorf("vcs ssh key: %w", err)
+	}
+	password, err := vendors.OpenCredential(row.SshKeyPassword)
+	if err != nil {
+		return conn, fmt.Errorf("vcs ssh key password: %w", err)
+	}
+	conn.Token = string(token)
+	conn.SSHKeyPassword = string(password)
+	if len(row.Token) == 0 && conn.CanBorrow(project) {
+		def := vendors.DefaultConnection(project)
+		conn.Token = def.Token
+		if len(conn.SSHKey) == 0 {
+			conn.SSHKeyPath = def.SSHKeyPath
+			conn.SSHKeyPassword = def.SSHKeyPassword
+		}
+	}
+	return conn, nil
+}
@@ -8,7 +8,20 @@ package templates
 import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
-func ConfigurationContent() templ.Component {
+import "fmt"
+
+// overrides tells whether edits go to the selected environment rather than the project
+// wide values, which is the case for every environment but the default one
+func overrides(envs []Environment) bool {
+	for _, e := range envs {
+		if e.Selected {
+			return !e.Default
+		}
+	}
+	return false
+}
+
+func ConfigurationContent(entries []ConfigEntry, envs []Environment) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -29,7 +42,294 @@ func ConfigurationContent() templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"w-full px-6 md:px-14 py-12 space-y-10\"><!-- HEADER --><div class=\"flex items-center justify-between\"><div class=\"flex items-center gap-4\"><a href=\"/dashboard/\" class=\"p-2 hover:bg-neutral-800 rounded-lg transition\"><svg xmlns=\"http://www.w3.org/2000/svg\" class=\"w-5 h-5 text-neutral-400\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M15 19l-7-7 7-7\"></path></svg></a><h1 class=\"text-4xl font-semibold text-white tracking-tight\">Configuration</h1></div><button onclick=\"addConfigRow()\" class=\"bg-blue-600 hover:bg-blue-700 text-white font-semibold px-4 py-2 rounded-xl transition\">New Config</button></div><p class=\"text-neutral-400 text-sm -mt-4\">Project-level configuration & encrypted secrets.</p><!-- CONFIG LIST --><div id=\"config-list\" class=\"space-y-3\"><!-- Example Row --><div class=\"config-item border border-neutral-800 bg-[#0e0e0f] rounded-2xl p-4\"><div class=\"flex items-center justify-between cursor-pointer\" onclick=\"toggleConfig(this)\"><div><h3 class=\"text-white font-semibold text-lg\">APP_MODE</h3><p class=\"text-neutral-500 text-sm\">production</p></div><img src=\"/static/imgs/arrow-down.svg\" class=\"w-5 h-5 opacity-60 rotate-0 transition-transform\"></div><!-- EXPANDED EDITOR --><div class=\"config-body hidden mt-4 space-y-3\"><!-- Key --><div><label class=\"text-neutral-400 text-sm\">Key</label> <input class=\"cfg-key w-full mt-1 p-2 bg-[#0c0c0d] border border-neutral-700 rounded-xl text-white\"></div><!-- Value --><div><label class=\"text-neutral-400 text-sm\">Value</label> <textarea class=\"cfg-value w-full h-32 mt-1 p-2 bg-[#0c0c0d] border border-neutral-700 rounded-xl text-white\"></textarea></div><!-- Options --><label class=\"flex items-center gap-2 text-neutral-300 text-sm\"><input type=\"checkbox\" class=\"cfg-encrypted\"> Encrypted</label><!-- Save/Cancel --><div class=\"flex justify-end gap-3 pt-2\"><button onclick=\"cancelConfigEdit(this)\" class=\"px-4 py-2 rounded-lg border border-neutral-700 text-neutral-300 hover:bg-neutral-800\">Cancel</button> <button onclick=\"saveConfig(this)\" class=\"px-4 py-2 rounded-lg bg-blue-600 hover:bg-blue-700 text-white\">Save</button></div></div></div></div></div><script>\n  /* Toggle expand/collapse */\n  function toggleConfig(el) {\n    const body = el.parentElement.querySelector(\".config-body\");\n    const arrow = el.querySelector(\"img\");\n\n    if (body.classList.contains(\"hidden\")) {\n      body.classList.remove(\"hidden\");\n      arrow.style.transform = \"rotate(180deg)\";\n    } else {\n      body.classList.add(\"hidden\");\n      arrow.style.transform = \"rotate(0deg)\";\n    }\n  }\n\n  /* Add a new empty row */\n  function addConfigRow() {\n    const list = document.getElementById(\"config-list\");\n\n    const div = document.createElement(\"div\");\n    div.className = \"config-item border border-neutral-800 bg-[#0e0e0f] rounded-2xl p-4\";\n\n    div.innerHTML = `\n\t\t<div class=\"flex items-center justify-between cursor-pointer\" onclick=\"toggleConfig(this)\">\n\t\t\t<div>\n\t\t\t\t<h3 class=\"text-white font-semibold text-lg\">New Key</h3>\n\t\t\t\t<p class=\"text-neutral-500 text-sm\">Click to edit…</p>\n\t\t\t</div>\n\t\t\t<img src=\"/static/imgs/arrow-down.svg\" class=\"w-5 h-5 opacity-60 rotate-0 transition-transform\"/>\n\t\t</div>\n\n\t\t<div class=\"config-body mt-4 space-y-3\">\n\t\t\t<div>\n\t\t\t\t<label class=\"text-neutral-400 text-sm\">Key</label>\n\t\t\t\t<input class=\"cfg-key w-full mt-1 p-2 bg-[#0c0c0d] border border-neutral-700 rounded-xl text-white\"/>\n\t\t\t</div>\n\n\t\t\t<div>\n\t\t\t\t<label class=\"text-neutral-400 text-sm\">Value</label>\n\t\t\t\t<textarea class=\"cfg-value w-full h-32 mt-1 p-2 bg-[#0c0c0d] border border-neutral-700 rounded-xl text-white\"></textarea>\n\t\t\t</div>\n\n\t\t\t<label class=\"flex items-center gap-2 text-neutral-300 text-sm\">\n\t\t\t\t<input type=\"checkbox\" class=\"cfg-encrypted\"/>\n\t\t\t\tEncrypted\n\t\t\t</label>\n\n\t\t\t<div class=\"flex justify-end gap-3 pt-2\">\n\t\t\t\t<button onclick=\"cancelConfigEdit(this)\" \n\t\t\t\t\tclass=\"px-4 py-2 rounded-lg border border-neutral-700 text-neutral-300 hover:bg-neutral-800\">\n\t\t\t\t\tCancel\n\t\t\t\t</button>\n\t\t\t\t<button onclick=\"saveConfig(this)\" \n\t\t\t\t\tclass=\"px-4 py-2 rounded-lg bg-blue-600 hover:bg-blue-700 text-white\">\n\t\t\t\t\tSave\n\t\t\t\t</button>\n\t\t\t</div>\n\t\t</div>`;\n\n    list.prepend(div);\n  }\n\n  /* Cancel editing */\n  function cancelConfigEdit(btn) {\n    const body = btn.closest(\".config-body\");\n    body.classList.add(\"hidden\");\n\n    const arrow = body.parentElement.querySelector(\"img\");\n    arrow.style.transform = \"rotate(0deg)\";\n  }\n\n  /* Save logic placeholder */\n  function saveConfig(btn) {\n    const container = btn.closest(\".config-item\");\n    const key = container.querySelector(\".cfg-key\").value;\n    const val = container.querySelector(\".cfg-value\").value;\n    const enc = container.querySelector(\".cfg-encrypted\").checked;\n\n    console.log(\"TODO: Send save to backend\", {key, val, enc});\n\n    // update collapsed summary UI\n    container.querySelector(\"h3\").textContent = key || \"Unnamed\";\n    container.querySelector(\"p\").textContent = enc ? \"(encrypted)\" : val.slice(0, 50);\n\n    cancelConfigEdit(btn);\n  }\n</script><style>\n  .config-item {\n    transition: border-color 0.2s, background-color 0.2s;\n  }\n\n  .config-item:hover {\n    border-color: #666;\n    background-color: #141416;\n  }\n</style>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"w-full px-6 md:px-14 py-12 space-y-10\"><!-- HEADER --><div class=\"flex items-center justify-between\"><div class=\"flex items-center gap-4\"><a href=\"/dashboard/\" class=\"p-2 hover:bg-neutral-800 rounded-lg transition\"><svg xmlns=\"http://www.w3.org/2000/svg\" class=\"w-5 h-5 text-neutral-400\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M15 19l-7-7 7-7\"></path></svg></a><h1 class=\"text-4xl font-semibold text-white tracking-tight\">Configuration</h1></div><div class=\"flex items-center gap-3\"><select id=\"env-select\" class=\"p-2 bg-[#0c0c0d] border border-neutral-700 rounded-xl text-white\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		for _, env := range envs {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<option value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var2 string
+			templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(env.Name)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/configuration.templ`, Line: 34, Col: 30}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if env.Selected {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, " selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, ">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var3 string
+			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(env.Name)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/configuration.templ`, Line: 34, Col: 70}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, " (")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var4 string
+			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(env.Branch)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/configuration.templ`, Line: 34, Col: 86}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, ")</option>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "</select> <button onclick=\"commitConfig()\" class=\"border border-neutral-700 text-neutral-300 hover:bg-neutral-800 font-semibold px-4 py-2 rounded-xl transition\">Commit to Repo</button> <button onclick=\"addConfigRow()\" class=\"bg-blue-600 hover:bg-blue-700 text-white font-semibold px-4 py-2 rounded-xl transition\">New Config</button></div></div><p class=\"text-neutral-400 text-sm -mt-4\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if overrides(envs) {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "Changes here override the project-level values for this environment only.")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		} else {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "Project-level configuration & encrypted secrets.")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "</p><!-- CONFIG LIST --><div id=\"config-list\" class=\"space-y-3\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if len(entries) == 0 {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "<div id=\"config-empty\" class=\"w-full text-center py-20 text-neutral-500 text-lg\">No configuration yet.</div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		for _, e := range entries {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "<div class=\"config-item border border-neutral-800 bg-[#0e0e0f] rounded-2xl p-4\" data-key=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var5 string
+			templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(e.Key)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/configuration.templ`, Line: 68, Col: 21}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "\" data-override=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var6 string
+			templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprint(e.Environment != ""))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/configuration.templ`, Line: 69, Col: 52}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "\"><div class=\"flex items-center justify-between cursor-pointer\" onclick=\"toggleConfig(this)\"><div><h3 class=\"text-white font-semibold text-lg\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var7 string
+			templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(e.Key)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/configuration.templ`, Line: 73, Col: 59}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "</h3><p class=\"text-neutral-500 text-sm\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if e.Secret {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "(secret)")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			} else {
+				var templ_7745c5c3_Var8 string
+				templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(e.Value)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/configuration.templ`, Line: 78, Col: 18}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "</p></div><div class=\"flex items-center gap-3\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if e.Environment != "" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "<span class=\"text-blue-400 text-xs\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var9 string
+				templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(e.Environment)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/configuration.templ`, Line: 84, Col: 59}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "</span> ")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "<span class=\"text-neutral-500 text-xs\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var10 string
+			templ_7745c5c3_Var10, templ_7745c5c3_Err = templ.JoinStringErrs(e.Type)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/configuration.templ`, Line: 86, Col: 54}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var10))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, " · v")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var11 string
+			templ_7745c5c3_Var11, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprint(e.Version))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/configuration.templ`, Line: 86, Col: 84}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var11))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 23, "</span> <img src=\"/static/imgs/arrow-down.svg\" class=\"w-5 h-5 opacity-60 rotate-0 transition-transform\"></div></div><!-- EXPANDED EDITOR --><div class=\"config-body hidden mt-4 space-y-3\"><!-- Key --><div><label class=\"text-neutral-400 text-sm\">Key</label> <input class=\"cfg-key w-full mt-1 p-2 bg-[#0c0c0d] border border-neutral-700 rounded-xl text-white\" value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var12 string
+			templ_7745c5c3_Var12, templ_7745c5c3_Err = templ.JoinStringErrs(e.Key)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/configuration.templ`, Line: 95, Col: 120}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var12))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 24, "\" readonly></div><!-- Type --><div><label class=\"text-neutral-400 text-sm\">Type</label> <select class=\"cfg-type w-full mt-1 p-2 bg-[#0c0c0d] border border-neutral-700 rounded-xl text-white\"><option value=\"string\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if e.Type == "string" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 25, " selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 26, ">string</option> <option value=\"number\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if e.Type == "number" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 27, " selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 28, ">number</option> <option value=\"bool\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if e.Type == "bool" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 29, " selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 30, ">bool</option> <option value=\"json\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if e.Type == "json" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 31, " selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 32, ">json</option></select></div><!-- Value --><div><label class=\"text-neutral-400 text-sm\">Value</label> <textarea class=\"cfg-value w-full h-32 mt-1 p-2 bg-[#0c0c0d] border border-neutral-700 rounded-xl text-white\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var13 string
+			templ_7745c5c3_Var13, templ_7745c5c3_Err = templ.JoinStringErrs(e.Value)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/configuration.templ`, Line: 112, Col: 17}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var13))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 33, "</textarea></div><!-- Options --><label class=\"flex items-center gap-2 text-neutral-300 text-sm\"><input type=\"checkbox\" class=\"cfg-encrypted\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if e.Secret {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 34, " checked")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 35, "> Secret</label><p class=\"cfg-error hidden text-red-400 text-sm\"></p><!-- History --><div class=\"cfg-history hidden space-y-2\"></div><!-- Save/Cancel --><div class=\"flex justify-end gap-3 pt-2\"><button onclick=\"toggleHistory(this)\" class=\"px-4 py-2 rounded-lg border border-neutral-700 text-neutral-300 hover:bg-neutral-800 mr-auto\">History</button> <button onclick=\"deleteConfig(this)\" class=\"px-4 py-2 rounded-lg border border-red-700 text-red-400 hover:bg-red-900/40\">Delete</button> <button onclick=\"cancelConfigEdit(this)\" class=\"px-4 py-2 rounded-lg border border-neutral-700 text-neutral-300 hover:bg-neutral-800\">Cancel</button> <button onclick=\"saveConfig(this)\" class=\"px-4 py-2 rounded-lg bg-blue-600 hover:bg-blue-700 text-white\">Save</button></div></div></div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 36, "</div></div><script data-override=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var14 string
+		templ_7745c5c3_Var14, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprint(overrides(envs)))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/configuration.templ`, Line: 151, Col: 52}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var14))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 37, "\">\n  /* edits land on the selected environment unless it's the default one */\n  const OVERRIDE = document.currentScript.dataset.override === \"true\";\n\n  document.getElementById(\"env-select\").onchange = e => {\n    document.cookie = \"lws_env=\" + e.target.value + \"; path=/; SameSite=Lax\";\n    location.reload();\n  };\n\n  /* Toggle expand/collapse */\n  function toggleConfig(el) {\n    const body = el.parentElement.querySelector(\".config-body\");\n    const arrow = el.querySelector(\"img\");\n\n    if (body.classList.contains(\"hidden\")) {\n      body.classList.remove(\"hidden\");\n      arrow.style.transform = \"rotate(180deg)\";\n    } else {\n      body.classList.add(\"hidden\");\n      arrow.style.transform = \"rotate(0deg)\";\n    }\n  }\n\n  /* Add a new empty row */\n  function addConfigRow() {\n    const list = document.getElementById(\"config-list\");\n    document.getElementById(\"config-empty\")?.remove();\n\n    const div = document.createElement(\"div\");\n    div.className = \"config-item border border-neutral-800 bg-[#0e0e0f] rounded-2xl p-4\";\n\n    div.innerHTML = `\n\t\t<div class=\"flex items-center justify-between cursor-pointer\" onclick=\"toggleConfig(this)\">\n\t\t\t<div>\n\t\t\t\t<h3 class=\"text-white font-semibold text-lg\">New Key</h3>\n\t\t\t\t<p class=\"text-neutral-500 text-sm\">Click to edit…</p>\n\t\t\t</div>\n\t\t\t<img src=\"/static/imgs/arrow-down.svg\" class=\"w-5 h-5 opacity-60 rotate-0 transition-transform\"/>\n\t\t</div>\n\n\t\t<div class=\"config-body mt-4 space-y-3\">\n\t\t\t<div>\n\t\t\t\t<label class=\"text-neutral-400 text-sm\">Key</label>\n\t\t\t\t<input class=\"cfg-key w-full mt-1 p-2 bg-[#0c0c0d] border border-neutral-700 rounded-xl text-white\"/>\n\t\t\t</div>\n\n\t\t\t<div>\n\t\t\t\t<label class=\"text-neutral-400 text-sm\">Type</label>\n\t\t\t\t<select class=\"cfg-type w-full mt-1 p-2 bg-[#0c0c0d] border border-neutral-700 rounded-xl text-white\">\n\t\t\t\t\t<option value=\"string\">string</option>\n\t\t\t\t\t<option value=\"number\">number</option>\n\t\t\t\t\t<option value=\"bool\">bool</option>\n\t\t\t\t\t<option value=\"json\">json</option>\n\t\t\t\t</select>\n\t\t\t</div>\n\n\t\t\t<div>\n\t\t\t\t<label class=\"text-neutral-400 text-sm\">Value</label>\n\t\t\t\t<textarea class=\"cfg-value w-full h-32 mt-1 p-2 bg-[#0c0c0d] border border-neutral-700 rounded-xl text-white\"></textarea>\n\t\t\t</div>\n\n\t\t\t<label class=\"flex items-center gap-2 text-neutral-300 text-sm\">\n\t\t\t\t<input type=\"checkbox\" class=\"cfg-encrypted\"/>\n\t\t\t\tSecret\n\t\t\t</label>\n\n\t\t\t<p class=\"cfg-error hidden text-red-400 text-sm\"></p>\n\n\t\t\t<div class=\"flex justify-end gap-3 pt-2\">\n\t\t\t\t<button onclick=\"cancelConfigEdit(this)\" \n\t\t\t\t\tclass=\"px-4 py-2 rounded-lg border border-neutral-700 text-neutral-300 hover:bg-neutral-800\">\n\t\t\t\t\tCancel\n\t\t\t\t</button>\n\t\t\t\t<button onclick=\"saveConfig(this)\" \n\t\t\t\t\tclass=\"px-4 py-2 rounded-lg bg-blue-600 hover:bg-blue-700 text-white\">\n\t\t\t\t\tSave\n\t\t\t\t</button>\n\t\t\t</div>\n\t\t</div>`;\n\n    list.prepend(div);\n  }\n\n  /* Cancel editing */\n  function cancelConfigEdit(btn) {\n    const body = btn.closest(\".config-body\");\n    body.classList.add(\"hidden\");\n\n    const arrow = body.parentElement.querySelector(\"img\");\n    arrow.style.transform = \"rotate(0deg)\";\n  }\n\n  function showConfigError(container, msg) {\n    const el = container.querySelector(\".cfg-error\");\n    el.textContent = msg;\n    el.classList.remove(\"hidden\");\n  }\n\n  async function putConfig(payload) {\n    const res = await fetch(\"/api/config/\", {\n      method: \"PUT\",\n      headers: {\"Content-Type\": \"application/json\"},\n      body: JSON.stringify(payload)\n    });\n    const body = await res.json().catch(() => ({}));\n    if (!res.ok) throw new Error(body.error || \"request failed\");\n    return body;\n  }\n\n  async function saveConfig(btn) {\n    const container = btn.closest(\".config-item\");\n    const key = container.querySelector(\".cfg-key\").value.trim();\n    const value = container.querySelector(\".cfg-value\").value;\n    const type = container.querySelector(\".cfg-type\").value;\n    const secret = container.querySelector(\".cfg-encrypted\").checked;\n\n    try {\n      await putConfig({entries: [{key, value, type, secret}], override: OVERRIDE});\n      location.reload();\n    } catch (e) {\n      showConfigError(container, e.message);\n    }\n  }\n\n  async function deleteConfig(btn) {\n    const container = btn.closest(\".config-item\");\n    const key = container.dataset.key;\n    if (!key || !confirm(`Delete ${key}?`)) return;\n    try {\n      await putConfig({delete: [key], override: container.dataset.override === \"true\"});\n      location.reload();\n    } catch (e) {\n      showConfigError(container, e.message);\n    }\n  }\n\n  async function toggleHistory(btn) {\n    const container = btn.closest(\".config-item\");\n    const list = container.querySelector(\".cfg-history\");\n    if (!list.classList.contains(\"hidden\")) {\n      list.classList.add(\"hidden\");\n      return;\n    }\n    const key = container.dataset.key;\n    const override = container.dataset.override === \"true\";\n    const res = await fetch(`/api/config/history/?key=${encodeURIComponent(key)}&override=${override}`);\n    const rows = await res.json();\n    list.innerHTML = \"\";\n    rows.forEach(r => {\n      const row = document.createElement(\"div\");\n      row.className = \"flex items-center justify-between text-sm text-neutral-400 border-b border-neutral-800 py-1\";\n      const label = document.createElement(\"span\");\n      label.textContent = `v${r.version} · ${r.deleted ? \"(deleted)\" : r.value} · ${new Date(r.changed_at).toLocaleString()}`;\n      row.appendChild(label);\n      if (!r.deleted) {\n        const revert = document.createElement(\"button\");\n        revert.className = \"text-blue-400 hover:text-blue-300\";\n        revert.textContent = \"Revert\";\n        revert.onclick = () => revertConfig(container, key, r.version);\n        row.appendChild(revert);\n      }\n      list.appendChild(row);\n    });\n    list.classList.remove(\"hidden\");\n  }\n\n  async function revertConfig(container, key, version) {\n    const res = await fetch(\"/api/config/revert/\", {\n      method: \"POST\",\n      headers: {\"Content-Type\": \"application/json\"},\n      body: JSON.stringify({key, version, override: container.dataset.override === \"true\"})\n    });\n    if (!res.ok) {\n      const body = await res.json().catch(() => ({}));\n      showConfigError(container, body.error || \"revert failed\");\n      return;\n    }\n    location.reload();\n  }\n\n  async function commitConfig() {\n    const res = await fetch(\"/api/config/commit/\", {method: \"POST\"});\n    const body = await res.json().catch(() => ({}));\n    alert(res.ok ? `Committed ${body.path}` : (body.error || \"commit failed\"));\n  }\n</script><style>\n  .config-item {\n    transition: border-color 0.2s, background-color 0.2s;\n  }\n\n  .config-item:hover {\n    border-color: #666;\n    background-color: #141416;\n  }\n</style>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -85,7 +85,24 @@ func DashboardContent(username string, projects []Project, activeProjectID strin
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</h3><div class=\"flex items-center gap-2\"><svg onclick=\"event.stopPropagation(); syncProject()\" xmlns=\"http://www.w3.org/2000/svg\" class=\"w-4 h-4 text-green-400 opacity-0 group-hover:opacity-100 cursor-pointer hover:scale-110 transition-all\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\" title=\"Sync project\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M4 4v5h.582m15.356 2A8.001 8.001 0 004.582 9m0 0H9m11 11v-5h-.581m0 0a8.003 8.003 0 01-15.357-2m15.357 2H15\"></path></svg><div class=\"h-2 w-2 bg-green-500 rounded-full\"></div></div></div></button>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</h3><div class=\"flex items-center gap-2\"><svg onclick=\"event.stopPropagation(); syncProject()\" xmlns=\"http://www.w3.org/2000/svg\" class=\"w-4 h-4 text-green-400 opacity-0 group-hover:opacity-100 cursor-pointer hover:scale-110 transition-all\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\" title=\"Sync project\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M4 4v5h.582m15.356 2A8.001 8.001 0 004.582 9m0 0H9m11 11v-5h-.581m0 0a8.003 8.003 0 01-15.357-2m15.357 2H15\"></path></svg> ")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templ.RenderScriptItems(ctx, templ_7745c5c3_Buffer, templ.JSFuncCall("deleteProject", p.ID, p.Name))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "<svg onclick=\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var5 templ.ComponentScript = templ.JSFuncCall("deleteProject", p.ID, p.Name)
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ_7745c5c3_Var5.Call)
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "\" xmlns=\"http://www.w3.org/2000/svg\" class=\"w-4 h-4 text-red-400 opacity-0 group-hover:opacity-100 cursor-pointer hover:scale-110 transition-all\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\" title=\"Delete project\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M19 7l-.867 12.142A2 2 0 0116.138 21H7.862a2 2 0 01-1.995-1.858L5 7m5 4v6m4-6v6m1-10V4a1 1 0 00-1-1h-4a1 1 0 00-1 1v3M4 7h16\"></path></svg><div class=\"h-2 w-2 bg-green-500 rounded-full\"></div></div></div></button>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
@@ -94,50 +111,109 @@ func DashboardContent(username string, projects []Project, activeProjectID strin
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "<button onclick=\"")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "<button onclick=\"")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				var templ_7745c5c3_Var5 templ.ComponentScript = selectProject(p.ID)
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ_7745c5c3_Var5.Call)
+				var templ_7745c5c3_Var6 templ.ComponentScript = selectProject(p.ID)
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ_7745c5c3_Var6.Call)
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "\" class=\"min-w-[220px] rounded-xl border border-neutral-800 bg-[#0e0e0f] p-4\"><div class=\"flex items-center justify-between\"><h3 class=\"text-white font-semibold text-base\">")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "\" class=\"min-w-[220px] rounded-xl border border-neutral-800 bg-[#0e0e0f] p-4\"><div class=\"flex items-center justify-between\"><h3 class=\"text-white font-semibold text-base\">")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				var templ_7745c5c3_Var6 string
-				templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(p.Name)
+				var templ_7745c5c3_Var7 string
+				templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(p.Name)
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/dashboard.templ`, Line: 75, Col: 64}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/dashboard.templ`, Line: 86, Col: 64}
 				}
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "</h3></div></button>")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "</h3></div></button>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "<!-- New project button --><button onclick=\"openProjectModal()\" class=\"min-w-[220px] rounded-xl border border-neutral-800 bg-[#0b0b0c]\n\t\t\t\t\t\tp-4 flex flex-col items-center justify-center text-neutral-500 \n\t\t\t\t\t\thover:text-white hover:border-neutral-600 hover:bg-neutral-900 shadow-md\"><div class=\"h-8 w-8 rounded-full border border-neutral-700 grid place-items-center\"><span class=\"text-xl\">+</span></div><p class=\"mt-2 font-medium text-sm\">New Project</p></button></div></div></div><!-- IF NO ACTIVE PROJECT -->")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "<!-- New project button --><button onclick=\"openProjectModal()\" class=\"min-w-[220px] rounded-xl border border-neutral-800 bg-[#0b0b0c]\n\t\t\t\t\t\tp-4 flex flex-col items-center justify-center text-neutral-500 \n\t\t\t\t\t\thover:text-white hover:border-neutral-600 hover:bg-neutral-900 shadow-md\"><div class=\"h-8 w-8 rounded-full border border-neutral-700 grid place-items-center\"><span class=\"text-xl\">+</span></div><p class=\"mt-2 font-medium text-sm\">New Project</p></button></div></div></div><!-- IF NO ACTIVE PROJECT -->")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		if activeProjectID == "" {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "<div class=\"w-full text-center py-20 text-neutral-400 text-lg\">Please select a project to continue.</div>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "<div class=\"w-full text-center py-20 text-neutral-400 text-lg\">Please select a project to continue.</div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		} else {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "<!-- FEATURE SECTIONS --> <div class=\"space-y-4\"><h2 class=\"text-neutral-400 font-medium text-sm tracking-wide\">Tools & Features</h2><div class=\"grid grid-cols-1 sm:grid-cols-2 lg:grid-cols-3 gap-6\"><a href=\"/functions/\" class=\"group rounded-2xl border border-neutral-800 bg-[#0e0e0f] p-6 hover:border-neutral-600 shadow-md\"><div class=\"flex items-center gap-3\"><svg xmlns=\"http://www.w3.org/2000/svg\" class=\"w-6 h-6 text-blue-400\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"1.7\" d=\"M6 18L18 6M6 6l12 12\"></path></svg><h3 class=\"text-lg text-white font-semibold\">Functions</h3></div></a> <a href=\"/endpoints/\" class=\"group rounded-2xl border border-neutral-800 bg-[#0e0e0f] p-6 hover:border-neutral-600 shadow-md\"><div class=\"flex items-center gap-3\"><svg xmlns=\"http://www.w3.org/2000/svg\" class=\"w-6 h-6 text-purple-400\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"1.7\" d=\"M4 12h16m-7-7l7 7-7 7\"></path></svg><h3 class=\"text-lg text-white font-semibold\">Endpoints</h3></div></a> <a href=\"/configuration/\" class=\"group rounded-2xl border border-neutral-800 bg-[#0e0e0f] p-6 hover:border-neutral-600 shadow-md\"><div class=\"flex items-center gap-3\"><svg xmlns=\"http://www.w3.org/2000/svg\" class=\"w-6 h-6 text-yellow-400\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"1.7\" d=\"M12 8c-1.1 0-2 .9-2 2v4h4v-4c0-1.1-.9-2-2-2zM4 12h16\"></path></svg><h3 class=\"text-lg text-white font-semibold\">Configuration</h3></div></a></div></div>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "<!-- FEATURE SECTIONS --> <div class=\"space-y-4\"><h2 class=\"text-neutral-400 font-medium text-sm tracking-wide\">Tools & Features</h2><div class=\"grid grid-cols-1 sm:grid-cols-2 lg:grid-cols-3 gap-6\"><a href=\"/functions/\" class=\"group rounded-2xl border border-neutral-800 bg-[#0e0e0f] p-6 hover:border-neutral-600 shadow-md\"><div class=\"flex items-center gap-3\"><svg xmlns=\"http://www.w3.org/2000/svg\" class=\"w-6 h-6 text-blue-400\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"1.7\" d=\"M6 18L18 6M6 6l12 12\"></path></svg><h3 class=\"text-lg text-white font-semibold\">Functions</h3></div></a> <a href=\"/endpoints/\" class=\"group rounded-2xl border border-neutral-800 bg-[#0e0e0f] p-6 hover:border-neutral-600 shadow-md\"><div class=\"flex items-center gap-3\"><svg xmlns=\"http://www.w3.org/2000/svg\" class=\"w-6 h-6 text-purple-400\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"1.7\" d=\"M4 12h16m-7-7l7 7-7 7\"></path></svg><h3 class=\"text-lg text-white font-semibold\">Endpoints</h3></div></a> <a href=\"/configuration/\" class=\"group rounded-2xl border border-neutral-800 bg-[#0e0e0f] p-6 hover:border-neutral-600 shadow-md\"><div class=\"flex items-center gap-3\"><svg xmlns=\"http://www.w3.org/2000/svg\" class=\"w-6 h-6 text-yellow-400\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"1.7\" d=\"M12 8c-1.1 0-2 .9-2 2v4h4v-4c0-1.1-.9-2-2-2zM4 12h16\"></path></svg><h3 class=\"text-lg text-white font-semibold\">Configuration</h3></div></a></div></div><!-- MEMBERS --> <div class=\"space-y-4\" data-project-id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var8 string
+			templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(activeProjectID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/dashboard.templ`, Line: 176, Col: 59}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "\" id=\"members-section\"><h2 class=\"text-neutral-400 font-medium text-sm tracking-wide\">Members</h2><div class=\"rounded-2xl border border-neutral-800 bg-[#0e0e0f] p-6 space-y-6\"><div id=\"members-list\" class=\"divide-y divide-neutral-800 text-sm text-neutral-300\"><p class=\"text-neutral-500\">Loading members...</p></div><div id=\"members-manage\" class=\"hidden flex-col md:flex-row gap-3\"><input id=\"member-username\" class=\"flex-1 px-3 py-2 bg-[#0b0b0c] border border-neutral-800 rounded-xl text-white text-sm\" placeholder=\"Username\"> <select id=\"member-role\" class=\"px-3 py-2 bg-[#0b0b0c] border border-neutral-800 rounded-xl text-white text-sm\"><option value=\"developer\">Developer</option> <option value=\"maintainer\">Maintainer</option> <option value=\"viewer\">Viewer</option> <option value=\"owner\">Owner</option></select> <button onclick=\"addMember()\" class=\"bg-white text-black px-4 py-2 rounded-xl font-semibold text-sm\">Add</button> <button onclick=\"createInviteLink()\" class=\"border border-neutral-700 text-white px-4 py-2 rounded-xl text-sm hover:border-neutral-500\">Invite link</button></div><p id=\"invite-link\" class=\"hidden text-xs text-neutral-400 break-all\"></p></div></div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "<!-- ACCESS TOKENS --><div class=\"space-y-4\"><h2 class=\"text-neutral-400 font-medium text-sm tracking-wide\">Personal Access Tokens</h2><div class=\"rounded-2xl border border-neutral-800 bg-[#0e0e0f] p-6 space-y-6\"><p class=\"text-neutral-500 text-sm\">Use the api from scripts and CI with <code class=\"text-neutral-300\">Authorization: Bearer &lt;token&gt;</code>, select a project with the <code class=\"text-neutral-300\">X-LWS-Project</code> header.</p><div id=\"tokens-list\" class=\"divide-y divide-neutral-800 text-sm text-neutral-300\"></div><div class=\"flex flex-col md:flex-row gap-3\"><input id=\"token-name\" class=\"flex-1 px-3 py-2 bg-[#0b0b0c] border border-neutral-800 rounded-xl text-white text-sm\" placeholder=\"Token name, e.g. ci\"> <select id=\"token-access\" class=\"px-3 py-2 bg-[#0b0b0c] border border-neutral-800 rounded-xl text-white text-sm\"><option value=\"read\">Read</option> <option value=\"write\">Read &amp; write</option></select> <input id=\"token-expiry\" type=\"number\" min=\"0\" class=\"w-36 px-3 py-2 bg-[#0b0b0c] border border-neutral-800 rounded-xl text-white text-sm\" placeholder=\"Expires (days)\"> <button onclick=\"createToken()\" class=\"bg-white text-black px-4 py-2 rounded-xl font-semibold text-sm\">Create</button></div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if len(projects) > 0 {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "<div class=\"flex flex-wrap gap-4 text-xs text-neutral-400\"><span>Limit to projects (none selected means all):</span> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			for _, p := range projects {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "<label class=\"flex items-center gap-1\"><input type=\"checkbox\" class=\"token-project\" value=\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var9 string
+				templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(p.ID)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/dashboard.templ`, Line: 234, Col: 65}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "\"> ")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var10 string
+				templ_7745c5c3_Var10, templ_7745c5c3_Err = templ.JoinStringErrs(p.Name)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/dashboard.templ`, Line: 235, Col: 16}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var10))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "</label>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "</div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "<!-- MODAL --><div id=\"project-modal\" class=\"fixed inset-0 bg-black bg-opacity-50 hidden justify-center items-center\"><div class=\"bg-[#0e0e0f] border border-neutral-800 rounded-2xl p-6 w-96\"><h3 class=\"text-lg text-white font-semibold\">Create Project</h3><input id=\"new-project-name\" class=\"w-full mt-4 px-3 py-2 bg-[#0b0b0c] border border-neutral-800 rounded-xl text-white\" placeholder=\"Project name\"><div class=\"flex justify-end gap-3 mt-6\"><button onclick=\"closeProjectModal()\" class=\"text-neutral-400\">Cancel</button> <button onclick=\"submitNewProject()\" class=\"bg-white text-black px-4 py-2 rounded-xl font-semibold\">Create</button></div></div></div><script>\n    function openProjectModal() {\n      const modal = document.getElementById(\"project-modal\")\n      modal.classList.remove(\"hidden\")\n      modal.classList.add(\"flex\")\n    }\n    function closeProjectModal() {\n      const modal = document.getElementById(\"project-modal\")\n      modal.classList.add(\"hidden\")\n      modal.classList.remove(\"flex\")\n    }\n\n    async function submitNewProject() {\n      const name = document.getElementById(\"new-project-name\").value.trim()\n      if (!name) return\n      await fetch(\"/api/projects/\", {\n        method: \"POST\",\n        headers: {\"Content-Type\": \"application/json\"},\n        body: JSON.stringify({name})\n      })\n      closeProjectModal()\n      location.reload()\n    }\n\n    async function syncProject() {\n      await fetch(\"/api/projects/sync/\", {\n        method: \"POST\"\n      })\n      alert(\"Sync completed!\")\n      location.reload()\n    }\n  </script></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "<p id=\"token-created\" class=\"hidden text-xs text-green-300 break-all\"></p></div></div><!-- MODAL --><div id=\"project-modal\" class=\"fixed inset-0 bg-black bg-opacity-50 hidden justify-center items-center\"><div class=\"bg-[#0e0e0f] border border-neutral-800 rounded-2xl p-6 w-96\"><h3 class=\"text-lg text-white font-semibold\">Create Project</h3><input id=\"new-project-name\" class=\"w-full mt-4 px-3 py-2 bg-[#0b0b0c] border border-neutral-800 rounded-xl text-white\" placeholder=\"Project name\"><div class=\"flex justify-end gap-3 mt-6\"><button onclick=\"closeProjectModal()\" class=\"text-neutral-400\">Cancel</button> <button onclick=\"submitNewProject()\" class=\"bg-white text-black px-4 py-2 rounded-xl font-semibold\">Create</button></div></div></div><script>\n    function openProjectModal() {\n      const modal = document.getElementById(\"project-modal\")\n      modal.classList.remove(\"hidden\")\n      modal.classList.add(\"flex\")\n    }\n    function closeProjectModal() {\n      const modal = document.getElementById(\"project-modal\")\n      modal.classList.add(\"hidden\")\n      modal.classList.remove(\"flex\")\n    }\n\n    async function submitNewProject() {\n      const name = document.getElementById(\"new-project-name\").value.trim()\n      if (!name) return\n      await fetch(\"/api/projects/\", {\n        method: \"POST\",\n        headers: {\"Content-Type\": \"application/json\"},\n        body: JSON.stringify({name})\n      })\n      closeProjectModal()\n      location.reload()\n    }\n\n    async function deleteProject(id, name) {\n      window.event?.stopPropagation()\n      if (!confirm(`Delete project ${name}? This cannot be undone.`)) return\n      const keepRepo = confirm(\"Keep the git repository? (OK keeps it, Cancel deletes it if the portal created it, imported repos are always kept)\")\n      const res = await fetch(`/api/projects/${id}/?keep_repo=${keepRepo}`, {method: \"DELETE\"})\n      if (!res.ok) {\n        const body = await res.json().catch(() => ({}))\n        alert(body.error || \"failed to delete project\")\n        return\n      }\n      location.reload()\n    }\n\n    const roles = [\"owner\", \"maintainer\", \"developer\", \"viewer\"]\n\n    function projectBase() {\n      const section = document.getElementById(\"members-section\")\n      return section ? `/api/projects/${section.dataset.projectId}` : null\n    }\n\n    async function apiCall(url, opts) {\n      const res = await fetch(url, opts)\n      const body = await res.json().catch(() => ({}))\n      if (!res.ok) {\n        alert(body.error || `request failed (${res.status})`)\n        return null\n      }\n      return body\n    }\n\n    async function loadMembers() {\n      const base = projectBase()\n      if (!base) return\n      const [project, members] = await Promise.all([\n        fetch(`${base}/`).then(r => r.json()),\n        fetch(`${base}/members/`).then(r => r.json()),\n      ])\n      const isOwner = project.role === \"owner\"\n      const list = document.getElementById(\"members-list\")\n      list.innerHTML = \"\"\n      for (const m of members) {\n        const row = document.createElement(\"div\")\n        row.className = \"flex items-center justify-between py-3\"\n        const name = document.createElement(\"span\")\n        name.className = \"text-white\"\n        name.textContent = m.display_name || m.name\n        row.appendChild(name)\n        const actions = document.createElement(\"div\")\n        actions.className = \"flex items-center gap-3\"\n        if (isOwner) {\n          const select = document.createElement(\"select\")\n          select.className = \"px-2 py-1 bg-[#0b0b0c] border border-neutral-800 rounded-lg text-white text-xs\"\n          for (const r of roles) select.add(new Option(r, r, false, r === m.role))\n          select.onchange = () => updateMemberRole(m.user_id, select.value)\n          const remove = document.createElement(\"button\")\n          remove.className = \"text-red-400 text-xs\"\n          remove.textContent = \"Remove\"\n          remove.onclick = () => removeMember(m.user_id, m.name)\n          actions.append(select, remove)\n        } else {\n          actions.textContent = m.role\n        }\n        row.appendChild(actions)\n        list.appendChild(row)\n      }\n      if (isOwner) {\n        const manage = document.getElementById(\"members-manage\")\n        manage.classList.remove(\"hidden\")\n        manage.classList.add(\"flex\")\n      }\n    }\n\n    async function addMember() {\n      const username = document.getElementById(\"member-username\").value.trim()\n      const role = document.getElementById(\"member-role\").value\n      if (!username) return\n      const ok = await apiCall(`${projectBase()}/members/`, {\n        method: \"POST\",\n        headers: {\"Content-Type\": \"application/json\"},\n        body: JSON.stringify({username, role})\n      })\n      if (ok) {\n        document.getElementById(\"member-username\").value = \"\"\n        loadMembers()\n      }\n    }\n\n    async function updateMemberRole(userID, role) {\n      await apiCall(`${projectBase()}/members/${userID}/`, {\n        method: \"PUT\",\n        headers: {\"Content-Type\": \"application/json\"},\n        body: JSON.stringify({role})\n      })\n      loadMembers()\n    }\n\n    async function removeMember(userID, name) {\n      if (!confirm(`Remove ${name} from this project?`)) return\n      if (await apiCall(`${projectBase()}/members/${userID}/`, {method: \"DELETE\"})) loadMembers()\n    }\n\n    async function createInviteLink() {\n      let role = document.getElementById(\"member-role\").value\n      if (role === \"owner\") role = \"maintainer\"\n      const invite = await apiCall(`${projectBase()}/invites/`, {\n        method: \"POST\",\n        headers: {\"Content-Type\": \"application/json\"},\n        body: JSON.stringify({role})\n      })\n      if (!invite) return\n      const el = document.getElementById(\"invite-link\")\n      el.textContent = `Single use ${invite.role} link, expires ${new Date(invite.expires_at).toLocaleString()}: ${invite.url}`\n      el.classList.remove(\"hidden\")\n      navigator.clipboard?.writeText(invite.url)\n    }\n\n    loadMembers()\n\n    async function loadTokens() {\n      const tokens = await fetch(\"/api/tokens/\").then(r => r.ok ? r.json() : [])\n      const list = document.getElementById(\"tokens-list\")\n      list.innerHTML = \"\"\n      for (const t of tokens) {\n        const row = document.createElement(\"div\")\n        row.className = \"flex items-center justify-between py-3\"\n        const info = document.createElement(\"div\")\n        const name = document.createElement(\"p\")\n        name.className = \"text-white\"\n        name.textContent = `${t.name} (${t.prefix}…)`\n        const meta = document.createElement(\"p\")\n        meta.className = \"text-neutral-500 text-xs\"\n        meta.textContent = [\n          t.access,\n          t.projects.length ? `${t.projects.length} project(s)` : \"all projects\",\n          t.expires_at ? `expires ${new Date(t.expires_at).toLocaleDateString()}` : \"never expires\",\n          t.last_used_at ? `last used ${new Date(t.last_used_at).toLocaleString()}` : \"never used\",\n        ].join(\" · \")\n        info.append(name, meta)\n        const revoke = document.createElement(\"button\")\n        revoke.className = \"text-red-400 text-xs\"\n        revoke.textContent = \"Revoke\"\n        revoke.onclick = () => revokeToken(t.id, t.name)\n        row.append(info, revoke)\n        list.appendChild(row)\n      }\n    }\n\n    async function createToken() {\n      const name = document.getElementById(\"token-name\").value.trim()\n      if (!name) return\n      const projects = [...document.querySelectorAll(\".token-project:checked\")].map(el => el.value)\n      const token = await apiCall(\"/api/tokens/\", {\n        method: \"POST\",\n        headers: {\"Content-Type\": \"application/json\"},\n        body: JSON.stringify({\n          name,\n          access: document.getElementById(\"token-access\").value,\n          projects,\n          expires_in_days: parseInt(document.getElementById(\"token-expiry\").value, 10) || 0\n        })\n      })\n      if (!token) return\n      const el = document.getElementById(\"token-created\")\n      el.textContent = `Copy this token now, it won't be shown again: ${token.token}`\n      el.classList.remove(\"hidden\")\n      document.getElementById(\"token-name\").value = \"\"\n      loadTokens()\n    }\n\n    async function revokeToken(id, name) {\n      if (!confirm(`Revoke token ${name}? Scripts using it will stop working.`)) return\n      if (await apiCall(`/api/tokens/${id}/`, {method: \"DELETE\"})) loadTokens()\n    }\n\n    loadTokens()\n\n    async function syncProject() {\n      await fetch(\"/api/projects/sync/\", {\n        method: \"POST\"\n      })\n      alert(\"Sync completed!\")\n      location.reload()\n    }\n  </script></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
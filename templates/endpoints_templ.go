@@ -8,7 +8,7 @@ package templates
 import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
-func EndpointsContent() templ.Component {
+func EndpointsContent(endpoints []Endpoint, functions []Function) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -29,7 +29,756 @@ func EndpointsContent() templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"w-full px-6 md:px-14 py-12 space-y-14\"><!-- HEADER --><div class=\"flex items-center justify-between\"><div class=\"flex items-center gap-4\"><a href=\"/dashboard/\" class=\"p-2 hover:bg-neutral-800 rounded-lg transition\"><svg xmlns=\"http://www.w3.org/2000/svg\" class=\"w-5 h-5 text-neutral-400\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M15 19l-7-7 7-7\"></path></svg></a><h1 class=\"text-3xl md:text-4xl font-semibold text-white tracking-tight\">Endpoints</h1></div><button onclick=\"openNewEndpointModal()\" class=\"bg-blue-500 hover:bg-blue-600 text-white font-semibold px-4 py-2 rounded-xl transition\">New Endpoint</button></div><p class=\"text-neutral-400 text-sm md:text-base -mt-6\">Create REST endpoints or map functions to routes.</p><!-- ENDPOINT LIST --><div id=\"endpoints-list\" class=\"space-y-5 mt-10\"><!-- ENDPOINT CARD --><div class=\"rounded-2xl border border-neutral-800 bg-[#0e0e0f] p-6 space-y-4\"><div class=\"flex justify-between items-center\"><div class=\"flex items-center gap-3\"><span class=\"px-3 py-1 rounded-lg bg-blue-600/20 text-blue-400 text-xs font-semibold\">GET</span><h3 class=\"text-white text-lg font-semibold\">/hello</h3></div><button onclick=\"toggleManage('endpoint-hello')\" class=\"text-neutral-400 hover:text-white transition text-sm\">Manage</button></div><!-- MANAGE COLLAPSIBLE --><div id=\"endpoint-hello\" class=\"hidden mt-4 space-y-8\"><!-- ENGINE SELECTION --><div><h4 class=\"text-white font-semibold mb-2\">Engine</h4><p class=\"text-neutral-500 text-sm mb-3\">Choose which edge engine drives this endpoint.</p><div class=\"grid grid-cols-2 md:grid-cols-4 gap-4\"><!-- Card --><button onclick=\"selectEngine('hello','liteginx')\" id=\"engine-liteginx-hello\" class=\"engine-card p-4 rounded-xl border border-neutral-700 hover:border-neutral-500 hover:bg-[#1a1a1b] transition\"><p class=\"text-white text-sm font-semibold\">Liteginx</p><p class=\"text-neutral-500 text-xs mt-1\">Small + fast</p></button> <button onclick=\"selectEngine('hello','nginx')\" id=\"engine-nginx-hello\" class=\"engine-card p-4 rounded-xl border border-neutral-700 hover:border-neutral-500 hover:bg-[#1a1a1b] transition\"><p class=\"text-white text-sm font-semibold\">Nginx</p><p class=\"text-neutral-500 text-xs mt-1\">Industry standard</p></button> <button onclick=\"selectEngine('hello','envoy')\" id=\"engine-envoy-hello\" class=\"engine-card p-4 rounded-xl border border-neutral-700 hover:border-neutral-500 hover:bg-[#1a1a1b] transition\"><p class=\"text-white text-sm font-semibold\">Envoy</p><p class=\"text-neutral-500 text-xs mt-1\">Modern proxy</p></button> <button onclick=\"selectEngine('hello','traefik')\" id=\"engine-traefik-hello\" class=\"engine-card p-4 rounded-xl border border-neutral-700 hover:border-neutral-500 hover:bg-[#1a1a1b] transition\"><p class=\"text-white text-sm font-semibold\">Traefik</p><p class=\"text-neutral-500 text-xs mt-1\">Dynamic routing</p></button></div></div><!-- RATE LIMITING --><div><h4 class=\"text-white font-semibold mb-2\">Rate Limiting</h4><p class=\"text-neutral-500 text-sm mb-3\">Protect the endpoint with simple throttling.</p><div class=\"flex items-center gap-3\"><input type=\"number\" min=\"1\" id=\"rl-hello\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-lg p-2 text-white w-32\" placeholder=\"req/min\"> <button class=\"text-white bg-blue-600 hover:bg-blue-700 px-3 py-1 rounded-lg text-sm\">Save</button></div></div><!-- AUTH / PUB CONTROLS --><div class=\"grid grid-cols-1 md:grid-cols-2 gap-6\"><div><h4 class=\"text-white font-semibold mb-2\">Authentication</h4><select id=\"auth-hello\" class=\"bg-[#0b0b0c] p-2 border border-neutral-700 rounded-xl text-white w-full\"><option>No Auth</option> <option>API Key</option> <option>JWT</option> <option>Session</option></select></div><div><h4 class=\"text-white font-semibold mb-2\">Pub/Sub</h4><select id=\"pub-hello\" class=\"bg-[#0b0b0c] p-2 border border-neutral-700 rounded-xl text-white w-full\"><option>Off</option> <option>Publish</option> <option>Subscribe</option> <option>Both</option></select></div></div></div></div></div><!-- NEW ENDPOINT MODAL --><div id=\"new-endpoint-modal\" class=\"hidden fixed inset-0 bg-black/50 backdrop-blur-sm z-50 flex items-center justify-center\"><div class=\"w-[90%] md:w-[600px] bg-[#0f0f10] border border-neutral-800 rounded-2xl p-6 space-y-4\"><h2 class=\"text-xl font-semibold text-white\">Create Endpoint</h2><label class=\"text-neutral-400 text-sm\">Method</label> <select id=\"new-method\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-xl text-white p-2 w-full\"><option>GET</option> <option>POST</option> <option>PUT</option> <option>DELETE</option></select> <label class=\"text-neutral-400 text-sm mt-3\">Path</label> <input id=\"new-path\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-xl text-white p-2 w-full\" placeholder=\"/example\"><div class=\"flex justify-end gap-3 mt-6\"><button onclick=\"closeNewEndpointModal()\" class=\"text-neutral-400 hover:text-neutral-200\">Cancel</button> <button class=\"bg-blue-600 hover:bg-blue-700 text-white px-4 py-2 rounded-xl\">Create</button></div></div></div><script>\n    function toggleManage(id) {\n      let el = document.getElementById(id);\n      if (el.classList.contains('hidden')) el.classList.remove('hidden');\n      else el.classList.add('hidden');\n    }\n\n    function openNewEndpointModal() {\n      document.getElementById(\"new-endpoint-modal\").classList.remove(\"hidden\");\n    }\n\n    function closeNewEndpointModal() {\n      document.getElementById(\"new-endpoint-modal\").classList.add(\"hidden\");\n    }\n\n    function selectEngine(endpoint, engine) {\n      // clear all\n      [\"liteginx\", \"nginx\", \"envoy\", \"traefik\"].forEach(e => {\n        let card = document.getElementById(`engine-${e}-${endpoint}`);\n        card.classList.remove(\"border-blue-500\", \"bg-blue-500/10\");\n      });\n      // highlight selected\n      let c = document.getElementById(`engine-${engine}-${endpoint}`);\n      c.classList.add(\"border-blue-500\", \"bg-blue-500/10\");\n    }\n  </script></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"w-full px-6 md:px-14 py-12 space-y-14\"><!-- HEADER --><div class=\"flex items-center justify-between\"><div class=\"flex items-center gap-4\"><a href=\"/dashboard/\" class=\"p-2 hover:bg-neutral-800 rounded-lg transition\"><svg xmlns=\"http://www.w3.org/2000/svg\" class=\"w-5 h-5 text-neutral-400\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M15 19l-7-7 7-7\"></path></svg></a><h1 class=\"text-3xl md:text-4xl font-semibold text-white tracking-tight\">Endpoints</h1></div><button onclick=\"openNewEndpointModal()\" class=\"bg-blue-500 hover:bg-blue-600 text-white font-semibold px-4 py-2 rounded-xl transition\">New Endpoint</button></div><p class=\"text-neutral-400 text-sm md:text-base -mt-6\">Create REST endpoints or map functions to routes.</p><!-- ENDPOINT LIST --><div id=\"endpoints-list\" class=\"space-y-5 mt-10\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if len(endpoints) == 0 {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<div class=\"w-full text-center py-20 text-neutral-500 text-lg\">Create a new endpoint to begin.</div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		for _, ep := range endpoints {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "<!-- ENDPOINT CARD --> <div class=\"rounded-2xl border border-neutral-800 bg-[#0e0e0f] p-6 space-y-4\"><div class=\"flex justify-between items-center\"><div class=\"flex items-center gap-3\"><span class=\"px-3 py-1 rounded-lg bg-blue-600/20 text-blue-400 text-xs font-semibold\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var2 string
+			templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(ep.Method)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 39, Col: 104}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "</span><h3 class=\"text-white text-lg font-semibold\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var3 string
+			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(ep.Name)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 40, Col: 61}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</h3><span class=\"text-neutral-500 text-sm\">→ ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var4 string
+			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(ep.FunctionName)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 41, Col: 67}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "</span></div><div class=\"flex items-center gap-3\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templ.RenderScriptItems(ctx, templ_7745c5c3_Buffer, templ.JSFuncCall("toggleManage", "endpoint-"+ep.ID))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "<button onclick=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var5 templ.ComponentScript = templ.JSFuncCall("toggleManage", "endpoint-"+ep.ID)
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ_7745c5c3_Var5.Call)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "\" class=\"text-neutral-400 hover:text-white transition text-sm\">Manage</button> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templ.RenderScriptItems(ctx, templ_7745c5c3_Buffer, templ.JSFuncCall("deleteEndpoint", ep.ID))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "<button onclick=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var6 templ.ComponentScript = templ.JSFuncCall("deleteEndpoint", ep.ID)
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ_7745c5c3_Var6.Call)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "\" class=\"text-red-400 hover:text-red-300 transition text-sm\">Delete</button></div></div><!-- MANAGE COLLAPSIBLE --><div id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var7 string
+			templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs("endpoint-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 53, Col: 34}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "\" class=\"hidden mt-4 space-y-8\"><!-- ENGINE SELECTION --><div><h4 class=\"text-white font-semibold mb-2\">Engine</h4><p class=\"text-neutral-500 text-sm mb-3\">Choose which edge engine drives this endpoint.</p><div class=\"grid grid-cols-2 md:grid-cols-4 gap-4\"><!-- Card -->")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templ.RenderScriptItems(ctx, templ_7745c5c3_Buffer, templ.JSFuncCall("selectEngine", ep.ID, "liteginx"))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "<button onclick=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var8 templ.ComponentScript = templ.JSFuncCall("selectEngine", ep.ID, "liteginx")
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ_7745c5c3_Var8.Call)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "\" id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var9 string
+			templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs("engine-liteginx-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 62, Col: 40}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "\" class=\"engine-card p-4 rounded-xl border border-neutral-700 hover:border-neutral-500 hover:bg-[#1a1a1b] transition\"><p class=\"text-white text-sm font-semibold\">Liteginx</p><p class=\"text-neutral-500 text-xs mt-1\">Small + fast</p></button> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templ.RenderScriptItems(ctx, templ_7745c5c3_Buffer, templ.JSFuncCall("selectEngine", ep.ID, "nginx"))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "<button onclick=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var10 templ.ComponentScript = templ.JSFuncCall("selectEngine", ep.ID, "nginx")
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ_7745c5c3_Var10.Call)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "\" id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var11 string
+			templ_7745c5c3_Var11, templ_7745c5c3_Err = templ.JoinStringErrs("engine-nginx-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 70, Col: 37}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var11))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "\" class=\"engine-card p-4 rounded-xl border border-neutral-700 hover:border-neutral-500 hover:bg-[#1a1a1b] transition\"><p class=\"text-white text-sm font-semibold\">Nginx</p><p class=\"text-neutral-500 text-xs mt-1\">Industry standard</p></button> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templ.RenderScriptItems(ctx, templ_7745c5c3_Buffer, templ.JSFuncCall("selectEngine", ep.ID, "envoy"))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "<button onclick=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var12 templ.ComponentScript = templ.JSFuncCall("selectEngine", ep.ID, "envoy")
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ_7745c5c3_Var12.Call)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "\" id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var13 string
+			templ_7745c5c3_Var13, templ_7745c5c3_Err = templ.JoinStringErrs("engine-envoy-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 78, Col: 37}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var13))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "\" class=\"engine-card p-4 rounded-xl border border-neutral-700 hover:border-neutral-500 hover:bg-[#1a1a1b] transition\"><p class=\"text-white text-sm font-semibold\">Envoy</p><p class=\"text-neutral-500 text-xs mt-1\">Modern proxy</p></button> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templ.RenderScriptItems(ctx, templ_7745c5c3_Buffer, templ.JSFuncCall("selectEngine", ep.ID, "traefik"))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "<button onclick=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var14 templ.ComponentScript = templ.JSFuncCall("selectEngine", ep.ID, "traefik")
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ_7745c5c3_Var14.Call)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, "\" id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var15 string
+			templ_7745c5c3_Var15, templ_7745c5c3_Err = templ.JoinStringErrs("engine-traefik-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 86, Col: 39}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var15))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 23, "\" class=\"engine-card p-4 rounded-xl border border-neutral-700 hover:border-neutral-500 hover:bg-[#1a1a1b] transition\"><p class=\"text-white text-sm font-semibold\">Traefik</p><p class=\"text-neutral-500 text-xs mt-1\">Dynamic routing</p></button></div></div><!-- RATE LIMITING --><div><h4 class=\"text-white font-semibold mb-2\">Rate Limiting</h4><p class=\"text-neutral-500 text-sm mb-3\">Protect the endpoint with simple throttling.</p><div class=\"flex flex-wrap items-center gap-3\"><input type=\"number\" min=\"0\" id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var16 string
+			templ_7745c5c3_Var16, templ_7745c5c3_Err = templ.JoinStringErrs("rl-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 102, Col: 27}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var16))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 24, "\" value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var17 string
+			templ_7745c5c3_Var17, templ_7745c5c3_Err = templ.JoinStringErrs(ep.RateLimit)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 103, Col: 29}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var17))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 25, "\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-lg p-2 text-white w-28\" placeholder=\"requests\"> <span class=\"text-neutral-500 text-sm\">per</span> <select id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var18 string
+			templ_7745c5c3_Var18, templ_7745c5c3_Err = templ.JoinStringErrs("rl-window-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 108, Col: 41}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var18))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 26, "\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-lg p-2 text-white\"><option value=\"1\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if ep.RateWindow == "1" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 27, " selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 28, ">second</option> <option value=\"60\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if ep.RateWindow == "60" || ep.RateWindow == "" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 29, " selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 30, ">minute</option> <option value=\"3600\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if ep.RateWindow == "3600" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 31, " selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 32, ">hour</option></select> <input type=\"number\" min=\"0\" id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var19 string
+			templ_7745c5c3_Var19, templ_7745c5c3_Err = templ.JoinStringErrs("rl-burst-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 116, Col: 33}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var19))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 33, "\" value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var20 string
+			templ_7745c5c3_Var20, templ_7745c5c3_Err = templ.JoinStringErrs(ep.RateBurst)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 117, Col: 29}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var20))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 34, "\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-lg p-2 text-white w-28\" placeholder=\"burst\"> <select id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var21 string
+			templ_7745c5c3_Var21, templ_7745c5c3_Err = templ.JoinStringErrs("rl-key-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 121, Col: 38}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var21))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 35, "\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-lg p-2 text-white\"><option value=\"ip\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if ep.RateKey == "ip" || ep.RateKey == "" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 36, " selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 37, ">per IP</option> <option value=\"api_key\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if ep.RateKey == "api_key" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 38, " selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 39, ">per API key</option> <option value=\"user\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if ep.RateKey == "user" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 40, " selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 41, ">per user</option></select> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templ.RenderScriptItems(ctx, templ_7745c5c3_Buffer, templ.JSFuncCall("saveRateLimit", ep.ID))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 42, "<button onclick=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var22 templ.ComponentScript = templ.JSFuncCall("saveRateLimit", ep.ID)
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ_7745c5c3_Var22.Call)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 43, "\" class=\"text-white bg-blue-600 hover:bg-blue-700 px-3 py-1 rounded-lg text-sm\">Save</button> <span id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var23 string
+			templ_7745c5c3_Var23, templ_7745c5c3_Err = templ.JoinStringErrs("rl-status-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 132, Col: 39}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var23))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 44, "\" class=\"text-neutral-500 text-xs\"></span></div><p class=\"text-neutral-600 text-xs mt-2\">Leave empty or 0 for no limit. Burst defaults to the limit.</p></div><!-- AUTH / PUB CONTROLS --><div class=\"grid grid-cols-1 md:grid-cols-2 gap-6\"><div><h4 class=\"text-white font-semibold mb-2\">Authentication</h4>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templ.RenderScriptItems(ctx, templ_7745c5c3_Buffer, templ.JSFuncCall("showAuthFields", ep.ID))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 45, "<select id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var24 string
+			templ_7745c5c3_Var24, templ_7745c5c3_Err = templ.JoinStringErrs("auth-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 141, Col: 29}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var24))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 46, "\" onchange=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var25 templ.ComponentScript = templ.JSFuncCall("showAuthFields", ep.ID)
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ_7745c5c3_Var25.Call)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 47, "\" class=\"bg-[#0b0b0c] p-2 border border-neutral-700 rounded-xl text-white w-full\"><option value=\"public\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if ep.Scope == "public" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 48, " selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 49, ">No Auth</option> <option value=\"api_key\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if ep.Scope == "api_key" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 50, " selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 51, ">API Key</option> <option value=\"jwt\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if ep.Scope == "jwt" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 52, " selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 53, ">JWT</option> <option value=\"authn\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if ep.Scope == "authn" {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 54, " selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 55, ">Session</option></select>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var26 = []any{"mt-3 space-y-2", templ.KV("hidden", ep.Scope != "api_key")}
+			templ_7745c5c3_Err = templ.RenderCSSItems(ctx, templ_7745c5c3_Buffer, templ_7745c5c3_Var26...)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 56, "<div id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var27 string
+			templ_7745c5c3_Var27, templ_7745c5c3_Err = templ.JoinStringErrs("auth-api_key-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 150, Col: 41}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var27))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 57, "\" class=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var28 string
+			templ_7745c5c3_Var28, templ_7745c5c3_Err = templ.JoinStringErrs(templ.CSSClasses(templ_7745c5c3_Var26).String())
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 1, Col: 0}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var28))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 58, "\"><input id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var29 string
+			templ_7745c5c3_Var29, templ_7745c5c3_Err = templ.JoinStringErrs("auth-key-scope-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 152, Col: 40}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var29))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 59, "\" value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var30 string
+			templ_7745c5c3_Var30, templ_7745c5c3_Err = templ.JoinStringErrs(ep.APIKeyScope)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 153, Col: 32}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var30))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 60, "\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-lg p-2 text-white w-full\" placeholder=\"required key scope, e.g. orders:read (optional)\"><p class=\"text-neutral-600 text-xs\">Callers send X-API-Key or Authorization: Bearer with a project key.</p></div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var31 = []any{"mt-3 space-y-2", templ.KV("hidden", ep.Scope != "jwt")}
+			templ_7745c5c3_Err = templ.RenderCSSItems(ctx, templ_7745c5c3_Buffer, templ_7745c5c3_Var31...)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 61, "<div id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var32 string
+			templ_7745c5c3_Var32, templ_7745c5c3_Err = templ.JoinStringErrs("auth-jwt-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 159, Col: 37}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var32))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 62, "\" class=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var33 string
+			templ_7745c5c3_Var33, templ_7745c5c3_Err = templ.JoinStringErrs(templ.CSSClasses(templ_7745c5c3_Var31).String())
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 1, Col: 0}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var33))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 63, "\"><input id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var34 string
+			templ_7745c5c3_Var34, templ_7745c5c3_Err = templ.JoinStringErrs("auth-jwt-secret-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 161, Col: 41}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var34))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 64, "\" type=\"password\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if ep.JWTHasSecret {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 65, " value=\"********\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 66, " class=\"bg-[#0b0b0c] border border-neutral-700 rounded-lg p-2 text-white w-full\" placeholder=\"HS256 secret (32+ characters)\"> <input id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var35 string
+			templ_7745c5c3_Var35, templ_7745c5c3_Err = templ.JoinStringErrs("auth-jwt-jwks-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 170, Col: 39}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var35))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 67, "\" value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var36 string
+			templ_7745c5c3_Var36, templ_7745c5c3_Err = templ.JoinStringErrs(ep.JWTJWKS)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 171, Col: 28}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var36))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 68, "\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-lg p-2 text-white w-full\" placeholder=\"or JWKS: https://… or a path in the repo\"><div class=\"grid grid-cols-2 gap-2\"><input id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var37 string
+			templ_7745c5c3_Var37, templ_7745c5c3_Err = templ.JoinStringErrs("auth-jwt-issuer-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 177, Col: 42}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var37))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 69, "\" value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var38 string
+			templ_7745c5c3_Var38, templ_7745c5c3_Err = templ.JoinStringErrs(ep.JWTIssuer)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 178, Col: 31}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var38))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 70, "\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-lg p-2 text-white\" placeholder=\"issuer (optional)\"> <input id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var39 string
+			templ_7745c5c3_Var39, templ_7745c5c3_Err = templ.JoinStringErrs("auth-jwt-audience-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 183, Col: 44}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var39))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 71, "\" value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var40 string
+			templ_7745c5c3_Var40, templ_7745c5c3_Err = templ.JoinStringErrs(ep.JWTAudience)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 184, Col: 33}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var40))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 72, "\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-lg p-2 text-white\" placeholder=\"audience (optional)\"></div></div><div class=\"flex items-center gap-3 mt-3\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templ.RenderScriptItems(ctx, templ_7745c5c3_Buffer, templ.JSFuncCall("saveAuth", ep.ID))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 73, "<button onclick=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var41 templ.ComponentScript = templ.JSFuncCall("saveAuth", ep.ID)
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ_7745c5c3_Var41.Call)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 74, "\" class=\"text-white bg-blue-600 hover:bg-blue-700 px-3 py-1 rounded-lg text-sm\">Save</button> <span id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var42 string
+			templ_7745c5c3_Var42, templ_7745c5c3_Err = templ.JoinStringErrs("auth-status-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 197, Col: 42}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var42))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 75, "\" class=\"text-neutral-500 text-xs\"></span></div></div><div><h4 class=\"text-white font-semibold mb-2\">Pub/Sub</h4><select id=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var43 string
+			templ_7745c5c3_Var43, templ_7745c5c3_Err = templ.JoinStringErrs("pub-" + ep.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 202, Col: 35}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var43))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 76, "\" class=\"bg-[#0b0b0c] p-2 border border-neutral-700 rounded-xl text-white w-full\"><option>Off</option> <option>Publish</option> <option>Subscribe</option> <option>Both</option></select></div></div></div></div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 77, "</div><!-- API KEYS --><div class=\"space-y-4\"><div><h2 class=\"text-xl font-semibold text-white\">API Keys</h2><p class=\"text-neutral-500 text-sm\">Project keys for endpoints using API Key auth. A key is shown once, when it's created.</p></div><div class=\"flex flex-wrap items-center gap-3\"><input id=\"new-key-name\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-lg p-2 text-white\" placeholder=\"name\"> <input id=\"new-key-scopes\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-lg p-2 text-white w-64\" placeholder=\"scopes, comma separated\"> <input id=\"new-key-expiry\" type=\"number\" min=\"0\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-lg p-2 text-white w-32\" placeholder=\"expires (days)\"> <button onclick=\"createAPIKey()\" class=\"text-white bg-blue-600 hover:bg-blue-700 px-3 py-2 rounded-lg text-sm\">Create Key</button></div><div id=\"new-key-result\" class=\"hidden rounded-xl border border-green-800 bg-green-900/20 p-4 text-sm text-green-300 break-all\"></div><p id=\"api-keys-error\" class=\"hidden text-red-400 text-sm\"></p><div id=\"api-keys-list\" class=\"space-y-2\"></div></div><!-- NEW ENDPOINT MODAL --><div id=\"new-endpoint-modal\" class=\"hidden fixed inset-0 bg-black/50 backdrop-blur-sm z-50 flex items-center justify-center\"><div class=\"w-[90%] md:w-[600px] bg-[#0f0f10] border border-neutral-800 rounded-2xl p-6 space-y-4\"><h2 class=\"text-xl font-semibold text-white\">Create Endpoint</h2><label class=\"text-neutral-400 text-sm\">Method</label> <select id=\"new-method\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-xl text-white p-2 w-full\"><option>GET</option> <option>POST</option> <option>PUT</option> <option>DELETE</option></select> <label class=\"text-neutral-400 text-sm mt-3\">Path</label> <input id=\"new-path\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-xl text-white p-2 w-full\" placeholder=\"/example\"> <label class=\"text-neutral-400 text-sm mt-3\">Function</label> <select id=\"new-function\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-xl text-white p-2 w-full\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		for _, fn := range functions {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 78, "<option value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var44 string
+			templ_7745c5c3_Var44, templ_7745c5c3_Err = templ.JoinStringErrs(fn.ID)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 253, Col: 27}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var44))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 79, "\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var45 string
+			templ_7745c5c3_Var45, templ_7745c5c3_Err = templ.JoinStringErrs(fn.Name)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 253, Col: 39}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var45))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 80, " (")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var46 string
+			templ_7745c5c3_Var46, templ_7745c5c3_Err = templ.JoinStringErrs(fn.Language)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/endpoints.templ`, Line: 253, Col: 56}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var46))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 81, ")</option>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 82, "</select> <label class=\"text-neutral-400 text-sm mt-3\">Authentication</label> <select id=\"new-scope\" class=\"bg-[#0b0b0c] border border-neutral-700 rounded-xl text-white p-2 w-full\"><option value=\"public\">No Auth</option> <option value=\"api_key\">API Key</option> <option value=\"authn\">Session</option></select><p class=\"text-neutral-600 text-xs\">JWT is configured from the endpoint's Manage panel.</p><p id=\"new-endpoint-error\" class=\"hidden text-red-400 text-sm\"></p><div class=\"flex justify-end gap-3 mt-6\"><button onclick=\"closeNewEndpointModal()\" class=\"text-neutral-400 hover:text-neutral-200\">Cancel</button> <button onclick=\"createEndpoint()\" class=\"bg-blue-600 hover:bg-blue-700 text-white px-4 py-2 rounded-xl\">Create</button></div></div></div><script>\n    function toggleManage(id) {\n      let el = document.getElementById(id);\n      if (el.classList.contains('hidden')) el.classList.remove('hidden');\n      else el.classList.add('hidden');\n    }\n\n    function openNewEndpointModal() {\n      document.getElementById(\"new-endpoint-modal\").classList.remove(\"hidden\");\n    }\n\n    function closeNewEndpointModal() {\n      document.getElementById(\"new-endpoint-modal\").classList.add(\"hidden\");\n    }\n\n    async function createEndpoint() {\n      const errEl = document.getElementById(\"new-endpoint-error\");\n      const payload = {\n        method: document.getElementById(\"new-method\").value,\n        name: document.getElementById(\"new-path\").value.trim(),\n        function_id: document.getElementById(\"new-function\").value,\n        scope: document.getElementById(\"new-scope\").value\n      };\n      const res = await fetch(\"/api/endpoints/\", {\n        method: \"POST\",\n        headers: {\"Content-Type\": \"application/json\"},\n        body: JSON.stringify(payload)\n      });\n      if (!res.ok) {\n        const body = await res.json().catch(() => ({}));\n        errEl.textContent = body.error || \"failed to create endpoint\";\n        errEl.classList.remove(\"hidden\");\n        return;\n      }\n      closeNewEndpointModal();\n      location.reload();\n    }\n\n    function showAuthFields(id) {\n      const scope = document.getElementById(`auth-${id}`).value;\n      [\"api_key\", \"jwt\"].forEach(mode => {\n        document.getElementById(`auth-${mode}-${id}`).classList.toggle(\"hidden\", scope !== mode);\n      });\n    }\n\n    async function saveAuth(id) {\n      const status = document.getElementById(`auth-status-${id}`);\n      const scope = document.getElementById(`auth-${id}`).value;\n      const payload = {scope};\n      if (scope === \"api_key\") {\n        payload.api_key_scope = document.getElementById(`auth-key-scope-${id}`).value.trim();\n      }\n      if (scope === \"jwt\") {\n        payload.jwt = {\n          secret: document.getElementById(`auth-jwt-secret-${id}`).value,\n          jwks: document.getElementById(`auth-jwt-jwks-${id}`).value.trim(),\n          issuer: document.getElementById(`auth-jwt-issuer-${id}`).value.trim(),\n          audience: document.getElementById(`auth-jwt-audience-${id}`).value.trim()\n        };\n      }\n      const res = await fetch(`/api/endpoints/${id}/`, {\n        method: \"PUT\",\n        headers: {\"Content-Type\": \"application/json\"},\n        body: JSON.stringify(payload)\n      });\n      if (!res.ok) {\n        const body = await res.json().catch(() => ({}));\n        status.textContent = body.error || \"failed to save\";\n        status.className = \"text-red-400 text-xs\";\n        return;\n      }\n      status.textContent = \"saved\";\n      status.className = \"text-neutral-500 text-xs\";\n    }\n\n    function escapeHTML(s) {\n      const d = document.createElement(\"div\");\n      d.textContent = s;\n      return d.innerHTML;\n    }\n\n    async function loadAPIKeys() {\n      const list = document.getElementById(\"api-keys-list\");\n      const res = await fetch(\"/api/apikeys/\");\n      if (!res.ok) return;\n      const keys = await res.json();\n      if (keys.length === 0) {\n        list.innerHTML = `<p class=\"text-neutral-600 text-sm\">No keys yet.</p>`;\n        return;\n      }\n      list.innerHTML = keys.map(k => `\n        <div class=\"flex items-center justify-between rounded-xl border border-neutral-800 bg-[#0e0e0f] px-4 py-3\">\n          <div class=\"space-y-1\">\n            <p class=\"text-white text-sm font-semibold\">${escapeHTML(k.name)} <span class=\"text-neutral-500 font-mono text-xs\">${escapeHTML(k.prefix)}…</span></p>\n            <p class=\"text-neutral-500 text-xs\">\n              ${k.scopes.length ? k.scopes.map(escapeHTML).join(\", \") : \"no scopes\"}\n              · ${k.expires_at ? \"expires \" + new Date(k.expires_at).toLocaleDateString() : \"never expires\"}\n              · ${k.last_used_at ? \"last used \" + new Date(k.last_used_at).toLocaleString() : \"never used\"}\n            </p>\n          </div>\n          <button onclick=\"deleteAPIKey('${k.id}')\" class=\"text-red-400 hover:text-red-300 text-sm\">Revoke</button>\n        </div>`).join(\"\");\n    }\n\n    async function createAPIKey() {\n      const errEl = document.getElementById(\"api-keys-error\");\n      const result = document.getElementById(\"new-key-result\");\n      errEl.classList.add(\"hidden\");\n      const payload = {\n        name: document.getElementById(\"new-key-name\").value.trim(),\n        scopes: document.getElementById(\"new-key-scopes\").value.split(\",\").map(s => s.trim()).filter(Boolean),\n        expires_in_days: parseInt(document.getElementById(\"new-key-expiry\").value, 10) || 0\n      };\n      const res = await fetch(\"/api/apikeys/\", {\n        method: \"POST\",\n        headers: {\"Content-Type\": \"application/json\"},\n        body: JSON.stringify(payload)\n      });\n      const body = await res.json().catch(() => ({}));\n      if (!res.ok) {\n        errEl.textContent = body.error || \"failed to create key\";\n        errEl.classList.remove(\"hidden\");\n        return;\n      }\n      result.innerHTML = `Copy this key now, it won't be shown again:<br/><code class=\"font-mono text-white\">${escapeHTML(body.key)}</code>`;\n      result.classList.remove(\"hidden\");\n      loadAPIKeys();\n    }\n\n    async function deleteAPIKey(id) {\n      if (!confirm(\"Revoke this key? Callers using it will get 401s.\")) return;\n      await fetch(`/api/apikeys/${id}/`, {method: \"DELETE\"});\n      loadAPIKeys();\n    }\n\n    loadAPIKeys();\n\n    async function saveRateLimit(id) {\n      const status = document.getElementById(`rl-status-${id}`);\n      const rate_limit = {\n        limit: parseInt(document.getElementById(`rl-${id}`).value, 10) || 0,\n        window_seconds: parseInt(document.getElementById(`rl-window-${id}`).value, 10),\n        burst: parseInt(document.getElementById(`rl-burst-${id}`).value, 10) || 0,\n        key: document.getElementById(`rl-key-${id}`).value\n      };\n      const res = await fetch(`/api/endpoints/${id}/`, {\n        method: \"PUT\",\n        headers: {\"Content-Type\": \"application/json\"},\n        body: JSON.stringify({rate_limit})\n      });\n      if (!res.ok) {\n        const body = await res.json().catch(() => ({}));\n        status.textContent = body.error || \"failed to save\";\n        status.className = \"text-red-400 text-xs\";\n        return;\n      }\n      status.textContent = rate_limit.limit ? \"saved\" : \"limit removed\";\n      status.className = \"text-neutral-500 text-xs\";\n    }\n\n    async function deleteEndpoint(id) {\n      if (!confirm(\"Delete this endpoint?\")) return;\n      await fetch(`/api/endpoints/${id}/`, {method: \"DELETE\"});\n      location.reload();\n    }\n\n    function selectEngine(endpoint, engine) {\n      // clear all\n      [\"liteginx\", \"nginx\", \"envoy\", \"traefik\"].forEach(e => {\n        let card = document.getElementById(`engine-${e}-${endpoint}`);\n        card.classList.remove(\"border-blue-500\", \"bg-blue-500/10\");\n      });\n      // highlight selected\n      let c = document.getElementById(`engine-${engine}-${endpoint}`);\n      c.classList.add(\"border-blue-500\", \"bg-blue-500/10\");\n    }\n  </script></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -355,7 +355,7 @@ func FunctionContent(functions []Function, langs []Lang, activeProjectID string,
 				return templ_7745c5c3_Err
 			}
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 27, "</div><!-- META --><div class=\"mt-4 flex flex-col gap-3\"><input id=\"fn-name-input\" class=\"w-full px-3 py-2 rounded-xl bg-[#0b0b0c] border border-neutral-800 text-white\" placeholder=\"Function name\"></div><!-- EDITOR --><div id=\"create-ace\" class=\"flex-1 w-full rounded-xl border border-neutral-800 mt-4\"></div><div class=\"flex justify-end gap-3 pt-3\"><button onclick=\"closeCreate()\" class=\"p-2 rounded-lg border border-neutral-700 text-neutral-300 hover:bg-neutral-800\"><img src=\"/static/imgs/close-circle-svgrepo-com.svg\" class=\"w-5 h-5\"></button> <button onclick=\"saveCreate(true)\" class=\"p-2 rounded-lg bg-blue-500 hover:bg-blue-600 text-white\"><img src=\"/static/imgs/save-floppy-svgrepo-com.svg\" class=\"w-5 h-5\"></button></div></div></div><!-- EDIT --><div id=\"edit-modal\" class=\"hidden fixed inset-0 bg-black/70 backdrop-blur-md z-50 flex items-center justify-center\"><div class=\"w-[98vw] h-[96vh] bg-[#0f0f10] border border-neutral-800 rounded-2xl p-6 flex flex-col\"><div class=\"flex items-center justify-between mb-4\"><h2 class=\"text-xl font-semibold text-white\">Edit Function</h2><button onclick=\"closeEdit()\" class=\"p-2 text-neutral-300 hover:text-white\"><img src=\"/static/imgs/x.svg\" class=\"w-5 h-5\"></button></div><div id=\"edit-ace\" class=\"flex-1 w-full rounded-xl border border-neutral-800\"></div><div class=\"flex justify-end gap-3 pt-3\"><button onclick=\"closeEdit()\" class=\"p-2 rounded-lg border border-neutral-700 text-neutral-300 hover:bg-neutral-800\"><img src=\"/static/imgs/cancel.svg\" class=\"w-5 h-5\"></button> <button onclick=\"saveEdit(true)\" class=\"p-2 rounded-lg bg-blue-500 hover:bg-blue-600 text-white\"><img src=\"/static/imgs/save-floppy-svgrepo-com.svg\" class=\"w-5 h-5\"></button></div></div></div><!-- DELETE CONFIRM MODAL --><div id=\"delete-modal\" class=\"hidden fixed inset-0 bg-black/70 backdrop-blur-md z-50 flex items-center justify-center\"><div class=\"bg-[#0f0f10] border border-neutral-800 rounded-2xl p-8 w-[420px]\"><h2 class=\"text-xl font-semibold text-white mb-4\">Delete Function?</h2><p class=\"text-neutral-400 mb-6\">This action cannot be undone.</p><div class=\"flex justify-end gap-3\"><button onclick=\"closeDelete()\" class=\"px-4 py-2 border border-neutral-700 text-neutral-300 rounded-lg hover:bg-neutral-800\">Cancel</button> <button onclick=\"confirmDelete()\" class=\"px-4 py-2 bg-red-600 hover:bg-red-700 text-white rounded-lg\">Delete</button></div></div></div><!-- ACE from CDN (fallback to local if needed) --><script>\n\t(function(){\n\t\t// try to load ACE from CDN, but allow local base if preferred by the app\n\t\tconst cdn = \"https://cdnjs.cloudflare.com/ajax/libs/ace/1.32.3/\";\n\t\tconst s1 = document.createElement('script');\n\t\ts1.src = cdn + 'ace.js';\n\t\ts1.onload = () => {\n\t\t\t// load optional ext and keybinding after ace\n\t\t\tconst s2 = document.createElement('script');\n\t\t\ts2.src = cdn + 'ext-language_tools.js';\n\t\t\tdocument.head.appendChild(s2);\n\t\t\tconst s3 = document.createElement('script');\n\t\t\ts3.src = cdn + 'keybinding-vim.js';\n\t\t\tdocument.head.appendChild(s3);\n\t\t};\n\t\tdocument.head.appendChild(s1);\n\t})();\n\t</script><script>\nwindow.ACE_MODES = {\n    python: \"python\",\n    javascript: \"javascript\",\n    go: \"golang\",\n    rust: \"rust\",\n    lua: \"lua\"\n};\n\nwindow.__activeProjectID = \"{ activeProjectID }\";\n\nlet createEditor = null;\nlet editEditor = null;\nlet selectedLang = \"python\";\n\nconst codeTemplates = {\n  python: `from fastapi import Request\nfrom pydantic import BaseModel\n\nclass Input(BaseModel):\n    name: str | None = None\n\nclass Output(BaseModel):\n    message: str\n\nasync def handle(request: Request) -> Output:\n    data = await request.json()\n    input = Input(**data)\n    name = input.name or \"stranger\"\n    return Output(message=f\"Hello {name} from Python!\")\n`,\n\n  go: `package main\n\nimport (\n    \"net/http\"\n\n    \"github.com/gin-gonic/gin\"\n)\n\ntype Input struct {\n    Name *string \\`json:\"name\"\\`\n}\n\ntype Output struct {\n    Message string \\`json:\"message\"\\`\n}\n\nfunc Handle(c *gin.Context) {\n    var input Input\n    if err := c.ShouldBindJSON(&input); err != nil {\n        c.JSON(http.StatusBadRequest, gin.H{\"error\": \"invalid request\"})\n        return\n    }\n\n    name := \"stranger\"\n    if input.Name != nil && *input.Name != \"\" {\n        name = *input.Name\n    }\n\n    c.JSON(http.StatusOK, Output{\n        Message: \"Hello \" + name + \" from Go!\",\n    })\n}\n`,\n\n  rust: `use axum::{Json};\nuse serde::{Deserialize, Serialize};\n\n#[derive(Deserialize)]\npub struct Input {\n    pub name: Option<String>,\n}\n\n#[derive(Serialize)]\npub struct Output {\n    pub message: String,\n}\n\npub async fn handle(Json(input): Json<Input>) -> Json<Output> {\n    let name = input.name.unwrap_or(\"stranger\".into());\n    Json(Output { \n        message: format!(\"Hello {} from Rust!\", name) \n    })\n}\n`,\n\n  javascript: `export async function handle(req) {\n  class Input {\n    constructor(obj = {}) {\n      this.name = obj.name ?? null;\n    }\n  }\n\n  class Output {\n    constructor(message) {\n      this.message = message;\n    }\n  }\n\n  const body = await req.json();\n  const input = new Input(body);\n  const name = input.name || \"stranger\";\n\n  return Response.json(\n    new Output(\\`Hello \\${name} from JavaScript!\\`)\n  );\n}\n`,\n\n  lua: `-- Input serializer\nInput = {}\nInput.__index = Input\n\nfunction Input:new(o)\n  o = o or {}\n  setmetatable(o, self)\n  o.name = o.name or nil\n  return o\nend\n\n-- Output serializer\nOutput = {}\nOutput.__index = Output\n\nfunction Output:new(message)\n  return setmetatable({ message = message }, self)\nend\n\nfunction handle(req)\n  local input = Input:new(req or {})\n  local name = input.name or \"stranger\"\n  return Output:new(string.format(\"Hello %s from Lua!\", name))\nend\n`\n};\n\n\nconst modeMap = window.ACE_MODES;\n\n/* --- Helpers for project id resolution (use cookie fallback) --- */\nfunction getCookie(name) {\n  const v = document.cookie.match('(^|;)\\\\s*' + name + '\\\\s*=\\\\s*([^;]+)');\n  return v ? decodeURIComponent(v.pop()) : '';\n}\n\nfunction getActiveProjectID() {\n  const raw = (window.__activeProjectID || '').trim();\n  // treat templ placeholder or empty as \"not provided\"\n  if (raw && raw !== '{ activeProjectID }' && raw !== '') return raw;\n  // fallback to cookie\n  return getCookie('lws_project') || '';\n}\n\nfunction projectUrl(pathSuffix) {\n  const pid = getActiveProjectID();\n  if (!pid) {\n    console.warn('no active project id set (lws_project cookie missing and server didn\\'t provide one)');\n    return pathSuffix || '';\n  }\n  if (pathSuffix && pathSuffix[0] !== '/') pathSuffix = '/' + pathSuffix;\n  // NOTE: prepend /api here so we call server routes under /api\n  return `/api/projects/${encodeURIComponent(pid)}${pathSuffix || ''}`;\n}\n\n/* --- Ace + Vim ex helpers --- */\nwindow.__isCreateEditor = false;\nwindow.__isEditEditor = false;\n\nfunction defineVimEx(){\n  try {\n    const vimMod = ace.require && ace.require(\"ace/keyboard/vim\");\n    if (!vimMod || !vimMod.CodeMirror) return;\n    const Vim = vimMod.CodeMirror.Vim;\n    if (!Vim) return;\n    if (Vim.__lws_ex_defined) return;\n    Vim.defineEx(\"w\", \"w\", function(cm, input){\n      if (window.__isCreateEditor) saveCreate(true);\n      else if (window.__isEditEditor) saveEdit(true);\n    });\n    Vim.defineEx(\"wq\", \"wq\", function(cm, input){\n      if (window.__isCreateEditor) saveCreate(true);\n      if (window.__isEditEditor) saveEdit(true);\n      if (window.__isCreateEditor) closeCreate();\n      if (window.__isEditEditor) closeEdit();\n    });\n    Vim.defineEx(\"q\", \"q\", function(cm, input){\n      if (window.__isCreateEditor) closeCreate();\n      else if (window.__isEditEditor) closeEdit();\n    });\n    Vim.__lws_ex_defined = true;\n  } catch (e) {\n    // ignore if vim keybinding not present yet\n  }\n}\n\n/* --- UI functions --- */\nfunction copyFn(id){\n  const curl = `curl -X POST ${projectUrl(`/api/functions/`)}${id ? id : ''}`;\n  navigator.clipboard.writeText(curl);\n}\n\nfunction openCreate(){\n  window.__isCreateEditor = true;\n  window.__isEditEditor = false;\n\n  document.getElementById('create-modal').classList.remove('hidden');\n\n  if(!createEditor && window.ace){\n    createEditor = ace.edit('create-ace');\n    createEditor.setTheme('ace/theme/dracula');\n    try{ createEditor.setKeyboardHandler('ace/keyboard/vim'); }catch(e){}\n    defineVimEx();\n  }\n\n  if(createEditor){\n    createEditor.session.setMode('ace/mode/' + modeMap[selectedLang]);\n    createEditor.setValue(codeTemplates[selectedLang] || '', -1);\n    setTimeout(()=>createEditor.focus(),120);\n  }\n}\n\nfunction selectLang(lang){\n  selectedLang = lang;\n  document.querySelectorAll('.lang-btn').forEach(b=>b.classList.remove('selected'));\n  const el = document.getElementById('lang-' + lang);\n  if(el) el.classList.add('selected');\n  if(createEditor){\n    createEditor.session.setMode('ace/mode/' + modeMap[lang]);\n    createEditor.setValue(codeTemplates[lang] || '', -1);\n    setTimeout(()=>createEditor.focus(),120);\n  }\n}\n\nfunction closeCreate(){\n  window.__isCreateEditor = false;\n  document.getElementById('create-modal').classList.add('hidden');\n}\n\nfunction saveCreate(exit){\n  const name = document.getElementById('fn-name-input')?.value?.trim();\n  if(!name){\n    const el = document.getElementById('fn-name-input');\n    el.classList.add('shake');\n    setTimeout(()=>el.classList.remove('shake'),400);\n    el.focus();\n    return;\n  }\n  const description = document.getElementById('fn-desc-input')?.value?.trim();\n  const payload = {\n    name: name,\n    language: selectedLang,\n    description: description,\n    path: createEditor ? createEditor.getValue() : ''\n  };\n  fetch(`/api/functions/`,{\n    method:'POST',\n    headers:{'Content-Type':'application/json'},\n    body:JSON.stringify(payload)\n  }).then(()=>{ \n    if(exit) closeCreate();\n    refreshList()\n  });\n}\n\nfunction openEdit(id, lang){\n  window.__isCreateEditor = false;\n  window.__isEditEditor = true;\n  window.__editFnID = id;\n  console.log(\"opening edit modal\")\n  document.getElementById('edit-modal').classList.remove('hidden');\n  if(!editEditor && window.ace){\n    editEditor = ace.edit('edit-ace');\n    editEditor.setTheme('ace/theme/dracula');\n    try{ editEditor.setKeyboardHandler('ace/keyboard/vim'); }catch(e){}\n    defineVimEx();\n  }\n\n  if(editEditor){\n    editEditor.session.setMode('ace/mode/' + modeMap[lang]);\n    fetch(`/api/functions/${id}/`).then(r=>r.json()).then(data=>{\n      editEditor.setValue(data.content || codeTemplates[lang] || '', -1);\n      setTimeout(()=>editEditor.focus(),120);\n    }).catch(()=>{\n      editEditor.setValue(codeTemplates[lang] || '', -1);\n    });\n  }\n}\n\nfunction closeEdit(){\n  window.__isEditEditor = false;\n  document.getElementById('edit-modal').classList.add('hidden');\n}\n\nfunction saveEdit(exit){\n  if(!window.__editFnID) return;\n  const body = editEditor ? editEditor.getValue() : '';\n  fetch(`/api/functions/${window.__editFnID}/`,{\n    method:'PUT',\n    headers:{'Content-Type':'text/plain'},\n    body: body\n  }).then(()=>{ \n    if(exit) closeEdit(); \n    refreshList()\n  });\n}\n\n/* --- bind language tiles and other DOM wiring after load --- */\ndocument.addEventListener('DOMContentLoaded', () => {\n  // wire language tiles\n  document.querySelectorAll('.lang-btn[data-lang]').forEach(btn=>{\n    btn.addEventListener('click', ()=> {\n      const lang = btn.getAttribute('data-lang');\n      selectLang(lang);\n    });\n  });\n\n  // if server didn't provide activeProjectID, try cookie\n  window.__activeProjectID = getActiveProjectID();\n});\n\nwindow.__deleteFnID = null;\n\nfunction deleteFn(id) {\n    window.__deleteFnID = id;\n    document.getElementById(\"delete-modal\").classList.remove(\"hidden\");\n}\n\nfunction closeDelete() {\n    window.__deleteFnID = null;\n    document.getElementById(\"delete-modal\").classList.add(\"hidden\");\n}\n\nfunction confirmDelete() {\n    if (!window.__deleteFnID) return;\n\n    fetch(`/api/functions/${window.__deleteFnID}/`, {\n        method: \"DELETE\"\n    })\n    .then(() => {\n        closeDelete();\n        refreshList(); // refresh UI\n    });\n}\n\n\nfunction refreshList() {\n    fetch(`/api/functions/`)\n        .then(r => r.json())\n        .then(obj => {\n\n            const list = Object.values(obj);\n\n            const container = document.querySelector(\"#fn-list-container\");\n            if (!container) return;\n\n            container.innerHTML = \"\";\n\n            if (list.length === 0) {\n                container.innerHTML = `\n                    <div class=\"w-full text-center py-20 text-neutral-500 text-lg\">\n                        Create a new function to begin.\n                    </div>\n                `;\n                return;\n            }\n\n            list.forEach(fn => {\n                const id = fn.id;\n                const name = fn.name;\n                const lang = fn.language;\n\n                const icon = `/static/imgs/${lang}-svgrepo-com.svg`;\n\n                const mobileCard = document.createElement(\"div\");\n                mobileCard.className = \"sm:hidden w-full rounded-xl border border-neutral-800 bg-[#0e0e0f] px-4 py-4\";\n                mobileCard.innerHTML = `\n                    <!-- TOP: Icon + Name -->\n                    <div class=\"flex items-center gap-3 mb-3\">\n                        <img src=\"${icon}\" class=\"w-5 h-5 opacity-80\"/>\n                        <h2 class=\"text-white font-medium text-base\">${name}</h2>\n                    </div>\n                \n                    <!-- BOTTOM: Actions -->\n                    <div class=\"flex items-center gap-3\">\n                \n                        <!-- Copy -->\n                        <button onclick=\"copyFn('${id}')\"\n                            class=\"p-2 rounded-lg hover:bg-neutral-800 transition text-neutral-400 hover:text-white\">\n                            <img src=\"/static/imgs/copy-svgrepo-com.svg\" class=\"w-4 h-4\"/>\n                        </button>\n                \n                        <!-- Edit -->\n                        <button onclick=\"openEdit('${id}', '${lang}')\"\n                            class=\"px-4 py-2 text-sm rounded-lg border border-neutral-700 text-neutral-300 hover:bg-neutral-800\">\n                            Edit\n                        </button>\n                \n                        <!-- Delete -->\n                        <button onclick=\"deleteFn('${id}')\"\n                            class=\"px-4 py-2 text-sm rounded-lg border border-red-700 text-red-400 hover:bg-red-900/40\">\n                            Delete\n                        </button>\n                \n                    </div>\n                `;\n\n                const desktopRow = document.createElement(\"div\");\n                desktopRow.className = \"hidden sm:flex items-center justify-between px-2 py-3 border-b border-neutral-800 hover:bg-neutral-900/30 transition\";\n                desktopRow.innerHTML = `\n                    <!-- LEFT -->\n                    <div class=\"flex items-center gap-3\">\n                        <img src=\"${icon}\" class=\"w-5 h-5 opacity-80\"/>\n                        <span class=\"text-white font-medium\">${name}</span>\n                    </div>\n\n                    <!-- RIGHT -->\n                    <div class=\"flex items-center gap-2 opacity-60 hover:opacity-100 transition\">\n\n                        <button onclick=\"copyFn('${id}')\"\n                            class=\"p-1 rounded-lg hover:bg-neutral-800 transition\">\n                            <img src=\"/static/imgs/copy-svgrepo-com.svg\" class=\"w-4 h-4\"/>\n                        </button>\n\n                        <button onclick=\"openEdit('${id}', '${lang}')\"\n                            class=\"px-3 py-1 text-sm rounded-lg border border-neutral-700 text-neutral-300 hover:bg-neutral-800\">\n                            Edit\n                        </button>\n\n                        <button onclick=\"deleteFn('${id}')\"\n                            class=\"px-3 py-1 text-sm rounded-lg border border-red-700 text-red-400 hover:bg-red-900/40\">\n                            Delete\n                        </button>\n\n                    </div>\n                `;\n\n                container.appendChild(mobileCard);\n                container.appendChild(desktopRow);\n            });\n        });\n}\n\n\n\n\t</script><style>\nhtml,body{background:#0f0f10!important;}\n.ace_editor,.ace_scroller,.ace_content{background:#0b0b0c!important;color:#eee!important;}\n.shake{animation:shake .3s linear;}\n@keyframes shake{0%{transform:translateX(0)}25%{transform:translateX(-6px)}50%{transform:translateX(6px)}75%{transform:translateX(-6px)}100%{transform:translateX(0)}}\n\n.lang-btn{padding:10px 8px;border-radius:12px;background:#0e0e0f;border:1px solid #282828;color:white;font-size:0.85rem;transition:0.15s}\n.lang-btn:hover{background:#1c1c1c;border-color:#666}\n.lang-btn.selected{background:#1f1f20;border-color:#888}\n\t</style></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 27, "</div><!-- META --><div class=\"mt-4 flex flex-col gap-3\"><input id=\"fn-name-input\" class=\"w-full px-3 py-2 rounded-xl bg-[#0b0b0c] border border-neutral-800 text-white\" placeholder=\"Function name\"></div><!-- EDITOR --><div id=\"create-ace\" class=\"flex-1 w-full rounded-xl border border-neutral-800 mt-4\"></div><div class=\"flex justify-end gap-3 pt-3\"><button onclick=\"closeCreate()\" class=\"p-2 rounded-lg border border-neutral-700 text-neutral-300 hover:bg-neutral-800\"><img src=\"/static/imgs/close-circle-svgrepo-com.svg\" class=\"w-5 h-5\"></button> <button onclick=\"saveCreate(true)\" class=\"p-2 rounded-lg bg-blue-500 hover:bg-blue-600 text-white\"><img src=\"/static/imgs/save-floppy-svgrepo-com.svg\" class=\"w-5 h-5\"></button></div></div></div><!-- EDIT --><div id=\"edit-modal\" class=\"hidden fixed inset-0 bg-black/70 backdrop-blur-md z-50 flex items-center justify-center\"><div class=\"w-[98vw] h-[96vh] bg-[#0f0f10] border border-neutral-800 rounded-2xl p-6 flex flex-col\"><div class=\"flex items-center justify-between mb-4\"><h2 class=\"text-xl font-semibold text-white\">Edit Function</h2><button onclick=\"closeEdit()\" class=\"p-2 text-neutral-300 hover:text-white\"><img src=\"/static/imgs/x.svg\" class=\"w-5 h-5\"></button></div><div id=\"edit-ace\" class=\"flex-1 w-full rounded-xl border border-neutral-800\"></div><div id=\"conflict-panel\" class=\"hidden mt-3 p-3 border border-amber-700 rounded-xl bg-[#0b0b0c]\"><div class=\"flex items-center justify-between gap-3 mb-2\"><p id=\"conflict-msg\" class=\"text-amber-400 text-sm\"></p><div class=\"flex gap-2\"><button onclick=\"loadMerged()\" class=\"px-3 py-1 rounded-lg bg-amber-600 hover:bg-amber-700 text-white text-xs font-semibold\">Load merged</button> <button onclick=\"overwriteConflict()\" class=\"px-3 py-1 rounded-lg border border-neutral-700 text-neutral-300 hover:bg-neutral-800 text-xs\">Keep mine</button> <button onclick=\"discardMine()\" class=\"px-3 py-1 rounded-lg border border-neutral-700 text-neutral-300 hover:bg-neutral-800 text-xs\">Take theirs</button></div></div><pre id=\"conflict-diff\" class=\"max-h-40 overflow-auto text-neutral-300 font-mono text-xs\"></pre></div><div id=\"invoke-panel\" class=\"hidden mt-3 grid grid-cols-1 md:grid-cols-2 gap-3 h-48\"><textarea id=\"invoke-input\" class=\"w-full h-full p-3 bg-[#0b0b0c] border border-neutral-800 rounded-xl text-neutral-200 font-mono text-xs\" placeholder='Request body, e.g. {\"name\": \"ada\"}'></textarea><pre id=\"invoke-output\" class=\"w-full h-full p-3 bg-[#0b0b0c] border border-neutral-800 rounded-xl text-neutral-300 font-mono text-xs overflow-auto\"></pre></div><div class=\"flex justify-end gap-3 pt-3\"><button onclick=\"toggleInvoke()\" class=\"px-3 py-2 rounded-lg border border-neutral-700 text-neutral-300 hover:bg-neutral-800 text-sm\">Test</button> <button onclick=\"invokeFn()\" class=\"px-3 py-2 rounded-lg bg-green-600 hover:bg-green-700 text-white text-sm font-semibold\">Run</button> <button onclick=\"closeEdit()\" class=\"p-2 rounded-lg border border-neutral-700 text-neutral-300 hover:bg-neutral-800\"><img src=\"/static/imgs/cancel.svg\" class=\"w-5 h-5\"></button> <button onclick=\"saveEdit(true)\" class=\"p-2 rounded-lg bg-blue-500 hover:bg-blue-600 text-white\"><img src=\"/static/imgs/save-floppy-svgrepo-com.svg\" class=\"w-5 h-5\"></button></div></div></div><!-- DELETE CONFIRM MODAL --><div id=\"delete-modal\" class=\"hidden fixed inset-0 bg-black/70 backdrop-blur-md z-50 flex items-center justify-center\"><div class=\"bg-[#0f0f10] border border-neutral-800 rounded-2xl p-8 w-[420px]\"><h2 class=\"text-xl font-semibold text-white mb-4\">Delete Function?</h2><p class=\"text-neutral-400 mb-6\">This action cannot be undone.</p><div class=\"flex justify-end gap-3\"><button onclick=\"closeDelete()\" class=\"px-4 py-2 border border-neutral-700 text-neutral-300 rounded-lg hover:bg-neutral-800\">Cancel</button> <button onclick=\"confirmDelete()\" class=\"px-4 py-2 bg-red-600 hover:bg-red-700 text-white rounded-lg\">Delete</button></div></div></div><!-- ACE from CDN (fallback to local if needed) --><script>\n\t(function(){\n\t\t// try to load ACE from CDN, but allow local base if preferred by the app\n\t\tconst cdn = \"https://cdnjs.cloudflare.com/ajax/libs/ace/1.32.3/\";\n\t\tconst s1 = document.createElement('script');\n\t\ts1.src = cdn + 'ace.js';\n\t\ts1.onload = () => {\n\t\t\t// load optional ext and keybinding after ace\n\t\t\tconst s2 = document.createElement('script');\n\t\t\ts2.src = cdn + 'ext-language_tools.js';\n\t\t\tdocument.head.appendChild(s2);\n\t\t\tconst s3 = document.createElement('script');\n\t\t\ts3.src = cdn + 'keybinding-vim.js';\n\t\t\tdocument.head.appendChild(s3);\n\t\t};\n\t\tdocument.head.appendChild(s1);\n\t})();\n\t</script><script>\nwindow.ACE_MODES = {\n    python: \"python\",\n    javascript: \"javascript\",\n    go: \"golang\",\n    rust: \"rust\",\n    lua: \"lua\"\n};\n\nwindow.__activeProjectID = \"{ activeProjectID }\";\n\nlet createEditor = null;\nlet editEditor = null;\nlet selectedLang = \"python\";\n\nconst codeTemplates = {\n  python: `from dataclasses import dataclass\n\n@dataclass\nclass Input:\n    name: str | None = None\n\n@dataclass\nclass Output:\n    message: str\n\nasync def handle(request) -> Output:\n    data = await request.json()\n    input = Input(**data)\n    name = input.name or \"stranger\"\n    return Output(message=f\"Hello {name} from Python!\")\n`,\n\n  go: `package main\n\ntype Input struct {\n    Name *string \\`json:\"name\"\\`\n}\n\ntype Output struct {\n    Message string \\`json:\"message\"\\`\n}\n\n// Request and Response are provided by the runtime\nfunc Handle(req Request) (any, error) {\n    var input Input\n    if err := req.Bind(&input); err != nil {\n        return Response{Status: 400, Body: map[string]string{\"error\": \"invalid request\"}}, nil\n    }\n\n    name := \"stranger\"\n    if input.Name != nil && *input.Name != \"\" {\n        name = *input.Name\n    }\n\n    return Output{\n        Message: \"Hello \" + name + \" from Go!\",\n    }, nil\n}\n`,\n\n  rust: `use axum::{Json};\nuse serde::{Deserialize, Serialize};\n\n#[derive(Deserialize)]\npub struct Input {\n    pub name: Option<String>,\n}\n\n#[derive(Serialize)]\npub struct Output {\n    pub message: String,\n}\n\npub async fn handle(Json(input): Json<Input>) -> Json<Output> {\n    let name = input.name.unwrap_or(\"stranger\".into());\n    Json(Output { \n        message: format!(\"Hello {} from Rust!\", name) \n    })\n}\n`,\n\n  javascript: `export async function handle(req) {\n  class Input {\n    constructor(obj = {}) {\n      this.name = obj.name ?? null;\n    }\n  }\n\n  class Output {\n    constructor(message) {\n      this.message = message;\n    }\n  }\n\n  const body = await req.json();\n  const input = new Input(body);\n  const name = input.name || \"stranger\";\n\n  return Response.json(\n    new Output(\\`Hello \\${name} from JavaScript!\\`)\n  );\n}\n`,\n\n  lua: `-- Input serializer\nInput = {}\nInput.__index = Input\n\nfunction Input:new(o)\n  o = o or {}\n  setmetatable(o, self)\n  o.name = o.name or nil\n  return o\nend\n\n-- Output serializer\nOutput = {}\nOutput.__index = Output\n\nfunction Output:new(message)\n  return setmetatable({ message = message }, self)\nend\n\nfunction handle(req)\n  local input = Input:new(req.json or {})\n  local name = input.name or \"stranger\"\n  return Output:new(string.format(\"Hello %s from Lua!\", name))\nend\n`\n};\n\n\nconst modeMap = window.ACE_MODES;\n\n/* --- Helpers for project id resolution (use cookie fallback) --- */\nfunction getCookie(name) {\n  const v = document.cookie.match('(^|;)\\\\s*' + name + '\\\\s*=\\\\s*([^;]+)');\n  return v ? decodeURIComponent(v.pop()) : '';\n}\n\nfunction getActiveProjectID() {\n  const raw = (window.__activeProjectID || '').trim();\n  // treat templ placeholder or empty as \"not provided\"\n  if (raw && raw !== '{ activeProjectID }' && raw !== '') return raw;\n  // fallback to cookie\n  return getCookie('lws_project') || '';\n}\n\nfunction projectUrl(pathSuffix) {\n  const pid = getActiveProjectID();\n  if (!pid) {\n    console.warn('no active project id set (lws_project cookie missing and server didn\\'t provide one)');\n    return pathSuffix || '';\n  }\n  if (pathSuffix && pathSuffix[0] !== '/') pathSuffix = '/' + pathSuffix;\n  // NOTE: prepend /api here so we call server routes under /api\n  return `/api/projects/${encodeURIComponent(pid)}${pathSuffix || ''}`;\n}\n\n/* --- Ace + Vim ex helpers --- */\nwindow.__isCreateEditor = false;\nwindow.__isEditEditor = false;\n\nfunction defineVimEx(){\n  try {\n    const vimMod = ace.require && ace.require(\"ace/keyboard/vim\");\n    if (!vimMod || !vimMod.CodeMirror) return;\n    const Vim = vimMod.CodeMirror.Vim;\n    if (!Vim) return;\n    if (Vim.__lws_ex_defined) return;\n    Vim.defineEx(\"w\", \"w\", function(cm, input){\n      if (window.__isCreateEditor) saveCreate(true);\n      else if (window.__isEditEditor) saveEdit(true);\n    });\n    Vim.defineEx(\"wq\", \"wq\", function(cm, input){\n      if (window.__isCreateEditor) saveCreate(true);\n      if (window.__isEditEditor) saveEdit(true);\n      if (window.__isCreateEditor) closeCreate();\n      if (window.__isEditEditor) closeEdit();\n    });\n    Vim.defineEx(\"q\", \"q\", function(cm, input){\n      if (window.__isCreateEditor) closeCreate();\n      else if (window.__isEditEditor) closeEdit();\n    });\n    Vim.__lws_ex_defined = true;\n  } catch (e) {\n    // ignore if vim keybinding not present yet\n  }\n}\n\n/* --- UI functions --- */\nfunction copyFn(id){\n  const curl = `curl -X POST ${projectUrl(`/api/functions/`)}${id ? id : ''}`;\n  navigator.clipboard.writeText(curl);\n}\n\nfunction openCreate(){\n  window.__isCreateEditor = true;\n  window.__isEditEditor = false;\n\n  document.getElementById('create-modal').classList.remove('hidden');\n\n  if(!createEditor && window.ace){\n    createEditor = ace.edit('create-ace');\n    createEditor.setTheme('ace/theme/dracula');\n    try{ createEditor.setKeyboardHandler('ace/keyboard/vim'); }catch(e){}\n    defineVimEx();\n  }\n\n  if(createEditor){\n    createEditor.session.setMode('ace/mode/' + modeMap[selectedLang]);\n    createEditor.setValue(codeTemplates[selectedLang] || '', -1);\n    setTimeout(()=>createEditor.focus(),120);\n  }\n}\n\nfunction selectLang(lang){\n  selectedLang = lang;\n  document.querySelectorAll('.lang-btn').forEach(b=>b.classList.remove('selected'));\n  const el = document.getElementById('lang-' + lang);\n  if(el) el.classList.add('selected');\n  if(createEditor){\n    createEditor.session.setMode('ace/mode/' + modeMap[lang]);\n    createEditor.setValue(codeTemplates[lang] || '', -1);\n    setTimeout(()=>createEditor.focus(),120);\n  }\n}\n\nfunction closeCreate(){\n  window.__isCreateEditor = false;\n  document.getElementById('create-modal').classList.add('hidden');\n}\n\nfunction saveCreate(exit){\n  const name = document.getElementById('fn-name-input')?.value?.trim();\n  if(!name){\n    const el = document.getElementById('fn-name-input');\n    el.classList.add('shake');\n    setTimeout(()=>el.classList.remove('shake'),400);\n    el.focus();\n    return;\n  }\n  const description = document.getElementById('fn-desc-input')?.value?.trim();\n  const payload = {\n    name: name,\n    language: selectedLang,\n    description: description,\n    path: createEditor ? createEditor.getValue() : ''\n  };\n  fetch(`/api/functions/`,{\n    method:'POST',\n    headers:{'Content-Type':'application/json'},\n    body:JSON.stringify(payload)\n  }).then(()=>{ \n    if(exit) closeCreate();\n    refreshList()\n  });\n}\n\nfunction openEdit(id, lang){\n  window.__isCreateEditor = false;\n  window.__isEditEditor = true;\n  window.__editFnID = id;\n  window.__editETag = null;\n  hideConflict();\n  console.log(\"opening edit modal\")\n  document.getElementById('edit-modal').classList.remove('hidden');\n  if(!editEditor && window.ace){\n    editEditor = ace.edit('edit-ace');\n    editEditor.setTheme('ace/theme/dracula');\n    try{ editEditor.setKeyboardHandler('ace/keyboard/vim'); }catch(e){}\n    defineVimEx();\n  }\n\n  if(editEditor){\n    editEditor.session.setMode('ace/mode/' + modeMap[lang]);\n    fetch(`/api/functions/${id}/`).then(r=>r.json()).then(data=>{\n      window.__editETag = data.etag || null;\n      editEditor.setValue(data.content || codeTemplates[lang] || '', -1);\n      setTimeout(()=>editEditor.focus(),120);\n    }).catch(()=>{\n      editEditor.setValue(codeTemplates[lang] || '', -1);\n    });\n  }\n}\n\nfunction toggleInvoke(){\n  document.getElementById('invoke-panel').classList.toggle('hidden');\n}\n\nfunction invokeFn(){\n  if(!window.__editFnID) return;\n  const panel = document.getElementById('invoke-panel');\n  panel.classList.remove('hidden');\n  const out = document.getElementById('invoke-output');\n  const raw = document.getElementById('invoke-input').value.trim();\n  let input = raw;\n  try { input = raw ? JSON.parse(raw) : {}; } catch(e) {}\n  out.textContent = 'Running...';\n  fetch(`/api/functions/${window.__editFnID}/invoke/`,{\n    method:'POST',\n    headers:{'Content-Type':'application/json'},\n    body: JSON.stringify({input: input, source: editEditor ? editEditor.getValue() : undefined})\n  }).then(r=>r.json()).then(res=>{\n    const lines = [];\n    if(res.error){\n      lines.push('Error: ' + res.error);\n    } else {\n      lines.push(`${res.status} (${res.duration_ms}ms)`);\n      lines.push(res.body);\n    }\n    if(res.logs && res.logs.length){\n      lines.push('', '--- logs ---', ...res.logs);\n    }\n    out.textContent = lines.join('\\n');\n  }).catch(err=>{\n    out.textContent = 'Error: ' + err;\n  });\n}\n\nfunction closeEdit(){\n  window.__isEditEditor = false;\n  document.getElementById('edit-modal').classList.add('hidden');\n}\n\nfunction saveEdit(exit, etag){\n  if(!window.__editFnID) return;\n  const body = editEditor ? editEditor.getValue() : '';\n  fetch(`/api/functions/${window.__editFnID}/`,{\n    method:'PUT',\n    // the etag the source was loaded with, the server refuses the write if it moved on since\n    headers:{'Content-Type':'text/plain', 'If-Match': etag || window.__editETag || '*'},\n    body: body\n  }).then(async r=>{\n    const res = await r.json().catch(()=>({}));\n    if(r.status === 409){\n      showConflict(res);\n      return;\n    }\n    if(!r.ok){\n      alert(res.error || 'save failed');\n      return;\n    }\n    window.__editETag = res.etag || window.__editETag;\n    hideConflict();\n    if(exit) closeEdit();\n    refreshList()\n  });\n}\n\n/* --- edit conflicts: someone else saved the function after it was opened --- */\nwindow.__conflict = null;\n\nfunction showConflict(res){\n  window.__conflict = res;\n  const msg = res.conflicts > 0\n    ? `${res.error}: ${res.conflicts} conflicting region(s), loading the merge lets you resolve them`\n    : `${res.error}: your changes merge cleanly onto the current version`;\n  document.getElementById('conflict-msg').textContent = msg;\n  document.getElementById('conflict-diff').textContent = res.diff || '';\n  document.getElementById('conflict-panel').classList.remove('hidden');\n}\n\nfunction hideConflict(){\n  window.__conflict = null;\n  document.getElementById('conflict-panel').classList.add('hidden');\n}\n\nfunction loadMerged(){\n  const res = window.__conflict;\n  if(!res || !editEditor) return;\n  window.__editETag = res.etag;\n  editEditor.setValue(res.merged || '', -1);\n  hideConflict();\n}\n\nfunction overwriteConflict(){\n  const res = window.__conflict;\n  if(!res) return;\n  saveEdit(false, res.etag || '*');\n}\n\nfunction discardMine(){\n  const res = window.__conflict;\n  if(!res || !editEditor) return;\n  window.__editETag = res.etag;\n  editEditor.setValue(res.current || '', -1);\n  hideConflict();\n}\n\n/* --- bind language tiles and other DOM wiring after load --- */\ndocument.addEventListener('DOMContentLoaded', () => {\n  // wire language tiles\n  document.querySelectorAll('.lang-btn[data-lang]').forEach(btn=>{\n    btn.addEventListener('click', ()=> {\n      const lang = btn.getAttribute('data-lang');\n      selectLang(lang);\n    });\n  });\n\n  // if server didn't provide activeProjectID, try cookie\n  window.__activeProjectID = getActiveProjectID();\n});\n\nwindow.__deleteFnID = null;\n\nfunction deleteFn(id) {\n    window.__deleteFnID = id;\n    document.getElementById(\"delete-modal\").classList.remove(\"hidden\");\n}\n\nfunction closeDelete() {\n    window.__deleteFnID = null;\n    document.getElementById(\"delete-modal\").classList.add(\"hidden\");\n}\n\nfunction confirmDelete() {\n    if (!window.__deleteFnID) return;\n\n    fetch(`/api/functions/${window.__deleteFnID}/`, {\n        method: \"DELETE\"\n    })\n    .then(() => {\n        closeDelete();\n        refreshList(); // refresh UI\n    });\n}\n\n\nfunction refreshList() {\n    fetch(`/api/functions/`)\n        .then(r => r.json())\n        .then(obj => {\n\n            const list = Object.values(obj);\n\n            const container = document.querySelector(\"#fn-list-container\");\n            if (!container) return;\n\n            container.innerHTML = \"\";\n\n            if (list.length === 0) {\n                container.innerHTML = `\n                    <div class=\"w-full text-center py-20 text-neutral-500 text-lg\">\n                        Create a new function to begin.\n                    </div>\n                `;\n                return;\n            }\n\n            list.forEach(fn => {\n                const id = fn.id;\n                const name = fn.name;\n                const lang = fn.language;\n\n                const icon = `/static/imgs/${lang}-svgrepo-com.svg`;\n\n                const mobileCard = document.createElement(\"div\");\n                mobileCard.className = \"sm:hidden w-full rounded-xl border border-neutral-800 bg-[#0e0e0f] px-4 py-4\";\n                mobileCard.innerHTML = `\n                    <!-- TOP: Icon + Name -->\n                    <div class=\"flex items-center gap-3 mb-3\">\n                        <img src=\"${icon}\" class=\"w-5 h-5 opacity-80\"/>\n                        <h2 class=\"text-white font-medium text-base\">${name}</h2>\n                    </div>\n                \n                    <!-- BOTTOM: Actions -->\n                    <div class=\"flex items-center gap-3\">\n                \n                        <!-- Copy -->\n                        <button onclick=\"copyFn('${id}')\"\n                            class=\"p-2 rounded-lg hover:bg-neutral-800 transition text-neutral-400 hover:text-white\">\n                            <img src=\"/static/imgs/copy-svgrepo-com.svg\" class=\"w-4 h-4\"/>\n                        </button>\n                \n                        <!-- Edit -->\n                        <button onclick=\"openEdit('${id}', '${lang}')\"\n                            class=\"px-4 py-2 text-sm rounded-lg border border-neutral-700 text-neutral-300 hover:bg-neutral-800\">\n                            Edit\n                        </button>\n                \n                        <!-- Delete -->\n                        <button onclick=\"deleteFn('${id}')\"\n                            class=\"px-4 py-2 text-sm rounded-lg border border-red-700 text-red-400 hover:bg-red-900/40\">\n                            Delete\n                        </button>\n                \n                    </div>\n                `;\n\n                const desktopRow = document.createElement(\"div\");\n                desktopRow.className = \"hidden sm:flex items-center justify-between px-2 py-3 border-b border-neutral-800 hover:bg-neutral-900/30 transition\";\n                desktopRow.innerHTML = `\n                    <!-- LEFT -->\n                    <div class=\"flex items-center gap-3\">\n                        <img src=\"${icon}\" class=\"w-5 h-5 opacity-80\"/>\n                        <span class=\"text-white font-medium\">${name}</span>\n                    </div>\n\n                    <!-- RIGHT -->\n                    <div class=\"flex items-center gap-2 opacity-60 hover:opacity-100 transition\">\n\n                        <button onclick=\"copyFn('${id}')\"\n                            class=\"p-1 rounded-lg hover:bg-neutral-800 transition\">\n                            <img src=\"/static/imgs/copy-svgrepo-com.svg\" class=\"w-4 h-4\"/>\n                        </button>\n\n                        <button onclick=\"openEdit('${id}', '${lang}')\"\n                            class=\"px-3 py-1 text-sm rounded-lg border border-neutral-700 text-neutral-300 hover:bg-neutral-800\">\n                            Edit\n                        </button>\n\n                        <button onclick=\"deleteFn('${id}')\"\n                            class=\"px-3 py-1 text-sm rounded-lg border border-red-700 text-red-400 hover:bg-red-900/40\">\n                            Delete\n                        </button>\n\n                    </div>\n                `;\n\n                container.appendChild(mobileCard);\n                container.appendChild(desktopRow);\n            });\n        });\n}\n\n\n\n\t</script><style>\nhtml,body{background:#0f0f10!important;}\n.ace_editor,.ace_scroller,.ace_content{background:#0b0b0c!important;color:#eee!important;}\n.shake{animation:shake .3s linear;}\n@keyframes shake{0%{transform:translateX(0)}25%{transform:translateX(-6px)}50%{transform:translateX(6px)}75%{transform:translateX(-6px)}100%{transform:translateX(0)}}\n\n.lang-btn{padding:10px 8px;border-radius:12px;background:#0e0e0f;border:1px solid #282828;color:white;font-size:0.85rem;transition:0.15s}\n.lang-btn:hover{background:#1c1c1c;border-color:#666}\n.lang-btn.selected{background:#1f1f20;border-color:#888}\n\t</style></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -29,7 +29,7 @@ func HomeContent() templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<script src=\"https://unpkg.com/@simplewebauthn/browser/dist/bundle/index.umd.min.js\"></script><script>\n  function extractPublicKey(opts) {\n    console.log(\"OPTIONS RECEIVED:\", opts);\n    if (opts.publicKey) {return opts.publicKey;}\n    if (opts.response) {return opts.response;}\n    if (opts.challenge) {return opts;}\n    throw new Error(\"Backend did not return valid WebAuthn publicKey options\");\n  }\n\n  window.registerPasskey = async function () {\n    try {\n      const username = document.getElementById(\"username\").value.trim();\n      if (!username) {\n        alert(\"Please enter a username first.\");\n        return;\n      }\n\n      const startResp = await fetch(\"/passkey/register/start/\", {\n        method: \"POST\",\n        headers: {\"Content-Type\": \"application/json\"},\n        body: JSON.stringify({username}),\n      });\n\n      if (!startResp.ok) {\n        alert(\"Failed to start registration: \" + await startResp.text());\n        return;\n      }\n\n      const sessionKey = startResp.headers.get(\"Session-Key\");\n      const options = await startResp.json();\n      const publicKeyOpts = extractPublicKey(options);\n      const attResp = await SimpleWebAuthnBrowser.startRegistration(publicKeyOpts);\n\n      const finishResp = await fetch(\"/passkey/register/finish/\", {\n        method: \"POST\",\n        headers: {\n          \"Content-Type\": \"application/json\",\n          \"Session-Key\": sessionKey,\n        },\n        body: JSON.stringify(attResp),\n      });\n\n      if (finishResp.ok) {\n        window.location.href = '/dashboard';\n      } else {\n        const msg = await finishResp.text();\n        alert(\"Registration failed: \" + msg);\n      }\n    } catch (err) {\n      console.error(err);\n      alert(\"Registration error: \" + err);\n    }\n  };\n\n  window.loginPasskey = async function () {\n    try {\n      const username = document.getElementById(\"username\").value.trim();\n      if (!username) {\n        alert(\"Please enter your username first.\");\n        return;\n      }\n\n      const startResp = await fetch(\"/passkey/login/start/\", {\n        method: \"POST\",\n        headers: {\"Content-Type\": \"application/json\"},\n        body: JSON.stringify({username}),\n      });\n\n      if (!startResp.ok) {\n        alert(\"Failed to start login: \" + await startResp.text());\n        return;\n      }\n\n      const sessionKey = startResp.headers.get(\"Session-Key\");\n      const options = await startResp.json();\n      const publicKeyOpts = extractPublicKey(options);\n      const assertionResp = await SimpleWebAuthnBrowser.startAuthentication(publicKeyOpts);\n\n      const finishResp = await fetch(\"/passkey/login/finish/\", {\n        method: \"POST\",\n        headers: {\n          \"Content-Type\": \"application/json\",\n          \"Session-Key\": sessionKey,\n        },\n        body: JSON.stringify(assertionResp),\n      });\n\n      if (finishResp.ok) {\n        // Check if there's a redirect parameter\n        const urlParams = new URLSearchParams(window.location.search);\n        const redirect = urlParams.get('redirect');\n        if (redirect) {\n          window.location.href = redirect;\n        } else {\n          window.location.href = '/dashboard';\n        }\n      } else {\n        const msg = await finishResp.text();\n        alert(\"Login failed: \" + msg);\n      }\n    } catch (err) {\n      console.error(err);\n      alert(\"Login error: \" + err);\n    }\n  };\n</script><div class=\"w-full px-6 md:px-20 py-16 md:py-24 relative min-h-[80vh] flex items-center\"><div class=\"grid grid-cols-1 lg:grid-cols-[1fr_auto] items-center gap-16 w-full\"><!-- LEFT HERO --><div class=\"flex flex-col justify-center max-w-xl\"><h1 class=\"text-5xl md:text-6xl font-semibold tracking-tight text-white leading-tight\">Lite Web Services</h1><p class=\"mt-6 text-lg md:text-xl text-neutral-400 leading-relaxed max-w-lg\">Deploy, scale, and connect tiny cloud primitives — fast.</p><div class=\"mt-8 md:mt-10 overflow-hidden\"><div class=\"flex gap-10 whitespace-nowrap text-neutral-300 text-lg font-medium animate-[marquee_18s_linear_infinite]\"><span>Litefunctions</span> <span>Litestore</span> <span>Litecron</span> <span>Liteobjects</span> <span>Litegateway</span> <span>Litefunctions</span> <span>Litestore</span> <span>Litecron</span> <span>Liteobjects</span> <span>Litegateway</span></div></div></div><!-- CARD --><div class=\"bg-[#0e0e0f] border border-neutral-800 rounded-2xl shadow-xl w-full lg:w-[420px] max-w-[420px] p-6\"><header class=\"pb-3\"><h2 class=\"text-xl font-semibold text-white\">Get Started</h2><p class=\"text-neutral-400 text-sm mt-1\">Create a new account using your device's secure passkey.</p></header><section class=\"flex flex-col gap-4 pt-2\"><input id=\"username\" type=\"text\" placeholder=\"Enter username\" class=\"w-full px-4 py-3 rounded-xl bg-neutral-900 text-neutral-200 border border-neutral-700 focus:outline-none\"> <button onclick=\"registerPasskey()\" class=\"w-full bg-white text-black font-semibold px-4 py-3 rounded-xl hover:bg-neutral-200 transition\">Register with Passkey</button> <button onclick=\"loginPasskey()\" class=\"w-full border border-neutral-700 text-neutral-300 px-4 py-3 rounded-xl hover:bg-neutral-800 transition\">Sign In with Passkey</button></section><footer class=\"pt-4 text-neutral-700 text-xs\">Your device will securely store your passkey.</footer></div></div></div><style>\n  @keyframes marquee {\n    0% {\n      transform: translateX(0);\n    }\n\n    100% {\n      transform: translateX(-50%);\n    }\n  }\n</style>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<script src=\"https://unpkg.com/@simplewebauthn/browser/dist/bundle/index.umd.min.js\"></script><script>\n  function extractPublicKey(opts) {\n    console.log(\"OPTIONS RECEIVED:\", opts);\n    if (opts.publicKey) {return opts.publicKey;}\n    if (opts.response) {return opts.response;}\n    if (opts.challenge) {return opts;}\n    throw new Error(\"Backend did not return valid WebAuthn publicKey options\");\n  }\n\n  // invite links land here as /?invite=<token>, redeem once the user has a session\n  async function redeemPendingInvite() {\n    const token = new URLSearchParams(window.location.search).get('invite');\n    if (!token) return false;\n    const res = await fetch(`/api/invites/${encodeURIComponent(token)}/redeem/`, {method: \"POST\"});\n    if (!res.ok) {\n      const body = await res.json().catch(() => ({}));\n      alert(\"Could not join project: \" + (body.error || res.status));\n      return false;\n    }\n    return true;\n  }\n\n  window.registerPasskey = async function () {\n    try {\n      const username = document.getElementById(\"username\").value.trim();\n      if (!username) {\n        alert(\"Please enter a username first.\");\n        return;\n      }\n\n      const startResp = await fetch(\"/passkey/register/start/\", {\n        method: \"POST\",\n        headers: {\"Content-Type\": \"application/json\"},\n        body: JSON.stringify({username}),\n      });\n\n      if (!startResp.ok) {\n        alert(\"Failed to start registration: \" + await startResp.text());\n        return;\n      }\n\n      const sessionKey = startResp.headers.get(\"Session-Key\");\n      const options = await startResp.json();\n      const publicKeyOpts = extractPublicKey(options);\n      const attResp = await SimpleWebAuthnBrowser.startRegistration(publicKeyOpts);\n\n      const finishResp = await fetch(\"/passkey/register/finish/\", {\n        method: \"POST\",\n        headers: {\n          \"Content-Type\": \"application/json\",\n          \"Session-Key\": sessionKey,\n        },\n        body: JSON.stringify(attResp),\n      });\n\n      if (finishResp.ok) {\n        await redeemPendingInvite();\n        window.location.href = '/dashboard';\n      } else {\n        const msg = await finishResp.text();\n        alert(\"Registration failed: \" + msg);\n      }\n    } catch (err) {\n      console.error(err);\n      alert(\"Registration error: \" + err);\n    }\n  };\n\n  window.loginPasskey = async function () {\n    try {\n      const username = document.getElementById(\"username\").value.trim();\n      if (!username) {\n        alert(\"Please enter your username first.\");\n        return;\n      }\n\n      const startResp = await fetch(\"/passkey/login/start/\", {\n        method: \"POST\",\n        headers: {\"Content-Type\": \"application/json\"},\n        body: JSON.stringify({username}),\n      });\n\n      if (!startResp.ok) {\n        alert(\"Failed to start login: \" + await startResp.text());\n        return;\n      }\n\n      const sessionKey = startResp.headers.get(\"Session-Key\");\n      const options = await startResp.json();\n      const publicKeyOpts = extractPublicKey(options);\n      const assertionResp = await SimpleWebAuthnBrowser.startAuthentication(publicKeyOpts);\n\n      const finishResp = await fetch(\"/passkey/login/finish/\", {\n        method: \"POST\",\n        headers: {\n          \"Content-Type\": \"application/json\",\n          \"Session-Key\": sessionKey,\n        },\n        body: JSON.stringify(assertionResp),\n      });\n\n      if (finishResp.ok) {\n        if (await redeemPendingInvite()) {\n          window.location.href = '/dashboard';\n          return;\n        }\n        // Check if there's a redirect parameter\n        const urlParams = new URLSearchParams(window.location.search);\n        const redirect = urlParams.get('redirect');\n        if (redirect) {\n          window.location.href = redirect;\n        } else {\n          window.location.href = '/dashboard';\n        }\n      } else {\n        const msg = await finishResp.text();\n        alert(\"Login failed: \" + msg);\n      }\n    } catch (err) {\n      console.error(err);\n      alert(\"Login error: \" + err);\n    }\n  };\n</script><div class=\"w-full px-6 md:px-20 py-16 md:py-24 relative min-h-[80vh] flex items-center\"><div class=\"grid grid-cols-1 lg:grid-cols-[1fr_auto] items-center gap-16 w-full\"><!-- LEFT HERO --><div class=\"flex flex-col justify-center max-w-xl\"><h1 class=\"text-5xl md:text-6xl font-semibold tracking-tight text-white leading-tight\">Lite Web Services</h1><p class=\"mt-6 text-lg md:text-xl text-neutral-400 leading-relaxed max-w-lg\">Deploy, scale, and connect tiny cloud primitives — fast.</p><div class=\"mt-8 md:mt-10 overflow-hidden\"><div class=\"flex gap-10 whitespace-nowrap text-neutral-300 text-lg font-medium animate-[marquee_18s_linear_infinite]\"><span>Litefunctions</span> <span>Litestore</span> <span>Litecron</span> <span>Liteobjects</span> <span>Litegateway</span> <span>Litefunctions</span> <span>Litestore</span> <span>Litecron</span> <span>Liteobjects</span> <span>Litegateway</span></div></div></div><!-- CARD --><div class=\"bg-[#0e0e0f] border border-neutral-800 rounded-2xl shadow-xl w-full lg:w-[420px] max-w-[420px] p-6\"><header class=\"pb-3\"><h2 class=\"text-xl font-semibold text-white\">Get Started</h2><p class=\"text-neutral-400 text-sm mt-1\">Create a new account using your device's secure passkey.</p></header><section class=\"flex flex-col gap-4 pt-2\"><input id=\"username\" type=\"text\" placeholder=\"Enter username\" class=\"w-full px-4 py-3 rounded-xl bg-neutral-900 text-neutral-200 border border-neutral-700 focus:outline-none\"> <button onclick=\"registerPasskey()\" class=\"w-full bg-white text-black font-semibold px-4 py-3 rounded-xl hover:bg-neutral-200 transition\">Register with Passkey</button> <button onclick=\"loginPasskey()\" class=\"w-full border border-neutral-700 text-neutral-300 px-4 py-3 rounded-xl hover:bg-neutral-800 transition\">Sign In with Passkey</button></section><footer class=\"pt-4 text-neutral-700 text-xs\">Your device will securely store your passkey.</footer></div></div></div><style>\n  @keyframes marquee {\n    0% {\n      transform: translateX(0);\n    }\n\n    100% {\n      transform: translateX(-50%);\n    }\n  }\n</style>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
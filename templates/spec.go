@@ -18,3 +18,40 @@ type Lang struct {
 	Icon    string
 	AceMode string
 }
+
+type Endpoint struct {
+	ID           string
+	Name         string
+	Method       string
+	Scope        string
+	FunctionID   string
+	FunctionName string
+	// rate limit fields are preformatted for inputs, empty when unlimited
+	RateLimit  string
+	RateWindow string
+	RateBurst  string
+	RateKey    string
+	// auth settings, the jwt secret itself never reaches the page
+	APIKeyScope  string
+	JWTHasSecret bool
+	JWTJWKS      string
+	JWTIssuer    string
+	JWTAudience  string
+}
+
+type ConfigEntry struct {
+	Key     string
+	Value   string
+	Type    string
+	Secret  bool
+	Version int32
+	// Environment is set when the value overrides the project wide one
+	Environment string
+}
+
+type Environment struct {
+	Name     string
+	Branch   string
+	Default  bool
+	Selected bool
+}